@@ -13,16 +13,21 @@ func init() {
 		Long: `Archive and compress experiment directories to save disk space.
 
 This command helps manage disk space by archiving older or completed
-experiments into compressed archives (tar.gz or zip). Experiments can be
+experiments into compressed archives (tar.gz, tar.zst, tar.xz or zip). Experiments can be
 filtered by age, status, and other criteria before archiving.
 
 You can specify one or more run directories to archive specific experiments,
 or use the filtering options to archive experiments based on criteria.
 
 An archive index is maintained for easy reference to archived experiments.`,
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Archive experiments using config values
+			args, err := resolveRunRefs(args)
+			if err != nil {
+				return err
+			}
 			return archive.Main(args)
 		},
 	}
@@ -32,15 +37,61 @@ An archive index is maintained for easy reference to archived experiments.`,
 	archiveCmd.Flags().StringVarP(&cfg.Archive.OlderThan, "older-than", "o", "",
 		"Archive experiments older than duration (e.g., '30d')")
 	archiveCmd.Flags().StringVarP(&cfg.Archive.Status, "status", "s", "",
-		"Archive by status (success, failure, running, all)")
+		"Archive by status (success, failure, interrupted, all)")
 	archiveCmd.Flags().StringVarP(&cfg.Archive.Format, "format", "f", "",
-		"Archive format (zip, tar.gz)")
+		"Archive format (zip, tar.gz, tar.zst, tar.xz)")
+	archiveCmd.Flags().IntVar(&cfg.Archive.Level, "level", 0,
+		"Compression level (0 for the default of the format)")
 	archiveCmd.Flags().StringVarP(&cfg.Archive.To, "to", "t", "",
-		"Archive destination directory")
+		"Archive destination directory or S3 location (s3://bucket/prefix)")
 	archiveCmd.Flags().BoolVar(&cfg.Archive.Delete, "delete", false,
 		"Remove original directories after archiving")
 	archiveCmd.Flags().BoolVar(&cfg.Archive.DryRun, "dry-run", false,
 		"Show what would be archived without executing")
+	archiveCmd.Flags().BoolVarP(&cfg.Archive.Yes, "yes", "y", false,
+		"Archive without asking for confirmation")
+	archiveCmd.Flags().StringSliceVar(&cfg.Archive.Exclude, "exclude", nil,
+		"Exclude files matching patterns (e.g., '*.ckpt', 'tmp/**')")
+	archiveCmd.Flags().StringVar(&cfg.Archive.EncryptTo, "encrypt-to", "",
+		"Encrypt archives to an age recipient or a GPG key")
+
+	// Complete flag values
+	archiveCmd.RegisterFlagCompletionFunc("status", completeValues("success", "failure", "interrupted", "all"))
+	archiveCmd.RegisterFlagCompletionFunc("format", completeValues("tar.gz", "tar.zst", "tar.xz", "zip"))
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [archives...]",
+		Short: "Restore archived runs into the base directory",
+		Long: `Extract archived runs (tar.gz, tar.zst, tar.xz or zip) back into the base directory.
+
+Each archive must contain a single run directory, and existing runs are
+never overwritten. Encrypted archives are decrypted with age (using the
+identity file given by --identity) or gpg.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return archive.Restore(args)
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify [archives...]",
+		Short: "Verify the integrity of archives",
+		Long: `Re-read archives and check their contents against the SHA-256
+checksums recorded in the manifest of each archive.
+
+Archives are given as files or as names in the archive index. All archives
+in the archive index are verified if none are given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return archive.Verify(args)
+		},
+	}
+
+	for _, cmd := range []*cobra.Command{restoreCmd, verifyCmd} {
+		cmd.Flags().StringVar(&cfg.Archive.Identity, "identity", "",
+			"age identity file to decrypt archives")
+	}
 
+	archiveCmd.AddCommand(restoreCmd)
+	archiveCmd.AddCommand(verifyCmd)
 	rootCmd.AddCommand(archiveCmd)
 }
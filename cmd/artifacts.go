@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/artifacts"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts [run] [artifact]",
+		Short: "List and fetch artifacts of a run",
+		Long: `List the artifacts recorded for a run with their sizes and SHA-256
+checksums, or copy an artifact to the current directory (or --output).
+
+Runs that are no longer in the base directory are looked up in the archive
+index, and artifacts are extracted from their archives without restoring
+them. Copies are checked against the recorded checksums.
+If no run is given, it can be selected interactively by fuzzy search.`,
+		Args:              cobra.MaximumNArgs(2),
+		ValidArgsFunction: completeRunThenArtifacts,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			run := ""
+			if len(args) == 0 {
+				runs, err := runArgs(args)
+				if err != nil {
+					return err
+				}
+				run = runs[0]
+			} else if runs, err := resolveRunRefs(args[:1]); err == nil {
+				run = runs[0]
+			} else {
+				// The run may have been archived
+				run = args[0]
+			}
+
+			path := ""
+			if len(args) == 2 {
+				path = args[1]
+			}
+			return artifacts.Main(run, path)
+		},
+	}
+
+	cfg := config.GetPointer()
+	artifactsCmd.Flags().StringVarP(&cfg.Artifacts.Format, "format", "f", "", "Output format (table, json)")
+	artifactsCmd.Flags().StringVarP(&cfg.Artifacts.Output, "output", "o", "", "Destination file or directory of the copied artifact")
+	artifactsCmd.Flags().StringVar(&cfg.Archive.Identity, "identity", "", "age identity file to decrypt archives")
+
+	artifactsCmd.RegisterFlagCompletionFunc("format", completeValues("table", "json"))
+
+	rootCmd.AddCommand(artifactsCmd)
+}
+
+// completeRunThenArtifacts completes a run directory and then the paths of
+// its artifacts
+func completeRunThenArtifacts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeRunDirs(cmd, args, toComplete)
+	case 1:
+		runs, err := resolveRunRefs(args)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		artifacts, err := utils.ReadArtifacts(runs[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var paths []string
+		for _, artifact := range artifacts {
+			paths = append(paths, artifact.Path)
+		}
+		return paths, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
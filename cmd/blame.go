@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/blame"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	blameCmd := &cobra.Command{
+		Use:   "blame [file]",
+		Short: "Find the runs that produced a file",
+		Long: `Find the runs that produced a file.
+
+This command searches run directories and run archives for files with the
+same content as the given file (e.g., a model checkpoint that has been
+copied elsewhere) and reports which run produced each of them, along with
+its start time, branch, commit, command, and status. Artifacts are looked
+up by the hashes recorded in artifacts.json and archive checksum manifests,
+and only other files of the same size are hashed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return blame.Main(args[0])
+		},
+	}
+
+	rootCmd.AddCommand(blameCmd)
+}
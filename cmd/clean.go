@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/remove"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Delete finished runs in bulk",
+		Long: `Delete finished runs in the base directory in bulk.
+
+By default, all failed (including interrupted) runs are deleted. Runs can be
+filtered by age and status in the same way as for archive. Running runs are
+never deleted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return remove.Clean()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	cleanCmd.Flags().StringVarP(&cfg.Clean.OlderThan, "older-than", "o", "",
+		"Delete runs older than duration (e.g., '30d')")
+	cleanCmd.Flags().StringVarP(&cfg.Clean.Status, "status", "s", "",
+		"Delete runs by status (success, failure, interrupted, all)")
+	cleanCmd.Flags().BoolVar(&cfg.Clean.DryRun, "dry-run", false,
+		"Show what would be deleted without executing")
+	cleanCmd.Flags().BoolVarP(&cfg.Clean.Yes, "yes", "y", false,
+		"Delete without asking for confirmation")
+
+	// Complete flag values
+	cleanCmd.RegisterFlagCompletionFunc("status", completeValues("success", "failure", "interrupted", "all"))
+
+	rootCmd.AddCommand(cleanCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// completeValues returns a completion function for a fixed set of values
+func completeValues(values ...string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// completeRunDirs completes run directories in the base directory
+func completeRunDirs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	runDirs, err := utils.FindRunDirs(config.Get().BaseDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return append(runDirs, utils.RunRefs...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRunValues returns a completion function for values recorded in
+// runs, which are read through the index
+func completeRunValues(values func(utils.RunInfo) []string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg := config.Get()
+		runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		idx := index.Open(cfg.BaseDir, cfg.SummaryFile)
+		defer idx.Save()
+		var completions []string
+		for _, runDir := range runDirs {
+			runInfo, err := idx.Get(runDir)
+			if err != nil {
+				continue
+			}
+			for _, value := range values(runInfo) {
+				if value != "" && !slices.Contains(completions, value) {
+					completions = append(completions, value)
+				}
+			}
+		}
+		slices.Sort(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeBranches completes branch names recorded in runs
+var completeBranches = completeRunValues(func(run utils.RunInfo) []string {
+	return []string{run.Branch}
+})
+
+// completeIssues completes issue IDs linked to runs
+var completeIssues = completeRunValues(func(run utils.RunInfo) []string {
+	return run.Issues
+})
+
+// completeNames completes names of runs
+var completeNames = completeRunValues(func(run utils.RunInfo) []string {
+	return []string{run.Name}
+})
+
+// completeTags completes tags of runs
+var completeTags = completeRunValues(func(run utils.RunInfo) []string {
+	return run.Tags
+})
+
+// completeMetrics completes names of metrics recorded in runs
+var completeMetrics = completeRunValues(func(run utils.RunInfo) []string {
+	return slices.Collect(maps.Keys(run.Metrics))
+})
+
+// completeSortFields completes fields that list sorts by, including metrics
+// as metric:<name>
+func completeSortFields(fields ...string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		metrics, directive := completeMetrics(cmd, args, toComplete)
+		if directive == cobra.ShellCompDirectiveError {
+			return nil, directive
+		}
+		completions := slices.Clone(fields)
+		for _, metric := range metrics {
+			completions = append(completions, "metric:"+metric)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeRunThenTags completes a run directory and then tags
+func completeRunThenTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeRunDirs(cmd, args, toComplete)
+	}
+	return completeTags(cmd, args, toComplete)
+}
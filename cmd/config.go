@@ -19,7 +19,22 @@ func init() {
 		},
 	}
 
+	configValidateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check configuration files for invalid settings",
+		Long: `Check the user-level and project-level configuration files for syntax
+errors, unknown keys, and invalid settings such as unknown formats, malformed
+durations and sizes, and directories that don't exist, reporting all
+problems at once with the file and key that caused them.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{validatesConfig: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.MainValidate()
+		},
+	}
+
 	cfg := config.GetPointer()
 	configCmd.Flags().BoolVarP(&cfg.Config.Default, "default", "", false, "Show the default configuration")
+	configCmd.AddCommand(configValidateCmd)
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with the environment and runs",
+		Long: `Diagnose problems with the environment and runs.
+
+This command checks:
+- Availability of git and the health of the current repository, including
+  a stale index.lock
+- Configuration files for syntax errors and unknown keys
+- Permissions of and free space for the base directory
+- Availability of the pager and editor
+- Consistency of each run directory: the summary exists and parses, the
+  log files exist, the directory name matches the summary, runs recorded
+  as running still have their moco process, and finished runs have no
+  leftover PID or heartbeat file
+- Consistency of the index with the run directories
+
+Each problem is reported with a suggested fix. With --fix, summaries of
+abandoned runs are closed, recording them as interrupted, leftover PID and
+heartbeat files are removed, and the index is updated.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{validatesConfig: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doctor.Main()
+		},
+	}
+
+	cfg := config.GetPointer()
+	doctorCmd.Flags().BoolVar(&cfg.Doctor.Fix, "fix", false,
+		"Repair safe problems (close abandoned runs, remove leftover files, update the index)")
+
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/export"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export runs to other experiment trackers",
+		Long: `Export runs selected by the same filters as list to other experiment
+trackers.
+
+The mlflow format writes runs to an MLflow file store (the mlruns directory),
+which mlflow ui --backend-store-uri shows. Sweep parameters and recorded
+environment variables become parameters, durations and resource usage become
+metrics, and files in the run directories become artifacts. Exporting a run
+again replaces it.
+
+The wandb format creates W&B runs through the W&B API with WANDB_API_KEY,
+recording the Git commit, command, parameters, and metrics of runs, and
+uploading their summaries and logs. Exporting a run again updates it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return export.Main()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	exportCmd.Flags().StringVarP(&cfg.Export.Format, "format", "f", "mlflow", "Export format (mlflow, wandb)")
+	exportCmd.Flags().StringVarP(&cfg.Export.Output, "output", "o", "mlruns", "Directory to export runs to")
+	exportCmd.Flags().StringVar(&cfg.Export.Experiment, "experiment", "",
+		"Experiment or W&B project name (default: name of the repository directory)")
+	addFilterFlags(exportCmd)
+
+	// Complete flag values
+	exportCmd.RegisterFlagCompletionFunc("format", completeValues("mlflow", "wandb"))
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+// addFilterFlags adds the flags of list selecting runs to a command
+func addFilterFlags(cmd *cobra.Command) {
+	cfg := config.GetPointer()
+	cmd.Flags().StringVarP(&cfg.List.Branch, "branch", "b", "", "Filter by branch name")
+	cmd.Flags().StringVar(&cfg.List.Status, "status", "", "Filter by status (success, failure, running, stale)")
+	cmd.Flags().StringVar(&cfg.List.Since, "since", "", "Filter by date (e.g., '7d' for last 7 days)")
+	cmd.Flags().StringVarP(&cfg.List.Command, "command", "c", "", "Filter by command pattern (regex)")
+	cmd.Flags().StringVar(&cfg.List.Issue, "issue", "", "Filter by linked issue (e.g., PROJ-42)")
+	cmd.Flags().StringVar(&cfg.List.Tag, "tag", "", "Filter by tag")
+	cmd.Flags().StringVar(&cfg.List.Name, "name", "", "Filter by run name (glob pattern)")
+	cmd.Flags().StringVar(&cfg.List.Sweep, "sweep", "", "Filter by sweep ID")
+	cmd.Flags().StringArrayVar(&cfg.List.Where, "where", nil, "Filter by a metric or sweep parameter (e.g., 'accuracy>0.9'; repeatable)")
+	cmd.Flags().IntVarP(&cfg.List.Limit, "limit", "n", 0, "Limit number of results (0 = no limit)")
+
+	cmd.RegisterFlagCompletionFunc("branch", completeBranches)
+	cmd.RegisterFlagCompletionFunc("status", completeValues("success", "failure", "running", "stale"))
+	cmd.RegisterFlagCompletionFunc("issue", completeIssues)
+	cmd.RegisterFlagCompletionFunc("tag", completeTags)
+	cmd.RegisterFlagCompletionFunc("name", completeNames)
+}
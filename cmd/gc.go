@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/remove"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete orphaned run directories",
+		Long: `Delete directories in the base directory that are named like runs but
+have a missing or empty summary and no outputs, such as those left when moco
+crashes between creating a run directory and writing its summary.
+
+Directories modified within the grace period are kept, since a run being
+set up may not have written its summary yet. Directories with an invalid
+summary or with files other than moco's own are only reported, since they
+may hold results. Runs with a valid summary are never deleted (see clean
+for deleting finished runs).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return remove.GC()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	gcCmd.Flags().StringVar(&cfg.GC.Grace, "grace", "24h",
+		"Keep directories modified within this duration (e.g., '1h')")
+	gcCmd.Flags().BoolVar(&cfg.GC.DryRun, "dry-run", false,
+		"Show what would be deleted without executing")
+	gcCmd.Flags().BoolVarP(&cfg.GC.Yes, "yes", "y", false,
+		"Delete without asking for confirmation")
+
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a configuration file for the project",
+		Long: `Create a configuration file for the project.
+
+This writes .moco.toml in the current directory with all the default
+settings commented out and creates the base directory. With --gitignore,
+the base directory is also added to .gitignore. With --user, the user-level
+configuration file is created instead.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{validatesConfig: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return scaffold.Main()
+		},
+	}
+
+	cfg := config.GetPointer()
+	initCmd.Flags().BoolVar(&cfg.Init.User, "user", false, "Create the user-level configuration file instead")
+	initCmd.Flags().BoolVar(&cfg.Init.Gitignore, "gitignore", false, "Add the base directory to .gitignore")
+	initCmd.Flags().BoolVarP(&cfg.Init.Force, "force", "f", false, "Overwrite an existing configuration file")
+
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitForceInvalidConfig(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid setting": "[list]\nformat = \"bogus\"\n",
+		"syntax error":    "[list\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config")) // No user config
+			t.Chdir(dir)
+			require.NoError(t, os.WriteFile(".moco.toml", []byte(content), 0644))
+			require.NoError(t, config.Init())
+			require.Error(t, config.Err())
+
+			// The invalid file is not overwritten without --force
+			rootCmd.SetArgs([]string{"init"})
+			err := rootCmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "already exists")
+
+			rootCmd.SetArgs([]string{"init", "--force"})
+			require.NoError(t, rootCmd.Execute())
+			data, err := os.ReadFile(".moco.toml")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(data), "# Moco configuration"))
+			assert.DirExists(t, config.GetDefault().BaseDir)
+
+			require.NoError(t, config.Init())
+			assert.NoError(t, config.Err())
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/kill"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	killCmd := &cobra.Command{
+		Use:   "kill [run_directory]",
+		Short: "Terminate a running experiment",
+		Long: `Send a signal (SIGTERM by default) to the process group of a running
+experiment and wait for it to exit.
+
+The run is recorded as interrupted. If the moco process that started the
+run is gone, the execution results are written to the summary file so that
+the run is no longer shown as running; this also works for stale runs whose
+process has already died. The process is signaled only if the heartbeat of
+the run is fresh and it started with the command, since its PID may have
+been reused; otherwise the run is recorded as lost without sending a
+signal. If no run is given, it can be selected interactively by fuzzy search.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := runArgs(args)
+			if err != nil {
+				return err
+			}
+			return kill.Main(args[0])
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	killCmd.Flags().StringVarP(&cfg.Kill.Signal, "signal", "s", "TERM",
+		"Signal to send (e.g., TERM, INT, KILL, or a number)")
+	killCmd.Flags().StringVar(&cfg.Kill.Timeout, "timeout", "30s",
+		"How long to wait for the process to exit")
+
+	// Complete flag values
+	killCmd.RegisterFlagCompletionFunc("signal", completeValues("TERM", "INT", "HUP", "KILL"))
+
+	rootCmd.AddCommand(killCmd)
+}
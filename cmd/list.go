@@ -24,14 +24,38 @@ Results can be sorted and formatted in different ways for easy analysis.`,
 
 	// Add flags
 	cfg := config.GetPointer()
-	listCmd.Flags().StringVarP(&cfg.List.Format, "format", "f", "", "Output format (table, json, csv, plain)")
-	listCmd.Flags().StringVarP(&cfg.List.SortBy, "sort", "s", "", "Sort by (date, branch, status, duration)")
+	listCmd.Flags().StringVarP(&cfg.List.Format, "format", "f", "", "Output format (table, json, jsonl, yaml, csv, markdown, plain)")
+	listCmd.Flags().StringVarP(&cfg.List.SortBy, "sort", "s", "", "Sort by a field (e.g., date, status, duration, cost) or metric:<name>")
 	listCmd.Flags().BoolVarP(&cfg.List.Reverse, "reverse", "r", false, "Reverse sort order")
 	listCmd.Flags().StringVarP(&cfg.List.Branch, "branch", "b", "", "Filter by branch name")
-	listCmd.Flags().StringVar(&cfg.List.Status, "status", "", "Filter by status (success, failure, running)")
+	listCmd.Flags().StringVar(&cfg.List.Status, "status", "", "Filter by status (success, failure, running, stale)")
 	listCmd.Flags().StringVar(&cfg.List.Since, "since", "", "Filter by date (e.g., '7d' for last 7 days)")
 	listCmd.Flags().StringVarP(&cfg.List.Command, "command", "c", "", "Filter by command pattern (regex)")
+	listCmd.Flags().StringVar(&cfg.List.Issue, "issue", "", "Filter by linked issue (e.g., PROJ-42)")
+	listCmd.Flags().StringVar(&cfg.List.Tag, "tag", "", "Filter by tag")
+	listCmd.Flags().StringVar(&cfg.List.Name, "name", "", "Filter by run name (glob pattern)")
+	listCmd.Flags().StringVar(&cfg.List.Sweep, "sweep", "", "Filter by sweep ID")
+	listCmd.Flags().StringArrayVar(&cfg.List.Where, "where", nil, "Filter by a metric or sweep parameter (e.g., 'accuracy>0.9'; repeatable)")
+	listCmd.Flags().BoolVar(&cfg.List.Notes, "notes", false, "Show the latest note of each run")
+	listCmd.Flags().StringSliceVar(&cfg.List.Metrics, "metrics", nil, "Show final metric values as extra columns (e.g., loss,accuracy)")
+	listCmd.Flags().StringVarP(&cfg.List.GroupBy, "group-by", "g", "", "Group runs by branch, command, or day")
+	listCmd.Flags().BoolVar(&cfg.List.Archived, "archived", false, "List archived runs from the archive index")
 	listCmd.Flags().IntVarP(&cfg.List.Limit, "limit", "n", 0, "Limit number of results (0 = no limit)")
+	listCmd.Flags().BoolVarP(&cfg.List.Interactive, "interactive", "i", false, "Browse the runs interactively")
+	listCmd.Flags().BoolVarP(&cfg.List.Watch, "watch", "w", false, "Refresh the table periodically, highlighting runs that finish")
+	listCmd.Flags().StringVar(&cfg.Tui.Refresh, "refresh", "2s", "Refresh interval of --watch and --interactive (e.g., '5s')")
+	listCmd.Flags().BoolVarP(&cfg.List.AllProjects, "all-projects", "A", false, "Include runs of all registered projects")
+
+	// Complete flag values
+	listCmd.RegisterFlagCompletionFunc("format", completeValues("table", "json", "jsonl", "yaml", "csv", "markdown", "plain"))
+	listCmd.RegisterFlagCompletionFunc("metrics", completeMetrics)
+	listCmd.RegisterFlagCompletionFunc("sort", completeSortFields("date", "directory", "name", "branch", "commit", "command", "host", "project", "status", "duration", "cpu", "memory", "gpu_hours", "cost"))
+	listCmd.RegisterFlagCompletionFunc("group-by", completeValues("branch", "command", "day"))
+	listCmd.RegisterFlagCompletionFunc("branch", completeBranches)
+	listCmd.RegisterFlagCompletionFunc("status", completeValues("success", "failure", "running", "stale"))
+	listCmd.RegisterFlagCompletionFunc("issue", completeIssues)
+	listCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	listCmd.RegisterFlagCompletionFunc("name", completeNames)
 
 	rootCmd.AddCommand(listCmd)
 }
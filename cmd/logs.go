@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/logs"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	logsCmd := &cobra.Command{
+		Use:   "logs [run]",
+		Short: "Print the captured output of a run",
+		Long: `Print the captured stdout (or stderr with --stderr) of a run.
+
+The latest run is used if no run is specified. With --follow, output
+appended to the log is printed until the run finishes, so it can be used
+while the run is still executing.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			run := ""
+			if len(args) > 0 {
+				runs, err := resolveRunRefs(args)
+				if err != nil {
+					return err
+				}
+				run = runs[0]
+			}
+			return logs.Main(run)
+		},
+	}
+
+	cfg := config.GetPointer()
+	logsCmd.Flags().BoolVarP(&cfg.Logs.Stderr, "stderr", "e", false,
+		"Print stderr instead of stdout")
+	logsCmd.Flags().IntVarP(&cfg.Logs.Tail, "tail", "n", 0,
+		"Print only the last N lines")
+	logsCmd.Flags().BoolVarP(&cfg.Logs.Follow, "follow", "f", false,
+		"Keep printing new output until the run finishes")
+
+	rootCmd.AddCommand(logsCmd)
+}
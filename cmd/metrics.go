@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	metricsCmd := &cobra.Command{
+		Use:   "metrics [metric...]",
+		Short: "Tabulate metrics of runs with their statistics",
+		Long: `Tabulate the final metric values of runs selected by the same filters as
+list, followed by the minimum, maximum, and mean of each metric across them.
+
+Metrics are recorded from metrics.json or metrics.jsonl in run directories.
+All metrics are shown unless metric names are given.`,
+		ValidArgsFunction: completeMetrics,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return metrics.Main(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	metricsCmd.Flags().StringVarP(&cfg.Metrics.Format, "format", "f", "", "Output format (table, csv, json)")
+	metricsCmd.Flags().StringVarP(&cfg.Metrics.SortBy, "sort", "s", "", "Sort runs by the value of a metric")
+	metricsCmd.Flags().BoolVarP(&cfg.Metrics.Reverse, "reverse", "r", false, "Sort in descending order")
+	addFilterFlags(metricsCmd)
+
+	metricsCmd.RegisterFlagCompletionFunc("format", completeValues("table", "csv", "json"))
+	metricsCmd.RegisterFlagCompletionFunc("sort", completeMetrics)
+
+	rootCmd.AddCommand(metricsCmd)
+}
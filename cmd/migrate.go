@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/migrate"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the metadata of old runs to the current format",
+		Long: `Upgrade the metadata of runs in the base directory written by older
+versions of moco to the current format, so that runs of mixed versions can
+be listed together.
+
+Timestamps in legacy formats are converted to RFC3339 (assuming local time),
+keeping the original summary file with a .bak suffix, and summary.json is
+written for runs without an up-to-date one. Runs already in the current
+format are left untouched.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return migrate.Main()
+		},
+	}
+
+	cfg := config.GetPointer()
+	migrateCmd.Flags().BoolVar(&cfg.Migrate.DryRun, "dry-run", false,
+		"Show what would be changed without executing")
+
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/note"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	noteCmd := &cobra.Command{
+		Use:   "note [run] [text...]",
+		Short: "Append a note to a run's summary",
+		Long: `Append a timestamped note to the Notes section of a run's summary.
+
+If no text is given, the note is written in the editor ($EDITOR). Notes are
+shown by show, and the latest one by list --notes.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return note.Main(args[0], args[1:])
+		},
+	}
+
+	rootCmd.AddCommand(noteCmd)
+}
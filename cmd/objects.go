@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/objects"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	gcObjectsCmd := &cobra.Command{
+		Use:   "gc-objects",
+		Short: "Delete unreferenced objects of deduplicated artifacts",
+		Long: `Delete objects in the artifact store of the base directory (.moco/objects)
+that are no longer referenced by any run.
+
+Objects are referenced by runs through hard links or symbolic links created
+when artifacts are deduplicated (objects.dedup), so they are kept while a
+run linking to them exists.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return objects.GC()
+		},
+	}
+
+	cfg := config.GetPointer()
+	gcObjectsCmd.Flags().BoolVar(&cfg.Objects.DryRun, "dry-run", false,
+		"Show what would be deleted without executing")
+
+	rootCmd.AddCommand(gcObjectsCmd)
+}
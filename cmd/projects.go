@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/projects"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	projectsCmd := &cobra.Command{
+		Use:   "projects",
+		Short: "Manage the registry of projects",
+		Long: `Manage the registry of projects.
+
+Registered projects are stored in the user-level configuration file and
+can be listed together with --all-projects of the list and status commands.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return projects.List()
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add [paths...]",
+		Short: "Register projects",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return projects.Add(args)
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:     "remove [paths...]",
+		Aliases: []string{"rm"},
+		Short:   "Unregister projects",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return projects.Remove(args)
+		},
+	}
+
+	projectsCmd.AddCommand(addCmd, removeCmd)
+	rootCmd.AddCommand(projectsCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/ps"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	psCmd := &cobra.Command{
+		Use:   "ps",
+		Short: "List running experiments and their processes",
+		Long: `List experiments whose summaries say they are running, with the PID of
+the command, the elapsed time, and whether the process is actually alive.
+
+Runs whose process died without finishing the summary (e.g., because the
+machine rebooted) are flagged as stale. The state of processes on other
+hosts is unknown.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ps.Main()
+		},
+	}
+
+	rootCmd.AddCommand(psCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/queue"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	queueCmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Manage the queue of experiments",
+		Long: `Manage the queue of experiments.
+
+Commands are added to the queue with run --queue and run by queue start.
+The queue is stored in the base directory, so queued and finished jobs
+survive restarts; jobs left running by a worker that is gone are run again
+by the next worker.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return queue.List()
+		},
+	}
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Run queued experiments until the queue is drained",
+		Long: `Run queued experiments until the queue is drained.
+
+At most --workers experiments run at the same time. Outputs of the commands
+are only written to the log files of the runs. On interrupt, no more jobs are
+started and the worker exits after the running ones finish.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return queue.Start()
+		},
+	}
+	cfg := config.GetPointer()
+	startCmd.Flags().IntVarP(&cfg.Queue.Workers, "workers", "w", 1,
+		"Maximum number of experiments running at the same time")
+
+	removeCmd := &cobra.Command{
+		Use:     "remove [ids...]",
+		Aliases: []string{"rm"},
+		Short:   "Remove pending jobs, or finished jobs if no ID is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return queue.Remove(args)
+		},
+	}
+
+	queueCmd.AddCommand(startCmd, removeCmd)
+	rootCmd.AddCommand(queueCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/repro"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	reproCmd := &cobra.Command{
+		Use:     "repro [run]",
+		Aliases: []string{"reproduce"},
+		Short:   "Reproduce a past run from its recorded code state",
+		Long: `Reproduce a past run as exactly as possible.
+
+This command will:
+1. Check out the run's recorded commit into a temporary worktree
+2. Apply the run's recorded uncommitted changes (uncommitted.patch if saved)
+3. Recreate the recorded environment variables (see run.record_env)
+4. Re-run the recorded command as a new run linked to the original
+
+The command runs in a run directory created in the worktree, so that paths
+relative to it refer to the checked-out code, and the run is moved into the
+current base directory when it finishes; the temporary worktree is removed
+afterwards. With --worktree, the worktree is created at
+the given path and kept, and with --no-run only the code state is restored.
+If the code state of the worktree does not match the original run (e.g.,
+the recorded changes did not fully apply), --allow-different-code is
+required and the discrepancy is recorded.
+
+If no run is given, it can be selected interactively by fuzzy search.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := runArgs(args)
+			if err != nil {
+				return err
+			}
+			return repro.Main(args[0])
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	reproCmd.Flags().BoolVarP(&cfg.Run.NoPushd, "no-pushd", "n", false,
+		"Execute command in the worktree (don't cd to experiment dir)")
+	reproCmd.Flags().BoolVarP(&cfg.Run.Silent, "silent", "s", false,
+		"Suppress command output to stdout/stderr (write only to log files)")
+	reproCmd.Flags().BoolVar(&cfg.Run.AllowDifferentCode, "allow-different-code", false,
+		"Run even if the code state differs from the original run")
+	reproCmd.Flags().StringVarP(&cfg.Run.Message, "message", "m", "",
+		"Message for the new experiment")
+	reproCmd.Flags().StringVar(&cfg.Repro.Worktree, "worktree", "",
+		"Create the worktree at this path and keep it")
+	reproCmd.Flags().StringVarP(&cfg.Repro.Branch, "branch", "b", "",
+		"Create a new branch for the worktree (requires --worktree)")
+	reproCmd.Flags().BoolVar(&cfg.Repro.NoRun, "no-run", false,
+		"Only restore the code state without re-running the command (requires --worktree)")
+
+	rootCmd.AddCommand(reproCmd)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/rerun"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rerunCmd := &cobra.Command{
+		Use:   "rerun [run]",
+		Short: "Run the command of a past run again",
+		Long: `Run the command recorded in a past run's summary again as a new run.
+
+The command runs on the current code state unless --checkout is given, in
+which case the run's recorded commit is checked out into a temporary
+worktree first (recorded uncommitted changes are not applied; use repro for
+that) and, as with repro, the run directory is created in the worktree and
+moved into the base directory afterwards. The new summary links back to the original run. If no run is given,
+it can be selected interactively by fuzzy search.
+
+If HEAD or the uncommitted changes differ from those of the original run,
+the rerun is refused unless --allow-different-code is given, in which case
+the differences are recorded in the new summary.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := runArgs(args)
+			if err != nil {
+				return err
+			}
+			return rerun.Main(args[0])
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	rerunCmd.Flags().BoolVar(&cfg.Run.Checkout, "checkout", false,
+		"Run on the recorded commit checked out into a temporary worktree")
+	rerunCmd.Flags().BoolVar(&cfg.Run.AllowDifferentCode, "allow-different-code", false,
+		"Run even if the code state differs from the original run")
+	rerunCmd.Flags().BoolVarP(&cfg.Run.Force, "force", "f", false,
+		"Allow experiments to run with uncommitted changes")
+	rerunCmd.Flags().BoolVarP(&cfg.Run.NoPushd, "no-pushd", "n", false,
+		"Execute command in current directory (don't cd to experiment dir)")
+	rerunCmd.Flags().BoolVarP(&cfg.Run.Silent, "silent", "s", false,
+		"Suppress command output to stdout/stderr (write only to log files)")
+	rerunCmd.Flags().StringVarP(&cfg.Run.Message, "message", "m", "",
+		"Message for the new experiment")
+
+	rootCmd.AddCommand(rerunCmd)
+}
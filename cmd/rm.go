@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/remove"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rmCmd := &cobra.Command{
+		Use:   "rm [run_directories...]",
+		Short: "Delete run directories",
+		Long: `Delete run directories.
+
+Only directories named like runs (timestamp_branch_hash) with a readable
+summary file are removed, and running runs are refused unless --force is
+given. Nothing is removed if any of the targets is invalid. If no run is
+given, one can be selected interactively by fuzzy search.`,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := runArgs(args)
+			if err != nil {
+				return err
+			}
+			return remove.Main(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	rmCmd.Flags().BoolVarP(&cfg.Remove.Force, "force", "f", false,
+		"Remove runs even if they are still running")
+	rmCmd.Flags().BoolVar(&cfg.Remove.DryRun, "dry-run", false,
+		"Show what would be removed without executing")
+	rmCmd.Flags().BoolVarP(&cfg.Remove.Yes, "yes", "y", false,
+		"Remove without asking for confirmation")
+
+	rootCmd.AddCommand(rmCmd)
+}
@@ -2,9 +2,21 @@ package cmd
 
 import (
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 )
 
+// Version of moco, set at build time with
+// -ldflags "-X github.com/bicycle1885/moco/cmd.Version=vX.Y.Z"
+var Version = "dev"
+
+// validatesConfig annotates commands that report invalid settings themselves
+// instead of failing before running
+const validatesConfig = "validates_config"
+
+// Logging verbosity given by the global flags
+var quiet, verbose bool
+
 var rootCmd = &cobra.Command{
 	Use:   "moco",
 	Short: "Moco - Research experiment manager",
@@ -12,8 +24,21 @@ var rootCmd = &cobra.Command{
 
 It ensures reproducibility by tracking git repository state, 
 capturing command output, and documenting execution details.`,
+	Version:       Version,
 	SilenceErrors: true,
 	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if quiet {
+			log.SetLevel(log.WarnLevel)
+		} else if verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		if cmd.Annotations[validatesConfig] != "" {
+			return nil
+		}
+		return config.Err()
+	},
 }
 
 // Execute runs the root command
@@ -25,4 +50,9 @@ func init() {
 	cfg := config.GetPointer()
 	rootCmd.PersistentFlags().StringVarP(&cfg.BaseDir, "base-dir", "d", "",
 		"Base directory for experiment output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Show only warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
+		"Show debug messages")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
 }
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/queue"
 	"github.com/bicycle1885/moco/internal/run"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func init() {
@@ -25,6 +27,9 @@ Each experiment is stored in a directory with a timestamp, branch name,
 and git commit hash to ensure traceability.`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.Get().Run.Queue {
+				return queue.Enqueue(queuedArgs(cmd, args))
+			}
 			// Execute the command with experiment tracking
 			return run.Main(args)
 		},
@@ -40,10 +45,55 @@ and git commit hash to ensure traceability.`,
 		"Remove experiment directory if command fails")
 	runCmd.Flags().BoolVarP(&cfg.Run.Silent, "silent", "s", false,
 		"Suppress command output to stdout/stderr (write only to log files)")
+	runCmd.Flags().BoolVar(&cfg.Run.Progress, "progress", false,
+		"Show a spinner with the elapsed time while the command runs silently")
+	runCmd.Flags().StringVar(&cfg.Run.Name, "name", "",
+		"Name the experiment (appended to the directory name)")
 	runCmd.Flags().StringVarP(&cfg.Run.Message, "message", "m", "",
 		"Get user input for experiment message")
+	runCmd.Flags().BoolVar(&cfg.Run.GitNotes, "git-notes", false,
+		"Attach a record of the experiment to the commit as a git note (refs/notes/moco)")
+	runCmd.Flags().BoolVar(&cfg.Run.Queue, "queue", false,
+		"Add the command to the queue instead of running it (see moco queue)")
+	runCmd.Flags().StringVar(&cfg.Run.After, "after", "",
+		"Wait for a run (directory or @last) to finish and run only if it succeeded")
+	runCmd.Flags().StringVar(&cfg.Run.Timeout, "timeout", "",
+		"Terminate the command if it runs longer than this (e.g., 2h)")
+	runCmd.Flags().StringSliceVar(&cfg.Run.Issues, "issue", nil,
+		"Link the experiment to an issue (e.g., PROJ-42); can be repeated")
+	runCmd.Flags().StringSliceVar(&cfg.Run.Include, "include", nil,
+		"Copy input files matching a glob into the run directory (e.g., 'configs/*.yaml'); can be repeated")
+	runCmd.Flags().StringSliceVar(&cfg.Run.Templates, "template", nil,
+		"Render a Go template file into the run directory (e.g., config.yaml.tmpl); can be repeated")
+	runCmd.Flags().StringSliceVar(&cfg.Run.Tags, "tag", nil,
+		"Tag the experiment (e.g., baseline); can be repeated")
 	runCmd.Flags().BoolVarP(&cfg.Run.PromptMessage, "prompt-message", "p", false,
 		"Prompt for user input for experiment message")
 
+	// Complete flag values
+	runCmd.RegisterFlagCompletionFunc("issue", completeIssues)
+	runCmd.RegisterFlagCompletionFunc("tag", completeTags)
+
+	runCmd.RegisterFlagCompletionFunc("after", completeRunDirs)
+
 	rootCmd.AddCommand(runCmd)
 }
+
+// queuedArgs reconstructs the arguments of moco run for a queued command from
+// the flags given on the command line, except --queue
+func queuedArgs(cmd *cobra.Command, commands []string) []string {
+	var args []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Name == "queue" {
+			return
+		}
+		if value, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, item := range value.GetSlice() {
+				args = append(args, "--"+flag.Name+"="+item)
+			}
+			return
+		}
+		args = append(args, "--"+flag.Name+"="+flag.Value.String())
+	})
+	return append(append(args, "--"), commands...)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/tui"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// runArgs resolves runs given as arguments, or returns the directory of a
+// run selected with the fuzzy run picker if no arguments are given
+func runArgs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		run, err := tui.PickRun()
+		if err != nil {
+			return nil, err
+		}
+		return []string{run}, nil
+	}
+	return resolveRunRefs(args)
+}
+
+// resolveRunRefs resolves symbolic references such as @last and names of
+// run directories in the base directory into paths of runs
+func resolveRunRefs(args []string) ([]string, error) {
+	cfg := config.Get()
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		run, err := utils.ResolveRunRef(cfg.BaseDir, cfg.SummaryFile, arg)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = run
+	}
+	return resolved, nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/search"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	searchCmd := &cobra.Command{
+		Use:   "search [query...]",
+		Short: "Search runs by their messages and summaries",
+		Long: `Search runs by their messages and summaries.
+
+Each term of the query must match for a run to be listed. A plain term is
+searched for in the whole summary text, while a term qualified with a
+field only matches that field:
+
+  name:     the run name
+  message:  the experiment message
+  command:  the executed command
+  branch:   the branch name
+  commit:   the commit hash
+  issue:    the linked issues
+  tag:      the tags
+  note:     the texts of notes
+
+Terms are matched case-insensitively as substrings, or as regular
+expressions with --regex. With --logs, plain terms are also searched for in
+the stdout and stderr logs. Matching runs are listed with excerpts of the
+matching lines, and can be narrowed down by the same filters as list.
+For example:
+
+  moco search message:baseline command:train.py
+  moco search --regex 'lr=0\.(1|01)'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return search.Main(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	searchCmd.Flags().BoolVarP(&cfg.Search.Regex, "regex", "e", false,
+		"Interpret terms as regular expressions")
+	searchCmd.Flags().BoolVar(&cfg.Search.Logs, "logs", false,
+		"Search the stdout and stderr logs as well as summaries")
+	addFilterFlags(searchCmd)
+
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	selfUpdateCmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update moco to the latest release",
+		Long: `Update moco to the latest release on GitHub.
+
+This command finds the latest release of the selected channel, downloads
+the binary for the current platform, verifies its SHA-256 checksum against
+the checksums published with the release, and replaces the running binary.
+The checksum only detects corrupted downloads; it is not a signature.
+
+The stable channel only considers regular releases, while the prerelease
+channel also considers pre-releases. Versions are compared as semantic
+versions, and the binary is only replaced by a strictly newer release.
+Builds without a release version (e.g., dev) are not replaced unless
+--force is given, which also allows reinstalling or downgrading.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return selfupdate.Main(Version)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	selfUpdateCmd.Flags().StringVar(&cfg.SelfUpdate.Channel, "channel", "stable",
+		"Release channel (stable, prerelease)")
+	selfUpdateCmd.Flags().BoolVar(&cfg.SelfUpdate.Check, "check", false,
+		"Only check whether a new version is available")
+	selfUpdateCmd.Flags().BoolVarP(&cfg.SelfUpdate.Force, "force", "f", false,
+		"Install the latest release even if it is not newer")
+
+	// Complete flag values
+	selfUpdateCmd.RegisterFlagCompletionFunc("channel", completeValues("stable", "prerelease"))
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/serve"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a web UI for browsing runs",
+		Long: `Serve a web UI for browsing runs in the base directory.
+
+The UI lists runs with the same filters as the list command, and shows the
+summaries and logs of runs. A read-only JSON API is served under /api:
+
+  /api/runs          Runs as in list --format json, with the same filters
+                     as query parameters (e.g., ?status=failure&limit=10)
+  /api/runs/{dir}    Information of a run given its directory name
+  /api/status        Project status as in status --format json
+
+The server listens on localhost unless another address is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve.Main()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	serveCmd.Flags().StringVar(&cfg.Serve.Addr, "addr", "127.0.0.1:8080",
+		"Address to listen on")
+	serveCmd.Flags().BoolVar(&cfg.Serve.APIOnly, "api-only", false,
+		"Serve only the JSON API, without the web UI")
+
+	rootCmd.AddCommand(serveCmd)
+}
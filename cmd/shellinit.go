@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/shellinit"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	shellInitCmd := &cobra.Command{
+		Use:   "shell-init [bash|zsh|fish]",
+		Short: "Print shell integration code",
+		Long: `Print shell integration code for bash, zsh, or fish.
+
+The code defines the following helpers:
+
+- mlast: print the path of the most recent run
+- mshow [run]: show the summary of a run (defaults to the most recent run)
+- mcd [run]: change directory to a run (defaults to the most recent run)
+- moco_prompt: prompt segment showing the number of running runs
+
+To enable it, add the following to your shell's startup file:
+
+  eval "$(moco shell-init bash)"          # ~/.bashrc
+  eval "$(moco shell-init zsh)"           # ~/.zshrc
+  moco shell-init fish | source           # ~/.config/fish/config.fish`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return shellinit.Main(args[0])
+		},
+	}
+
+	rootCmd.AddCommand(shellInitCmd)
+}
@@ -16,9 +16,15 @@ func init() {
 The summary file is rendered as markdown by default and displayed in a pager.
 You can specify either a directory containing the summary file or the summary file itself.
   
-If a directory is provided, it will look for the summary file as defined in your configuration.`,
-		Args: cobra.ExactArgs(1),
+If a directory is provided, it will look for the summary file as defined in your configuration.
+If no run is given, it can be selected interactively by fuzzy search.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := runArgs(args)
+			if err != nil {
+				return err
+			}
 			return show.Main(args[0])
 		},
 	}
@@ -28,6 +28,14 @@ The level of detail and output format can be customized.`,
 	// Add flags
 	cfg := config.GetPointer()
 	statusCmd.Flags().StringVarP(&cfg.Status.Level, "level", "l", "normal", "Level of detail (minimal, normal, full)")
+	statusCmd.Flags().StringVarP(&cfg.Status.Format, "format", "f", "text", "Output format (text, markdown, json, yaml, prometheus)")
+	statusCmd.Flags().BoolVarP(&cfg.Status.AllProjects, "all-projects", "A", false, "Include runs of all registered projects")
+	statusCmd.Flags().BoolVarP(&cfg.Status.Watch, "watch", "w", false, "Redraw the status periodically, listing running experiments first")
+	statusCmd.Flags().StringVar(&cfg.Tui.Refresh, "refresh", "2s", "Refresh interval of --watch (e.g., '5s')")
+
+	// Complete flag values
+	statusCmd.RegisterFlagCompletionFunc("level", completeValues("minimal", "normal", "full"))
+	statusCmd.RegisterFlagCompletionFunc("format", completeValues("text", "markdown", "json", "yaml", "prometheus"))
 
 	rootCmd.AddCommand(statusCmd)
 }
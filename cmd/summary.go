@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	summaryCmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Manage summary files of runs",
+	}
+
+	regenerateCmd := &cobra.Command{
+		Use:   "regenerate [runs...]",
+		Short: "Rebuild summary files with the current template",
+		Long: `Rebuild summary files of runs with the current template.
+
+The data recorded in each summary file (metadata, git state, environment,
+and execution results) is parsed and written back in the current format.
+This is useful after the summary template has changed. A summary is left
+untouched if it cannot be parsed.`,
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return summary.Regenerate(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	regenerateCmd.Flags().BoolVarP(&cfg.Summary.All, "all", "a", false,
+		"Regenerate summaries of all runs in the base directory")
+
+	summaryCmd.AddCommand(regenerateCmd)
+	rootCmd.AddCommand(summaryCmd)
+}
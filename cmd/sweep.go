@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/sweep"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	sweepCmd := &cobra.Command{
+		Use:   "sweep [command]",
+		Short: "Run a command over a grid of parameter values",
+		Long: `Run a command for each combination of parameter values.
+
+Parameters are given with --param (e.g., --param lr=0.1,0.01) or in a TOML
+specification file with a [params] table (e.g., lr = [0.1, 0.01]). Each
+"{name}" placeholder in the command is replaced with the value of the
+parameter, and every combination runs as a tracked experiment with its own
+run directory. The runs share a sweep ID, which is recorded in their
+summaries along with the parameter values and can be used with list --sweep.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sweep.Main(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	sweepCmd.Flags().StringArrayVar(&cfg.Sweep.Params, "param", nil,
+		"Parameter and its values (e.g., lr=0.1,0.01); can be repeated")
+	sweepCmd.Flags().StringVar(&cfg.Sweep.Spec, "spec", "",
+		"TOML file specifying parameters in a [params] table")
+	sweepCmd.Flags().BoolVar(&cfg.Sweep.DryRun, "dry-run", false,
+		"Print the commands to run without running them")
+	sweepCmd.Flags().BoolVarP(&cfg.Run.Force, "force", "f", false,
+		"Allow experiments to run with uncommitted changes")
+	sweepCmd.Flags().BoolVarP(&cfg.Run.NoPushd, "no-pushd", "n", false,
+		"Execute commands in current directory (don't cd to experiment dir)")
+	sweepCmd.Flags().BoolVarP(&cfg.Run.Silent, "silent", "s", false,
+		"Suppress command output to stdout/stderr (write only to log files)")
+	sweepCmd.Flags().StringVarP(&cfg.Run.Message, "message", "m", "",
+		"Message for the experiments")
+	sweepCmd.Flags().StringSliceVar(&cfg.Run.Templates, "template", nil,
+		"Render a Go template file into each run directory with {{.Params.name}}; can be repeated")
+	sweepCmd.Flags().StringSliceVar(&cfg.Run.Tags, "tag", nil,
+		"Tag the experiments (e.g., baseline); can be repeated")
+
+	// Complete flag values
+	sweepCmd.RegisterFlagCompletionFunc("tag", completeTags)
+
+	rootCmd.AddCommand(sweepCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/tag"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Manage tags of runs",
+		Long: `Manage tags of runs.
+
+Tags are recorded in the metadata of the summary file and can be used to
+filter runs with list --tag.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:               "add [run] [tags...]",
+		Short:             "Add tags to a run",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeRunThenTags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tag.Add(args[0], args[1:])
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:               "remove [run] [tags...]",
+		Aliases:           []string{"rm"},
+		Short:             "Remove tags from a run",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeRunThenTags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tag.Remove(args[0], args[1:])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:               "list [run]",
+		Aliases:           []string{"ls"},
+		Short:             "List tags of a run, or all tags with their number of runs",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRunDirs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			run := ""
+			if len(args) > 0 {
+				run = args[0]
+			}
+			return tag.List(run)
+		},
+	}
+
+	tagCmd.AddCommand(addCmd, removeCmd, listCmd)
+	rootCmd.AddCommand(tagCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/tensorboard"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tensorboardCmd := &cobra.Command{
+		Use:     "tensorboard [-- tensorboard args...]",
+		Aliases: []string{"tb"},
+		Short:   "Launch TensorBoard on the logs of runs",
+		Long: `Launch TensorBoard on the log directories of runs selected by the same
+filters as list.
+
+Runs record the log directory given by run.tensorboard_dir, and each log
+directory is labeled with the name of its run directory (without colons,
+which TensorBoard does not allow in names). Arguments after -- are passed to
+TensorBoard.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tensorboard.Main(args)
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	tensorboardCmd.Flags().BoolVar(&cfg.TensorBoard.DryRun, "dry-run", false,
+		"Print the command without running it")
+	addFilterFlags(tensorboardCmd)
+
+	rootCmd.AddCommand(tensorboardCmd)
+}
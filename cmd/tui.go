@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Open a full-screen dashboard of the project",
+		Long: `Open a full-screen dashboard of the project.
+
+The dashboard shows the repository status, the list of runs, and the tail
+of the selected run's stdout or stderr, refreshed periodically so that
+running experiments can be followed live. The selected run's summary can
+be viewed, and finished runs can be archived or deleted.
+
+Keys:
+  j/k, up/down  select a run
+  enter, s      view the summary
+  tab           switch between stdout and stderr
+  /             filter runs (enter to finish, esc to clear)
+  a             archive the selected run
+  d             delete the selected run
+  r             refresh
+  q             quit`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.Main()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	tuiCmd.Flags().StringVar(&cfg.Tui.Refresh, "refresh", "2s",
+		"Refresh interval (e.g., '2s')")
+
+	rootCmd.AddCommand(tuiCmd)
+}
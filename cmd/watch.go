@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch running experiments live",
+		Long: `Show running experiments with their elapsed time, recently finished
+experiments, and the disk usage of the base directory, refreshed
+periodically until you press q.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.Watch()
+		},
+	}
+
+	// Add flags
+	cfg := config.GetPointer()
+	watchCmd.Flags().StringVar(&cfg.Tui.Refresh, "refresh", "2s",
+		"Refresh interval (e.g., '2s')")
+
+	rootCmd.AddCommand(watchCmd)
+}
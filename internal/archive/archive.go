@@ -3,36 +3,54 @@ package archive
 import (
 	"archive/tar"
 	"archive/zip"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
 	"github.com/bicycle1885/moco/internal/utils"
 	"github.com/charmbracelet/log"
 )
 
-// Run archives experiments
+// Options holds settings of archiving that are not part of the configuration
+type Options struct {
+	// Archive without asking for confirmation, as with --yes
+	Yes bool
+}
+
+// Main archives experiments
 func Main(runs []string) error {
+	return MainWithOptions(runs, Options{})
+}
+
+// MainWithOptions archives experiments with options given by the caller
+// rather than the command line
+func MainWithOptions(runs []string, opts Options) error {
 	// Get config
 	cfg := config.Get()
 
 	// Validate format
-	if cfg.Archive.Format != "tar.gz" && cfg.Archive.Format != "zip" {
-		return fmt.Errorf("unsupported archive format: %s", cfg.Archive.Format)
+	if err := validateFormat(cfg.Archive.Format); err != nil {
+		return err
+	}
+	if cfg.Archive.EncryptTo != "" {
+		if err := validateEncryption(cfg.Archive.EncryptTo); err != nil {
+			return err
+		}
 	}
 
 	// Parse olderThan if provided
 	var cutoff time.Time
 	if cfg.Archive.OlderThan != "" {
 		var err error
-		cutoff, err = parseCutoff(cfg.Archive.OlderThan)
+		cutoff, err = ParseCutoff(cfg.Archive.OlderThan)
 		if err != nil {
 			return fmt.Errorf("invalid olderThan format: %w", err)
 		}
@@ -42,7 +60,7 @@ func Main(runs []string) error {
 	}
 
 	// Filter runs to archive
-	runInfos := filterRunsToArchive(runs, cutoff, cfg.Archive.Status)
+	runInfos := FilterRuns(runs, cutoff, cfg.Archive.Status)
 	if len(runInfos) == 0 {
 		return fmt.Errorf("no runs found matching the criteria")
 	}
@@ -51,7 +69,7 @@ func Main(runs []string) error {
 	log.Infof("Found %d run(s) to archive:", len(runInfos))
 	for _, runInfo := range runInfos {
 		var status string
-		if runInfo.ExitStatus == 0 {
+		if runInfo.Success {
 			status = "Success"
 		} else {
 			status = "Failure"
@@ -65,46 +83,78 @@ func Main(runs []string) error {
 	}
 
 	// Confirm with user
-	if !confirmArchive() {
+	if !cfg.Archive.Yes && !opts.Yes && !confirmArchive() {
 		log.Info("Archive operation cancelled")
 		return nil
 	}
 
-	// Ensure destination directory
-	destDir := cfg.Archive.To
-	if destDir == "" {
-		destDir = "archives"
+	// Set up the destination
+	storage, err := NewStorage(destination(cfg))
+	if err != nil {
+		return err
 	}
 
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	// Archive each run, recording it in the archive index even if a later one fails
+	var archived []ArchivedRun
+	err = archiveRuns(runInfos, storage, func(run ArchivedRun) {
+		archived = append(archived, run)
+	})
+	if len(archived) > 0 {
+		if err := updateArchiveIndex(storage, archived); err != nil {
+			log.Warnf("Failed to update archive index: %v", err)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	// Archive each run
+	log.Infof("Successfully archived %d run(s)", len(runInfos))
+
+	// Drop deleted runs from the index
+	if cfg.Archive.Delete {
+		index.Update(cfg.BaseDir, cfg.SummaryFile)
+	}
+
+	return nil
+}
+
+// archiveRuns archives runs to the storage and reports each archived run
+func archiveRuns(runInfos []utils.RunInfo, storage Storage, done func(ArchivedRun)) error {
+	cfg := config.Get()
 	for _, runInfo := range runInfos {
 		runDir := runInfo.Directory
-		dirName := filepath.Base(filepath.Clean(runDir))
-		archivePath := filepath.Join(destDir, dirName+"."+cfg.Archive.Format)
-		log.Infof("Archiving %s to %s", runDir, archivePath)
-		if err := archiveDirectory(runDir, archivePath, cfg.Archive.Format); err != nil {
+		name := filepath.Base(filepath.Clean(runDir)) + "." + cfg.Archive.Format
+		if cfg.Archive.EncryptTo != "" {
+			name += encryptionSuffix(cfg.Archive.EncryptTo)
+		}
+		log.Infof("Archiving %s to %s", runDir, storage.Location(name))
+		if err := archiveDirectory(runDir, storage, name, cfg.Archive.Format); err != nil {
 			return fmt.Errorf("failed to archive %s: %w", runDir, err)
 		}
+		run := ArchivedRun{
+			Name:       name,
+			Archive:    storage.Location(name),
+			ArchivedAt: time.Now(),
+			Run:        runInfo,
+		}
 
 		// Delete original if requested
 		if cfg.Archive.Delete {
 			log.Infof("Deleting original directory %s", runDir)
 			if err := os.RemoveAll(runDir); err != nil {
+				done(run)
 				return fmt.Errorf("failed to delete %s: %w", runDir, err)
 			}
+			run.Deleted = true
 		}
+		done(run)
 	}
-
-	log.Infof("Successfully archived %d run(s)", len(runInfos))
-
 	return nil
 }
 
-func filterRunsToArchive(runDirs []string, cutoff time.Time, status string) []utils.RunInfo {
+// FilterRuns returns finished runs started before the cutoff with the given
+// status (success, failure, interrupted, or all)
+func FilterRuns(runDirs []string, cutoff time.Time, status string) []utils.RunInfo {
 	var results []utils.RunInfo
 
 	// Get configuration
@@ -124,8 +174,7 @@ func filterRunsToArchive(runDirs []string, cutoff time.Time, status string) []ut
 		}
 
 		// Parse timestamp from directory name
-		dirName := filepath.Base(filepath.Clean(runDir))
-		timestamp, err := time.Parse("2006-01-02T15:04:05.000", dirName[:23])
+		timestamp, err := utils.ParseRunDirTime(runDir)
 		if err != nil {
 			log.Warnf("Failed to parse timestamp: %v", err)
 			continue // Invalid timestamp format
@@ -141,6 +190,7 @@ func filterRunsToArchive(runDirs []string, cutoff time.Time, status string) []ut
 		runInfo, err := utils.ParseRunInfo(summaryPath)
 		if err != nil {
 			log.Warnf("Failed to parse summary file: %v", err)
+			continue
 		}
 
 		// Apply status filter
@@ -148,10 +198,13 @@ func filterRunsToArchive(runDirs []string, cutoff time.Time, status string) []ut
 			continue
 		}
 		if status != "" && status != "all" {
-			if status == "success" && runInfo.ExitStatus != 0 {
+			if status == "success" && !runInfo.Success {
 				continue
 			}
-			if status == "failure" && runInfo.ExitStatus == 0 {
+			if status == "failure" && runInfo.Success {
+				continue
+			}
+			if status == "interrupted" && !runInfo.Interrupted {
 				continue
 			}
 		}
@@ -173,8 +226,8 @@ func directoryExists(path string) (bool, error) {
 	return info.IsDir(), nil
 }
 
-// parseCutoff parses a cutoff string like "30d" to a time.Time
-func parseCutoff(cutoff string) (time.Time, error) {
+// ParseCutoff parses a cutoff string like "30d" to a time.Time
+func ParseCutoff(cutoff string) (time.Time, error) {
 	// Parse the duration string
 	re := regexp.MustCompile(`^(\d+)([dhm])$`)
 	matches := re.FindStringSubmatch(cutoff)
@@ -214,46 +267,78 @@ func confirmArchive() bool {
 	return response == "y" || response == "yes"
 }
 
-// archiveDirectory handles the actual archiving process
-func archiveDirectory(srcDir, destPath, format string) error {
+// archiveDirectory handles the actual archiving process, streaming the
+// archive to the storage and encrypting it if a recipient is configured
+func archiveDirectory(srcDir string, storage Storage, name, format string) error {
+	cfg := config.Get()
+	w, err := storage.Create(name)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = w
+	var encrypter *filterWriter
+	if cfg.Archive.EncryptTo != "" {
+		encrypter, err = encryptTo(w, cfg.Archive.EncryptTo)
+		if err != nil {
+			w.Abort()
+			return err
+		}
+		out = encrypter
+	}
+
 	switch format {
-	case "tar.gz":
-		return archiveToTarGz(srcDir, destPath)
 	case "zip":
-		return archiveToZip(srcDir, destPath)
+		err = archiveToZip(srcDir, out)
 	default:
-		return fmt.Errorf("unsupported archive format: %s", format)
+		err = compressTar(format, cfg.Archive.Level, out, func(w io.Writer) error {
+			return archiveToTar(srcDir, w)
+		})
+	}
+	if encrypter != nil {
+		// A failure of the encrypter is more informative than a broken pipe
+		if closeErr := encrypter.Close(); closeErr != nil {
+			err = closeErr
+		}
 	}
-}
-
-// archiveToTarGz creates a tar.gz archive of a directory
-func archiveToTarGz(srcDir, destPath string) error {
-	// Create destination file
-	destFile, err := os.Create(destPath)
 	if err != nil {
+		w.Abort()
 		return err
 	}
-	defer destFile.Close()
+	return w.Close()
+}
 
-	// Create gzip writer
-	gzWriter := gzip.NewWriter(destFile)
-	defer gzWriter.Close()
+// excluded reports whether a path relative to a run directory matches any of
+// the exclude patterns; the summary file is never excluded
+func excluded(relPath string) bool {
+	cfg := config.Get()
+	if relPath == "." || relPath == cfg.SummaryFile {
+		return false
+	}
+	file := filepath.ToSlash(relPath)
+	return slices.ContainsFunc(cfg.Archive.Exclude, func(pattern string) bool {
+		return utils.MatchPathPattern(pattern, file)
+	})
+}
 
+// archiveToTar writes a tar archive of a directory
+func archiveToTar(srcDir string, w io.Writer) error {
 	// Create tar writer
-	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
+	tarWriter := tar.NewWriter(w)
+	hasher := hashingWriter{sums: manifest{}}
 
 	// Walk through all files in source directory
 	baseDir := filepath.Base(srcDir)
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Create tar header
-		header, err := tar.FileInfoHeader(info, info.Name())
-		if err != nil {
-			return err
+		// Artifacts linked to the object store are archived as files
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); err != nil {
+				return err
+			}
 		}
 
 		// Set header name relative to source directory
@@ -261,6 +346,18 @@ func archiveToTarGz(srcDir, destPath string) error {
 		if err != nil {
 			return err
 		}
+		if relPath == ManifestFile || excluded(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Create tar header
+		header, err := tar.FileInfoHeader(info, info.Name())
+		if err != nil {
+			return err
+		}
 		header.Name = filepath.Join(baseDir, relPath)
 
 		// Write header
@@ -280,31 +377,68 @@ func archiveToTarGz(srcDir, destPath string) error {
 		}
 		defer file.Close()
 
-		_, err = io.Copy(tarWriter, file)
-		return err
+		if !info.Mode().IsRegular() {
+			_, err = io.Copy(tarWriter, file)
+			return err
+		}
+		return hasher.copy(tarWriter, file, relPath)
 	})
-}
-
-// archiveToZip creates a zip archive of a directory
-func archiveToZip(srcDir, destPath string) error {
-	// Create zip file
-	zipFile, err := os.Create(destPath)
 	if err != nil {
 		return err
 	}
-	defer zipFile.Close()
 
+	// Add the checksum manifest
+	data := hasher.sums.format()
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filepath.Join(baseDir, ManifestFile),
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return err
+	}
+
+	// Closing flushes the remaining data
+	return tarWriter.Close()
+}
+
+// archiveToZip writes a zip archive of a directory
+func archiveToZip(srcDir string, w io.Writer) error {
 	// Create zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	zipWriter := zip.NewWriter(w)
+	hasher := hashingWriter{sums: manifest{}}
 
 	// Walk through all files in source directory
 	baseDir := filepath.Base(srcDir)
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Artifacts linked to the object store are archived as files
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); err != nil {
+				return err
+			}
+		}
+
+		// Set header name relative to source directory
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == ManifestFile || excluded(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Skip directories directly
 		if info.IsDir() {
 			return nil
@@ -315,12 +449,6 @@ func archiveToZip(srcDir, destPath string) error {
 		if err != nil {
 			return err
 		}
-
-		// Set header name relative to source directory
-		relPath, err := filepath.Rel(srcDir, path)
-		if err != nil {
-			return err
-		}
 		header.Name = filepath.Join(baseDir, relPath)
 		header.Method = zip.Deflate
 
@@ -337,76 +465,29 @@ func archiveToZip(srcDir, destPath string) error {
 		}
 		defer file.Close()
 
-		_, err = io.Copy(writer, file)
-		return err
+		if !info.Mode().IsRegular() {
+			_, err = io.Copy(writer, file)
+			return err
+		}
+		return hasher.copy(writer, file, relPath)
 	})
-}
-
-// updateArchiveIndex creates or updates an index of archived experiments
-/*
-func updateArchiveIndex(destDir string, exps []ExperimentInfo, format string, deleted bool) error {
-	// Create or open index file
-	indexPath := filepath.Join(destDir, "archive_index.md")
-
-	// Check if file exists
-	var indexContent string
-
-	data, err := os.ReadFile(indexPath)
-	if err == nil {
-		indexContent = string(data)
-	} else if !errors.Is(err, os.ErrNotExist) {
+	if err != nil {
 		return err
 	}
 
-	// Open file for writing
-	file, err := os.Create(indexPath)
+	// Add the checksum manifest
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     filepath.Join(baseDir, ManifestFile),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// If no content, create header
-	if indexContent == "" {
-		indexContent = "# Moco Experiment Archive Index\n\n"
-		indexContent += "| Archive File | Original Directory | Timestamp | Branch | Status | Archived On | Original Deleted |\n"
-		indexContent += "|-------------|-------------------|-----------|--------|--------|------------|------------------|\n"
-	}
-
-	// Add entries for newly archived experiments
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	for _, exp := range exps {
-		status := "Running"
-		if exp.IsFinished {
-			if exp.ExitStatus == 0 {
-				status = "Success"
-			} else {
-				status = fmt.Sprintf("Failed (%d)", exp.ExitStatus)
-			}
-		}
-
-		// Use proper Go syntax for conditional values
-		deletedStr := "No"
-		if deleted {
-			deletedStr = "Yes"
-		}
-
-		// Create a new row for this experiment
-		newRow := fmt.Sprintf("| %s.%s | %s | %s | %s | %s | %s | %s |\n",
-			exp.Name, format,
-			exp.Name,
-			exp.Timestamp.Format("2006-01-02 15:04:05"),
-			exp.Branch,
-			status,
-			now,
-			deletedStr)
-
-		// Add to content
-		indexContent += newRow
+	if _, err := writer.Write(hasher.sums.format()); err != nil {
+		return err
 	}
 
-	// Write to file
-	_, err = file.WriteString(indexContent)
-	return err
+	// Closing writes the central directory
+	return zipWriter.Close()
 }
-*/
@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveExclude(t *testing.T) {
+	cfg := config.GetPointer()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+	*cfg = config.GetDefault()
+	cfg.Archive.Exclude = []string{"*.ckpt", "checkpoints", "tmp/**", "summary.md"}
+
+	runDir := filepath.Join(t.TempDir(), "2025-03-24T00:00:00.000_main_1234567")
+	for _, file := range []string{"summary.md", "model.ckpt", "checkpoints/last.pt", "tmp/scratch.txt", "results/metrics.csv"} {
+		path := filepath.Join(runDir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0644))
+	}
+	// The summary file is archived even if it matches
+	expected := []string{"summary.md", "results/metrics.csv", ManifestFile}
+
+	t.Run("tar", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, archiveToTar(runDir, &buf))
+		var files []string
+		reader := tar.NewReader(&buf)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if header.Typeflag != tar.TypeDir {
+				files = append(files, relName(t, header.Name))
+			}
+		}
+		assert.ElementsMatch(t, expected, files)
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, archiveToZip(runDir, &buf))
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		var files []string
+		for _, file := range reader.File {
+			files = append(files, relName(t, file.Name))
+		}
+		assert.ElementsMatch(t, expected, files)
+	})
+}
+
+// relName returns the name of an archived file relative to the run directory
+func relName(t *testing.T, name string) string {
+	rel, err := filepath.Rel("2025-03-24T00:00:00.000_main_1234567", name)
+	require.NoError(t, err)
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,142 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// Files of the archive index in an archive destination
+const (
+	indexFile         = "index.json"
+	indexMarkdownFile = "index.md"
+)
+
+// ArchivedRun is an entry of the archive index
+type ArchivedRun struct {
+	Name       string        `json:"name"`    // file name in the destination
+	Archive    string        `json:"archive"` // location of the archive
+	ArchivedAt time.Time     `json:"archived_at"`
+	Deleted    bool          `json:"deleted"` // whether the original directory was deleted
+	Run        utils.RunInfo `json:"run"`
+}
+
+// ListArchived returns the runs in the archive index of the configured
+// destination, oldest first
+func ListArchived() ([]ArchivedRun, error) {
+	storage, err := NewStorage(destination(config.Get()))
+	if err != nil {
+		return nil, err
+	}
+	return loadArchiveIndex(storage)
+}
+
+// destination returns the configured archive destination
+func destination(cfg config.Config) string {
+	if cfg.Archive.To == "" {
+		return "archives"
+	}
+	return cfg.Archive.To
+}
+
+// loadArchiveIndex reads the archive index, which is empty if it does not exist yet
+func loadArchiveIndex(storage Storage) ([]ArchivedRun, error) {
+	r, err := storage.Open(indexFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive index: %w", err)
+	}
+	defer r.Close()
+
+	var runs []ArchivedRun
+	if err := json.NewDecoder(r).Decode(&runs); err != nil {
+		return nil, fmt.Errorf("failed to parse archive index: %w", err)
+	}
+	return runs, nil
+}
+
+// updateArchiveIndex adds or replaces entries of the archive index and
+// writes it together with its markdown view
+func updateArchiveIndex(storage Storage, updates []ArchivedRun) error {
+	runs, err := loadArchiveIndex(storage)
+	if err != nil {
+		return err
+	}
+	for _, update := range updates {
+		i := slices.IndexFunc(runs, func(run ArchivedRun) bool { return run.Name == update.Name })
+		if i >= 0 {
+			runs[i] = update
+		} else {
+			runs = append(runs, update)
+		}
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive index: %w", err)
+	}
+	if err := writeStorageFile(storage, indexFile, string(data)+"\n"); err != nil {
+		return err
+	}
+	return writeStorageFile(storage, indexMarkdownFile, formatArchiveIndex(runs))
+}
+
+// formatArchiveIndex formats the archive index as a markdown table
+func formatArchiveIndex(runs []ArchivedRun) string {
+	var b strings.Builder
+	b.WriteString("# Moco Experiment Archive Index\n\n")
+	b.WriteString("| Archive File | Original Directory | Timestamp | Branch | Status | Archived On | Original Deleted |\n")
+	b.WriteString("|--------------|--------------------|-----------|--------|--------|-------------|------------------|\n")
+	for _, run := range runs {
+		deleted := "No"
+		if run.Deleted {
+			deleted = "Yes"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			run.Name,
+			run.Run.Directory,
+			run.Run.StartTime.Format("2006-01-02 15:04:05"),
+			run.Run.Branch,
+			utils.StatusString(run.Run),
+			run.ArchivedAt.Format("2006-01-02 15:04:05"),
+			deleted)
+	}
+	return b.String()
+}
+
+// writeStorageFile writes a file to the storage
+func writeStorageFile(storage Storage, name, content string) error {
+	w, err := storage.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// findArchived finds an entry of the archive index by its archive file
+// name or location, or the name of the original run directory
+func findArchived(runs []ArchivedRun, ref string) (ArchivedRun, bool) {
+	name := filepath.Base(filepath.Clean(ref))
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		if run.Archive == ref || run.Name == name || filepath.Base(filepath.Clean(run.Run.Directory)) == name {
+			return run, true
+		}
+	}
+	return ArchivedRun{}, false
+}
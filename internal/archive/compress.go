@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Formats are the supported archive formats
+var Formats = []string{"tar.gz", "tar.zst", "tar.xz", "zip"}
+
+// compressors are external programs used for tar formats without a
+// compressor in the standard library
+var compressors = map[string]string{
+	"tar.zst": "zstd",
+	"tar.xz":  "xz",
+}
+
+// FormatOf returns the format of an archive file from its name, or an empty
+// string if it is not an archive
+func FormatOf(name string) string {
+	for _, format := range Formats {
+		if strings.HasSuffix(name, "."+format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// validateFormat checks that an archive format is supported and available
+func validateFormat(format string) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("unsupported archive format: %s (expected %s)", format, strings.Join(Formats, ", "))
+	}
+	if program, ok := compressors[format]; ok {
+		if _, err := exec.LookPath(program); err != nil {
+			return fmt.Errorf("%s is required for the %s format but not found", program, format)
+		}
+	}
+	return nil
+}
+
+// compressTar writes a compressed tar archive produced by write to w, with
+// the compressor's default level if level is 0
+func compressTar(format string, level int, w io.Writer, write func(io.Writer) error) error {
+	if format == "tar.gz" {
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gzWriter, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return err
+		}
+		if err := write(gzWriter); err != nil {
+			return err
+		}
+		return gzWriter.Close()
+	}
+
+	args := []string{"-q", "-c"}
+	if level != 0 {
+		args = append(args, "-"+strconv.Itoa(level))
+	}
+	if format == "tar.zst" {
+		// Use all cores
+		args = append(args, "-T0")
+	}
+	compressor, err := startFilter(w, compressors[format], args...)
+	if err != nil {
+		return err
+	}
+	writeErr := write(compressor)
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// OpenTar opens a compressed tar archive for reading; the returned function
+// must be called to release resources
+func OpenTar(archive string) (*tar.Reader, func() error, error) {
+	format := FormatOf(archive)
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format == "tar.gz" {
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return tar.NewReader(gzReader), func() error {
+			gzReader.Close()
+			return file.Close()
+		}, nil
+	}
+
+	program, ok := compressors[format]
+	if !ok {
+		file.Close()
+		return nil, nil, fmt.Errorf("not a tar archive: %s", archive)
+	}
+	cmd := exec.Command(program, "-d", "-q", "-c")
+	cmd.Stdin = file
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to run %s: %w", program, err)
+	}
+	return tar.NewReader(stdout), func() error {
+		// Closing the pipe stops the decompressor if it is not done yet
+		stdout.Close()
+		cmd.Wait()
+		return file.Close()
+	}, nil
+}
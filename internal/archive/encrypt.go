@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+)
+
+// encryptionSuffixes map suffixes of encrypted archives to the programs used
+// to encrypt and decrypt them
+var encryptionSuffixes = map[string]string{
+	".age": "age",
+	".gpg": "gpg",
+}
+
+// encryptionSuffix returns the suffix of archives encrypted to a recipient;
+// age recipients are public keys, and anything else is a GPG key ID or email
+func encryptionSuffix(recipient string) string {
+	if strings.HasPrefix(recipient, "age1") || strings.HasPrefix(recipient, "ssh-") {
+		return ".age"
+	}
+	return ".gpg"
+}
+
+// splitEncryption splits the name of an encrypted archive into the name of
+// the archive inside and the program that decrypts it
+func splitEncryption(name string) (string, string) {
+	ext := filepath.Ext(name)
+	if program, ok := encryptionSuffixes[ext]; ok {
+		return strings.TrimSuffix(name, ext), program
+	}
+	return name, ""
+}
+
+// validateEncryption checks that the program to encrypt to a recipient is available
+func validateEncryption(recipient string) error {
+	program := encryptionSuffixes[encryptionSuffix(recipient)]
+	if _, err := exec.LookPath(program); err != nil {
+		return fmt.Errorf("%s is required to encrypt to %s but not found", program, recipient)
+	}
+	return nil
+}
+
+// encryptTo starts encrypting everything written to the returned writer to
+// a recipient and writing the result to w
+func encryptTo(w io.Writer, recipient string) (*filterWriter, error) {
+	if encryptionSuffix(recipient) == ".age" {
+		return startFilter(w, "age", "--encrypt", "--recipient", recipient)
+	}
+	return startFilter(w, "gpg", "--batch", "--quiet", "--trust-model", "always",
+		"--encrypt", "--recipient", recipient)
+}
+
+// decryptArchive decrypts an encrypted archive into a directory and returns
+// the path of the decrypted archive; archives that are not encrypted are
+// returned as is
+func decryptArchive(archive, dir string) (string, error) {
+	name, program := splitEncryption(filepath.Base(archive))
+	if program == "" {
+		return archive, nil
+	}
+
+	var args []string
+	switch program {
+	case "age":
+		identity := config.Get().Archive.Identity
+		if identity == "" {
+			return "", fmt.Errorf("an identity file is required to decrypt %s (use --identity)", archive)
+		}
+		args = []string{"--decrypt", "--identity", identity}
+	case "gpg":
+		args = []string{"--batch", "--quiet", "--decrypt"}
+	}
+	if _, err := exec.LookPath(program); err != nil {
+		return "", fmt.Errorf("%s is required to decrypt %s but not found", program, archive)
+	}
+
+	in, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	path := filepath.Join(dir, name)
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = in
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		out.Close()
+		return "", fmt.Errorf("failed to decrypt %s: %w", archive, err)
+	}
+	return path, out.Close()
+}
+
+// filterWriter pipes everything written to it through an external program
+type filterWriter struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// startFilter starts a program that reads from the returned writer and
+// writes to w
+func startFilter(w io.Writer, program string, args ...string) (*filterWriter, error) {
+	cmd := exec.Command(program, args...)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", program, err)
+	}
+	return &filterWriter{WriteCloser: stdin, cmd: cmd}, nil
+}
+
+// Close closes the input of the program and waits for it to finish
+func (f *filterWriter) Close() error {
+	f.WriteCloser.Close()
+	if err := f.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %w", filepath.Base(f.cmd.Path), err)
+	}
+	return nil
+}
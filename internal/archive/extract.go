@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+)
+
+// FindArchived looks up a run in the archive index of the configured
+// destination by the name of its directory or archive
+func FindArchived(ref string) (ArchivedRun, bool, error) {
+	runs, err := ListArchived()
+	if err != nil {
+		return ArchivedRun{}, false, err
+	}
+	run, found := findArchived(runs, ref)
+	return run, found, nil
+}
+
+// ExtractFile copies a file of an archived run, given relative to the run
+// directory, to dest without restoring the whole run; archives not found
+// locally are fetched from the storage, and encrypted archives are decrypted
+func ExtractFile(archivedRun ArchivedRun, file, dest string) error {
+	storage, err := NewStorage(destination(config.Get()))
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "moco-extract-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive := archivedRun.Archive
+	if _, err := os.Stat(archive); err != nil {
+		archive, err = fetchArchive(storage, archivedRun.Name, tmpDir)
+		if err != nil {
+			return err
+		}
+	}
+	archive, err = decryptArchive(archive, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	entry := path.Join(filepath.Base(filepath.Clean(archivedRun.Run.Directory)), filepath.ToSlash(file))
+	var found bool
+	switch FormatOf(archive) {
+	case "zip":
+		found, err = extractZipEntry(archive, entry, dest)
+	case "":
+		return fmt.Errorf("unsupported archive format (expected %s)", strings.Join(Formats, ", "))
+	default:
+		found, err = extractTarEntry(archive, entry, dest)
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s not found in %s", file, archivedRun.Archive)
+	}
+	return nil
+}
+
+// extractTarEntry writes a regular file in a compressed tar archive to dest
+func extractTarEntry(archive, entry, dest string) (bool, error) {
+	tarReader, closeTar, err := OpenTar(archive)
+	if err != nil {
+		return false, err
+	}
+	defer closeTar()
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if path.Clean(header.Name) == entry && header.FileInfo().Mode().IsRegular() {
+			return true, writeFile(dest, tarReader, header.FileInfo().Mode(), header.ModTime)
+		}
+	}
+}
+
+// extractZipEntry writes a regular file in a zip archive to dest
+func extractZipEntry(archive, entry, dest string) (bool, error) {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return false, err
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		if path.Clean(file.Name) != entry || !file.Mode().IsRegular() {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return false, err
+		}
+		defer reader.Close()
+		return true, writeFile(dest, reader, file.Mode(), file.Modified)
+	}
+	return false, nil
+}
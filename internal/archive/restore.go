@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Restore extracts archived runs back into the base directory; archives are
+// given as files or looked up in the archive index by archive or run name
+func Restore(archives []string) error {
+	// Get config
+	cfg := config.Get()
+
+	storage, err := NewStorage(destination(cfg))
+	if err != nil {
+		return err
+	}
+	archivedRuns, err := loadArchiveIndex(storage)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cfg.BaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %w", err)
+	}
+	var restored []ArchivedRun
+	for _, archive := range archives {
+		archivedRun, indexed := findArchived(archivedRuns, archive)
+		if _, err := os.Stat(archive); err != nil {
+			if !indexed {
+				return fmt.Errorf("archive not found: %s", archive)
+			}
+			archive = archivedRun.Archive
+		}
+
+		runDir, err := restoreArchive(archive, storage, archivedRun, cfg.BaseDir)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", archive, err)
+		}
+		log.Infof("Restored %s to %s", archive, runDir)
+
+		// The original directory exists again
+		if indexed {
+			archivedRun.Deleted = false
+			restored = append(restored, archivedRun)
+		}
+	}
+
+	if len(restored) > 0 {
+		if err := updateArchiveIndex(storage, restored); err != nil {
+			log.Warnf("Failed to update archive index: %v", err)
+		}
+	}
+	index.Update(cfg.BaseDir, cfg.SummaryFile)
+	return nil
+}
+
+// restoreArchive extracts an archive into a temporary directory and then
+// moves the run directory in it to the base directory; archives not found
+// locally are fetched from the storage, and encrypted archives are decrypted
+func restoreArchive(archive string, storage Storage, archivedRun ArchivedRun, baseDir string) (string, error) {
+	tmpDir, err := os.MkdirTemp(baseDir, ".restore-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := os.Stat(archive); err != nil {
+		archive, err = fetchArchive(storage, archivedRun.Name, tmpDir)
+		if err != nil {
+			return "", err
+		}
+	}
+	archive, err = decryptArchive(archive, tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	var runName string
+	switch FormatOf(archive) {
+	case "zip":
+		runName, err = extractZip(archive, tmpDir)
+	case "":
+		return "", fmt.Errorf("unsupported archive format (expected %s)", strings.Join(Formats, ", "))
+	default:
+		runName, err = extractTar(archive, tmpDir)
+	}
+	if err != nil {
+		return "", err
+	}
+	if runName == "" {
+		return "", fmt.Errorf("empty archive")
+	}
+
+	if err := verifyRunDir(filepath.Join(tmpDir, runName)); err != nil {
+		return "", err
+	}
+
+	runDir := filepath.Join(baseDir, runName)
+	if _, err := os.Lstat(runDir); err == nil {
+		return "", fmt.Errorf("run already exists: %s", runDir)
+	}
+	if err := os.Rename(filepath.Join(tmpDir, runName), runDir); err != nil {
+		return "", err
+	}
+	return runDir, nil
+}
+
+// fetchArchive downloads an archive from the storage into a directory
+func fetchArchive(storage Storage, name, dir string) (string, error) {
+	r, err := storage.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to fetch archive: %w", err)
+	}
+	return path, file.Close()
+}
+
+// entryPath validates the name of an archive entry, which must be in a
+// single run directory, and returns its cleaned name and the run name
+func entryPath(name, runName string) (string, string, error) {
+	cleaned := path.Clean(strings.TrimSuffix(name, "/"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	top, _, _ := strings.Cut(cleaned, "/")
+	if !utils.RunDirPattern.MatchString(top) {
+		return "", "", fmt.Errorf("not a run directory: %s", top)
+	}
+	if runName != "" && top != runName {
+		return "", "", fmt.Errorf("archive contains more than one run: %s, %s", runName, top)
+	}
+	return cleaned, top, nil
+}
+
+// extractTar extracts a compressed tar archive of a run and returns the run name
+func extractTar(archive, destDir string) (string, error) {
+	tarReader, closeTar, err := OpenTar(archive)
+	if err != nil {
+		return "", err
+	}
+	defer closeTar()
+
+	runName := ""
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		name, top, err := entryPath(header.Name, runName)
+		if err != nil {
+			return "", err
+		}
+		runName = top
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tarReader, header.FileInfo().Mode(), header.ModTime); err != nil {
+				return "", err
+			}
+		default:
+			log.Warnf("Skipping unsupported entry in archive: %s", header.Name)
+		}
+	}
+	return runName, nil
+}
+
+// extractZip extracts a zip archive of a run and returns the run name
+func extractZip(archive, destDir string) (string, error) {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", err
+	}
+	defer zipReader.Close()
+
+	runName := ""
+	for _, entry := range zipReader.File {
+		name, top, err := entryPath(entry.Name, runName)
+		if err != nil {
+			return "", err
+		}
+		runName = top
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+		case mode.IsRegular():
+			reader, err := entry.Open()
+			if err != nil {
+				return "", err
+			}
+			err = writeFile(target, reader, mode, entry.Modified)
+			reader.Close()
+			if err != nil {
+				return "", err
+			}
+		default:
+			log.Warnf("Skipping unsupported entry in archive: %s", entry.Name)
+		}
+	}
+	return runName, nil
+}
+
+// writeFile writes the contents of an archive entry to a file
+func writeFile(target string, r io.Reader, mode os.FileMode, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, modTime, modTime)
+}
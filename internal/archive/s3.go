@@ -0,0 +1,386 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// s3PartSize is the size of parts uploaded in a multipart upload; archives
+// are streamed to S3 holding at most one part in memory
+const s3PartSize = 16 * 1024 * 1024
+
+// s3Credentials are the AWS credentials used to sign requests
+type s3Credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// s3Storage stores archives in an S3 bucket
+type s3Storage struct {
+	bucket      string
+	prefix      string
+	region      string
+	endpoint    string // custom endpoint (e.g., MinIO) addressed in path style
+	credentials s3Credentials
+	client      *http.Client
+}
+
+// newS3Storage configures S3 storage from the standard AWS environment
+// variables and the shared credentials file
+func newS3Storage(bucket, prefix string) (*s3Storage, error) {
+	credentials, err := loadS3Credentials()
+	if err != nil {
+		return nil, err
+	}
+	region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"))
+	return &s3Storage{
+		bucket:      bucket,
+		prefix:      strings.Trim(prefix, "/"),
+		region:      region,
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		credentials: credentials,
+		client:      &http.Client{},
+	}, nil
+}
+
+// loadS3Credentials reads credentials from the environment or the profile
+// in the shared credentials file (~/.aws/credentials)
+func loadS3Credentials() (s3Credentials, error) {
+	credentials := s3Credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.accessKeyID != "" && credentials.secretAccessKey != "" {
+		return credentials, nil
+	}
+
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return credentials, fmt.Errorf("no AWS credentials found")
+		}
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+	file, err := os.Open(credentialsFile)
+	if err != nil {
+		return credentials, fmt.Errorf("no AWS credentials found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	defer file.Close()
+
+	profile := cmp.Or(os.Getenv("AWS_PROFILE"), "default")
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || section != profile {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			credentials.accessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			credentials.secretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			credentials.sessionToken = strings.TrimSpace(value)
+		}
+	}
+	if credentials.accessKeyID == "" || credentials.secretAccessKey == "" {
+		return credentials, fmt.Errorf("no AWS credentials for profile %s in %s", profile, credentialsFile)
+	}
+	return credentials, nil
+}
+
+func (s *s3Storage) Create(name string) (ArchiveWriter, error) {
+	return &s3Writer{storage: s, key: s.key(name)}, nil
+}
+
+func (s *s3Storage) Location(name string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.key(name))
+}
+
+// key returns the object key of an archive
+func (s *s3Storage) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "/" + name
+}
+
+// objectURL returns the URL of an object with a query string
+func (s *s3Storage) objectURL(key string, query url.Values) string {
+	escapedKey := escapePath(key)
+	var u string
+	if s.endpoint != "" {
+		u = fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, escapedKey)
+	} else {
+		u = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, escapedKey)
+	}
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	return u
+}
+
+func (s *s3Storage) Open(name string) (io.ReadCloser, error) {
+	resp, err := s.send(http.MethodGet, s.key(name), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send sends a signed request and returns the response if it succeeded
+func (s *s3Storage) send(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.objectURL(key, query), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, body, s.credentials, s.region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("S3 %s %s failed: %s: %s", method, key, resp.Status, s3ErrorMessage(data))
+		if resp.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// do sends a signed request and returns the response body
+func (s *s3Storage) do(method, key string, query url.Values, body []byte) ([]byte, *http.Response, error) {
+	resp, err := s.send(method, key, query, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, resp, err
+}
+
+// s3Writer streams an archive to S3, using a multipart upload once the
+// archive exceeds a single part
+type s3Writer struct {
+	storage  *s3Storage
+	key      string
+	buffer   bytes.Buffer
+	uploadID string
+	parts    []s3Part
+}
+
+// s3Part is an uploaded part of a multipart upload
+type s3Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	n, _ := w.buffer.Write(p)
+	for w.buffer.Len() >= s3PartSize {
+		if err := w.uploadPart(w.buffer.Next(s3PartSize)); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// uploadPart uploads a part, starting a multipart upload if necessary
+func (w *s3Writer) uploadPart(data []byte) error {
+	if w.uploadID == "" {
+		body, _, err := w.storage.do(http.MethodPost, w.key, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil || result.UploadID == "" {
+			return fmt.Errorf("failed to start multipart upload to %s", w.key)
+		}
+		w.uploadID = result.UploadID
+	}
+
+	partNumber := len(w.parts) + 1
+	query := url.Values{"partNumber": {fmt.Sprint(partNumber)}, "uploadId": {w.uploadID}}
+	_, resp, err := w.storage.do(http.MethodPut, w.key, query, data)
+	if err != nil {
+		return err
+	}
+	w.parts = append(w.parts, s3Part{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+func (w *s3Writer) Close() error {
+	// Small archives are uploaded at once
+	if w.uploadID == "" {
+		_, _, err := w.storage.do(http.MethodPut, w.key, nil, w.buffer.Bytes())
+		return err
+	}
+
+	if w.buffer.Len() > 0 {
+		if err := w.uploadPart(w.buffer.Bytes()); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: w.parts})
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	data, _, err := w.storage.do(http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, body)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	// Completion can fail after a successful response
+	if bytes.Contains(data, []byte("<Error>")) {
+		w.Abort()
+		return fmt.Errorf("failed to complete multipart upload to %s: %s", w.key, s3ErrorMessage(data))
+	}
+	return nil
+}
+
+func (w *s3Writer) Abort() {
+	if w.uploadID != "" {
+		w.storage.do(http.MethodDelete, w.key, url.Values{"uploadId": {w.uploadID}}, nil)
+		w.uploadID = ""
+	}
+}
+
+// s3ErrorMessage extracts the message of an S3 error response
+func s3ErrorMessage(data []byte) string {
+	var result struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil || result.Code == "" {
+		return strings.TrimSpace(string(data))
+	}
+	return fmt.Sprintf("%s (%s)", result.Message, result.Code)
+}
+
+// signS3Request signs a request with AWS Signature Version 4
+func signS3Request(req *http.Request, body []byte, credentials s3Credentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.sessionToken)
+	}
+
+	// All headers set so far and the host are signed
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		cmp.Or(req.URL.EscapedPath(), "/"),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes a query string sorted by key as required for signing
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, escape(key)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath URI-encodes each segment of an object key
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return path.Join(segments...)
+}
+
+// escape URI-encodes a string leaving only unreserved characters as is
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage is a destination where archives are written
+type Storage interface {
+	// Create starts writing an archive with the given name
+	Create(name string) (ArchiveWriter, error)
+	// Open opens a file in the storage, returning an error wrapping
+	// fs.ErrNotExist if it does not exist
+	Open(name string) (io.ReadCloser, error)
+	// Location returns where an archive with the given name is stored
+	Location(name string) string
+}
+
+// ArchiveWriter writes an archive, which is stored only when it is closed
+type ArchiveWriter interface {
+	io.Writer
+	// Close finishes writing and stores the archive
+	Close() error
+	// Abort discards the archive written so far
+	Abort()
+}
+
+// NewStorage returns the storage for a destination, which is either a local
+// directory or an S3 location (s3://bucket/prefix)
+func NewStorage(dest string) (Storage, error) {
+	if after, found := strings.CutPrefix(dest, "s3://"); found {
+		bucket, prefix, _ := strings.Cut(after, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("no bucket in S3 destination: %s", dest)
+		}
+		return newS3Storage(bucket, prefix)
+	}
+	return localStorage{dir: dest}, nil
+}
+
+// localStorage stores archives in a local directory
+type localStorage struct {
+	dir string
+}
+
+func (s localStorage) Create(name string) (ArchiveWriter, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	// Write to a temporary file so that no partial archive is left on failure
+	file, err := os.CreateTemp(s.dir, "."+name+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &localWriter{File: file, path: s.Location(name)}, nil
+}
+
+func (s localStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(s.Location(name))
+}
+
+func (s localStorage) Location(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// localWriter writes an archive to a temporary file and renames it when closed
+type localWriter struct {
+	*os.File
+	path string
+}
+
+func (w *localWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.Name())
+		return err
+	}
+	if err := os.Chmod(w.Name(), 0644); err != nil {
+		os.Remove(w.Name())
+		return err
+	}
+	return os.Rename(w.Name(), w.path)
+}
+
+func (w *localWriter) Abort() {
+	w.File.Close()
+	os.Remove(w.Name())
+}
@@ -0,0 +1,297 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// ManifestFile is the name of the checksum manifest stored in the run
+// directory of each archive, in the format of sha256sum
+const ManifestFile = ".moco-manifest.sha256"
+
+// manifest maps file paths relative to the run directory to SHA-256 checksums
+type manifest map[string]string
+
+// format returns the manifest as sha256sum output sorted by path
+func (m manifest) format() []byte {
+	paths := make([]string, 0, len(m))
+	for p := range m {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	var buf bytes.Buffer
+	for _, p := range paths {
+		fmt.Fprintf(&buf, "%s  %s\n", m[p], p)
+	}
+	return buf.Bytes()
+}
+
+// ParseManifest parses a checksum manifest, returning the checksums of files
+// by their paths relative to the run directory
+func ParseManifest(data []byte) (map[string]string, error) {
+	return parseManifest(data)
+}
+
+// parseManifest parses a manifest in the format of sha256sum
+func parseManifest(data []byte) (manifest, error) {
+	m := manifest{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		sum, p, ok := strings.Cut(line, "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid manifest line: %s", line)
+		}
+		m[p] = sum
+	}
+	return m, scanner.Err()
+}
+
+// compare returns the problems found by checking actual checksums against
+// the manifest
+func (m manifest) compare(actual manifest) []string {
+	var problems []string
+	for p, sum := range m {
+		actualSum, ok := actual[p]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("missing file: %s", p))
+		} else if actualSum != sum {
+			problems = append(problems, fmt.Sprintf("checksum mismatch: %s", p))
+		}
+	}
+	for p := range actual {
+		if _, ok := m[p]; !ok {
+			problems = append(problems, fmt.Sprintf("file not in manifest: %s", p))
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+// hashingWriter records the SHA-256 checksum of each file written to an archive
+type hashingWriter struct {
+	sums manifest
+}
+
+// copy copies a file to w while recording its checksum under relPath
+func (h hashingWriter) copy(w io.Writer, file io.Reader, relPath string) error {
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), file); err != nil {
+		return err
+	}
+	h.sums[filepath.ToSlash(relPath)] = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// Verify re-reads archives and checks their contents against the checksum
+// manifests; archives are given as files or looked up in the archive index,
+// and all indexed archives are verified if none are given
+func Verify(archives []string) error {
+	// Get config
+	cfg := config.Get()
+
+	storage, err := NewStorage(destination(cfg))
+	if err != nil {
+		return err
+	}
+	archivedRuns, err := loadArchiveIndex(storage)
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		for _, archivedRun := range archivedRuns {
+			archives = append(archives, archivedRun.Archive)
+		}
+		if len(archives) == 0 {
+			log.Info("No archives found")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, archive := range archives {
+		problems, err := verifyArchive(archive, storage, archivedRuns)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				log.Errorf("%s: %s", archive, problem)
+			}
+			failed++
+			continue
+		}
+		log.Infof("%s: OK", archive)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d archive(s) failed verification", failed, len(archives))
+	}
+	return nil
+}
+
+// verifyArchive checks an archive against its manifest and returns the
+// problems found
+func verifyArchive(archive string, storage Storage, archivedRuns []ArchivedRun) ([]string, error) {
+	tmpDir, err := os.MkdirTemp("", "moco-verify-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivedRun, indexed := findArchived(archivedRuns, archive)
+	if _, err := os.Stat(archive); err != nil {
+		if !indexed {
+			return nil, fmt.Errorf("archive not found")
+		}
+		archive, err = fetchArchive(storage, archivedRun.Name, tmpDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	archive, err = decryptArchive(archive, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var expected manifest
+	actual := manifest{}
+	err = walkArchive(archive, func(name string, r io.Reader) error {
+		_, relPath, _ := strings.Cut(path.Clean(name), "/")
+		if relPath == ManifestFile {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			expected, err = parseManifest(data)
+			return err
+		}
+		sum, err := utils.HashReader(r)
+		if err != nil {
+			return err
+		}
+		actual[relPath] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expected == nil {
+		log.Warnf("%s: no checksum manifest, only checked that the archive is readable", archive)
+		return nil, nil
+	}
+	return expected.compare(actual), nil
+}
+
+// walkArchive calls visit with the contents of each regular file in an archive
+func walkArchive(archive string, visit func(name string, r io.Reader) error) error {
+	switch FormatOf(archive) {
+	case "zip":
+		zipReader, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer zipReader.Close()
+
+		for _, entry := range zipReader.File {
+			if !entry.Mode().IsRegular() {
+				continue
+			}
+			reader, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			err = visit(entry.Name, reader)
+			reader.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "":
+		return fmt.Errorf("unsupported archive format (expected %s)", strings.Join(Formats, ", "))
+	default:
+		tarReader, closeTar, err := OpenTar(archive)
+		if err != nil {
+			return err
+		}
+		defer closeTar()
+
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := visit(header.Name, tarReader); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// verifyRunDir checks the files of an extracted run directory against its
+// manifest, if any, and removes the manifest
+func verifyRunDir(runDir string) error {
+	manifestPath := filepath.Join(runDir, ManifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	expected, err := parseManifest(data)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(manifestPath); err != nil {
+		return err
+	}
+
+	actual := manifest{}
+	err = filepath.WalkDir(runDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		relPath, err := filepath.Rel(runDir, p)
+		if err != nil {
+			return err
+		}
+		sum, err := utils.HashFile(p)
+		if err != nil {
+			return err
+		}
+		actual[filepath.ToSlash(relPath)] = sum
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if problems := expected.compare(actual); len(problems) > 0 {
+		return fmt.Errorf("archive is corrupted: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bicycle1885/moco/internal/archive"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main lists the artifacts of a run, or copies one of them if its path is
+// given; runs that are not found in the base directory are looked up in the
+// archive index
+func Main(run, path string) error {
+	cfg := config.Get()
+
+	artifacts, archivedRun, err := load(run)
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		return output(artifacts, cfg.Artifacts.Format)
+	}
+
+	i := slices.IndexFunc(artifacts, func(artifact utils.Artifact) bool { return artifact.Path == filepath.ToSlash(path) })
+	if i < 0 {
+		return fmt.Errorf("artifact not found: %s", path)
+	}
+	artifact := artifacts[i]
+
+	// Copy into the current directory by default, keeping the file name
+	dest := cfg.Artifacts.Output
+	if dest == "" {
+		dest = "."
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = filepath.Join(dest, filepath.Base(artifact.Path))
+	}
+
+	if archivedRun != nil {
+		err = archive.ExtractFile(*archivedRun, artifact.Path, dest)
+	} else {
+		err = copyFile(filepath.Join(run, filepath.FromSlash(artifact.Path)), dest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", artifact.Path, err)
+	}
+
+	// The copy must be identical to the file recorded after the run
+	hash, err := utils.HashFile(dest)
+	if err != nil {
+		return err
+	}
+	if hash != artifact.SHA256 {
+		os.Remove(dest)
+		return fmt.Errorf("checksum mismatch of %s: expected %s, got %s", artifact.Path, artifact.SHA256, hash)
+	}
+
+	log.Infof("Copied %s to %s", artifact.Path, dest)
+	return nil
+}
+
+// load returns the artifacts of a run and its entry of the archive index if
+// the run is not found in the base directory
+func load(run string) ([]utils.Artifact, *archive.ArchivedRun, error) {
+	if info, err := os.Stat(run); err == nil && info.IsDir() {
+		artifacts, err := utils.ReadArtifacts(run)
+		if err != nil {
+			return nil, nil, err
+		}
+		if artifacts == nil {
+			// Fall back to the summary, e.g., if the list was deleted
+			runInfo, err := utils.ParseRunInfo(filepath.Join(run, config.Get().SummaryFile))
+			if err != nil {
+				return nil, nil, err
+			}
+			artifacts = runInfo.Artifacts
+		}
+		return artifacts, nil, nil
+	}
+
+	archivedRun, found, err := archive.FindArchived(run)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("run not found: %s", run)
+	}
+	return archivedRun.Run.Artifacts, &archivedRun, nil
+}
+
+// output prints artifacts in a format
+func output(artifacts []utils.Artifact, format string) error {
+	switch format {
+	case "table":
+		if len(artifacts) == 0 {
+			log.Info("No artifacts recorded")
+			return nil
+		}
+		rows := make([][]string, len(artifacts))
+		for i, artifact := range artifacts {
+			rows[i] = []string{artifact.Path, utils.FormatSize(artifact.Size), artifact.SHA256}
+		}
+		fmt.Println(utils.RenderTable([]string{"Path", "Size", "SHA-256"}, rows))
+		return nil
+	case "json":
+		if artifacts == nil {
+			artifacts = []utils.Artifact{}
+		}
+		data, err := json.MarshalIndent(artifacts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	default:
+		return fmt.Errorf("invalid output format: %s", format)
+	}
+}
+
+// copyFile copies a file without overwriting an existing one
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
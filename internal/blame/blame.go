@@ -0,0 +1,378 @@
+package blame
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	moarchive "github.com/bicycle1885/moco/internal/archive"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Match is a file in a run (or an archived run) identical to the blamed file
+type Match struct {
+	Path    string
+	Archive string
+	Run     utils.RunInfo
+}
+
+// Main reports which runs produced a file with the same content as file
+func Main(file string) error {
+	cfg := config.Get()
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", file)
+	}
+	hash, err := utils.HashFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	// Search run directories
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return fmt.Errorf("failed to find runs: %w", err)
+	}
+	var matches []Match
+	for _, runDir := range runDirs {
+		found, err := searchRunDir(runDir, info.Size(), hash, cfg.SummaryFile)
+		if err != nil {
+			log.Warnf("Failed to search %s: %v", runDir, err)
+			continue
+		}
+		matches = append(matches, found...)
+	}
+
+	// Search archives
+	archives, err := findArchives(cfg.Archive.To)
+	if err != nil {
+		return fmt.Errorf("failed to find archives: %w", err)
+	}
+	for _, archive := range archives {
+		found, err := searchArchive(archive, info.Size(), hash, cfg.SummaryFile)
+		if err != nil {
+			log.Warnf("Failed to search %s: %v", archive, err)
+			continue
+		}
+		matches = append(matches, found...)
+	}
+
+	if len(matches) == 0 {
+		log.Infof("No runs found that produced %s", file)
+		return nil
+	}
+
+	for i, match := range matches {
+		if i > 0 {
+			fmt.Println()
+		}
+		printMatch(match)
+	}
+	return nil
+}
+
+// searchRunDir finds files in a run directory with the given size and hash;
+// artifacts are looked up by the hashes recorded in the artifacts file, and
+// only the other files are hashed
+func searchRunDir(runDir string, size int64, hash, summaryFile string) ([]Match, error) {
+	var matches []Match
+	artifacts, err := utils.ReadArtifacts(runDir)
+	if err != nil {
+		log.Warnf("%s: %v", runDir, err)
+	}
+	recorded := map[string]bool{}
+	for _, artifact := range artifacts {
+		path := filepath.Join(runDir, filepath.FromSlash(artifact.Path))
+		if _, err := os.Lstat(path); err != nil {
+			continue // Removed since the run
+		}
+		recorded[path] = true
+		if artifact.Size == size && artifact.SHA256 == hash {
+			matches = append(matches, Match{Path: path})
+		}
+	}
+
+	err = filepath.WalkDir(runDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if recorded[path] {
+			return nil
+		}
+
+		// Compare sizes first to avoid hashing unrelated files; links (e.g.,
+		// to deduplicated artifacts in the object store) are followed
+		var info fs.FileInfo
+		switch {
+		case d.Type().IsRegular():
+			if info, err = d.Info(); err != nil {
+				return err
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			if info, err = os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		default:
+			return nil
+		}
+		if info.Size() != size {
+			return nil
+		}
+		fileHash, err := utils.HashFile(path)
+		if err != nil {
+			return err
+		}
+		if fileHash == hash {
+			matches = append(matches, Match{Path: path})
+		}
+		return nil
+	})
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	runInfo, err := utils.ParseRunInfo(filepath.Join(runDir, summaryFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse summary file: %w", err)
+	}
+	for i := range matches {
+		matches[i].Run = runInfo
+	}
+	return matches, nil
+}
+
+// findArchives returns the paths of run archives in dir
+func findArchives(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && moarchive.FormatOf(name) != "" {
+			archives = append(archives, filepath.Join(dir, name))
+		}
+	}
+	return archives, nil
+}
+
+// archiveEntry is a file stored in an archive
+type archiveEntry struct {
+	name string
+	size int64
+	open func() (io.Reader, error)
+}
+
+// searchArchive finds files in a run archive with the given size and hash
+//
+// Files are looked up by the hashes recorded in checksum manifests and
+// artifacts files if they are read first (as in zip archives), and other
+// files of the same size are hashed while they are read.
+func searchArchive(archive string, size int64, hash, summaryFile string) ([]Match, error) {
+	var matches []Match
+	summaries := map[string][]byte{}
+	recorded := map[string]string{}
+
+	// Archives store files as "<run directory>/<path>"
+	isMetadata := func(name string) bool {
+		_, relPath, _ := strings.Cut(name, "/")
+		return relPath == summaryFile || relPath == moarchive.ManifestFile || relPath == utils.ArtifactsFile
+	}
+
+	visit := func(entry archiveEntry) error {
+		dir, relPath, _ := strings.Cut(entry.name, "/")
+		if isMetadata(entry.name) {
+			r, err := entry.open()
+			if err != nil {
+				return err
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			switch relPath {
+			case summaryFile:
+				summaries[dir] = data
+			case moarchive.ManifestFile:
+				sums, err := moarchive.ParseManifest(data)
+				if err != nil {
+					log.Warnf("%s: %v", archive, err)
+				}
+				for p, sum := range sums {
+					recorded[path.Join(dir, p)] = sum
+				}
+			case utils.ArtifactsFile:
+				var artifacts []utils.Artifact
+				if err := json.Unmarshal(data, &artifacts); err != nil {
+					log.Warnf("%s: invalid %s: %v", archive, entry.name, err)
+				}
+				for _, artifact := range artifacts {
+					recorded[path.Join(dir, artifact.Path)] = artifact.SHA256
+				}
+			}
+			// The contents have been read, so they are hashed in memory
+			if entry.size == size {
+				recorded[entry.name], _ = utils.HashReader(bytes.NewReader(data))
+			}
+		}
+		if entry.size != size {
+			return nil
+		}
+
+		entryHash, ok := recorded[entry.name]
+		if !ok {
+			r, err := entry.open()
+			if err != nil {
+				return err
+			}
+			entryHash, err = utils.HashReader(r)
+			if err != nil {
+				return err
+			}
+		}
+		if entryHash == hash {
+			matches = append(matches, Match{Path: entry.name, Archive: archive})
+		}
+		return nil
+	}
+
+	var err error
+	if strings.HasSuffix(archive, ".zip") {
+		err = walkZip(archive, isMetadata, visit)
+	} else {
+		err = walkTar(archive, visit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Summaries may appear after the matched files in an archive
+	for i, match := range matches {
+		dir, _, _ := strings.Cut(match.Path, "/")
+		summary, ok := summaries[dir]
+		if !ok {
+			log.Warnf("No summary file for %s in %s", dir, archive)
+			matches[i].Run = utils.RunInfo{Directory: dir + "/"}
+			continue
+		}
+		runInfo, err := utils.ParseRunInfoFrom(bytes.NewReader(summary), path.Join(dir, summaryFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse summary file: %w", err)
+		}
+		matches[i].Run = runInfo
+	}
+	return matches, nil
+}
+
+// walkTar calls visit for each regular file in a compressed tar archive
+func walkTar(archive string, visit func(archiveEntry) error) error {
+	tarReader, closeTar, err := moarchive.OpenTar(archive)
+	if err != nil {
+		return err
+	}
+	defer closeTar()
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry := archiveEntry{
+			name: header.Name,
+			size: header.Size,
+			open: func() (io.Reader, error) { return tarReader, nil },
+		}
+		if err := visit(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip calls visit for each regular file in a zip archive, visiting the
+// files for which first returns true before the others
+func walkZip(archive string, first func(name string) bool, visit func(archiveEntry) error) error {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	files := slices.Clone(zipReader.File)
+	slices.SortStableFunc(files, func(a, b *zip.File) int {
+		switch {
+		case first(a.Name) == first(b.Name):
+			return 0
+		case first(a.Name):
+			return -1
+		default:
+			return 1
+		}
+	})
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		var rc io.ReadCloser
+		entry := archiveEntry{
+			name: file.Name,
+			size: int64(file.UncompressedSize64),
+			open: func() (io.Reader, error) {
+				var err error
+				rc, err = file.Open()
+				return rc, err
+			},
+		}
+		err := visit(entry)
+		if rc != nil {
+			rc.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printMatch prints where a matching file was found and the run that produced it
+func printMatch(match Match) {
+	run := match.Run
+	if match.Archive != "" {
+		fmt.Printf("%s (in %s)\n", match.Path, match.Archive)
+	} else {
+		fmt.Println(match.Path)
+	}
+	fmt.Printf("  Run: %s\n", run.Directory)
+	if !run.StartTime.IsZero() {
+		fmt.Printf("  Started: %s\n", run.StartTime.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("  Branch: %s\n", run.Branch)
+	fmt.Printf("  Commit: %s\n", run.CommitHash)
+	fmt.Printf("  Command: %s\n", run.Command)
+	fmt.Printf("  Status: %s\n", utils.StatusString(run))
+}
@@ -0,0 +1,157 @@
+package blame
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	moarchive "github.com/bicycle1885/moco/internal/archive"
+	"github.com/bicycle1885/moco/internal/objects"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const runName = "2025-03-24T00:00:00.000_main_1234567"
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeRun creates a run directory with a summary and the given files
+func writeRun(t *testing.T, files map[string]string) string {
+	t.Helper()
+	runDir := filepath.Join(t.TempDir(), runName)
+	require.NoError(t, os.Mkdir(runDir, 0755))
+	meta := utils.RunMetadata{Repo: utils.RepoStatus{Branch: "main"}, Command: []string{"python", "train.py"}}
+	require.NoError(t, utils.WriteSummaryFileInit(filepath.Join(runDir, "summary.md"), meta))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(runDir, name), []byte(content), 0644))
+	}
+	return runDir
+}
+
+func TestSearchRunDir(t *testing.T) {
+	hash := sha256Hex("weights")
+	// Recorded artifacts are matched by their hashes without rehashing
+	artifacts := fmt.Sprintf(`[{"path": "model.bin", "size": 7, "sha256": %q}]`, hash)
+	runDir := writeRun(t, map[string]string{
+		"model.bin":         "wxights",
+		"copy.bin":          "weights",
+		"other.bin":         "others!",
+		utils.ArtifactsFile: artifacts,
+	})
+
+	matches, err := searchRunDir(runDir, 7, hash, "summary.md")
+	require.NoError(t, err)
+	var paths []string
+	for _, match := range matches {
+		paths = append(paths, filepath.Base(match.Path))
+		assert.Equal(t, "python train.py", match.Run.Command)
+	}
+	assert.ElementsMatch(t, []string{"model.bin", "copy.bin"}, paths)
+}
+
+func TestSearchRunDirDedup(t *testing.T) {
+	hash := sha256Hex("weights")
+	runDir := writeRun(t, map[string]string{"model.bin": "weights"})
+	artifacts, err := utils.CollectArtifacts(runDir, []string{"model.bin"})
+	require.NoError(t, err)
+	require.NoError(t, utils.WriteArtifacts(runDir, artifacts))
+	if err := objects.Dedup(filepath.Dir(runDir), runDir, artifacts, "symlink"); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(runDir, "model.bin"))
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&os.ModeSymlink)
+
+	matches, err := searchRunDir(runDir, 7, hash, "summary.md")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, filepath.Join(runDir, "model.bin"), matches[0].Path)
+
+	// Links are followed even without the artifacts file
+	require.NoError(t, os.Remove(filepath.Join(runDir, utils.ArtifactsFile)))
+	matches, err = searchRunDir(runDir, 7, hash, "summary.md")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, filepath.Join(runDir, "model.bin"), matches[0].Path)
+}
+
+// archiveFiles returns the files of a run in an archive, with the checksum
+// manifest last as moco writes it
+func archiveFiles(t *testing.T, runDir string, manifest string) [][2]string {
+	t.Helper()
+	summary, err := os.ReadFile(filepath.Join(runDir, "summary.md"))
+	require.NoError(t, err)
+	return [][2]string{
+		{runName + "/summary.md", string(summary)},
+		{runName + "/model.bin", "wxights"},
+		{runName + "/copy.bin", "weights"},
+		{runName + "/" + moarchive.ManifestFile, manifest},
+	}
+}
+
+func TestSearchArchive(t *testing.T) {
+	hash := sha256Hex("weights")
+	runDir := writeRun(t, nil)
+	manifest := fmt.Sprintf("%s  copy.bin\n%s  model.bin\n", hash, hash)
+	files := archiveFiles(t, runDir, manifest)
+	dir := t.TempDir()
+
+	t.Run("zip", func(t *testing.T) {
+		// The manifest is read first, so recorded hashes are used
+		archive := filepath.Join(dir, runName+".zip")
+		file, err := os.Create(archive)
+		require.NoError(t, err)
+		w := zip.NewWriter(file)
+		for _, f := range files {
+			fw, err := w.Create(f[0])
+			require.NoError(t, err)
+			_, err = io.WriteString(fw, f[1])
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, file.Close())
+
+		matches, err := searchArchive(archive, 7, hash, "summary.md")
+		require.NoError(t, err)
+		var paths []string
+		for _, match := range matches {
+			paths = append(paths, match.Path)
+			assert.Equal(t, "python train.py", match.Run.Command)
+		}
+		assert.ElementsMatch(t, []string{runName + "/model.bin", runName + "/copy.bin"}, paths)
+	})
+
+	t.Run("tar.gz", func(t *testing.T) {
+		// The manifest comes last, so files are hashed
+		archive := filepath.Join(dir, runName+".tar.gz")
+		file, err := os.Create(archive)
+		require.NoError(t, err)
+		gw := gzip.NewWriter(file)
+		w := tar.NewWriter(gw)
+		for _, f := range files {
+			require.NoError(t, w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: f[0], Mode: 0644, Size: int64(len(f[1]))}))
+			_, err = io.WriteString(w, f[1])
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, gw.Close())
+		require.NoError(t, file.Close())
+
+		matches, err := searchArchive(archive, 7, hash, "summary.md")
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, runName+"/copy.bin", matches[0].Path)
+		assert.Equal(t, "python train.py", matches[0].Run.Command)
+	})
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -19,11 +21,63 @@ type Config struct {
 		NoPushd       bool   `toml:"no_pushd"`
 		StdoutFile    string `toml:"stdout_file"`
 		StderrFile    string `toml:"stderr_file"`
+		CombinedLog   bool   `toml:"combined_log"`
+		TimestampLogs bool   `toml:"timestamp_logs"`
+		StripANSI     bool   `toml:"strip_ansi"`
+		MaxLogSize    string `toml:"max_log_size"`
+		LogRotations  int    `toml:"log_rotations"`
 		Silent        bool   `toml:"silent"`
+		Progress      bool   `toml:"progress"`
+		Stdin         bool   `toml:"stdin"`
+		StdinLog      bool   `toml:"stdin_log"`
+		Name          string `toml:"name"`
 		Message       string `toml:"message"`
 		PromptMessage bool   `toml:"prompt_message"`
+		GitNotes      bool   `toml:"git_notes"`
+		LatestLink    bool   `toml:"latest_link"`
+		SuccessLink   bool   `toml:"success_link"`
+		Queue         bool   `toml:"queue"`
+		After         string `toml:"after"`
+
+		Timeout           string `toml:"timeout"`
+		HeartbeatInterval string `toml:"heartbeat_interval"`
+		StaleAfter        string `toml:"stale_after"`
+
+		DirTimezone  string `toml:"dir_timezone"`
+		DirPrecision string `toml:"dir_precision"`
+
+		AllowDifferentCode bool `toml:"allow_different_code"`
+		Checkout           bool `toml:"checkout"`
+
+		Issues       []string `toml:"issues"`
+		IssuePattern string   `toml:"issue_pattern"`
+		IssueURL     string   `toml:"issue_url"`
+		Tags         []string `toml:"tags"`
+
+		SuccessExitCodes []int             `toml:"success_exit_codes"`
+		ExitCodeNames    map[string]string `toml:"exit_code_names"`
+
+		IgnoreDirtyPaths []string          `toml:"ignore_dirty_paths"`
+		ExtraRepos       []string          `toml:"extra_repos"`
+		RecordEnv        []string          `toml:"record_env"`
+		CaptureEnv       []string          `toml:"capture_env"`
+		PreHooks         []string          `toml:"pre_hooks"`
+		TensorBoardDir   string            `toml:"tensorboard_dir"`
+		Artifacts        []string          `toml:"artifacts"`
+		Include          []string          `toml:"include"`
+		Templates        []string          `toml:"templates"`
+		DVC              bool              `toml:"dvc"`
+		PostHooks        []string          `toml:"post_hooks"`
+		VersionProbes    map[string]string `toml:"version_probes"`
+		GPUInfo          []string          `toml:"gpu_info"`
 	} `toml:"run"`
 
+	Repro struct {
+		Worktree string `toml:"worktree"`
+		Branch   string `toml:"branch"`
+		NoRun    bool   `toml:"no_run"`
+	} `toml:"repro"`
+
 	Show struct {
 		Raw bool `toml:"raw"`
 	} `toml:"show"`
@@ -36,11 +90,32 @@ type Config struct {
 		Status  string `toml:"status"`
 		Since   string `toml:"since"`
 		Command string `toml:"command"`
-		Limit   int    `toml:"limit"`
+		Issue   string `toml:"issue"`
+		Tag     string `toml:"tag"`
+		Name    string `toml:"name"`
+		Sweep   string `toml:"sweep"`
+		Notes   bool   `toml:"notes"`
+
+		Where   []string `toml:"where"`
+		Metrics []string `toml:"metrics"`
+		GroupBy string   `toml:"group_by"`
+
+		Archived    bool `toml:"archived"`
+		Limit       int  `toml:"limit"`
+		Interactive bool `toml:"interactive"`
+		Watch       bool `toml:"watch"`
+
+		AllProjects bool `toml:"all_projects"`
 	} `toml:"list"`
 
 	Status struct {
-		Level string `toml:"level"`
+		Level       string `toml:"level"`
+		Format      string `toml:"format"`
+		AllProjects bool   `toml:"all_projects"`
+		DiskBudget  string `toml:"disk_budget"`
+		TrendWeeks  int    `toml:"trend_weeks"`
+		CommandKey  string `toml:"command_key"`
+		Watch       bool   `toml:"watch"`
 	} `toml:"status"`
 
 	Config struct {
@@ -48,13 +123,163 @@ type Config struct {
 	} `toml:"config"`
 
 	Archive struct {
-		Format    string `toml:"format"`
-		To        string `toml:"to"`
+		Format    string   `toml:"format"`
+		Level     int      `toml:"level"`
+		To        string   `toml:"to"`
+		OlderThan string   `toml:"older_than"`
+		Status    string   `toml:"status"`
+		Delete    bool     `toml:"delete"`
+		DryRun    bool     `toml:"dry_run"`
+		Yes       bool     `toml:"yes"`
+		EncryptTo string   `toml:"encrypt_to"`
+		Identity  string   `toml:"identity"`
+		Exclude   []string `toml:"exclude"`
+	} `toml:"archive"`
+
+	Remove struct {
+		Force  bool `toml:"force"`
+		DryRun bool `toml:"dry_run"`
+		Yes    bool `toml:"yes"`
+	} `toml:"remove"`
+
+	Clean struct {
 		OlderThan string `toml:"older_than"`
 		Status    string `toml:"status"`
-		Delete    bool   `toml:"delete"`
 		DryRun    bool   `toml:"dry_run"`
-	} `toml:"archive"`
+		Yes       bool   `toml:"yes"`
+	} `toml:"clean"`
+
+	GC struct {
+		Grace  string `toml:"grace"`
+		DryRun bool   `toml:"dry_run"`
+		Yes    bool   `toml:"yes"`
+	} `toml:"gc"`
+
+	Objects struct {
+		Dedup  bool   `toml:"dedup"`
+		Link   string `toml:"link"`
+		DryRun bool   `toml:"dry_run"`
+	} `toml:"objects"`
+
+	Migrate struct {
+		DryRun bool `toml:"dry_run"`
+	} `toml:"migrate"`
+
+	Init struct {
+		User      bool `toml:"user"`
+		Gitignore bool `toml:"gitignore"`
+		Force     bool `toml:"force"`
+	} `toml:"init"`
+
+	Doctor struct {
+		Fix bool `toml:"fix"`
+	} `toml:"doctor"`
+
+	Data struct {
+		Paths []string `toml:"paths"`
+		Mode  string   `toml:"mode"`
+	} `toml:"data"`
+
+	Cost struct {
+		Currency    string             `toml:"currency"`
+		DefaultRate float64            `toml:"default_rate"`
+		HostRates   map[string]float64 `toml:"host_rates"`
+		GPURate     float64            `toml:"gpu_rate"`
+	} `toml:"cost"`
+
+	Projects struct {
+		Paths []string `toml:"paths"`
+	} `toml:"projects"`
+
+	Tui struct {
+		Refresh string `toml:"refresh"`
+	} `toml:"tui"`
+
+	Index struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"index"`
+
+	Search struct {
+		Regex bool `toml:"regex"`
+		Logs  bool `toml:"logs"`
+	} `toml:"search"`
+
+	Summary struct {
+		All bool `toml:"all"`
+	} `toml:"summary"`
+
+	Logs struct {
+		Stderr bool `toml:"stderr"`
+		Tail   int  `toml:"tail"`
+		Follow bool `toml:"follow"`
+	} `toml:"logs"`
+
+	Kill struct {
+		Signal  string `toml:"signal"`
+		Timeout string `toml:"timeout"`
+	} `toml:"kill"`
+
+	Queue struct {
+		Workers int `toml:"workers"`
+	} `toml:"queue"`
+
+	TensorBoard struct {
+		Command string `toml:"command"`
+		DryRun  bool   `toml:"dry_run"`
+	} `toml:"tensorboard"`
+
+	Metrics struct {
+		Format  string `toml:"format"`
+		SortBy  string `toml:"sort_by"`
+		Reverse bool   `toml:"reverse"`
+	} `toml:"metrics"`
+
+	Artifacts struct {
+		Format string `toml:"format"`
+		Output string `toml:"output"`
+	} `toml:"artifacts"`
+
+	Export struct {
+		Format     string `toml:"format"`
+		Output     string `toml:"output"`
+		Experiment string `toml:"experiment"`
+
+		Wandb struct {
+			Entity  string `toml:"entity"`
+			BaseURL string `toml:"base_url"`
+		} `toml:"wandb"`
+	} `toml:"export"`
+
+	Serve struct {
+		Addr    string `toml:"addr"`
+		APIOnly bool   `toml:"api_only"`
+	} `toml:"serve"`
+
+	Notify struct {
+		Webhooks []string `toml:"webhooks"`
+		Timeout  string   `toml:"timeout"`
+		Retries  int      `toml:"retries"`
+
+		On          []string `toml:"on"`
+		MinDuration string   `toml:"min_duration"`
+
+		Slack struct {
+			WebhookURL  string `toml:"webhook_url"`
+			StderrLines int    `toml:"stderr_lines"`
+		} `toml:"slack"`
+	} `toml:"notify"`
+
+	Sweep struct {
+		Params []string `toml:"params"`
+		Spec   string   `toml:"spec"`
+		DryRun bool     `toml:"dry_run"`
+	} `toml:"sweep"`
+
+	SelfUpdate struct {
+		Channel string `toml:"channel"`
+		Check   bool   `toml:"check"`
+		Force   bool   `toml:"force"`
+	} `toml:"self_update"`
 }
 
 // temprary struct for toml unmarshal to check if the value is nil
@@ -68,11 +293,63 @@ type config struct {
 		NoPushd       *bool   `toml:"no_pushd"`
 		StdoutFile    *string `toml:"stdout_file"`
 		StderrFile    *string `toml:"stderr_file"`
+		CombinedLog   *bool   `toml:"combined_log"`
+		TimestampLogs *bool   `toml:"timestamp_logs"`
+		StripANSI     *bool   `toml:"strip_ansi"`
+		MaxLogSize    *string `toml:"max_log_size"`
+		LogRotations  *int    `toml:"log_rotations"`
 		Silent        *bool   `toml:"silent"`
+		Progress      *bool   `toml:"progress"`
+		Stdin         *bool   `toml:"stdin"`
+		StdinLog      *bool   `toml:"stdin_log"`
+		Name          *string `toml:"name"`
 		Message       *string `toml:"message"`
 		PromptMessage *bool   `toml:"prompt_message"`
+		GitNotes      *bool   `toml:"git_notes"`
+		LatestLink    *bool   `toml:"latest_link"`
+		SuccessLink   *bool   `toml:"success_link"`
+		Queue         *bool   `toml:"queue"`
+		After         *string `toml:"after"`
+
+		Timeout           *string `toml:"timeout"`
+		HeartbeatInterval *string `toml:"heartbeat_interval"`
+		StaleAfter        *string `toml:"stale_after"`
+
+		DirTimezone  *string `toml:"dir_timezone"`
+		DirPrecision *string `toml:"dir_precision"`
+
+		AllowDifferentCode *bool `toml:"allow_different_code"`
+		Checkout           *bool `toml:"checkout"`
+
+		Issues       *[]string `toml:"issues"`
+		IssuePattern *string   `toml:"issue_pattern"`
+		IssueURL     *string   `toml:"issue_url"`
+		Tags         *[]string `toml:"tags"`
+
+		SuccessExitCodes *[]int             `toml:"success_exit_codes"`
+		ExitCodeNames    *map[string]string `toml:"exit_code_names"`
+
+		IgnoreDirtyPaths *[]string          `toml:"ignore_dirty_paths"`
+		ExtraRepos       *[]string          `toml:"extra_repos"`
+		RecordEnv        *[]string          `toml:"record_env"`
+		CaptureEnv       *[]string          `toml:"capture_env"`
+		PreHooks         *[]string          `toml:"pre_hooks"`
+		TensorBoardDir   *string            `toml:"tensorboard_dir"`
+		Artifacts        *[]string          `toml:"artifacts"`
+		Include          *[]string          `toml:"include"`
+		Templates        *[]string          `toml:"templates"`
+		DVC              *bool              `toml:"dvc"`
+		PostHooks        *[]string          `toml:"post_hooks"`
+		VersionProbes    *map[string]string `toml:"version_probes"`
+		GPUInfo          *[]string          `toml:"gpu_info"`
 	} `toml:"run"`
 
+	Repro *struct {
+		Worktree *string `toml:"worktree"`
+		Branch   *string `toml:"branch"`
+		NoRun    *bool   `toml:"no_run"`
+	} `toml:"repro"`
+
 	Show *struct {
 		Raw *bool `toml:"raw"`
 	} `toml:"show"`
@@ -85,11 +362,32 @@ type config struct {
 		Status  *string `toml:"status"`
 		Since   *string `toml:"since"`
 		Command *string `toml:"command"`
-		Limit   *int    `toml:"limit"`
+		Issue   *string `toml:"issue"`
+		Tag     *string `toml:"tag"`
+		Name    *string `toml:"name"`
+		Sweep   *string `toml:"sweep"`
+		Notes   *bool   `toml:"notes"`
+
+		Where   *[]string `toml:"where"`
+		Metrics *[]string `toml:"metrics"`
+		GroupBy *string   `toml:"group_by"`
+
+		Archived    *bool `toml:"archived"`
+		Limit       *int  `toml:"limit"`
+		Interactive *bool `toml:"interactive"`
+		Watch       *bool `toml:"watch"`
+
+		AllProjects *bool `toml:"all_projects"`
 	} `toml:"list"`
 
 	Status *struct {
-		Level *string `toml:"level"`
+		Level       *string `toml:"level"`
+		Format      *string `toml:"format"`
+		AllProjects *bool   `toml:"all_projects"`
+		DiskBudget  *string `toml:"disk_budget"`
+		TrendWeeks  *int    `toml:"trend_weeks"`
+		CommandKey  *string `toml:"command_key"`
+		Watch       *bool   `toml:"watch"`
 	} `toml:"status"`
 
 	Config *struct {
@@ -97,13 +395,163 @@ type config struct {
 	} `toml:"config"`
 
 	Archive *struct {
-		Format    *string `toml:"format"`
-		To        *string `toml:"to"`
+		Format    *string   `toml:"format"`
+		Level     *int      `toml:"level"`
+		To        *string   `toml:"to"`
+		OlderThan *string   `toml:"older_than"`
+		Status    *string   `toml:"status"`
+		Delete    *bool     `toml:"delete"`
+		DryRun    *bool     `toml:"dry_run"`
+		Yes       *bool     `toml:"yes"`
+		EncryptTo *string   `toml:"encrypt_to"`
+		Identity  *string   `toml:"identity"`
+		Exclude   *[]string `toml:"exclude"`
+	} `toml:"archive"`
+
+	Remove *struct {
+		Force  *bool `toml:"force"`
+		DryRun *bool `toml:"dry_run"`
+		Yes    *bool `toml:"yes"`
+	} `toml:"remove"`
+
+	Clean *struct {
 		OlderThan *string `toml:"older_than"`
 		Status    *string `toml:"status"`
-		Delete    *bool   `toml:"delete"`
 		DryRun    *bool   `toml:"dry_run"`
-	} `toml:"archive"`
+		Yes       *bool   `toml:"yes"`
+	} `toml:"clean"`
+
+	GC *struct {
+		Grace  *string `toml:"grace"`
+		DryRun *bool   `toml:"dry_run"`
+		Yes    *bool   `toml:"yes"`
+	} `toml:"gc"`
+
+	Objects *struct {
+		Dedup  *bool   `toml:"dedup"`
+		Link   *string `toml:"link"`
+		DryRun *bool   `toml:"dry_run"`
+	} `toml:"objects"`
+
+	Migrate *struct {
+		DryRun *bool `toml:"dry_run"`
+	} `toml:"migrate"`
+
+	Init *struct {
+		User      *bool `toml:"user"`
+		Gitignore *bool `toml:"gitignore"`
+		Force     *bool `toml:"force"`
+	} `toml:"init"`
+
+	Doctor *struct {
+		Fix *bool `toml:"fix"`
+	} `toml:"doctor"`
+
+	Data *struct {
+		Paths *[]string `toml:"paths"`
+		Mode  *string   `toml:"mode"`
+	} `toml:"data"`
+
+	Cost *struct {
+		Currency    *string             `toml:"currency"`
+		DefaultRate *float64            `toml:"default_rate"`
+		HostRates   *map[string]float64 `toml:"host_rates"`
+		GPURate     *float64            `toml:"gpu_rate"`
+	} `toml:"cost"`
+
+	Projects *struct {
+		Paths *[]string `toml:"paths"`
+	} `toml:"projects"`
+
+	Tui *struct {
+		Refresh *string `toml:"refresh"`
+	} `toml:"tui"`
+
+	Index *struct {
+		Enabled *bool `toml:"enabled"`
+	} `toml:"index"`
+
+	Search *struct {
+		Regex *bool `toml:"regex"`
+		Logs  *bool `toml:"logs"`
+	} `toml:"search"`
+
+	Summary *struct {
+		All *bool `toml:"all"`
+	} `toml:"summary"`
+
+	Logs *struct {
+		Stderr *bool `toml:"stderr"`
+		Tail   *int  `toml:"tail"`
+		Follow *bool `toml:"follow"`
+	} `toml:"logs"`
+
+	Kill *struct {
+		Signal  *string `toml:"signal"`
+		Timeout *string `toml:"timeout"`
+	} `toml:"kill"`
+
+	Queue *struct {
+		Workers *int `toml:"workers"`
+	} `toml:"queue"`
+
+	TensorBoard *struct {
+		Command *string `toml:"command"`
+		DryRun  *bool   `toml:"dry_run"`
+	} `toml:"tensorboard"`
+
+	Metrics *struct {
+		Format  *string `toml:"format"`
+		SortBy  *string `toml:"sort_by"`
+		Reverse *bool   `toml:"reverse"`
+	} `toml:"metrics"`
+
+	Artifacts *struct {
+		Format *string `toml:"format"`
+		Output *string `toml:"output"`
+	} `toml:"artifacts"`
+
+	Export *struct {
+		Format     *string `toml:"format"`
+		Output     *string `toml:"output"`
+		Experiment *string `toml:"experiment"`
+
+		Wandb *struct {
+			Entity  *string `toml:"entity"`
+			BaseURL *string `toml:"base_url"`
+		} `toml:"wandb"`
+	} `toml:"export"`
+
+	Serve *struct {
+		Addr    *string `toml:"addr"`
+		APIOnly *bool   `toml:"api_only"`
+	} `toml:"serve"`
+
+	Notify *struct {
+		Webhooks *[]string `toml:"webhooks"`
+		Timeout  *string   `toml:"timeout"`
+		Retries  *int      `toml:"retries"`
+
+		On          *[]string `toml:"on"`
+		MinDuration *string   `toml:"min_duration"`
+
+		Slack *struct {
+			WebhookURL  *string `toml:"webhook_url"`
+			StderrLines *int    `toml:"stderr_lines"`
+		} `toml:"slack"`
+	} `toml:"notify"`
+
+	Sweep *struct {
+		Params *[]string `toml:"params"`
+		Spec   *string   `toml:"spec"`
+		DryRun *bool     `toml:"dry_run"`
+	} `toml:"sweep"`
+
+	SelfUpdate *struct {
+		Channel *string `toml:"channel"`
+		Check   *bool   `toml:"check"`
+		Force   *bool   `toml:"force"`
+	} `toml:"self_update"`
 }
 
 const defaultConfig = `
@@ -117,9 +565,62 @@ cleanup_on_fail = false
 no_pushd = false
 stdout_file = "stdout.log"
 stderr_file = "stderr.log"
+combined_log = false
+timestamp_logs = false
+strip_ansi = false
+max_log_size = ""
+log_rotations = 0
 silent = false
+progress = false
+stdin = true
+stdin_log = false
+name = ""
 message = ""
 prompt_message = false
+git_notes = false
+latest_link = true
+success_link = false
+timeout = ""
+heartbeat_interval = "30s"
+stale_after = "5m"
+dir_timezone = "local"
+dir_precision = "ms"
+allow_different_code = false
+checkout = false
+issues = []
+issue_pattern = '[A-Z][A-Z0-9]+-[0-9]+|#[0-9]+'
+issue_url = ""
+ignore_dirty_paths = []
+extra_repos = []
+record_env = []
+capture_env = []
+tensorboard_dir = ""
+artifacts = []
+include = []
+templates = []
+dvc = true
+pre_hooks = []
+post_hooks = []
+success_exit_codes = [0]
+gpu_info = [
+    "nvidia-smi --query-gpu=index,name,driver_version,memory.total --format=csv",
+    "nvidia-smi | grep -o 'CUDA Version: [0-9.]*'",
+]
+
+[run.exit_code_names]
+124 = "Timeout"
+130 = "Interrupted"
+137 = "Killed"
+139 = "Segmentation fault"
+143 = "Terminated"
+
+[run.version_probes]
+python = "python --version"
+python3 = "python3 --version"
+julia = "julia --version"
+Rscript = "Rscript --version"
+node = "node --version"
+nvcc = "nvcc --version | tail -n 1"
 
 [show]
 raw = false
@@ -132,25 +633,189 @@ branch = ""
 status = ""
 since = ""
 command = ""
+issue = ""
+tag = ""
+name = ""
+sweep = ""
+where = []
+notes = false
+metrics = []
+group_by = ""
+archived = false
 limit = 0
+all_projects = false
 
 [status]
 level = "normal"
+format = "text"
+all_projects = false
+disk_budget = ""
+trend_weeks = 4
+command_key = "first"
 
 [config]
 default = false
 
 [archive]
 format = "tar.gz"
+level = 0
 to = "archives"
 older_than = ""
 status = ""
 delete = false
 dry_run = false
+yes = false
+encrypt_to = ""
+identity = ""
+exclude = []
+
+[remove]
+force = false
+dry_run = false
+yes = false
+
+[clean]
+older_than = ""
+status = "failure"
+dry_run = false
+yes = false
+
+[gc]
+grace = "24h"
+dry_run = false
+yes = false
+
+[objects]
+dedup = false
+link = "hardlink"
+dry_run = false
+
+[migrate]
+dry_run = false
+
+[init]
+user = false
+gitignore = false
+force = false
+
+[doctor]
+fix = false
+
+[data]
+paths = []
+mode = "fast"
+
+[cost]
+currency = "USD"
+default_rate = 0.0
+gpu_rate = 0.0
+
+[cost.host_rates]
+
+[projects]
+paths = []
+
+[tui]
+refresh = "2s"
+
+[index]
+enabled = true
+
+[search]
+regex = false
+logs = false
+
+[summary]
+all = false
+
+[logs]
+stderr = false
+tail = 0
+follow = false
+
+[kill]
+signal = "TERM"
+timeout = "30s"
+
+[queue]
+workers = 1
+
+[tensorboard]
+command = "tensorboard"
+
+[metrics]
+format = "table"
+sort_by = ""
+reverse = false
+
+[artifacts]
+format = "table"
+
+[export]
+format = "mlflow"
+output = "mlruns"
+experiment = ""
+
+[export.wandb]
+entity = ""
+base_url = "https://api.wandb.ai"
+
+[serve]
+addr = "127.0.0.1:8080"
+api_only = false
+
+[notify]
+webhooks = []
+timeout = "10s"
+retries = 3
+on = ["success", "failure", "interrupted", "timeout"]
+min_duration = ""
+
+[notify.slack]
+webhook_url = ""
+stderr_lines = 20
+
+[sweep]
+params = []
+spec = ""
+
+[self_update]
+channel = "stable"
+check = false
+force = false
 `
 
 var globalConfig Config
 
+// initErr is the validation error of the configuration loaded by Init
+var initErr error
+
+// SnapshotFile is the name of the file in a run directory recording the
+// configuration in effect
+const SnapshotFile = "moco-config.toml"
+
+// redacted replaces secrets in configuration snapshots
+const redacted = "<redacted>"
+
+// WriteSnapshot writes a configuration to a file, with webhook URLs, which
+// are secrets, redacted
+func WriteSnapshot(path string, config Config) error {
+	webhooks := make([]string, len(config.Notify.Webhooks))
+	for i := range webhooks {
+		webhooks[i] = redacted
+	}
+	config.Notify.Webhooks = webhooks
+	if config.Notify.Slack.WebhookURL != "" {
+		config.Notify.Slack.WebhookURL = redacted
+	}
+
+	data, err := toml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
 func Main(config Config) error {
 	b, _ := toml.Marshal(config)
 	fmt.Print(string(b))
@@ -158,36 +823,202 @@ func Main(config Config) error {
 }
 
 // Init loads configuration from files
+//
+// Invalid settings and files that cannot be loaded don't fail Init so that
+// they can be listed by "config validate" or replaced by "init --force";
+// they are returned by Err instead, and the defaults are used for files that
+// cannot be loaded.
 func Init() error {
+	initErr = nil
+	config, err := load(".")
+	if err != nil {
+		globalConfig = GetDefault()
+		initErr = err
+		return nil
+	}
+	globalConfig = config
+	if problems := errorsOf(validate(config, ".", filesAt("."))); len(problems) > 0 {
+		initErr = &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Err returns a *ValidationError with the invalid settings found by Init,
+// or nil if there are none
+func Err() error {
+	return initErr
+}
+
+// LoadProject loads the configuration that applies to the project in dir
+func LoadProject(dir string) (Config, error) {
+	return load(dir)
+}
+
+// load merges the default, user-level, and project-level configurations
+func load(dir string) (Config, error) {
 	// Set defaults
-	config, _ := loadData([]byte(defaultConfig))
-	merge(&globalConfig, config)
+	result := GetDefault()
+
+	// Merge user-level and project-level configs in this order
+	for _, path := range filesAt(dir) {
+		config, err := loadFile(path)
+		if err != nil {
+			return result, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		merge(&result, config)
+	}
+
+	return result, nil
+}
+
+// Files returns the existing configuration files in the order they are loaded
+func Files() []string {
+	return filesAt(".")
+}
+
+// filesAt returns the existing configuration files of the project in dir
+func filesAt(dir string) []string {
+	var files []string
 
 	// Check for user-level config
-	configDir, err := os.UserConfigDir()
-	if err == nil {
-		userConfig := filepath.Join(configDir, "moco", "config.toml")
+	if userConfig, err := UserFile(); err == nil {
 		if _, err := os.Stat(userConfig); err == nil {
-			config, err := loadFile(userConfig)
-			if err != nil {
-				return err
-			}
-			merge(&globalConfig, config)
+			files = append(files, userConfig)
 		}
 	}
 
 	// Check for project-level config
-	if _, err := os.Stat(".moco.toml"); err == nil {
-		config, err := loadFile(".moco.toml")
-		if err != nil {
-			return err
+	projectConfig := filepath.Join(dir, ".moco.toml")
+	if _, err := os.Stat(projectConfig); err == nil {
+		files = append(files, projectConfig)
+	}
+
+	return files
+}
+
+// UserFile returns the path of the user-level configuration file
+func UserFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "moco", "config.toml"), nil
+}
+
+// UserProjects returns the projects registered in the user-level configuration file
+//
+// Unlike Get().Projects.Paths, this excludes projects set in the project-level
+// configuration file or environment variables.
+func UserProjects() ([]string, error) {
+	userConfig, err := UserFile()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := readSettings(userConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, _ := settings["projects"].(map[string]any)
+	values, _ := projects["paths"].([]any)
+	var paths []string
+	for _, value := range values {
+		if path, ok := value.(string); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// SetUserProjects updates the registered projects in the user-level configuration file
+//
+// Other settings in the file are preserved, but comments and formatting are not.
+func SetUserProjects(paths []string) error {
+	userConfig, err := UserFile()
+	if err != nil {
+		return err
+	}
+	settings, err := readSettings(userConfig)
+	if err != nil {
+		return err
+	}
+
+	projects, _ := settings["projects"].(map[string]any)
+	if projects == nil {
+		projects = map[string]any{}
+	}
+	projects["paths"] = paths
+	settings["projects"] = projects
+
+	data, err := toml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(userConfig), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(userConfig, data, 0644)
+}
+
+// readSettings loads a configuration file as a generic map, which is empty if
+// the file does not exist
+func readSettings(path string) (map[string]any, error) {
+	settings := map[string]any{}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := toml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
 		}
-		merge(&globalConfig, config)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// CheckFile reports syntax errors and unknown keys in a configuration file
+func CheckFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
+	decoder := toml.NewDecoder(file).DisallowUnknownFields()
+	var config config
+	if err := decoder.Decode(&config); err != nil {
+		var strictErr *toml.StrictMissingError
+		if errors.As(err, &strictErr) {
+			return fmt.Errorf("unknown key(s):\n%s", strictErr.String())
+		}
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, col := decodeErr.Position()
+			return fmt.Errorf("line %d, column %d: %w", line, col, err)
+		}
+		return err
+	}
 	return nil
 }
 
+// Template returns a configuration file with all the default settings
+// commented out, so that only uncommented settings override the defaults
+//
+// Section headers are kept so that uncommenting a setting is enough.
+func Template() string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(defaultConfig), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#"):
+			continue
+		case line == "" || strings.HasPrefix(line, "["):
+			b.WriteString(line + "\n")
+		default:
+			b.WriteString("# " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
 // Get returns the current configuration
 func Get() Config {
 	return globalConfig
@@ -230,15 +1061,160 @@ func merge(dst *Config, src config) {
 		if src.Run.StderrFile != nil {
 			dst.Run.StderrFile = *src.Run.StderrFile
 		}
+		if src.Run.CombinedLog != nil {
+			dst.Run.CombinedLog = *src.Run.CombinedLog
+		}
+		if src.Run.TimestampLogs != nil {
+			dst.Run.TimestampLogs = *src.Run.TimestampLogs
+		}
+		if src.Run.StripANSI != nil {
+			dst.Run.StripANSI = *src.Run.StripANSI
+		}
+		if src.Run.MaxLogSize != nil {
+			dst.Run.MaxLogSize = *src.Run.MaxLogSize
+		}
+		if src.Run.LogRotations != nil {
+			dst.Run.LogRotations = *src.Run.LogRotations
+		}
 		if src.Run.Silent != nil {
 			dst.Run.Silent = *src.Run.Silent
 		}
+		if src.Run.Progress != nil {
+			dst.Run.Progress = *src.Run.Progress
+		}
+		if src.Run.Stdin != nil {
+			dst.Run.Stdin = *src.Run.Stdin
+		}
+		if src.Run.StdinLog != nil {
+			dst.Run.StdinLog = *src.Run.StdinLog
+		}
+		if src.Run.Name != nil {
+			dst.Run.Name = *src.Run.Name
+		}
 		if src.Run.Message != nil {
 			dst.Run.Message = *src.Run.Message
 		}
 		if src.Run.PromptMessage != nil {
 			dst.Run.PromptMessage = *src.Run.PromptMessage
 		}
+		if src.Run.GitNotes != nil {
+			dst.Run.GitNotes = *src.Run.GitNotes
+		}
+		if src.Run.LatestLink != nil {
+			dst.Run.LatestLink = *src.Run.LatestLink
+		}
+		if src.Run.SuccessLink != nil {
+			dst.Run.SuccessLink = *src.Run.SuccessLink
+		}
+		if src.Run.Queue != nil {
+			dst.Run.Queue = *src.Run.Queue
+		}
+		if src.Run.After != nil {
+			dst.Run.After = *src.Run.After
+		}
+		if src.Run.Timeout != nil {
+			dst.Run.Timeout = *src.Run.Timeout
+		}
+		if src.Run.HeartbeatInterval != nil {
+			dst.Run.HeartbeatInterval = *src.Run.HeartbeatInterval
+		}
+		if src.Run.StaleAfter != nil {
+			dst.Run.StaleAfter = *src.Run.StaleAfter
+		}
+		if src.Run.DirTimezone != nil {
+			dst.Run.DirTimezone = *src.Run.DirTimezone
+		}
+		if src.Run.DirPrecision != nil {
+			dst.Run.DirPrecision = *src.Run.DirPrecision
+		}
+		if src.Run.AllowDifferentCode != nil {
+			dst.Run.AllowDifferentCode = *src.Run.AllowDifferentCode
+		}
+		if src.Run.Checkout != nil {
+			dst.Run.Checkout = *src.Run.Checkout
+		}
+		if src.Run.Issues != nil {
+			dst.Run.Issues = *src.Run.Issues
+		}
+		if src.Run.Tags != nil {
+			dst.Run.Tags = *src.Run.Tags
+		}
+		if src.Run.IssuePattern != nil {
+			dst.Run.IssuePattern = *src.Run.IssuePattern
+		}
+		if src.Run.IssueURL != nil {
+			dst.Run.IssueURL = *src.Run.IssueURL
+		}
+		if src.Run.SuccessExitCodes != nil {
+			dst.Run.SuccessExitCodes = *src.Run.SuccessExitCodes
+		}
+		if src.Run.ExitCodeNames != nil {
+			// Names are merged per exit code as version probes are
+			if dst.Run.ExitCodeNames == nil {
+				dst.Run.ExitCodeNames = map[string]string{}
+			}
+			for code, name := range *src.Run.ExitCodeNames {
+				dst.Run.ExitCodeNames[code] = name
+			}
+		}
+		if src.Run.IgnoreDirtyPaths != nil {
+			dst.Run.IgnoreDirtyPaths = *src.Run.IgnoreDirtyPaths
+		}
+		if src.Run.CaptureEnv != nil {
+			dst.Run.CaptureEnv = *src.Run.CaptureEnv
+		}
+		if src.Run.TensorBoardDir != nil {
+			dst.Run.TensorBoardDir = *src.Run.TensorBoardDir
+		}
+		if src.Run.Artifacts != nil {
+			dst.Run.Artifacts = *src.Run.Artifacts
+		}
+		if src.Run.Include != nil {
+			dst.Run.Include = *src.Run.Include
+		}
+		if src.Run.Templates != nil {
+			dst.Run.Templates = *src.Run.Templates
+		}
+		if src.Run.DVC != nil {
+			dst.Run.DVC = *src.Run.DVC
+		}
+		if src.Run.PreHooks != nil {
+			dst.Run.PreHooks = *src.Run.PreHooks
+		}
+		if src.Run.PostHooks != nil {
+			dst.Run.PostHooks = *src.Run.PostHooks
+		}
+		if src.Run.GPUInfo != nil {
+			dst.Run.GPUInfo = *src.Run.GPUInfo
+		}
+		if src.Run.ExtraRepos != nil {
+			dst.Run.ExtraRepos = *src.Run.ExtraRepos
+		}
+		if src.Run.RecordEnv != nil {
+			dst.Run.RecordEnv = *src.Run.RecordEnv
+		}
+		if src.Run.VersionProbes != nil {
+			// Probes are merged per program so that user configs can add or
+			// override (or disable with "") probes without repeating defaults
+			if dst.Run.VersionProbes == nil {
+				dst.Run.VersionProbes = map[string]string{}
+			}
+			for name, probe := range *src.Run.VersionProbes {
+				dst.Run.VersionProbes[name] = probe
+			}
+		}
+	}
+
+	if src.Repro != nil {
+		if src.Repro.Worktree != nil {
+			dst.Repro.Worktree = *src.Repro.Worktree
+		}
+		if src.Repro.Branch != nil {
+			dst.Repro.Branch = *src.Repro.Branch
+		}
+		if src.Repro.NoRun != nil {
+			dst.Repro.NoRun = *src.Repro.NoRun
+		}
 	}
 
 	if src.Show != nil {
@@ -269,15 +1245,69 @@ func merge(dst *Config, src config) {
 		if src.List.Command != nil {
 			dst.List.Command = *src.List.Command
 		}
+		if src.List.Issue != nil {
+			dst.List.Issue = *src.List.Issue
+		}
+		if src.List.Tag != nil {
+			dst.List.Tag = *src.List.Tag
+		}
+		if src.List.Name != nil {
+			dst.List.Name = *src.List.Name
+		}
+		if src.List.Sweep != nil {
+			dst.List.Sweep = *src.List.Sweep
+		}
+		if src.List.Notes != nil {
+			dst.List.Notes = *src.List.Notes
+		}
+		if src.List.Where != nil {
+			dst.List.Where = *src.List.Where
+		}
+		if src.List.Metrics != nil {
+			dst.List.Metrics = *src.List.Metrics
+		}
+		if src.List.GroupBy != nil {
+			dst.List.GroupBy = *src.List.GroupBy
+		}
+		if src.List.Archived != nil {
+			dst.List.Archived = *src.List.Archived
+		}
 		if src.List.Limit != nil {
 			dst.List.Limit = *src.List.Limit
 		}
+		if src.List.Interactive != nil {
+			dst.List.Interactive = *src.List.Interactive
+		}
+		if src.List.Watch != nil {
+			dst.List.Watch = *src.List.Watch
+		}
+		if src.List.AllProjects != nil {
+			dst.List.AllProjects = *src.List.AllProjects
+		}
 	}
 
 	if src.Status != nil {
 		if src.Status.Level != nil {
 			dst.Status.Level = *src.Status.Level
 		}
+		if src.Status.Format != nil {
+			dst.Status.Format = *src.Status.Format
+		}
+		if src.Status.AllProjects != nil {
+			dst.Status.AllProjects = *src.Status.AllProjects
+		}
+		if src.Status.DiskBudget != nil {
+			dst.Status.DiskBudget = *src.Status.DiskBudget
+		}
+		if src.Status.TrendWeeks != nil {
+			dst.Status.TrendWeeks = *src.Status.TrendWeeks
+		}
+		if src.Status.CommandKey != nil {
+			dst.Status.CommandKey = *src.Status.CommandKey
+		}
+		if src.Status.Watch != nil {
+			dst.Status.Watch = *src.Status.Watch
+		}
 	}
 
 	if src.Config != nil {
@@ -290,6 +1320,9 @@ func merge(dst *Config, src config) {
 		if src.Archive.Format != nil {
 			dst.Archive.Format = *src.Archive.Format
 		}
+		if src.Archive.Level != nil {
+			dst.Archive.Level = *src.Archive.Level
+		}
 		if src.Archive.To != nil {
 			dst.Archive.To = *src.Archive.To
 		}
@@ -305,6 +1338,292 @@ func merge(dst *Config, src config) {
 		if src.Archive.DryRun != nil {
 			dst.Archive.DryRun = *src.Archive.DryRun
 		}
+		if src.Archive.Yes != nil {
+			dst.Archive.Yes = *src.Archive.Yes
+		}
+		if src.Archive.EncryptTo != nil {
+			dst.Archive.EncryptTo = *src.Archive.EncryptTo
+		}
+		if src.Archive.Identity != nil {
+			dst.Archive.Identity = *src.Archive.Identity
+		}
+		if src.Archive.Exclude != nil {
+			dst.Archive.Exclude = *src.Archive.Exclude
+		}
+	}
+
+	if src.Remove != nil {
+		if src.Remove.Force != nil {
+			dst.Remove.Force = *src.Remove.Force
+		}
+		if src.Remove.DryRun != nil {
+			dst.Remove.DryRun = *src.Remove.DryRun
+		}
+		if src.Remove.Yes != nil {
+			dst.Remove.Yes = *src.Remove.Yes
+		}
+	}
+
+	if src.Clean != nil {
+		if src.Clean.OlderThan != nil {
+			dst.Clean.OlderThan = *src.Clean.OlderThan
+		}
+		if src.Clean.Status != nil {
+			dst.Clean.Status = *src.Clean.Status
+		}
+		if src.Clean.DryRun != nil {
+			dst.Clean.DryRun = *src.Clean.DryRun
+		}
+		if src.Clean.Yes != nil {
+			dst.Clean.Yes = *src.Clean.Yes
+		}
+	}
+
+	if src.GC != nil {
+		if src.GC.Grace != nil {
+			dst.GC.Grace = *src.GC.Grace
+		}
+		if src.GC.DryRun != nil {
+			dst.GC.DryRun = *src.GC.DryRun
+		}
+		if src.GC.Yes != nil {
+			dst.GC.Yes = *src.GC.Yes
+		}
+	}
+
+	if src.Objects != nil {
+		if src.Objects.Dedup != nil {
+			dst.Objects.Dedup = *src.Objects.Dedup
+		}
+		if src.Objects.Link != nil {
+			dst.Objects.Link = *src.Objects.Link
+		}
+		if src.Objects.DryRun != nil {
+			dst.Objects.DryRun = *src.Objects.DryRun
+		}
+	}
+
+	if src.Migrate != nil {
+		if src.Migrate.DryRun != nil {
+			dst.Migrate.DryRun = *src.Migrate.DryRun
+		}
+	}
+
+	if src.Init != nil {
+		if src.Init.User != nil {
+			dst.Init.User = *src.Init.User
+		}
+		if src.Init.Gitignore != nil {
+			dst.Init.Gitignore = *src.Init.Gitignore
+		}
+		if src.Init.Force != nil {
+			dst.Init.Force = *src.Init.Force
+		}
+	}
+
+	if src.Doctor != nil {
+		if src.Doctor.Fix != nil {
+			dst.Doctor.Fix = *src.Doctor.Fix
+		}
+	}
+
+	if src.Data != nil {
+		if src.Data.Paths != nil {
+			dst.Data.Paths = *src.Data.Paths
+		}
+		if src.Data.Mode != nil {
+			dst.Data.Mode = *src.Data.Mode
+		}
+	}
+
+	if src.Cost != nil {
+		if src.Cost.Currency != nil {
+			dst.Cost.Currency = *src.Cost.Currency
+		}
+		if src.Cost.DefaultRate != nil {
+			dst.Cost.DefaultRate = *src.Cost.DefaultRate
+		}
+		if src.Cost.HostRates != nil {
+			// Rates are merged per host as version probes are
+			if dst.Cost.HostRates == nil {
+				dst.Cost.HostRates = map[string]float64{}
+			}
+			for host, rate := range *src.Cost.HostRates {
+				dst.Cost.HostRates[host] = rate
+			}
+		}
+		if src.Cost.GPURate != nil {
+			dst.Cost.GPURate = *src.Cost.GPURate
+		}
+	}
+
+	if src.Projects != nil {
+		if src.Projects.Paths != nil {
+			dst.Projects.Paths = *src.Projects.Paths
+		}
+	}
+
+	if src.Tui != nil {
+		if src.Tui.Refresh != nil {
+			dst.Tui.Refresh = *src.Tui.Refresh
+		}
+	}
+
+	if src.Index != nil {
+		if src.Index.Enabled != nil {
+			dst.Index.Enabled = *src.Index.Enabled
+		}
+	}
+
+	if src.Search != nil {
+		if src.Search.Regex != nil {
+			dst.Search.Regex = *src.Search.Regex
+		}
+		if src.Search.Logs != nil {
+			dst.Search.Logs = *src.Search.Logs
+		}
+	}
+
+	if src.Summary != nil {
+		if src.Summary.All != nil {
+			dst.Summary.All = *src.Summary.All
+		}
+	}
+
+	if src.Logs != nil {
+		if src.Logs.Stderr != nil {
+			dst.Logs.Stderr = *src.Logs.Stderr
+		}
+		if src.Logs.Tail != nil {
+			dst.Logs.Tail = *src.Logs.Tail
+		}
+		if src.Logs.Follow != nil {
+			dst.Logs.Follow = *src.Logs.Follow
+		}
+	}
+
+	if src.Kill != nil {
+		if src.Kill.Signal != nil {
+			dst.Kill.Signal = *src.Kill.Signal
+		}
+		if src.Kill.Timeout != nil {
+			dst.Kill.Timeout = *src.Kill.Timeout
+		}
+	}
+
+	if src.Queue != nil {
+		if src.Queue.Workers != nil {
+			dst.Queue.Workers = *src.Queue.Workers
+		}
+	}
+
+	if src.TensorBoard != nil {
+		if src.TensorBoard.Command != nil {
+			dst.TensorBoard.Command = *src.TensorBoard.Command
+		}
+		if src.TensorBoard.DryRun != nil {
+			dst.TensorBoard.DryRun = *src.TensorBoard.DryRun
+		}
+	}
+
+	if src.Metrics != nil {
+		if src.Metrics.Format != nil {
+			dst.Metrics.Format = *src.Metrics.Format
+		}
+		if src.Metrics.SortBy != nil {
+			dst.Metrics.SortBy = *src.Metrics.SortBy
+		}
+		if src.Metrics.Reverse != nil {
+			dst.Metrics.Reverse = *src.Metrics.Reverse
+		}
+	}
+
+	if src.Artifacts != nil {
+		if src.Artifacts.Format != nil {
+			dst.Artifacts.Format = *src.Artifacts.Format
+		}
+		if src.Artifacts.Output != nil {
+			dst.Artifacts.Output = *src.Artifacts.Output
+		}
+	}
+
+	if src.Export != nil {
+		if src.Export.Format != nil {
+			dst.Export.Format = *src.Export.Format
+		}
+		if src.Export.Output != nil {
+			dst.Export.Output = *src.Export.Output
+		}
+		if src.Export.Experiment != nil {
+			dst.Export.Experiment = *src.Export.Experiment
+		}
+		if src.Export.Wandb != nil {
+			if src.Export.Wandb.Entity != nil {
+				dst.Export.Wandb.Entity = *src.Export.Wandb.Entity
+			}
+			if src.Export.Wandb.BaseURL != nil {
+				dst.Export.Wandb.BaseURL = *src.Export.Wandb.BaseURL
+			}
+		}
+	}
+
+	if src.Serve != nil {
+		if src.Serve.Addr != nil {
+			dst.Serve.Addr = *src.Serve.Addr
+		}
+		if src.Serve.APIOnly != nil {
+			dst.Serve.APIOnly = *src.Serve.APIOnly
+		}
+	}
+
+	if src.Notify != nil {
+		if src.Notify.Webhooks != nil {
+			dst.Notify.Webhooks = *src.Notify.Webhooks
+		}
+		if src.Notify.Timeout != nil {
+			dst.Notify.Timeout = *src.Notify.Timeout
+		}
+		if src.Notify.Retries != nil {
+			dst.Notify.Retries = *src.Notify.Retries
+		}
+		if src.Notify.On != nil {
+			dst.Notify.On = *src.Notify.On
+		}
+		if src.Notify.MinDuration != nil {
+			dst.Notify.MinDuration = *src.Notify.MinDuration
+		}
+		if src.Notify.Slack != nil {
+			if src.Notify.Slack.WebhookURL != nil {
+				dst.Notify.Slack.WebhookURL = *src.Notify.Slack.WebhookURL
+			}
+			if src.Notify.Slack.StderrLines != nil {
+				dst.Notify.Slack.StderrLines = *src.Notify.Slack.StderrLines
+			}
+		}
+	}
+
+	if src.Sweep != nil {
+		if src.Sweep.Params != nil {
+			dst.Sweep.Params = *src.Sweep.Params
+		}
+		if src.Sweep.Spec != nil {
+			dst.Sweep.Spec = *src.Sweep.Spec
+		}
+		if src.Sweep.DryRun != nil {
+			dst.Sweep.DryRun = *src.Sweep.DryRun
+		}
+	}
+
+	if src.SelfUpdate != nil {
+		if src.SelfUpdate.Channel != nil {
+			dst.SelfUpdate.Channel = *src.SelfUpdate.Channel
+		}
+		if src.SelfUpdate.Check != nil {
+			dst.SelfUpdate.Check = *src.SelfUpdate.Check
+		}
+		if src.SelfUpdate.Force != nil {
+			dst.SelfUpdate.Force = *src.SelfUpdate.Force
+		}
 	}
 }
 
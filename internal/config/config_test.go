@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// Every key of the default configuration must be known
+	path := filepath.Join(dir, "default.toml")
+	require.NoError(t, os.WriteFile(path, []byte(defaultConfig), 0644))
+	assert.NoError(t, CheckFile(path))
+
+	// The default settings must be valid
+	assert.Empty(t, validate(GetDefault(), dir, []string{path}))
+}
+
+func TestTemplate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config")) // No user config
+	path := filepath.Join(dir, ".moco.toml")
+
+	// A scaffolded configuration is valid as is
+	require.NoError(t, os.WriteFile(path, []byte(Template()), 0644))
+	assert.NoError(t, CheckFile(path))
+
+	// and with all the settings uncommented
+	uncommented := strings.ReplaceAll(Template(), "\n# ", "\n")
+	require.NoError(t, os.WriteFile(path, []byte(uncommented), 0644))
+	assert.NoError(t, CheckFile(path))
+	config, err := load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, GetDefault(), config)
+}
+
+func TestUserProjects(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", filepath.Join(dir, "config"))
+	userConfig, err := UserFile()
+	require.NoError(t, err)
+
+	// No user config yet
+	paths, err := UserProjects()
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	// Other settings survive updates of the registry
+	require.NoError(t, os.MkdirAll(filepath.Dir(userConfig), 0755))
+	require.NoError(t, os.WriteFile(userConfig, []byte("[run]\nsilent = true\n"), 0644))
+	require.NoError(t, SetUserProjects([]string{"/work/a", "/work/b"}))
+	paths, err = UserProjects()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/work/a", "/work/b"}, paths)
+
+	config, err := load(dir)
+	require.NoError(t, err)
+	assert.True(t, config.Run.Silent)
+	assert.Equal(t, []string{"/work/a", "/work/b"}, config.Projects.Paths)
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Problem is an invalid setting with the file that set it
+//
+// Warnings are settings that may become valid later, such as directories
+// that do not exist yet, and do not prevent commands from running.
+type Problem struct {
+	File    string
+	Key     string
+	Message string
+	Warning bool
+}
+
+func (p Problem) String() string {
+	if p.Key == "" {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.File, p.Key, p.Message)
+}
+
+// errorsOf returns the problems that are not warnings
+func errorsOf(problems []Problem) []Problem {
+	var errs []Problem
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	return errs
+}
+
+// ValidationError reports all the problems found in a configuration
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d problem(s)):", len(e.Problems))
+	for _, problem := range e.Problems {
+		b.WriteString("\n  " + problem.String())
+	}
+	b.WriteString("\nRun 'moco config validate' after fixing them")
+	return b.String()
+}
+
+// Validate checks the configuration files of the project in dir for syntax
+// errors, unknown keys, and invalid settings
+func Validate(dir string) []Problem {
+	var problems []Problem
+	for _, path := range filesAt(dir) {
+		if err := CheckFile(path); err != nil {
+			problems = append(problems, Problem{File: path, Message: err.Error()})
+		}
+	}
+	config, err := load(dir)
+	if err != nil {
+		return problems // Reported by CheckFile
+	}
+	return append(problems, validate(config, dir, filesAt(dir))...)
+}
+
+// MainValidate reports the problems in the configuration files of the
+// current project, failing if any of them is not a warning
+func MainValidate() error {
+	files := Files()
+	if len(files) == 0 {
+		fmt.Println("No configuration files found; using the defaults")
+		return nil
+	}
+	problems := Validate(".")
+	for _, problem := range problems {
+		if problem.Warning {
+			fmt.Printf("warning: %s\n", problem)
+		} else {
+			fmt.Printf("error: %s\n", problem)
+		}
+	}
+	if n := len(errorsOf(problems)); n > 0 {
+		return fmt.Errorf("found %d invalid setting(s) in %s", n, strings.Join(files, ", "))
+	}
+	fmt.Printf("Configuration is valid: %s\n", strings.Join(files, ", "))
+	return nil
+}
+
+// cutoffPattern matches ages like "30d", as taken by --older-than
+var cutoffPattern = regexp.MustCompile(`^\d+[dhm]$`)
+
+// validator collects problems, attributing each key to the last file
+// setting it
+type validator struct {
+	dir      string
+	sources  map[string]string
+	problems []Problem
+}
+
+// validate checks settings of a merged configuration loaded from files
+func validate(config Config, dir string, files []string) []Problem {
+	v := validator{dir: dir, sources: map[string]string{}}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		settings := map[string]any{}
+		if err := toml.Unmarshal(data, &settings); err != nil {
+			continue
+		}
+		v.addSources(path, "", settings)
+	}
+
+	v.nonEmpty("base_dir", config.BaseDir)
+	v.nonEmpty("summary_file", config.SummaryFile)
+
+	v.nonEmpty("run.stdout_file", config.Run.StdoutFile)
+	v.nonEmpty("run.stderr_file", config.Run.StderrFile)
+	v.size("run.max_log_size", config.Run.MaxLogSize)
+	v.nonNegative("run.log_rotations", config.Run.LogRotations)
+	v.duration("run.timeout", config.Run.Timeout, true)
+	v.duration("run.heartbeat_interval", config.Run.HeartbeatInterval, false)
+	v.duration("run.stale_after", config.Run.StaleAfter, false)
+	v.oneOf("run.dir_precision", config.Run.DirPrecision, "s", "ms", "us")
+	if tz := config.Run.DirTimezone; tz != "" && tz != "local" && tz != "Local" && tz != "UTC" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			v.report("run.dir_timezone", "unknown time zone %q (expected local, UTC, or a name like Asia/Tokyo)", tz)
+		}
+	}
+	v.dirs("run.extra_repos", config.Run.ExtraRepos)
+	if err := utils.ValidateArtifactPatterns(config.Run.Artifacts); err != nil {
+		v.report("run.artifacts", "%v", err)
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(config.Run.VersionProbes)) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			v.report("run.version_probes", "invalid pattern %q", pattern)
+		}
+	}
+
+	v.oneOf("list.format", config.List.Format, "table", "json", "yaml", "jsonl", "csv", "markdown", "plain")
+	v.oneOf("list.status", config.List.Status, "", "success", "failure", "running", "stale")
+	v.oneOf("list.group_by", config.List.GroupBy, "", "branch", "command", "day")
+	v.nonNegative("list.limit", config.List.Limit)
+
+	v.oneOf("status.level", config.Status.Level, "minimal", "normal", "full")
+	v.oneOf("status.format", config.Status.Format, "text", "markdown", "json", "yaml", "prometheus")
+	v.size("status.disk_budget", config.Status.DiskBudget)
+	v.nonNegative("status.trend_weeks", config.Status.TrendWeeks)
+	v.oneOf("status.command_key", config.Status.CommandKey, "first", "full")
+
+	v.oneOf("archive.format", config.Archive.Format, "tar.gz", "tar.zst", "tar.xz", "zip")
+	v.cutoff("archive.older_than", config.Archive.OlderThan)
+	v.oneOf("archive.status", config.Archive.Status, "", "all", "success", "failure", "interrupted")
+	v.cutoff("clean.older_than", config.Clean.OlderThan)
+	v.oneOf("clean.status", config.Clean.Status, "all", "success", "failure", "interrupted")
+	v.duration("gc.grace", config.GC.Grace, false)
+	v.oneOf("objects.link", config.Objects.Link, "hardlink", "symlink")
+	v.oneOf("data.mode", config.Data.Mode, "fast", "full")
+
+	v.duration("tui.refresh", config.Tui.Refresh, false)
+	v.nonNegative("logs.tail", config.Logs.Tail)
+	if _, err := utils.ParseSignal(config.Kill.Signal); err != nil {
+		v.report("kill.signal", "%v", err)
+	}
+	v.duration("kill.timeout", config.Kill.Timeout, false)
+	if config.Queue.Workers < 1 {
+		v.report("queue.workers", "must be at least 1, got %d", config.Queue.Workers)
+	}
+
+	v.oneOf("metrics.format", config.Metrics.Format, "table", "csv", "json")
+	v.oneOf("artifacts.format", config.Artifacts.Format, "table", "json")
+	v.oneOf("export.format", config.Export.Format, "mlflow", "wandb")
+
+	v.duration("notify.timeout", config.Notify.Timeout, false)
+	v.nonNegative("notify.retries", config.Notify.Retries)
+	for _, on := range config.Notify.On {
+		v.oneOf("notify.on", on, "success", "failure", "interrupted", "timeout")
+	}
+	v.duration("notify.min_duration", config.Notify.MinDuration, true)
+
+	v.oneOf("self_update.channel", config.SelfUpdate.Channel, "stable", "prerelease")
+	v.dirs("projects.paths", config.Projects.Paths)
+
+	return v.problems
+}
+
+// addSources records the file setting each key of nested settings
+func (v *validator) addSources(path, prefix string, settings map[string]any) {
+	for key, value := range settings {
+		v.sources[prefix+key] = path
+		if table, ok := value.(map[string]any); ok {
+			v.addSources(path, prefix+key+".", table)
+		}
+	}
+}
+
+// report adds a problem with a setting
+func (v *validator) report(key, format string, args ...any) {
+	v.add(Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// warn adds a warning about a setting
+func (v *validator) warn(key, format string, args ...any) {
+	v.add(Problem{Key: key, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *validator) add(problem Problem) {
+	file, ok := v.sources[problem.Key]
+	if !ok {
+		file = "(default)"
+	}
+	problem.File = file
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) nonEmpty(key, value string) {
+	if value == "" {
+		v.report(key, "must not be empty")
+	}
+}
+
+func (v *validator) nonNegative(key string, value int) {
+	if value < 0 {
+		v.report(key, "must not be negative, got %d", value)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		var values []string
+		for _, a := range allowed {
+			if a != "" {
+				values = append(values, a)
+			}
+		}
+		v.report(key, "invalid value %q (expected %s)", value, strings.Join(values, ", "))
+	}
+}
+
+// duration checks a duration like "30s" or "1h30m", which may be empty if
+// optional
+func (v *validator) duration(key, value string, optional bool) {
+	if value == "" && optional {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil || d < 0 {
+		v.report(key, "invalid duration %q (expected e.g. 30s, 10m, or 2h)", value)
+	}
+}
+
+// cutoff checks an optional age like "30d"
+func (v *validator) cutoff(key, value string) {
+	if value != "" && !cutoffPattern.MatchString(value) {
+		v.report(key, "invalid age %q (expected e.g. 30d, 12h, or 45m)", value)
+	}
+}
+
+// size checks an optional size like "100MB"
+func (v *validator) size(key, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := utils.ParseSize(value); err != nil {
+		v.report(key, "invalid size %q (expected e.g. 500MB or 2GiB)", value)
+	}
+}
+
+// dirs checks that directories exist, relative to the project if not absolute
+func (v *validator) dirs(key string, paths []string) {
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(v.dir, path)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			v.warn(key, "directory not found: %s", path)
+		}
+	}
+}
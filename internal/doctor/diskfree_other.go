@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package doctor
+
+import "errors"
+
+// freeSpace is not supported on this platform
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
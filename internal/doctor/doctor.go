@@ -0,0 +1,282 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// minFreeSpace is the free space below which the base directory is reported
+const minFreeSpace = 1 << 30 // 1 GiB
+
+// staleLockAge is the age after which a git lock file is reported, since
+// younger ones are likely held by running git commands
+const staleLockAge = 10 * time.Minute
+
+// Severity of a check result
+type Severity int
+
+const (
+	OK Severity = iota
+	Warning
+	Failure
+)
+
+// Result is the outcome of a single diagnostic check
+type Result struct {
+	Name     string
+	Severity Severity
+	Message  string
+	// Suggested action to fix the problem, if any
+	Fix string
+}
+
+// Main runs diagnostics of the environment and the run directories and
+// prints the results
+func Main() error {
+	cfg := config.Get()
+
+	var results []Result
+	results = append(results, checkGit()...)
+	results = append(results, checkConfig()...)
+	results = append(results, checkBaseDir(cfg.BaseDir)...)
+	results = append(results, checkPager(), checkEditor())
+	results = append(results, checkRuns(cfg, cfg.Doctor.Fix)...)
+	results = append(results, checkIndex(cfg, cfg.Doctor.Fix))
+
+	failures := 0
+	for _, result := range results {
+		printResult(result)
+		if result.Severity == Failure {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d problem(s) found", failures)
+	}
+	return nil
+}
+
+// checkGit checks that git is available and the current directory is a healthy repository
+func checkGit() []Result {
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return []Result{{
+			Name:     "git",
+			Severity: Failure,
+			Message:  "git is not found in PATH",
+			Fix:      "install git and make sure it is in your PATH",
+		}}
+	}
+	output, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return []Result{{
+			Name:     "git",
+			Severity: Failure,
+			Message:  fmt.Sprintf("failed to run %s: %v", path, err),
+			Fix:      "check your git installation",
+		}}
+	}
+	results := []Result{{Name: "git", Message: strings.TrimSpace(string(output))}}
+
+	repo, err := utils.GetRepoStatus()
+	if err != nil {
+		return append(results, Result{
+			Name:     "repository",
+			Severity: Failure,
+			Message:  err.Error(),
+			Fix:      "run moco from within a git repository with at least one commit",
+		})
+	}
+	result := Result{
+		Name:    "repository",
+		Message: fmt.Sprintf("on %s at %s", repo.Branch, repo.ShortHash),
+	}
+	if dirtyFiles := utils.DirtyFiles(repo.ChangedFiles, config.Get().Run.IgnoreDirtyPaths); len(dirtyFiles) > 0 {
+		result.Severity = Warning
+		result.Message += fmt.Sprintf(" with %d uncommitted change(s)", len(dirtyFiles))
+		result.Fix = "commit your changes before running experiments (or use --force)"
+	}
+	results = append(results, result)
+	if result, ok := checkGitLock(path); ok {
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkGitLock reports a stale index.lock of the repository, which is left
+// by a crashed git command and makes git commands run by moco fail
+func checkGitLock(git string) (Result, bool) {
+	output, err := exec.Command(git, "rev-parse", "--git-path", "index.lock").Output()
+	if err != nil {
+		return Result{}, false
+	}
+	lockPath := strings.TrimSpace(string(output))
+	info, err := os.Stat(lockPath)
+	if err != nil || time.Since(info.ModTime()) < staleLockAge {
+		return Result{}, false
+	}
+	return Result{
+		Name:     "repository",
+		Severity: Warning,
+		Message:  fmt.Sprintf("%s was left %s ago", lockPath, time.Since(info.ModTime()).Round(time.Minute)),
+		Fix:      fmt.Sprintf("remove it if no git command is running (rm %s)", lockPath),
+	}, true
+}
+
+// checkConfig checks each configuration file for syntax errors, unknown keys,
+// and invalid settings
+func checkConfig() []Result {
+	files := config.Files()
+	if len(files) == 0 {
+		return []Result{{Name: "config", Message: "no configuration files (using defaults)"}}
+	}
+
+	var results []Result
+	problems := config.Validate(".")
+	for _, problem := range problems {
+		result := Result{
+			Name:     "config",
+			Severity: Failure,
+			Message:  problem.String(),
+			Fix:      "fix or remove the offending entries (see `moco config --default` for valid keys)",
+		}
+		if problem.Warning {
+			result.Severity = Warning
+		}
+		results = append(results, result)
+	}
+	for _, file := range files {
+		if !slices.ContainsFunc(problems, func(p config.Problem) bool { return p.File == file }) {
+			results = append(results, Result{Name: "config", Message: file})
+		}
+	}
+	return results
+}
+
+// checkBaseDir checks that the base directory is writable and has enough free space
+func checkBaseDir(baseDir string) []Result {
+	if baseDir == "" {
+		return []Result{{
+			Name:     "base directory",
+			Severity: Failure,
+			Message:  "base_dir is not set",
+			Fix:      "set base_dir in your configuration or pass --base-dir",
+		}}
+	}
+
+	info, err := os.Stat(baseDir)
+	if os.IsNotExist(err) {
+		// The base directory is created on the first run, so check its parent instead
+		parent := filepath.Dir(filepath.Clean(baseDir))
+		results := []Result{{
+			Name:    "base directory",
+			Message: fmt.Sprintf("%s does not exist yet (will be created on the first run)", baseDir),
+		}}
+		return append(results, checkFreeSpace(parent))
+	}
+	if err != nil {
+		return []Result{{
+			Name:     "base directory",
+			Severity: Failure,
+			Message:  err.Error(),
+			Fix:      "check the permissions of the base directory and its parents",
+		}}
+	}
+	if !info.IsDir() {
+		return []Result{{
+			Name:     "base directory",
+			Severity: Failure,
+			Message:  fmt.Sprintf("%s is not a directory", baseDir),
+			Fix:      "remove the file or set base_dir to another path",
+		}}
+	}
+
+	// Try to create a file to check write permission
+	file, err := os.CreateTemp(baseDir, ".moco-doctor-*")
+	if err != nil {
+		return []Result{{
+			Name:     "base directory",
+			Severity: Failure,
+			Message:  fmt.Sprintf("%s is not writable: %v", baseDir, err),
+			Fix:      fmt.Sprintf("fix the permissions (e.g., chmod u+w %s)", baseDir),
+		}}
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	results := []Result{{Name: "base directory", Message: fmt.Sprintf("%s is writable", baseDir)}}
+	return append(results, checkFreeSpace(baseDir))
+}
+
+// checkFreeSpace checks the free space of the file system containing path
+func checkFreeSpace(path string) Result {
+	free, err := freeSpace(path)
+	if err != nil {
+		return Result{
+			Name:     "free space",
+			Severity: Warning,
+			Message:  fmt.Sprintf("failed to get free space: %v", err),
+		}
+	}
+	result := Result{
+		Name:    "free space",
+		Message: fmt.Sprintf("%s available", utils.FormatSize(int64(free))),
+	}
+	if free < minFreeSpace {
+		result.Severity = Warning
+		result.Fix = "free up disk space (e.g., with `moco archive --delete`)"
+	}
+	return result
+}
+
+// checkPager checks that the pager used by `moco show` is available
+func checkPager() Result {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	if _, err := exec.LookPath(pager); err != nil {
+		return Result{
+			Name:     "pager",
+			Severity: Warning,
+			Message:  fmt.Sprintf("%s is not found (summaries will be printed without paging)", pager),
+			Fix:      "install less or set PAGER to an available pager",
+		}
+	}
+	return Result{Name: "pager", Message: pager}
+}
+
+// checkEditor checks that the editor used by --prompt-message is available
+func checkEditor() Result {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	if _, err := exec.LookPath(editor); err != nil {
+		return Result{
+			Name:     "editor",
+			Severity: Warning,
+			Message:  fmt.Sprintf("%s is not found (--prompt-message will not work)", editor),
+			Fix:      "set EDITOR to an available editor",
+		}
+	}
+	return Result{Name: "editor", Message: editor}
+}
+
+// printResult prints a check result with a fix suggestion if any
+func printResult(result Result) {
+	mark := map[Severity]string{OK: "[ok]", Warning: "[warn]", Failure: "[fail]"}[result.Severity]
+	fmt.Printf("%-6s %s: %s\n", mark, result.Name, result.Message)
+	if result.Fix != "" {
+		fmt.Printf("       fix: %s\n", result.Fix)
+	}
+}
@@ -0,0 +1,134 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRuns creates finished runs in a new base directory, one per minute
+func newTestRuns(t *testing.T, n int) (config.Config, []string) {
+	cfg := config.GetPointer()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+	*cfg = config.GetDefault()
+	cfg.BaseDir = t.TempDir()
+
+	var runDirs []string
+	startTime := time.Date(2025, 3, 24, 0, 0, 0, 0, time.Local)
+	for i := range n {
+		start := startTime.Add(time.Duration(i) * time.Minute)
+		runDir := filepath.Join(cfg.BaseDir, start.Format("2006-01-02T15:04:05.000")+"_main_1234567")
+		require.NoError(t, os.Mkdir(runDir, 0755))
+		summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+		meta := utils.RunMetadata{StartTime: start, Repo: utils.RepoStatus{Branch: "main", FullHash: "1234567890"}, Command: []string{"train"}}
+		require.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		result := utils.RunResult{EndTime: start.Add(time.Second), Success: true}
+		require.NoError(t, utils.WriteSummaryFileEnd(summaryPath, start, result))
+		for _, file := range []string{cfg.Run.StdoutFile, cfg.Run.StderrFile} {
+			require.NoError(t, os.WriteFile(filepath.Join(runDir, file), nil, 0644))
+		}
+		runDirs = append(runDirs, runDir)
+	}
+	return *cfg, runDirs
+}
+
+func TestCheckIndex(t *testing.T) {
+	cfg, runDirs := newTestRuns(t, 3)
+
+	// Runs without an index
+	result := checkIndex(cfg, false)
+	assert.Equal(t, Warning, result.Severity)
+	assert.Contains(t, result.Message, "not been built")
+
+	index.Update(cfg.BaseDir, cfg.SummaryFile)
+	result = checkIndex(cfg, false)
+	assert.Equal(t, OK, result.Severity, result.Message)
+
+	// A removed run, a new run, and a changed summary
+	require.NoError(t, os.RemoveAll(runDirs[0]))
+	require.NoError(t, os.Rename(runDirs[1], runDirs[1]+"_renamed"))
+	f, err := os.OpenFile(filepath.Join(runDirs[2], cfg.SummaryFile), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	result = checkIndex(cfg, false)
+	assert.Equal(t, Warning, result.Severity)
+	assert.Contains(t, result.Message, "1 run(s) not indexed")
+	assert.Contains(t, result.Message, "1 outdated entry(ies)")
+	assert.Contains(t, result.Message, "2 entry(ies) of removed runs")
+	assert.NotEmpty(t, result.Fix)
+
+	result = checkIndex(cfg, true)
+	assert.Contains(t, result.Message, "(updated)")
+	result = checkIndex(cfg, false)
+	assert.Equal(t, OK, result.Severity, result.Message)
+
+	// A corrupt index
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.BaseDir, index.FileName), []byte("{"), 0644))
+	result = checkIndex(cfg, false)
+	assert.Equal(t, Warning, result.Severity)
+	assert.Contains(t, result.Message, "corrupt index")
+	checkIndex(cfg, true)
+	result = checkIndex(cfg, false)
+	assert.Equal(t, OK, result.Severity, result.Message)
+
+	cfg.Index.Enabled = false
+	assert.Equal(t, "disabled", checkIndex(cfg, false).Message)
+}
+
+func TestCheckLeftoverLocks(t *testing.T) {
+	cfg, runDirs := newTestRuns(t, 1)
+	staleAfter := 5 * time.Minute
+
+	assert.Empty(t, checkRun(cfg, runDirs[0], staleAfter, false))
+
+	require.NoError(t, utils.WritePIDFile(runDirs[0], os.Getpid()))
+	require.NoError(t, utils.TouchHeartbeat(runDirs[0]))
+	results := checkRun(cfg, runDirs[0], staleAfter, false)
+	require.Len(t, results, 1)
+	assert.Equal(t, Warning, results[0].Severity)
+	assert.Contains(t, results[0].Message, "left over (run.pid, heartbeat)")
+	assert.FileExists(t, filepath.Join(runDirs[0], utils.PIDFile))
+
+	results = checkRun(cfg, runDirs[0], staleAfter, true)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "removed")
+	assert.NoFileExists(t, filepath.Join(runDirs[0], utils.PIDFile))
+	assert.NoFileExists(t, filepath.Join(runDirs[0], utils.HeartbeatFile))
+}
+
+func TestCheckGitLock(t *testing.T) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not available")
+	}
+	repo := t.TempDir()
+	require.NoError(t, exec.Command(git, "init", "-q", repo).Run())
+	t.Chdir(repo)
+
+	_, found := checkGitLock(git)
+	assert.False(t, found)
+
+	lockPath := filepath.Join(repo, ".git", "index.lock")
+	require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+	_, found = checkGitLock(git)
+	assert.False(t, found, "a fresh lock may be held by a running git command")
+
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(lockPath, old, old))
+	result, found := checkGitLock(git)
+	assert.True(t, found)
+	assert.Equal(t, Warning, result.Severity)
+	assert.Contains(t, result.Fix, "index.lock")
+}
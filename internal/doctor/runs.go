@@ -0,0 +1,297 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// lostExitCode is recorded for runs whose supervising moco process is gone,
+// following the convention for interrupted commands
+const lostExitCode = 130
+
+// checkRuns checks the consistency of each run directory in the base
+// directory, closing summaries of abandoned runs if fix is set
+func checkRuns(cfg config.Config, fix bool) []Result {
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return []Result{{Name: "runs", Severity: Failure, Message: err.Error()}}
+	}
+	staleAfter, err := time.ParseDuration(cfg.Run.StaleAfter)
+	if err != nil {
+		return []Result{{
+			Name:     "runs",
+			Severity: Failure,
+			Message:  fmt.Sprintf("invalid stale threshold: %s", cfg.Run.StaleAfter),
+			Fix:      "set run.stale_after to a duration (e.g., 5m)",
+		}}
+	}
+
+	var results []Result
+	for _, runDir := range runDirs {
+		results = append(results, checkRun(cfg, runDir, staleAfter, fix)...)
+	}
+	if len(results) == 0 {
+		results = append(results, Result{Name: "runs", Message: fmt.Sprintf("%d run(s) are consistent", len(runDirs))})
+	}
+	return results
+}
+
+// checkRun checks a run directory and returns its problems
+func checkRun(cfg config.Config, runDir string, staleAfter time.Duration, fix bool) []Result {
+	name := "run " + filepath.Base(runDir)
+	summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+	if _, err := os.Stat(summaryPath); os.IsNotExist(err) {
+		return []Result{{
+			Name:     name,
+			Severity: Failure,
+			Message:  "summary file is missing",
+			Fix:      fmt.Sprintf("remove the directory if it is a leftover (moco rm %s)", runDir),
+		}}
+	}
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return []Result{{
+			Name:     name,
+			Severity: Failure,
+			Message:  fmt.Sprintf("summary file cannot be parsed: %v", err),
+			Fix:      "run `moco migrate` if it was written by an older version, or fix it by hand",
+		}}
+	}
+
+	var results []Result
+	if message := checkRunDirName(runDir, runInfo); message != "" {
+		results = append(results, Result{
+			Name:     name,
+			Severity: Warning,
+			Message:  message,
+			Fix:      "rename the directory back if it was renamed by hand",
+		})
+	}
+	for _, file := range []string{cfg.Run.StdoutFile, cfg.Run.StderrFile} {
+		if _, err := os.Stat(filepath.Join(runDir, file)); os.IsNotExist(err) {
+			results = append(results, Result{
+				Name:     name,
+				Severity: Warning,
+				Message:  fmt.Sprintf("%s is missing", file),
+			})
+		}
+	}
+	if runInfo.IsRunning {
+		if result, ok := checkAbandoned(name, summaryPath, runInfo, staleAfter, fix); ok {
+			results = append(results, result)
+		}
+	} else if result, ok := checkLeftoverLocks(name, runDir, fix); ok {
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkLeftoverLocks reports the PID and heartbeat files of a finished run,
+// which make it look supervised (e.g., to moco kill), and removes them if fix
+// is set
+func checkLeftoverLocks(name, runDir string, fix bool) (Result, bool) {
+	var leftovers []string
+	for _, file := range []string{utils.PIDFile, utils.HeartbeatFile} {
+		if _, err := os.Stat(filepath.Join(runDir, file)); err == nil {
+			leftovers = append(leftovers, file)
+		}
+	}
+	if len(leftovers) == 0 {
+		return Result{}, false
+	}
+
+	result := Result{
+		Name:     name,
+		Severity: Warning,
+		Message:  fmt.Sprintf("run is finished, but files of its running state are left over (%s)", strings.Join(leftovers, ", ")),
+		Fix:      "run `moco doctor --fix` to remove them",
+	}
+	if !fix {
+		return result, true
+	}
+	if err := utils.RemovePIDFile(runDir); err != nil {
+		result.Message += fmt.Sprintf(" (failed to fix: %v)", err)
+		return result, true
+	}
+	if err := utils.RemoveHeartbeat(runDir); err != nil {
+		result.Message += fmt.Sprintf(" (failed to fix: %v)", err)
+		return result, true
+	}
+	return Result{Name: name, Severity: Warning, Message: fmt.Sprintf("leftover files of the running state removed (%s)", strings.Join(leftovers, ", "))}, true
+}
+
+// checkIndex checks that the index agrees with the run directories and
+// updates it if fix is set
+func checkIndex(cfg config.Config, fix bool) Result {
+	if !cfg.Index.Enabled {
+		return Result{Name: "index", Message: "disabled"}
+	}
+	problem, err := indexProblem(cfg.BaseDir, cfg.SummaryFile)
+	if err != nil {
+		return Result{Name: "index", Severity: Failure, Message: err.Error()}
+	}
+	if problem == "" {
+		return Result{Name: "index", Message: "consistent with the run directories"}
+	}
+	if fix {
+		index.Update(cfg.BaseDir, cfg.SummaryFile)
+		return Result{Name: "index", Severity: Warning, Message: problem + " (updated)"}
+	}
+	return Result{
+		Name:     "index",
+		Severity: Warning,
+		Message:  problem,
+		Fix:      "run `moco doctor --fix` or `moco list` to update it",
+	}
+}
+
+// indexProblem describes how the index of a base directory disagrees with
+// its run directories, or returns an empty string if it does not
+func indexProblem(baseDir, summaryFile string) (string, error) {
+	runDirs, err := utils.FindRunDirs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	idx, err := index.Load(baseDir)
+	if os.IsNotExist(err) {
+		if len(runDirs) == 0 {
+			return "", nil
+		}
+		return "index has not been built", nil
+	} else if err != nil {
+		return err.Error(), nil
+	}
+
+	missing, outdated := 0, 0
+	seen := map[string]bool{}
+	for _, runDir := range runDirs {
+		key := filepath.Base(runDir)
+		seen[key] = true
+		info, err := os.Stat(filepath.Join(runDir, summaryFile))
+		if err != nil {
+			// Missing summaries are reported by checkRun
+			continue
+		}
+		if entry, found := idx.Entries[key]; !found {
+			missing++
+		} else if entry.Outdated(info) {
+			outdated++
+		}
+	}
+	removed := 0
+	for key := range idx.Entries {
+		if !seen[key] {
+			removed++
+		}
+	}
+
+	var problems []string
+	if missing > 0 {
+		problems = append(problems, fmt.Sprintf("%d run(s) not indexed", missing))
+	}
+	if outdated > 0 {
+		problems = append(problems, fmt.Sprintf("%d outdated entry(ies)", outdated))
+	}
+	if removed > 0 {
+		problems = append(problems, fmt.Sprintf("%d entry(ies) of removed runs", removed))
+	}
+	if len(problems) == 0 {
+		return "", nil
+	}
+	return "index is out of date: " + strings.Join(problems, ", "), nil
+}
+
+// checkRunDirName checks that the name of a run directory agrees with the
+// start time, branch, and commit in its summary
+func checkRunDirName(runDir string, runInfo utils.RunInfo) string {
+	matches := utils.RunDirPattern.FindStringSubmatch(filepath.Base(runDir))
+	dirTime, err := utils.ParseRunDirTime(runDir)
+	if matches == nil || err != nil {
+		return "directory name cannot be parsed"
+	}
+	var mismatches []string
+	if !dirTime.Truncate(time.Second).Equal(runInfo.StartTime.Truncate(time.Second)) {
+		mismatches = append(mismatches, fmt.Sprintf("start time %s", runInfo.StartTime.Format(time.RFC3339)))
+	}
+	if matches[2] != utils.SanitizeBranchName(runInfo.Branch) {
+		mismatches = append(mismatches, fmt.Sprintf("branch %s", runInfo.Branch))
+	}
+	if !strings.HasPrefix(runInfo.CommitHash, matches[3]) {
+		mismatches = append(mismatches, fmt.Sprintf("commit %s", runInfo.CommitHash))
+	}
+	if len(mismatches) == 0 {
+		return ""
+	}
+	return "directory name does not match the summary (" + strings.Join(mismatches, ", ") + ")"
+}
+
+// checkAbandoned reports a running run whose supervising moco process is
+// gone, i.e., its command is dead on this host or its heartbeat is stale,
+// and closes its summary if fix is set
+func checkAbandoned(name, summaryPath string, runInfo utils.RunInfo, staleAfter time.Duration, fix bool) (Result, bool) {
+	runDir := filepath.Dir(summaryPath)
+	hostname, _ := os.Hostname()
+	endTime := time.Now()
+	abandoned := false
+	if pid, err := utils.ReadPIDFile(runDir); err == nil && (runInfo.Hostname == "" || runInfo.Hostname == hostname) {
+		abandoned = !utils.ProcessAlive(pid)
+	}
+	if info, err := os.Stat(filepath.Join(runDir, utils.HeartbeatFile)); err == nil {
+		// The last heartbeat is the best estimate of when the run ended
+		if staleAfter > 0 && time.Since(info.ModTime()) > staleAfter {
+			abandoned = true
+		}
+		if abandoned {
+			endTime = info.ModTime()
+		}
+	}
+	if !abandoned {
+		return Result{}, false
+	}
+
+	result := Result{
+		Name:     name,
+		Severity: Failure,
+		Message:  "run is recorded as running, but its moco process is gone",
+		Fix:      "run `moco doctor --fix` to record it as interrupted",
+	}
+	if !fix {
+		return result, true
+	}
+	if err := closeSummary(summaryPath, runInfo.StartTime, endTime); err != nil {
+		result.Message += fmt.Sprintf(" (failed to fix: %v)", err)
+		return result, true
+	}
+	return Result{Name: name, Severity: Warning, Message: "abandoned run recorded as interrupted"}, true
+}
+
+// closeSummary writes the results of an abandoned run as interrupted and
+// removes the files of the running state
+func closeSummary(summaryPath string, startTime, endTime time.Time) error {
+	cfg := config.Get()
+	result := utils.RunResult{
+		EndTime:     endTime,
+		ExitCode:    lostExitCode,
+		ExitReason:  "moco process lost",
+		Interrupted: true,
+		Currency:    cfg.Cost.Currency,
+	}
+	if err := utils.WriteSummaryFileEnd(summaryPath, startTime, result); err != nil {
+		return err
+	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		return err
+	}
+	runDir := filepath.Dir(summaryPath)
+	if err := utils.RemovePIDFile(runDir); err != nil {
+		return err
+	}
+	return utils.RemoveHeartbeat(runDir)
+}
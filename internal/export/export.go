@@ -0,0 +1,49 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main exports runs selected by the list filters in the configured format
+func Main() error {
+	cfg := config.Get()
+	cfg.List.Archived = false
+	cfg.List.AllProjects = false
+
+	runs, err := list.Find(cfg)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		log.Info("No runs match the specified criteria")
+		return nil
+	}
+
+	switch cfg.Export.Format {
+	case "mlflow":
+		return exportMLflow(runs, cfg)
+	case "wandb":
+		return exportWandb(runs, cfg)
+	default:
+		return fmt.Errorf("invalid export format: %s", cfg.Export.Format)
+	}
+}
+
+// runMetrics returns the metrics that the command of a run wrote, which are
+// read from its metrics file unless recorded in its summary (e.g., while the
+// run is running)
+func runMetrics(run utils.RunInfo) map[string]float64 {
+	if len(run.Metrics) > 0 {
+		return run.Metrics
+	}
+	metrics, err := utils.ReadMetrics(run.Directory)
+	if err != nil {
+		log.Warnf("Failed to read metrics of %s: %v", run.Directory, err)
+	}
+	return metrics
+}
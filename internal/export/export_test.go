@@ -0,0 +1,102 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRun returns a finished run whose command wrote metrics
+func newTestRun(t *testing.T, metrics string) utils.RunInfo {
+	runDir := filepath.Join(t.TempDir(), "2025-03-24T00:00:00.000_main_1234567")
+	require.NoError(t, os.Mkdir(runDir, 0755))
+	if metrics != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(runDir, utils.MetricsFile), []byte(metrics), 0644))
+	}
+	start := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	return utils.RunInfo{
+		Directory: runDir,
+		Command:   "python train.py",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		Success:   true,
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	run := newTestRun(t, `{"loss": 0.25, "eval": {"accuracy": 0.9}, "note": "text"}`)
+	assert.Equal(t, map[string]float64{"loss": 0.25, "eval.accuracy": 0.9}, runMetrics(run))
+
+	// Metrics recorded in the summary are used as they are
+	run.Metrics = map[string]float64{"loss": 0.5}
+	assert.Equal(t, map[string]float64{"loss": 0.5}, runMetrics(run))
+
+	assert.Empty(t, runMetrics(newTestRun(t, "")))
+}
+
+func TestWriteMLflowRunMetrics(t *testing.T) {
+	run := newTestRun(t, `{"loss": 0.25, "eval": {"accuracy": 0.9}, "exit_code": 3, "../escape": 1}`)
+	root := t.TempDir()
+	require.NoError(t, writeMLflowRun(root, "1", run, config.GetDefault()))
+
+	runDirs, err := filepath.Glob(filepath.Join(root, "1", "*"))
+	require.NoError(t, err)
+	require.Len(t, runDirs, 1)
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(runDirs[0], "metrics", name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	timestamp := "1742774460000"
+	assert.Equal(t, timestamp+" 0.25 0\n", read("loss"))
+	assert.Equal(t, timestamp+" 0.9 0\n", read("eval.accuracy"))
+	assert.Equal(t, timestamp+" 60 0\n", read("duration_seconds"))
+	assert.Equal(t, timestamp+" 3 0\n", read("exit_code"))
+	assert.NoFileExists(t, filepath.Join(runDirs[0], "escape"))
+}
+
+func TestExportWandbRunMetrics(t *testing.T) {
+	run := newTestRun(t, `{"loss": 0.25, "eval": {"accuracy": 0.9}}`)
+	var summary map[string]float64
+	var uploaded []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			uploaded = append(uploaded, r.URL.Path)
+			return
+		}
+		var request struct {
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if metrics, ok := request.Variables["summaryMetrics"].(string); ok {
+			require.NoError(t, json.Unmarshal([]byte(metrics), &summary))
+			io.WriteString(w, `{"data": {"upsertBucket": {"bucket": {"name": "run", "project": {"name": "proj", "entity": {"name": "me"}}}}}}`)
+			return
+		}
+		files := []map[string]string{}
+		for _, file := range request.Variables["files"].([]any) {
+			files = append(files, map[string]string{"name": file.(string), "uploadUrl": server.URL + "/upload/" + file.(string)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"createRunFiles": map[string]any{"files": files}}})
+	}))
+	defer server.Close()
+
+	client := &wandbClient{baseURL: server.URL, apiKey: "key", http: server.Client()}
+	_, err := client.exportRun(run, "", "proj", config.GetDefault())
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, summary["loss"])
+	assert.Equal(t, 0.9, summary["eval.accuracy"])
+	assert.Equal(t, 60.0, summary["duration_seconds"])
+	assert.Equal(t, []string{"/upload/" + utils.MetricsFile}, uploaded)
+}
@@ -0,0 +1,313 @@
+package export
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Run statuses of MLflow
+const (
+	mlflowRunning  = 1
+	mlflowFinished = 3
+	mlflowFailed   = 4
+	mlflowKilled   = 5
+)
+
+// mlflowSkipFiles are files of run directories not exported as artifacts
+var mlflowSkipFiles = []string{utils.PIDFile, utils.HeartbeatFile}
+
+// exportMLflow writes runs to an MLflow file store (the mlruns directory),
+// replacing runs exported before
+func exportMLflow(runs []utils.RunInfo, cfg config.Config) error {
+	root, err := filepath.Abs(cfg.Export.Output)
+	if err != nil {
+		return err
+	}
+	name := cfg.Export.Experiment
+	if name == "" {
+		name, err = defaultExperimentName()
+		if err != nil {
+			return err
+		}
+	}
+	experimentID, err := mlflowExperiment(root, name)
+	if err != nil {
+		return fmt.Errorf("failed to create experiment: %w", err)
+	}
+
+	for _, run := range runs {
+		if err := writeMLflowRun(root, experimentID, run, cfg); err != nil {
+			return fmt.Errorf("failed to export %s: %w", run.Directory, err)
+		}
+	}
+	log.Infof("Exported %d run(s) to experiment %q in %s", len(runs), name, root)
+	return nil
+}
+
+// defaultExperimentName returns the name of the top directory of the repository
+func defaultExperimentName() (string, error) {
+	top, err := utils.RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(top), nil
+}
+
+// mlflowExperiment returns the ID of the experiment with a name in the file
+// store, creating it if it does not exist
+func mlflowExperiment(root, name string) (string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	nextID := 1
+	for _, entry := range entries {
+		id, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		nextID = max(nextID, id+1)
+		meta, err := os.ReadFile(filepath.Join(root, entry.Name(), "meta.yaml"))
+		if err != nil {
+			continue
+		}
+		if slices.Contains(strings.Split(string(meta), "\n"), "name: "+yamlString(name)) {
+			return entry.Name(), nil
+		}
+	}
+
+	id := strconv.Itoa(nextID)
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	now := time.Now().UnixMilli()
+	meta := yamlFields(
+		"artifact_location", yamlString("file://"+dir),
+		"creation_time", strconv.FormatInt(now, 10),
+		"experiment_id", yamlString(id),
+		"last_update_time", strconv.FormatInt(now, 10),
+		"lifecycle_stage", "active",
+		"name", yamlString(name),
+	)
+	return id, os.WriteFile(filepath.Join(dir, "meta.yaml"), []byte(meta), 0644)
+}
+
+// writeMLflowRun writes a run with its parameters, metrics, tags, and
+// artifacts; the ID is derived from the run directory so that exporting a
+// run again replaces it
+func writeMLflowRun(root, experimentID string, run utils.RunInfo, cfg config.Config) error {
+	name := filepath.Base(run.Directory)
+	hash := md5.Sum([]byte(name))
+	runID := hex.EncodeToString(hash[:])
+	dir := filepath.Join(root, experimentID, runID)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	status := mlflowFinished
+	switch {
+	case run.IsRunning:
+		status = mlflowRunning
+	case run.Interrupted || run.TimedOut:
+		status = mlflowKilled
+	case !run.Success:
+		status = mlflowFailed
+	}
+	endTime := ""
+	if !run.IsRunning {
+		endTime = strconv.FormatInt(run.EndTime.UnixMilli(), 10)
+	}
+	userName := ""
+	if u, err := user.Current(); err == nil {
+		userName = u.Username
+	}
+	meta := yamlFields(
+		"artifact_uri", yamlString("file://"+filepath.Join(dir, "artifacts")),
+		"end_time", endTime,
+		"entry_point_name", "''",
+		"experiment_id", yamlString(experimentID),
+		"lifecycle_stage", "active",
+		"run_id", runID,
+		"run_name", yamlString(name),
+		"run_uuid", runID,
+		"source_name", "''",
+		"source_type", "4",
+		"source_version", yamlString(run.CommitHash),
+		"start_time", strconv.FormatInt(run.StartTime.UnixMilli(), 10),
+		"status", strconv.Itoa(status),
+		"tags", "[]",
+		"user_id", yamlString(userName),
+	)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "meta.yaml"), []byte(meta), 0644); err != nil {
+		return err
+	}
+
+	// Parameters are those of sweeps and recorded environment variables
+	params := map[string]string{}
+	for key, value := range run.SweepParams {
+		params[key] = value
+	}
+	for key, value := range run.EnvVars {
+		params["env."+key] = value
+	}
+	if err := writeMLflowValues(filepath.Join(dir, "params"), params); err != nil {
+		return err
+	}
+
+	// Metrics are recorded once, at the end of the run or when exported if it
+	// is running; those written by the command take precedence over those of
+	// moco with the same names
+	metrics := map[string]float64{}
+	timestamp := time.Now().UnixMilli()
+	if !run.IsRunning {
+		timestamp = run.EndTime.UnixMilli()
+		metrics["exit_code"] = float64(run.ExitStatus)
+		metrics["duration_seconds"] = run.EndTime.Sub(run.StartTime).Seconds()
+		if run.Resources != nil {
+			metrics["cpu_seconds"] = run.CPUSeconds()
+			metrics["max_memory_bytes"] = float64(run.MaxMemory())
+		}
+		if run.GPUHours > 0 {
+			metrics["gpu_hours"] = run.GPUHours
+		}
+		if run.Cost > 0 {
+			metrics["cost"] = run.Cost
+		}
+	}
+	maps.Copy(metrics, runMetrics(run))
+	lines := map[string]string{}
+	for key, value := range metrics {
+		lines[key] = fmt.Sprintf("%d %v 0\n", timestamp, value)
+	}
+	if err := writeMLflowValues(filepath.Join(dir, "metrics"), lines); err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		"mlflow.runName":           name,
+		"mlflow.user":              userName,
+		"mlflow.source.git.commit": run.CommitHash,
+		"mlflow.source.git.branch": run.Branch,
+		"moco.directory":           run.Directory,
+		"moco.command":             run.Command,
+		"moco.status":              utils.StatusString(run),
+	}
+	if run.Message != "" {
+		tags["mlflow.note.content"] = run.Message
+	}
+	if run.Hostname != "" {
+		tags["moco.hostname"] = run.Hostname
+	}
+	if len(run.Issues) > 0 {
+		tags["moco.issues"] = strings.Join(run.Issues, " ")
+	}
+	if run.Sweep != "" {
+		tags["moco.sweep"] = run.Sweep
+	}
+	for _, tag := range run.Tags {
+		tags[tag] = "true"
+	}
+	if err := writeMLflowValues(filepath.Join(dir, "tags"), tags); err != nil {
+		return err
+	}
+
+	return copyArtifacts(run.Directory, filepath.Join(dir, "artifacts"))
+}
+
+// writeMLflowValues writes each value to a file named by its key in dir
+func writeMLflowValues(dir string, values map[string]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for key, value := range values {
+		// Keys may contain slashes, which MLflow stores as subdirectories,
+		// but they must stay within the directory
+		if !filepath.IsLocal(filepath.FromSlash(key)) {
+			log.Warnf("Skipping invalid MLflow key: %s", key)
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyArtifacts copies the files of a run directory into the artifact directory
+func copyArtifacts(runDir, artifactDir string) error {
+	return filepath.WalkDir(runDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(runDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(artifactDir, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() || slices.Contains(mlflowSkipFiles, rel) {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a regular file
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// yamlFields formats pairs of keys and YAML values as a mapping
+func yamlFields(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			fmt.Fprintf(&b, "%s:\n", pairs[i])
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", pairs[i], pairs[i+1])
+		}
+	}
+	return b.String()
+}
+
+// yamlString quotes a string as a YAML scalar
+func yamlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,280 @@
+package export
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// wandbTimeout is the timeout of each request to W&B
+const wandbTimeout = 30 * time.Second
+
+// upsertRunQuery creates or updates a run
+const upsertRunQuery = `mutation UpsertBucket($name: String, $project: String, $entity: String, $displayName: String, $notes: String, $commit: String, $config: JSONString, $host: String, $program: String, $state: String, $tags: [String!], $summaryMetrics: JSONString) {
+  upsertBucket(input: {name: $name, modelName: $project, entityName: $entity, displayName: $displayName, notes: $notes, commit: $commit, config: $config, host: $host, jobProgram: $program, state: $state, tags: $tags, summaryMetrics: $summaryMetrics}) {
+    bucket { name project { name entity { name } } }
+  }
+}`
+
+// createRunFilesQuery returns URLs to upload files of a run to
+const createRunFilesQuery = `mutation CreateRunFiles($entity: String!, $project: String!, $run: String!, $files: [String!]!) {
+  createRunFiles(input: {entityName: $entity, projectName: $project, runName: $run, files: $files}) {
+    files { name uploadUrl }
+  }
+}`
+
+// wandbClient sends requests to the W&B API
+type wandbClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// exportWandb creates or updates W&B runs with the metadata and log files of
+// runs; the ID of a W&B run is derived from the run directory so that
+// exporting a run again updates it
+func exportWandb(runs []utils.RunInfo, cfg config.Config) error {
+	apiKey := os.Getenv("WANDB_API_KEY")
+	if apiKey == "" {
+		return errors.New("WANDB_API_KEY is not set")
+	}
+	project := cfg.Export.Experiment
+	if project == "" {
+		var err error
+		project, err = defaultExperimentName()
+		if err != nil {
+			return err
+		}
+	}
+	client := &wandbClient{
+		baseURL: strings.TrimRight(cfg.Export.Wandb.BaseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: wandbTimeout},
+	}
+
+	for _, run := range runs {
+		url, err := client.exportRun(run, cfg.Export.Wandb.Entity, project, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", run.Directory, err)
+		}
+		log.Infof("Exported %s to %s", run.Directory, url)
+	}
+	return nil
+}
+
+// exportRun upserts a W&B run and uploads its files, returning its URL
+func (c *wandbClient) exportRun(run utils.RunInfo, entity, project string, cfg config.Config) (string, error) {
+	name := filepath.Base(run.Directory)
+	hash := md5.Sum([]byte(name))
+	runID := hex.EncodeToString(hash[:])[:8]
+
+	state := "finished"
+	switch {
+	case run.IsRunning:
+		state = "running"
+	case run.Interrupted || run.TimedOut:
+		state = "crashed"
+	case !run.Success:
+		state = "failed"
+	}
+
+	// Values of the config are wrapped as W&B does
+	values := map[string]any{
+		"moco_directory": run.Directory,
+		"moco_command":   run.Command,
+		"moco_branch":    run.Branch,
+		"moco_status":    utils.StatusString(run),
+	}
+	if run.Sweep != "" {
+		values["moco_sweep"] = run.Sweep
+	}
+	for key, value := range run.SweepParams {
+		values[key] = value
+	}
+	for key, value := range run.EnvVars {
+		values["env."+key] = value
+	}
+	runConfig := map[string]any{}
+	for key, value := range values {
+		runConfig[key] = map[string]any{"value": value, "desc": nil}
+	}
+	configJSON, err := json.Marshal(runConfig)
+	if err != nil {
+		return "", err
+	}
+
+	summary := map[string]any{"exit_code": run.ExitStatus}
+	if !run.IsRunning {
+		summary["duration_seconds"] = run.EndTime.Sub(run.StartTime).Seconds()
+	}
+	if run.Resources != nil {
+		summary["cpu_seconds"] = run.CPUSeconds()
+		summary["max_memory_bytes"] = run.MaxMemory()
+	}
+	if run.GPUHours > 0 {
+		summary["gpu_hours"] = run.GPUHours
+	}
+	if run.Cost > 0 {
+		summary["cost"] = run.Cost
+	}
+	// Metrics written by the command take precedence over those of moco
+	for key, value := range runMetrics(run) {
+		summary[key] = value
+	}
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return "", err
+	}
+
+	tags := run.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var upserted struct {
+		UpsertBucket struct {
+			Bucket struct {
+				Name    string `json:"name"`
+				Project struct {
+					Name   string `json:"name"`
+					Entity struct {
+						Name string `json:"name"`
+					} `json:"entity"`
+				} `json:"project"`
+			} `json:"bucket"`
+		} `json:"upsertBucket"`
+	}
+	err = c.query(upsertRunQuery, map[string]any{
+		"name":           runID,
+		"project":        project,
+		"entity":         nilIfEmpty(entity),
+		"displayName":    name,
+		"notes":          run.Message,
+		"commit":         run.CommitHash,
+		"config":         string(configJSON),
+		"host":           run.Hostname,
+		"program":        run.Command,
+		"state":          state,
+		"tags":           tags,
+		"summaryMetrics": string(summaryJSON),
+	}, &upserted)
+	if err != nil {
+		return "", fmt.Errorf("failed to create run: %w", err)
+	}
+	bucket := upserted.UpsertBucket.Bucket
+
+	// Upload the summary, logs, and metrics so that they can be viewed from
+	// the run
+	var files []string
+	for _, file := range []string{cfg.SummaryFile, cfg.Run.StdoutFile, cfg.Run.StderrFile, utils.MetricsFile, utils.MetricsLogFile} {
+		if _, err := os.Stat(filepath.Join(run.Directory, file)); err == nil {
+			files = append(files, file)
+		}
+	}
+	if err := c.uploadFiles(bucket.Project.Entity.Name, bucket.Project.Name, bucket.Name, run.Directory, files); err != nil {
+		return "", fmt.Errorf("failed to upload files: %w", err)
+	}
+
+	url := strings.Replace(c.baseURL, "://api.", "://", 1)
+	return fmt.Sprintf("%s/%s/%s/runs/%s", url, bucket.Project.Entity.Name, bucket.Project.Name, bucket.Name), nil
+}
+
+// uploadFiles uploads files in a directory to a run
+func (c *wandbClient) uploadFiles(entity, project, runID, dir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	var created struct {
+		CreateRunFiles struct {
+			Files []struct {
+				Name      string `json:"name"`
+				UploadURL string `json:"uploadUrl"`
+			} `json:"files"`
+		} `json:"createRunFiles"`
+	}
+	err := c.query(createRunFilesQuery, map[string]any{
+		"entity":  entity,
+		"project": project,
+		"run":     runID,
+		"files":   files,
+	}, &created)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range created.CreateRunFiles.Files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name))
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPut, file.UploadURL, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("failed to upload %s: %s", file.Name, resp.Status)
+		}
+	}
+	return nil
+}
+
+// query sends a GraphQL request and decodes its data into result
+func (c *wandbClient) query(query string, variables map[string]any, result any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		return errors.New(response.Errors[0].Message)
+	}
+	return json.Unmarshal(response.Data, result)
+}
+
+// nilIfEmpty returns nil for an empty string so that it is sent as null
+func nilIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,250 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// FileName is the name of the index file in a base directory
+const FileName = ".moco-index.json"
+
+// parseWorkers is the maximum number of summary files parsed concurrently
+const parseWorkers = 16
+
+// version is incremented whenever the format of cached run information
+// changes, which invalidates existing indexes
+const version = 6
+
+// Index caches parsed summaries of the runs in a base directory
+type Index struct {
+	path        string // empty if the index is not persisted
+	summaryFile string
+	changed     bool
+
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"` // keyed by run directory name
+}
+
+// Entry is a cached summary with the state of its file when parsed
+type Entry struct {
+	ModTime time.Time     `json:"mod_time"`
+	Size    int64         `json:"size"`
+	Run     utils.RunInfo `json:"run"`
+}
+
+// Open loads the index of a base directory, starting from an empty one if
+// the index is disabled, missing, or stale
+func Open(baseDir, summaryFile string) *Index {
+	idx := &Index{summaryFile: summaryFile, Version: version, Entries: map[string]Entry{}}
+	if !config.Get().Index.Enabled {
+		return idx
+	}
+	idx.path = filepath.Join(baseDir, FileName)
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Failed to read index: %v", err)
+		}
+		return idx
+	}
+	loaded, err := decode(data)
+	if err != nil {
+		log.Debugf("Rebuilding stale index: %s", idx.path)
+		idx.changed = true
+		return idx
+	}
+	idx.Entries = loaded.Entries
+	return idx
+}
+
+// Load reads the index of a base directory as it is, failing if it is
+// missing, corrupt, or written in another format
+func Load(baseDir string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// decode parses an index file
+func decode(data []byte) (*Index, error) {
+	var loaded Index
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("corrupt index: %w", err)
+	}
+	if loaded.Version != version || loaded.Entries == nil {
+		return nil, fmt.Errorf("stale index (version %d, expected %d)", loaded.Version, version)
+	}
+	return &loaded, nil
+}
+
+// Outdated reports whether the entry does not reflect the current state of
+// a summary file
+func (e Entry) Outdated(info os.FileInfo) bool {
+	return !e.ModTime.Equal(info.ModTime()) || e.Size != info.Size()
+}
+
+// Get returns information about a run, parsing its summary file only if it
+// changed since it was indexed
+func (idx *Index) Get(runDir string) (utils.RunInfo, error) {
+	result := idx.lookup(runDir)
+	if result.err != nil {
+		return utils.RunInfo{}, result.err
+	}
+	idx.store(runDir, result)
+	return result.runInfo, nil
+}
+
+// Runs returns information about all runs in chronological order and drops
+// runs that no longer exist from the index
+func (idx *Index) Runs(baseDir string) ([]utils.RunInfo, error) {
+	runDirs, err := utils.FindRunDirs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look up runs with a bounded number of workers, which makes a big
+	// difference on network filesystems; results are kept in order
+	results := make([]lookupResult, len(runDirs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parseWorkers, len(runDirs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = idx.lookup(runDirs[i])
+			}
+		}()
+	}
+	for i := range runDirs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	runs := make([]utils.RunInfo, 0, len(runDirs))
+	seen := map[string]bool{}
+	for i, result := range results {
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to parse summary file: %w", result.err)
+		}
+		idx.store(runDirs[i], result)
+		runs = append(runs, result.runInfo)
+		seen[filepath.Base(runDirs[i])] = true
+	}
+	idx.Prune(seen)
+	return runs, nil
+}
+
+// lookupResult is the result of looking up a run in the index
+type lookupResult struct {
+	runInfo utils.RunInfo
+	entry   Entry
+	parsed  bool // whether the summary file was parsed again
+	err     error
+}
+
+// lookup finds information about a run without modifying the index, so that
+// it can be called concurrently
+func (idx *Index) lookup(runDir string) lookupResult {
+	summaryPath := filepath.Join(runDir, idx.summaryFile)
+	stat, err := os.Stat(summaryPath)
+	if err != nil {
+		return lookupResult{err: fmt.Errorf("failed to open summary file: %w", err)}
+	}
+
+	result := lookupResult{}
+	entry, found := idx.Entries[filepath.Base(filepath.Clean(runDir))]
+	if !found || entry.Outdated(stat) {
+		runInfo, err := utils.ParseRunInfo(summaryPath)
+		if err != nil {
+			return lookupResult{err: err}
+		}
+		entry = Entry{ModTime: stat.ModTime(), Size: stat.Size(), Run: runInfo}
+		result.parsed = true
+	}
+	result.entry = entry
+
+	// The base directory may be given differently than when indexed
+	result.runInfo = entry.Run
+	result.runInfo.Directory, result.runInfo.File = filepath.Split(summaryPath)
+	return result
+}
+
+// store records the result of a lookup in the index
+func (idx *Index) store(runDir string, result lookupResult) {
+	if result.parsed {
+		idx.Entries[filepath.Base(filepath.Clean(runDir))] = result.entry
+		idx.changed = true
+	}
+}
+
+// Prune drops runs not in the given set of run directory names
+func (idx *Index) Prune(keep map[string]bool) {
+	for key := range idx.Entries {
+		if !keep[key] {
+			delete(idx.Entries, key)
+			idx.changed = true
+		}
+	}
+}
+
+// Save writes the index if it has changed
+func (idx *Index) Save() error {
+	if idx.path == "" || !idx.changed {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Dir(idx.path)); err != nil {
+		// Nothing to index without a base directory
+		return nil
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(idx.path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	idx.changed = false
+	return nil
+}
+
+// Update brings the index of a base directory up to date, e.g., after runs
+// were created or removed
+func Update(baseDir, summaryFile string) {
+	idx := Open(baseDir, summaryFile)
+	if _, err := idx.Runs(baseDir); err != nil {
+		log.Warnf("Failed to update index: %v", err)
+		return
+	}
+	if err := idx.Save(); err != nil {
+		log.Warnf("Failed to save index: %v", err)
+	}
+}
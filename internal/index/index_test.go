@@ -0,0 +1,166 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBaseDir returns a new base directory with the index enabled
+func newBaseDir(t *testing.T) (string, config.Config) {
+	cfg := config.GetPointer()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+	*cfg = config.GetDefault()
+	cfg.Index.Enabled = true
+	cfg.BaseDir = t.TempDir()
+	return cfg.BaseDir, *cfg
+}
+
+// addRun creates a finished run started i minutes after a fixed time
+func addRun(t *testing.T, cfg config.Config, i int, command string) string {
+	start := time.Date(2025, 3, 24, 0, i, 0, 0, time.Local)
+	runDir := filepath.Join(cfg.BaseDir, start.Format("2006-01-02T15:04:05.000")+"_main_1234567")
+	require.NoError(t, os.Mkdir(runDir, 0755))
+	summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+	meta := utils.RunMetadata{StartTime: start, Repo: utils.RepoStatus{Branch: "main"}, Command: []string{command}}
+	require.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+	result := utils.RunResult{EndTime: start.Add(time.Second), Success: true}
+	require.NoError(t, utils.WriteSummaryFileEnd(summaryPath, start, result))
+	return runDir
+}
+
+// commands returns the commands of runs
+func commands(runs []utils.RunInfo) []string {
+	var commands []string
+	for _, run := range runs {
+		commands = append(commands, run.Command)
+	}
+	return commands
+}
+
+func TestSaveLoad(t *testing.T) {
+	baseDir, cfg := newBaseDir(t)
+	addRun(t, cfg, 0, "first")
+	addRun(t, cfg, 1, "second")
+
+	idx := Open(baseDir, cfg.SummaryFile)
+	runs, err := idx.Runs(baseDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, commands(runs))
+	require.NoError(t, idx.Save())
+
+	loaded, err := Load(baseDir)
+	require.NoError(t, err)
+	want, err := json.Marshal(idx.Entries)
+	require.NoError(t, err)
+	got, err := json.Marshal(loaded.Entries)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+
+	// Runs are read from the index without parsing unchanged summaries
+	reopened := Open(baseDir, cfg.SummaryFile)
+	runs, err = reopened.Runs(baseDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, commands(runs))
+	assert.False(t, reopened.changed)
+}
+
+func TestStaleEntries(t *testing.T) {
+	baseDir, cfg := newBaseDir(t)
+	first := addRun(t, cfg, 0, "first")
+	second := addRun(t, cfg, 1, "second")
+	Update(baseDir, cfg.SummaryFile)
+
+	// A changed summary is parsed again, and removed runs are dropped
+	require.NoError(t, os.RemoveAll(second))
+	summaryPath := filepath.Join(first, cfg.SummaryFile)
+	data, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(summaryPath, []byte(string(data)+"\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(summaryPath, later, later))
+	addRun(t, cfg, 2, "third")
+
+	Update(baseDir, cfg.SummaryFile)
+	loaded, err := Load(baseDir)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Entries, 2)
+	stat, err := os.Stat(summaryPath)
+	require.NoError(t, err)
+	assert.False(t, loaded.Entries[filepath.Base(first)].Outdated(stat))
+	assert.NotContains(t, loaded.Entries, filepath.Base(second))
+}
+
+func TestRebuild(t *testing.T) {
+	for name, content := range map[string]string{
+		"corrupt": `{"version": `,
+		"stale":   `{"version": 1, "entries": {}}`,
+		"empty":   fmt.Sprintf(`{"version": %d}`, version),
+	} {
+		t.Run(name, func(t *testing.T) {
+			baseDir, cfg := newBaseDir(t)
+			addRun(t, cfg, 0, "first")
+			indexPath := filepath.Join(baseDir, FileName)
+			require.NoError(t, os.WriteFile(indexPath, []byte(content), 0644))
+
+			_, err := Load(baseDir)
+			assert.Error(t, err)
+
+			idx := Open(baseDir, cfg.SummaryFile)
+			assert.Empty(t, idx.Entries)
+			runs, err := idx.Runs(baseDir)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first"}, commands(runs))
+			require.NoError(t, idx.Save())
+
+			loaded, err := Load(baseDir)
+			require.NoError(t, err)
+			assert.Len(t, loaded.Entries, 1)
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	baseDir, cfg := newBaseDir(t)
+	config.GetPointer().Index.Enabled = false
+	addRun(t, cfg, 0, "first")
+
+	Update(baseDir, cfg.SummaryFile)
+	assert.NoFileExists(t, filepath.Join(baseDir, FileName))
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	baseDir, cfg := newBaseDir(t)
+	for i := range 20 {
+		addRun(t, cfg, i, "run")
+	}
+
+	// Each update replaces the index atomically, so it is never left
+	// partially written
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Update(baseDir, cfg.SummaryFile)
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := Load(baseDir)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Entries, 20)
+	leftovers, err := filepath.Glob(filepath.Join(baseDir, FileName+".*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
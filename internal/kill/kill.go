@@ -0,0 +1,192 @@
+package kill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// pollInterval is the interval to check whether the process has exited
+	pollInterval = 200 * time.Millisecond
+	// finishTimeout is how long to wait for moco run to finish the summary
+	// after the process has exited
+	finishTimeout = 5 * time.Second
+	// startTolerance is how far the start time of a process may be from the
+	// recorded start of the command for them to be considered the same
+	startTolerance = 5 * time.Second
+	// lostExitCode is recorded for runs whose process was not signaled
+	// because it is gone or cannot be identified, as its exit status is unknown
+	lostExitCode = -1
+)
+
+// Main terminates the process of a running experiment and makes sure that
+// its summary records the interruption
+func Main(run string) error {
+	cfg := config.Get()
+
+	sig, err := utils.ParseSignal(cfg.Kill.Signal)
+	if err != nil {
+		return err
+	}
+	timeout, err := time.ParseDuration(cfg.Kill.Timeout)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid timeout: %s", cfg.Kill.Timeout)
+	}
+	staleAfter, err := time.ParseDuration(cfg.Run.StaleAfter)
+	if err != nil {
+		return fmt.Errorf("invalid stale threshold: %s", cfg.Run.StaleAfter)
+	}
+
+	summaryPath, err := utils.ResolveSummaryPath(run, cfg.SummaryFile)
+	if err != nil {
+		return err
+	}
+	runDir := filepath.Dir(summaryPath)
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse summary file: %w", err)
+	}
+	if !runInfo.IsRunning {
+		return fmt.Errorf("%s is not running", runDir)
+	}
+	hostname, _ := os.Hostname()
+	if runInfo.Hostname != "" && runInfo.Hostname != hostname {
+		return fmt.Errorf("%s is running on another host: %s", runDir, runInfo.Hostname)
+	}
+
+	pid, err := utils.ReadPIDFile(runDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	signaled := false
+	if pid > 0 && utils.ProcessAlive(pid) {
+		// The PID may have been reused by an unrelated process if moco run is gone
+		if reason := unidentified(runInfo, runDir, pid, staleAfter); reason != "" {
+			log.Warnf("Not signaling process %d of %s, as %s; marking it as lost", pid, runDir, reason)
+		} else {
+			log.Infof("Sending %v to process %d of %s", sig, pid, runDir)
+			if err := utils.SignalProcessGroup(pid, sig); err != nil {
+				return fmt.Errorf("failed to send signal: %w", err)
+			}
+			if !waitExit(pid, timeout) {
+				return fmt.Errorf("process %d did not exit within %s (try --signal KILL)", pid, timeout)
+			}
+			signaled = true
+		}
+	} else {
+		log.Warnf("The process of %s is gone; marking it as lost", runDir)
+	}
+
+	// moco run records the results if it is still supervising the process
+	if waitFinished(summaryPath, finishTimeout) {
+		log.Infof("Terminated %s", runDir)
+		return nil
+	}
+	if err := markInterrupted(summaryPath, runInfo.StartTime, sig, signaled); err != nil {
+		return err
+	}
+	if err := utils.RemovePIDFile(runDir); err != nil {
+		log.Warnf("Failed to remove PID file: %v", err)
+	}
+	if err := utils.RemoveHeartbeat(runDir); err != nil {
+		log.Warnf("Failed to remove heartbeat file: %v", err)
+	}
+	index.Update(cfg.BaseDir, cfg.SummaryFile)
+	log.Infof("Terminated %s", runDir)
+	return nil
+}
+
+// unidentified returns why the process with pid cannot be trusted to be the
+// command of a run, or "" if it can: the heartbeat of the run must be fresh,
+// and the process must have started when the command did
+func unidentified(runInfo utils.RunInfo, runDir string, pid int, staleAfter time.Duration) string {
+	if _, err := os.Stat(filepath.Join(runDir, utils.HeartbeatFile)); err != nil {
+		return "the run has no heartbeat"
+	}
+	runs := []utils.RunInfo{runInfo}
+	utils.MarkStale(runs, staleAfter)
+	if runs[0].Stale {
+		return "the heartbeat of the run is stale"
+	}
+
+	events, err := utils.ReadEvents(runDir)
+	if err != nil {
+		log.Debugf("Failed to read events: %v", err)
+	}
+	for _, event := range events {
+		if event.Type != utils.EventStarted || event.PID != pid {
+			continue
+		}
+		started, err := utils.ProcessStartTime(pid)
+		if err != nil {
+			log.Debugf("Failed to get the start time of process %d: %v", pid, err)
+		} else if diff := started.Sub(event.Time).Abs(); diff > startTolerance {
+			return fmt.Sprintf("it started at %s, not with the command", started.Format(time.DateTime))
+		}
+	}
+	return ""
+}
+
+// waitExit waits for a process to exit and reports whether it did in time
+func waitExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for utils.ProcessAlive(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+	return true
+}
+
+// waitFinished waits for the summary of a run to record its results and
+// reports whether it did in time
+func waitFinished(summaryPath string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		runInfo, err := utils.ParseRunInfo(summaryPath)
+		if err == nil && !runInfo.IsRunning {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// markInterrupted writes the results of a run whose supervising moco process
+// is gone, following the shell convention for the exit code if the process
+// was signaled, or recording it as lost otherwise
+func markInterrupted(summaryPath string, startTime time.Time, sig syscall.Signal, signaled bool) error {
+	cfg := config.Get()
+	exitCode := lostExitCode
+	reason := "lost"
+	if signaled {
+		exitCode = 128 + int(sig)
+		reason = cfg.Run.ExitCodeNames[strconv.Itoa(exitCode)]
+	}
+	result := utils.RunResult{
+		EndTime:     time.Now(),
+		ExitCode:    exitCode,
+		ExitReason:  reason,
+		Interrupted: true,
+		Currency:    cfg.Cost.Currency,
+	}
+	if err := utils.WriteSummaryFileEnd(summaryPath, startTime, result); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		log.Warnf("Failed to write %s: %v", utils.SummaryJSONFile, err)
+	}
+	return nil
+}
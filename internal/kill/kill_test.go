@@ -0,0 +1,66 @@
+package kill
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnidentified(t *testing.T) {
+	pid := os.Getpid()
+	started, err := utils.ProcessStartTime(pid)
+	if err != nil {
+		t.Skipf("process start times not available: %v", err)
+	}
+
+	runDir := t.TempDir()
+	runInfo := utils.RunInfo{Directory: runDir, IsRunning: true}
+	assert.Contains(t, unidentified(runInfo, runDir, pid, time.Minute), "no heartbeat")
+
+	require.NoError(t, utils.TouchHeartbeat(runDir))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(runDir, utils.HeartbeatFile), old, old))
+	assert.Contains(t, unidentified(runInfo, runDir, pid, time.Minute), "stale")
+
+	// Without events, the fresh heartbeat is enough
+	require.NoError(t, utils.TouchHeartbeat(runDir))
+	assert.Empty(t, unidentified(runInfo, runDir, pid, time.Minute))
+
+	require.NoError(t, utils.AppendEvent(runDir, utils.Event{Type: utils.EventStarted, PID: pid, Time: started}))
+	assert.Empty(t, unidentified(runInfo, runDir, pid, time.Minute))
+
+	// A process started long after the command has reused its PID
+	require.NoError(t, os.Remove(filepath.Join(runDir, utils.EventsFile)))
+	require.NoError(t, utils.AppendEvent(runDir, utils.Event{Type: utils.EventStarted, PID: pid, Time: started.Add(-time.Hour)}))
+	assert.Contains(t, unidentified(runInfo, runDir, pid, time.Minute), "not with the command")
+}
+
+func TestMarkInterrupted(t *testing.T) {
+	startTime := time.Now().Add(-time.Minute)
+	for _, tc := range []struct {
+		signaled bool
+		status   int
+		reason   string
+	}{
+		{true, 128 + int(syscall.SIGTERM), ""},
+		{false, lostExitCode, "lost"},
+	} {
+		summaryPath := filepath.Join(t.TempDir(), "summary.md")
+		meta := utils.RunMetadata{StartTime: startTime, Repo: utils.RepoStatus{Branch: "main"}, Command: []string{"sleep", "60"}}
+		require.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		require.NoError(t, markInterrupted(summaryPath, startTime, syscall.SIGTERM, tc.signaled))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		require.NoError(t, err)
+		assert.False(t, info.IsRunning)
+		assert.True(t, info.Interrupted)
+		assert.Equal(t, tc.status, info.ExitStatus)
+		assert.Equal(t, tc.reason, info.ExitReason)
+	}
+}
@@ -0,0 +1,132 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// Group is a group of runs sharing a branch, command, or day
+type Group struct {
+	Key         string          `json:"key"`
+	Count       int             `json:"count"`
+	Succeeded   int             `json:"succeeded"`
+	Failed      int             `json:"failed"`
+	SuccessRate float64         `json:"success_rate"`
+	Runs        []utils.RunInfo `json:"runs"`
+}
+
+// GroupedOutput is the structure of grouped runs output in JSON
+type GroupedOutput struct {
+	GroupBy string  `json:"group_by"`
+	Groups  []Group `json:"groups"`
+	Count   int     `json:"count"`
+}
+
+// groupRuns groups runs by branch, command, or day, keeping the order of
+// runs within groups and ordering groups by their first runs
+func groupRuns(runs []utils.RunInfo, by string) ([]Group, error) {
+	var groups []Group
+	index := map[string]int{}
+	for _, run := range runs {
+		var key string
+		switch by {
+		case "branch":
+			key = run.Branch
+		case "command":
+			key = run.Command
+		case "day":
+			key = run.StartTime.Local().Format("2006-01-02")
+		default:
+			return nil, fmt.Errorf("invalid group-by field: %s", by)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Key: key})
+		}
+		group := &groups[i]
+		group.Count++
+		if run.Succeeded() {
+			group.Succeeded++
+		} else if run.Failed() {
+			group.Failed++
+		}
+		group.Runs = append(group.Runs, run)
+	}
+
+	// Success rates are of finished runs
+	for i := range groups {
+		if finished := groups[i].Succeeded + groups[i].Failed; finished > 0 {
+			groups[i].SuccessRate = float64(groups[i].Succeeded) / float64(finished)
+		}
+	}
+	return groups, nil
+}
+
+// outputGroupedTable displays groups of runs as a table for each group
+func outputGroupedTable(groups []Group, by string) error {
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(groupTitle(group, by))
+		fmt.Println(renderTable(group.Runs))
+	}
+	return nil
+}
+
+// outputGroupedMarkdown displays groups of runs as a Markdown section with a
+// table for each group
+func outputGroupedMarkdown(groups []Group, by string) error {
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("## %s\n\n", groupTitle(group, by))
+		fmt.Print(utils.RenderRunInfosMarkdown(group.Runs, extraColumns(group.Runs)...))
+	}
+	return nil
+}
+
+// groupTitle describes a group with the number of runs and the success rate
+func groupTitle(group Group, by string) string {
+	rate := "no finished runs"
+	if group.Succeeded+group.Failed > 0 {
+		rate = fmt.Sprintf("%.0f%% success", group.SuccessRate*100)
+	}
+	return fmt.Sprintf("%s: %s (%d runs, %s)", by, group.Key, group.Count, rate)
+}
+
+// newGroupedOutput returns the JSON output of groups of runs
+func newGroupedOutput(groups []Group, by string) GroupedOutput {
+	output := GroupedOutput{GroupBy: by, Groups: groups}
+	for _, group := range groups {
+		output.Count += group.Count
+	}
+	return output
+}
+
+// outputGroupedJSON displays groups of runs as JSON
+func outputGroupedJSON(groups []Group, by string) error {
+	data, err := json.MarshalIndent(newGroupedOutput(groups, by), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// outputGroupedYAML displays groups of runs as YAML with the structure of
+// JSON output
+func outputGroupedYAML(groups []Group, by string) error {
+	data, err := utils.MarshalYAML(newGroupedOutput(groups, by))
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,83 @@
+package list
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// canStream reports whether runs can be output as they are read, which
+// requires them to be listed from the base directory in chronological order
+// without grouping
+func canStream(cfg config.Config) bool {
+	return !cfg.List.Archived && !cfg.List.AllProjects && cfg.List.GroupBy == "" &&
+		(cfg.List.SortBy == "" || cfg.List.SortBy == "date") && !cfg.List.Reverse
+}
+
+// streamJSONL writes runs in the base directory passing the filters as JSON
+// Lines, one run at a time as its summary is read, so that memory use does
+// not grow with the number of runs
+func streamJSONL(cfg config.Config) error {
+	match, err := newFilter(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to apply filters: %w", err)
+	}
+	staleAfter, err := staleThreshold(cfg)
+	if err != nil {
+		return err
+	}
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return fmt.Errorf("failed to find runs: %w", err)
+	}
+
+	idx := index.Open(cfg.BaseDir, cfg.SummaryFile)
+	defer func() {
+		if err := idx.Save(); err != nil {
+			log.Warnf("Failed to save index: %v", err)
+		}
+	}()
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	encoder := json.NewEncoder(w)
+	count := 0
+	for _, runDir := range runDirs {
+		if cfg.List.Limit > 0 && count >= cfg.List.Limit {
+			break
+		}
+		run, err := idx.Get(runDir)
+		if err != nil {
+			return fmt.Errorf("failed to parse summary file: %w", err)
+		}
+		runs := []utils.RunInfo{run}
+		utils.MarkStale(runs, staleAfter)
+		if !match(runs[0]) {
+			continue
+		}
+		if err := encoder.Encode(runs[0]); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		count++
+	}
+	return nil
+}
+
+// outputJSONL formats and displays runs as JSON Lines
+func outputJSONL(runs []utils.RunInfo) error {
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	encoder := json.NewEncoder(w)
+	for _, run := range runs {
+		if err := encoder.Encode(run); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	}
+	return nil
+}
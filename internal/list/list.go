@@ -1,17 +1,22 @@
 package list
 
 import (
+	"cmp"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bicycle1885/moco/internal/archive"
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/projects"
+	"github.com/bicycle1885/moco/internal/tui"
 	"github.com/bicycle1885/moco/internal/utils"
 	"github.com/charmbracelet/log"
 	"golang.org/x/exp/slices"
@@ -22,10 +27,47 @@ func Main() error {
 	// Get config
 	cfg := config.Get()
 
+	// Browse runs interactively, finding them again on each refresh
+	if cfg.List.Interactive {
+		if cfg.List.Archived {
+			return fmt.Errorf("archived runs cannot be listed interactively")
+		}
+		return tui.Pick(func() ([]utils.RunInfo, error) {
+			runs, err := findAllRuns(cfg)
+			if err != nil {
+				return nil, err
+			}
+			return selectRuns(runs, cfg)
+		})
+	}
+
+	// Refresh the table periodically, finding runs again each time
+	if cfg.List.Watch {
+		if cfg.List.Format != "table" || cfg.List.GroupBy != "" {
+			return fmt.Errorf("watch mode supports only ungrouped table output")
+		}
+		return tui.WatchList(func() ([]utils.RunInfo, error) {
+			runs, err := findAllRuns(cfg)
+			if err != nil {
+				return nil, err
+			}
+			runs, err = selectRuns(runs, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return runs, nil
+		}, extraColumns)
+	}
+
+	// Stream runs in chronological order as they are read
+	if cfg.List.Format == "jsonl" && canStream(cfg) {
+		return streamJSONL(cfg)
+	}
+
 	// Find all runs
-	runs, err := findRuns(cfg.BaseDir)
+	runs, err := findAllRuns(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to find runs: %w", err)
+		return err
 	}
 
 	if len(runs) == 0 {
@@ -33,10 +75,10 @@ func Main() error {
 		return nil
 	}
 
-	// Apply filters
-	filtered, err := filterRuns(runs, cfg)
+	// Apply filters, sort order, and limit
+	filtered, err := selectRuns(runs, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to apply filters: %w", err)
+		return err
 	}
 
 	if len(filtered) == 0 {
@@ -44,22 +86,40 @@ func Main() error {
 		return nil
 	}
 
-	// Sort runs
-	sortRuns(filtered, cfg.List.SortBy, cfg.List.Reverse)
-
-	// Apply limit if specified
-	if cfg.List.Limit > 0 && cfg.List.Limit < len(filtered) {
-		filtered = filtered[:cfg.List.Limit]
+	// Output groups of runs
+	if cfg.List.GroupBy != "" {
+		groups, err := groupRuns(filtered, cfg.List.GroupBy)
+		if err != nil {
+			return err
+		}
+		switch cfg.List.Format {
+		case "json":
+			return outputGroupedJSON(groups, cfg.List.GroupBy)
+		case "yaml":
+			return outputGroupedYAML(groups, cfg.List.GroupBy)
+		case "table":
+			return outputGroupedTable(groups, cfg.List.GroupBy)
+		case "markdown":
+			return outputGroupedMarkdown(groups, cfg.List.GroupBy)
+		default:
+			return fmt.Errorf("grouping is not supported for %s output", cfg.List.Format)
+		}
 	}
 
 	// Output in the requested format
 	switch cfg.List.Format {
 	case "json":
 		return outputJSON(filtered)
+	case "yaml":
+		return outputYAML(filtered)
+	case "jsonl":
+		return outputJSONL(filtered)
 	case "csv":
 		return outputCSV(filtered)
 	case "table":
 		return outputTable(filtered)
+	case "markdown":
+		return outputMarkdown(filtered)
 	case "plain":
 		return outputPlain(filtered)
 	default:
@@ -67,58 +127,135 @@ func Main() error {
 	}
 }
 
-// findRuns scans the base directory for experiment directories
-func findRuns(baseDir string) ([]utils.RunInfo, error) {
+// Find finds runs and applies the filters, sort order, and limit of the list
+// configuration
+func Find(cfg config.Config) ([]utils.RunInfo, error) {
+	runs, err := findAllRuns(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return selectRuns(runs, cfg)
+}
+
+// findAllRuns finds runs in the archive index, all projects, or the base
+// directory as configured
+func findAllRuns(cfg config.Config) ([]utils.RunInfo, error) {
 	var runs []utils.RunInfo
+	var err error
+	if cfg.List.Archived {
+		runs, err = findArchivedRuns()
+	} else if cfg.List.AllProjects {
+		runs, err = findProjectRuns()
+	} else {
+		runs, err = findRuns(cfg.BaseDir, cfg.SummaryFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find runs: %w", err)
+	}
 
-	// Ensure base directory exists
-	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
-		return runs, nil // Return empty slice if directory doesn't exist
+	// Runs whose heartbeat stopped are no longer running
+	if !cfg.List.Archived {
+		staleAfter, err := staleThreshold(cfg)
+		if err != nil {
+			return nil, err
+		}
+		utils.MarkStale(runs, staleAfter)
 	}
+	return runs, nil
+}
 
-	// Pattern for experiment directories
-	pattern := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3})_(.+)_([a-f0-9]{7})$`)
+// staleThreshold returns the age of heartbeats after which runs are stale
+func staleThreshold(cfg config.Config) (time.Duration, error) {
+	staleAfter, err := time.ParseDuration(cfg.Run.StaleAfter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stale threshold: %s", cfg.Run.StaleAfter)
+	}
+	return staleAfter, nil
+}
 
-	// Read all entries in base directory
-	entries, err := os.ReadDir(baseDir)
+// selectRuns filters and sorts runs and applies the limit
+func selectRuns(runs []utils.RunInfo, cfg config.Config) ([]utils.RunInfo, error) {
+	filtered, err := filterRuns(runs, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read base directory: %w", err)
+		return nil, fmt.Errorf("failed to apply filters: %w", err)
 	}
 
-	// Get configuration
-	cfg := config.Get()
+	// Sort runs
+	if err := SortRuns(filtered, cfg.List.SortBy, cfg.List.Reverse); err != nil {
+		return nil, err
+	}
 
-	// Check each entry
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue // Skip non-directories
-		}
+	// Apply limit if specified
+	if cfg.List.Limit > 0 && cfg.List.Limit < len(filtered) {
+		filtered = filtered[:cfg.List.Limit]
+	}
+	return filtered, nil
+}
 
-		// Check if the name matches our pattern
-		name := entry.Name()
-		matches := pattern.FindStringSubmatch(name)
-		if len(matches) != 4 {
-			continue // Not an experiment directory
+// findArchivedRuns finds runs in the archive index, located at their archives
+func findArchivedRuns() ([]utils.RunInfo, error) {
+	archivedRuns, err := archive.ListArchived()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]utils.RunInfo, 0, len(archivedRuns))
+	for _, archivedRun := range archivedRuns {
+		run := archivedRun.Run
+		if run.ID == "" {
+			run.ID = utils.ShortID(run.Directory)
 		}
+		run.Directory = archivedRun.Archive
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
 
-		// Parse summary file
-		summaryPath := filepath.Join(baseDir, name, cfg.SummaryFile)
-		runInfo, err := utils.ParseRunInfo(summaryPath)
+// findProjectRuns finds runs of all registered projects
+func findProjectRuns() ([]utils.RunInfo, error) {
+	var runs []utils.RunInfo
+	for _, project := range projects.Load() {
+		projectRuns, err := findRuns(project.BaseDir(), project.Config.SummaryFile)
 		if err != nil {
-			// TODO: Log error and continue
-			return nil, fmt.Errorf("failed to parse summary file: %w", err)
+			return nil, fmt.Errorf("%s: %w", project.Path, err)
 		}
-
-		runs = append(runs, runInfo)
+		for i := range projectRuns {
+			projectRuns[i].Project = project.Name
+		}
+		runs = append(runs, projectRuns...)
 	}
+	return runs, nil
+}
 
+// findRuns finds runs in the base directory using the index
+func findRuns(baseDir, summaryFile string) ([]utils.RunInfo, error) {
+	idx := index.Open(baseDir, summaryFile)
+	runs, err := idx.Runs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := idx.Save(); err != nil {
+		log.Warnf("Failed to save index: %v", err)
+	}
 	return runs, nil
 }
 
 // filterRuns applies filters to run results
 func filterRuns(runs []utils.RunInfo, cfg config.Config) ([]utils.RunInfo, error) {
+	match, err := newFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
 	var filtered []utils.RunInfo
+	for _, run := range runs {
+		if match(run) {
+			filtered = append(filtered, run)
+		}
+	}
+	return filtered, nil
+}
 
+// newFilter returns a function reporting whether a run passes the filters
+func newFilter(cfg config.Config) (func(utils.RunInfo) bool, error) {
 	// Parse 'since' filter if provided
 	var sinceTime time.Time
 	if cfg.List.Since != "" {
@@ -139,40 +276,77 @@ func filterRuns(runs []utils.RunInfo, cfg config.Config) ([]utils.RunInfo, error
 		}
 	}
 
-	// Filter each run
-	for _, run := range runs {
+	// Parse metric and parameter conditions
+	var conditions []utils.Condition
+	for _, where := range cfg.List.Where {
+		condition, err := utils.ParseCondition(where)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return func(run utils.RunInfo) bool {
 		// Filter by branch
 		if cfg.List.Branch != "" && !strings.Contains(run.Branch, cfg.List.Branch) {
-			continue
+			return false
 		}
 
 		// Filter by status
 		if cfg.List.Status != "" {
-			if cfg.List.Status == "success" && (run.IsRunning || run.ExitStatus != 0) {
-				continue
+			if cfg.List.Status == "success" && !run.Succeeded() {
+				return false
 			}
-			if cfg.List.Status == "failure" && (run.IsRunning || run.ExitStatus == 0) {
-				continue
+			if cfg.List.Status == "failure" && !run.Failed() {
+				return false
 			}
-			if cfg.List.Status == "running" && !run.IsRunning {
-				continue
+			if cfg.List.Status == "running" && (!run.IsRunning || run.Stale) {
+				return false
+			}
+			if cfg.List.Status == "stale" && !run.Stale {
+				return false
 			}
 		}
 
 		// Filter by date
 		if !sinceTime.IsZero() && run.StartTime.Before(sinceTime) {
-			continue
+			return false
 		}
 
 		// Filter by command
 		if commandRegex != nil && !commandRegex.MatchString(run.Command) {
-			continue
+			return false
 		}
 
-		filtered = append(filtered, run)
-	}
+		// Filter by linked issue
+		if cfg.List.Issue != "" && !slices.Contains(run.Issues, cfg.List.Issue) {
+			return false
+		}
 
-	return filtered, nil
+		// Filter by tag
+		if cfg.List.Tag != "" && !slices.Contains(run.Tags, cfg.List.Tag) {
+			return false
+		}
+
+		// Filter by name
+		if cfg.List.Name != "" {
+			if matched, _ := path.Match(cfg.List.Name, run.Name); !matched {
+				return false
+			}
+		}
+
+		// Filter by sweep
+		if cfg.List.Sweep != "" && run.Sweep != cfg.List.Sweep {
+			return false
+		}
+
+		// Filter by metrics and parameters
+		if slices.ContainsFunc(conditions, func(c utils.Condition) bool { return !c.Match(run) }) {
+			return false
+		}
+
+		return true
+	}, nil
 }
 
 // parseDuration parses a duration string like "7d" or "24h"
@@ -203,16 +377,41 @@ func parseDuration(s string) (time.Duration, error) {
 	return time.Duration(value) * multiplier, nil
 }
 
-// sortRuns sorts runs based on criteria
-func sortRuns(runs []utils.RunInfo, sortBy string, reverse bool) {
+// SortRuns sorts runs by a field or by the value of a metric given as
+// "metric:name", in which case runs without the metric come last
+func SortRuns(runs []utils.RunInfo, sortBy string, reverse bool) error {
 	// Define sort function based on criteria
 	var sortFunc func(i, j utils.RunInfo) int
 
 	switch sortBy {
+	case "directory":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.Directory, b.Directory)
+		}
+	case "name":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.Name, b.Name)
+		}
 	case "branch":
 		sortFunc = func(a, b utils.RunInfo) int {
 			return strings.Compare(a.Branch, b.Branch)
 		}
+	case "commit":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.CommitHash, b.CommitHash)
+		}
+	case "command":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.Command, b.Command)
+		}
+	case "host":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.Hostname, b.Hostname)
+		}
+	case "project":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return strings.Compare(a.Project, b.Project)
+		}
 	case "status":
 		sortFunc = func(a, b utils.RunInfo) int {
 			// Sort by running/completed, then by exit status
@@ -230,10 +429,44 @@ func sortRuns(runs []utils.RunInfo, sortBy string, reverse bool) {
 		sortFunc = func(a, b utils.RunInfo) int {
 			return compareDuration(a.EndTime.Sub(a.StartTime), b.EndTime.Sub(b.StartTime))
 		}
-	default: // "date" or any other value defaults to date
+	case "cpu":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return cmp.Compare(a.CPUSeconds(), b.CPUSeconds())
+		}
+	case "memory":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return cmp.Compare(a.MaxMemory(), b.MaxMemory())
+		}
+	case "gpu_hours":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return cmp.Compare(a.GPUHours, b.GPUHours)
+		}
+	case "cost":
+		sortFunc = func(a, b utils.RunInfo) int {
+			return cmp.Compare(a.Cost, b.Cost)
+		}
+	case "", "date":
 		sortFunc = func(a, b utils.RunInfo) int {
 			return compareTime(a.StartTime, b.StartTime)
 		}
+	default:
+		name, found := strings.CutPrefix(sortBy, "metric:")
+		if !found || name == "" {
+			return fmt.Errorf("invalid sort field: %s", sortBy)
+		}
+		sortFunc = func(a, b utils.RunInfo) int {
+			x, okA := a.Metrics[name]
+			y, okB := b.Metrics[name]
+			if !okA || !okB {
+				// Keep runs without the metric last even if reversed
+				c := cmp.Compare(boolInt(!okA), boolInt(!okB))
+				if reverse {
+					return -c
+				}
+				return c
+			}
+			return cmp.Compare(x, y)
+		}
 	}
 
 	// Apply reverse if requested
@@ -246,6 +479,14 @@ func sortRuns(runs []utils.RunInfo, sortBy string, reverse bool) {
 
 	// Sort the slice
 	slices.SortStableFunc(runs, sortFunc)
+	return nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 func compareInt(a, b int) int {
@@ -283,20 +524,87 @@ func compareTime(a, b time.Time) int {
 
 // outputTable formats and displays runs as a table
 func outputTable(runs []utils.RunInfo) error {
-	fmt.Println(utils.RenderRunInfos(runs))
+	fmt.Println(renderTable(runs))
 	return nil
 }
 
+// outputMarkdown formats and displays runs as a Markdown table
+func outputMarkdown(runs []utils.RunInfo) error {
+	fmt.Print(utils.RenderRunInfosMarkdown(runs, extraColumns(runs)...))
+	return nil
+}
+
+// renderTable renders runs as a table with the configured extra columns
+func renderTable(runs []utils.RunInfo) string {
+	return utils.RenderRunInfos(runs, extraColumns(runs)...)
+}
+
+// extraColumns returns the columns shown in tables in addition to the
+// default ones
+func extraColumns(runs []utils.RunInfo) []utils.Column {
+	var extra []utils.Column
+	if slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return run.DataChanged }) {
+		extra = append(extra, utils.Column{Header: "Data", Value: dataState})
+	}
+	if slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return len(run.Artifacts) > 0 }) {
+		extra = append(extra, utils.Column{Header: "Artifacts", Value: artifactsState})
+	}
+	for _, name := range config.Get().List.Metrics {
+		extra = append(extra, utils.Column{Header: name, Value: metricValue(name)})
+	}
+	if config.Get().List.Notes {
+		extra = append(extra, utils.Column{Header: "Note", Value: utils.LatestNote})
+	}
+	return extra
+}
+
+// dataState describes whether data tracked by DVC had changed from dvc.lock
+// when a run started
+func dataState(run utils.RunInfo) string {
+	if run.DataChanged {
+		return "changed"
+	}
+	return ""
+}
+
+// artifactsState describes the number and total size of the artifacts of a run
+func artifactsState(run utils.RunInfo) string {
+	if len(run.Artifacts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d (%s)", len(run.Artifacts), utils.FormatSize(utils.ArtifactsSize(run.Artifacts)))
+}
+
+// metricValue returns a function formatting the final value of a metric,
+// which is empty for runs without the metric
+func metricValue(name string) func(utils.RunInfo) string {
+	return func(run utils.RunInfo) string {
+		value, ok := run.Metrics[name]
+		if !ok {
+			return ""
+		}
+		return utils.FormatMetric(value)
+	}
+}
+
+// Output is the structure of runs output in JSON
+type Output struct {
+	Runs  []utils.RunInfo `json:"runs"`
+	Count int             `json:"count"`
+}
+
+// NewOutput returns the JSON output of runs
+func NewOutput(runs []utils.RunInfo) Output {
+	if runs == nil {
+		runs = []utils.RunInfo{}
+	}
+	return Output{Runs: runs, Count: len(runs)}
+}
+
 // outputJSON formats and displays runs as JSON
 func outputJSON(runs []utils.RunInfo) error {
 	// Create output structure
-	output := struct {
-		Runs  []utils.RunInfo `json:"runs"`
-		Count int             `json:"count"`
-	}{
-		Runs:  runs,
-		Count: len(runs),
-	}
+	output := NewOutput(runs)
 
 	// Marshal to JSON
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -309,6 +617,17 @@ func outputJSON(runs []utils.RunInfo) error {
 	return nil
 }
 
+// outputYAML formats and displays runs as YAML with the structure of JSON
+// output
+func outputYAML(runs []utils.RunInfo) error {
+	data, err := utils.MarshalYAML(NewOutput(runs))
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
 // outputCSV formats and displays runs as CSV
 func outputCSV(runs []utils.RunInfo) error {
 	// Create a CSV writer
@@ -316,26 +635,39 @@ func outputCSV(runs []utils.RunInfo) error {
 	defer w.Flush()
 
 	// Write header
-	header := []string{"Directory", "Timestamp", "Branch", "CommitHash", "Status", "Duration", "Command"}
+	header := []string{"Directory", "Timestamp", "Branch", "CommitHash", "Status", "Duration", "Command", "ID"}
+	withName := slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return run.Name != "" })
+	if withName {
+		header = append(header, "Name")
+	}
+	withProject := slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return run.Project != "" })
+	if withProject {
+		header = append(header, "Project")
+	}
+	withTags := slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return len(run.Tags) > 0 })
+	if withTags {
+		header = append(header, "Tags")
+	}
+	withData := slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return run.DataChanged })
+	if withData {
+		header = append(header, "Data")
+	}
+	withArtifacts := slices.ContainsFunc(runs, func(run utils.RunInfo) bool { return len(run.Artifacts) > 0 })
+	if withArtifacts {
+		header = append(header, "Artifacts")
+	}
+	metrics := config.Get().List.Metrics
+	header = append(header, metrics...)
+	withNotes := config.Get().List.Notes
+	if withNotes {
+		header = append(header, "Note")
+	}
 	if err := w.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
 	// Write each run
 	for _, run := range runs {
-		// Format status
-		status := "Running"
-		if !run.IsRunning {
-			if run.ExitStatus == 0 {
-				status = "Success"
-			} else {
-				status = fmt.Sprintf("Failed (%d)", run.ExitStatus)
-				if run.Interrupted {
-					status = "Interrupted"
-				}
-			}
-		}
-
 		// Format timestamp
 		timestamp := run.StartTime.Format("2006-01-02 15:04:05")
 
@@ -345,9 +677,35 @@ func outputCSV(runs []utils.RunInfo) error {
 			timestamp,
 			run.Branch,
 			run.CommitHash,
-			status,
+			utils.StatusString(run),
 			run.Duration(),
 			run.Command,
+			run.ID,
+		}
+		if withName {
+			record = append(record, run.Name)
+		}
+		if withProject {
+			record = append(record, run.Project)
+		}
+		if withTags {
+			record = append(record, strings.Join(run.Tags, " "))
+		}
+		if withData {
+			record = append(record, dataState(run))
+		}
+		if withArtifacts {
+			record = append(record, strconv.Itoa(len(run.Artifacts)))
+		}
+		for _, name := range metrics {
+			value := ""
+			if metric, ok := run.Metrics[name]; ok {
+				value = strconv.FormatFloat(metric, 'g', -1, 64)
+			}
+			record = append(record, value)
+		}
+		if withNotes {
+			record = append(record, utils.LatestNote(run))
 		}
 
 		// Write the record
@@ -0,0 +1,140 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// pollInterval is the interval to check for new output when following a log
+const pollInterval = 500 * time.Millisecond
+
+// Main prints the captured stdout or stderr of a run, the latest run if run
+// is empty
+func Main(run string) error {
+	cfg := config.Get()
+
+	runDir, err := resolveRunDir(run, cfg.BaseDir, cfg.SummaryFile)
+	if err != nil {
+		return err
+	}
+	logFile := cfg.Run.StdoutFile
+	if cfg.Logs.Stderr {
+		logFile = cfg.Run.StderrFile
+	}
+	logPath := filepath.Join(runDir, logFile)
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if cfg.Logs.Tail > 0 {
+		offset, err := tailOffset(file, cfg.Logs.Tail)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", logPath, err)
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(os.Stdout, file); err != nil {
+		return err
+	}
+
+	if cfg.Logs.Follow {
+		return follow(file, filepath.Join(runDir, cfg.SummaryFile))
+	}
+	return nil
+}
+
+// resolveRunDir returns the run directory of a run given as a directory or
+// a summary file, or the latest run in the base directory if run is empty
+func resolveRunDir(run, baseDir, summaryFile string) (string, error) {
+	if run == "" {
+		runDirs, err := utils.FindRunDirs(baseDir)
+		if err != nil {
+			return "", err
+		}
+		if len(runDirs) == 0 {
+			return "", fmt.Errorf("no runs found in %s", baseDir)
+		}
+		return runDirs[len(runDirs)-1], nil
+	}
+
+	summaryPath, err := utils.ResolveSummaryPath(run, summaryFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(summaryPath), nil
+}
+
+// follow prints output appended to a log until the run finishes or the user
+// interrupts
+func follow(file *os.File, summaryPath string) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+		}
+
+		// Check the status before reading so that no output written before
+		// the run finished is missed
+		runInfo, err := utils.ParseRunInfo(summaryPath)
+		finished := err == nil && !runInfo.IsRunning
+		if _, err := io.Copy(os.Stdout, file); err != nil {
+			return err
+		}
+		if finished {
+			return nil
+		}
+	}
+}
+
+// tailOffset returns the offset of the last n lines of a file
+func tailOffset(file *os.File, n int) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// Read backwards in chunks, counting newlines; a trailing newline does
+	// not start another line
+	const chunkSize = 64 * 1024
+	buf := make([]byte, chunkSize)
+	end := info.Size()
+	offset := end
+	count := 0
+	for offset > 0 {
+		size := min(int64(chunkSize), offset)
+		offset -= size
+		chunk := buf[:size]
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || offset+int64(i) == end-1 {
+				continue
+			}
+			count++
+			if count == n {
+				return offset + int64(i) + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main tabulates the metrics of runs selected by the list filters with
+// their statistics, showing only the named metrics if any
+func Main(names []string) error {
+	cfg := config.Get()
+	cfg.List.Archived = false
+	cfg.List.AllProjects = false
+
+	runs, err := list.Find(cfg)
+	if err != nil {
+		return err
+	}
+	runs = readMetrics(runs)
+	if len(runs) == 0 {
+		log.Info("No metrics found in the selected runs")
+		return nil
+	}
+	if len(names) == 0 {
+		names = metricNames(runs)
+	}
+	if cfg.Metrics.SortBy != "" {
+		if err := list.SortRuns(runs, "metric:"+cfg.Metrics.SortBy, cfg.Metrics.Reverse); err != nil {
+			return err
+		}
+	}
+	output := NewOutput(runs, names)
+
+	switch cfg.Metrics.Format {
+	case "table":
+		fmt.Println(utils.RenderTable(append([]string{"Directory"}, names...), output.rows(names, utils.FormatMetric)))
+		return nil
+	case "csv":
+		return output.writeCSV(names)
+	case "json":
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	default:
+		return fmt.Errorf("invalid output format: %s", cfg.Metrics.Format)
+	}
+}
+
+// readMetrics returns runs with metrics, reading metrics files of runs that
+// have not recorded metrics in their summaries (e.g., running runs)
+func readMetrics(runs []utils.RunInfo) []utils.RunInfo {
+	var selected []utils.RunInfo
+	for _, run := range runs {
+		if len(run.Metrics) == 0 {
+			metrics, err := utils.ReadMetrics(run.Directory)
+			if err != nil {
+				log.Warnf("Failed to read metrics of %s: %v", run.Directory, err)
+			}
+			run.Metrics = metrics
+		}
+		if len(run.Metrics) > 0 {
+			selected = append(selected, run)
+		}
+	}
+	return selected
+}
+
+// metricNames returns the sorted names of all metrics of runs
+func metricNames(runs []utils.RunInfo) []string {
+	names := map[string]bool{}
+	for _, run := range runs {
+		for name := range run.Metrics {
+			names[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// Stat is the statistics of a metric across runs
+type Stat struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+}
+
+// RunMetrics is the metrics of a run
+type RunMetrics struct {
+	Directory string             `json:"directory"`
+	Metrics   map[string]float64 `json:"metrics"`
+}
+
+// Output is the structure of metrics output in JSON
+type Output struct {
+	Runs  []RunMetrics    `json:"runs"`
+	Stats map[string]Stat `json:"stats"`
+}
+
+// NewOutput returns the metrics of runs and their statistics for the named
+// metrics
+func NewOutput(runs []utils.RunInfo, names []string) Output {
+	output := Output{Runs: []RunMetrics{}, Stats: map[string]Stat{}}
+	for _, run := range runs {
+		metrics := map[string]float64{}
+		for _, name := range names {
+			if value, ok := run.Metrics[name]; ok {
+				metrics[name] = value
+			}
+		}
+		output.Runs = append(output.Runs, RunMetrics{Directory: run.Directory, Metrics: metrics})
+	}
+	for _, name := range names {
+		stat := Stat{Min: math.Inf(1), Max: math.Inf(-1)}
+		sum := 0.0
+		for _, run := range output.Runs {
+			if value, ok := run.Metrics[name]; ok {
+				stat.Count++
+				stat.Min = min(stat.Min, value)
+				stat.Max = max(stat.Max, value)
+				sum += value
+			}
+		}
+		if stat.Count > 0 {
+			stat.Mean = sum / float64(stat.Count)
+			output.Stats[name] = stat
+		}
+	}
+	return output
+}
+
+// rows returns a row of the named metrics for each run followed by rows of
+// their minimums, maximums, and means
+func (o Output) rows(names []string, format func(float64) string) [][]string {
+	var rows [][]string
+	for _, run := range o.Runs {
+		row := []string{run.Directory}
+		for _, name := range names {
+			value, ok := run.Metrics[name]
+			row = append(row, formatValue(value, ok, format))
+		}
+		rows = append(rows, row)
+	}
+	for _, label := range []string{"min", "max", "mean"} {
+		row := []string{label}
+		for _, name := range names {
+			stat, ok := o.Stats[name]
+			value := map[string]float64{"min": stat.Min, "max": stat.Max, "mean": stat.Mean}[label]
+			row = append(row, formatValue(value, ok, format))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatValue(value float64, ok bool, format func(float64) string) string {
+	if !ok {
+		return ""
+	}
+	return format(value)
+}
+
+// writeCSV writes the rows of metrics as CSV
+func (o Output) writeCSV(names []string) error {
+	w := csv.NewWriter(os.Stdout)
+	rows := o.rows(names, func(value float64) string {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	})
+	if err := w.Write(append([]string{"Directory"}, names...)); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main upgrades the metadata of runs in the base directory written by older
+// versions to the current format
+func Main() error {
+	cfg := config.Get()
+
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return err
+	}
+
+	migrated, failed := 0, 0
+	for _, runDir := range runDirs {
+		summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+		changes, err := utils.MigrateSummary(summaryPath, cfg.Migrate.DryRun)
+		if err != nil {
+			log.Errorf("Failed to migrate %s: %v", runDir, err)
+			failed++
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		migrated++
+		if cfg.Migrate.DryRun {
+			log.Infof("Would migrate %s: %s", runDir, strings.Join(changes, ", "))
+		} else {
+			log.Infof("Migrated %s: %s", runDir, strings.Join(changes, ", "))
+		}
+	}
+
+	if cfg.Migrate.DryRun {
+		log.Infof("Dry run: %d of %d run(s) would be migrated", migrated, len(runDirs))
+	} else {
+		log.Infof("Migrated %d of %d run(s)", migrated, len(runDirs))
+		index.Update(cfg.BaseDir, cfg.SummaryFile)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to migrate %d of %d runs", failed, len(runDirs))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main appends a note to the summary of a run, prompting for it with the
+// editor if no text is given
+func Main(run string, words []string) error {
+	summaryPath, err := utils.ResolveSummaryPath(run, config.Get().SummaryFile)
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+
+	text := strings.Join(words, " ")
+	if text == "" {
+		text, err = utils.GetUserInput()
+		if err != nil {
+			return err
+		}
+	}
+
+	note := utils.Note{Time: time.Now(), Text: text}
+	if err := utils.AppendSummaryNote(summaryPath, note); err != nil {
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		log.Warnf("Failed to write %s: %v", utils.SummaryJSONFile, err)
+	}
+	log.Infof("Added note to %s", summaryPath)
+	return nil
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Payload is the JSON body sent to webhooks when a run finishes
+type Payload struct {
+	Directory       string    `json:"run_dir"`
+	Command         string    `json:"command"`
+	Status          string    `json:"status"`
+	Success         bool      `json:"success"`
+	ExitCode        int       `json:"exit_code"`
+	Duration        string    `json:"duration"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Hostname        string    `json:"hostname"`
+	Branch          string    `json:"branch"`
+	CommitHash      string    `json:"commit_hash"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+
+	Issues []utils.IssueLink `json:"issues,omitempty"`
+}
+
+// NewPayload returns the payload describing a finished run, linking its
+// issues with URLs made from issueURL (see utils.IssueLinks)
+func NewPayload(run utils.RunInfo, issueURL string) Payload {
+	hostname := run.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	return Payload{
+		Directory:       run.Directory,
+		Command:         run.Command,
+		Status:          utils.StatusString(run),
+		Success:         run.Success,
+		ExitCode:        run.ExitStatus,
+		Duration:        run.Duration(),
+		DurationSeconds: run.EndTime.Sub(run.StartTime).Seconds(),
+		Hostname:        hostname,
+		Branch:          run.Branch,
+		CommitHash:      run.CommitHash,
+		StartTime:       run.StartTime,
+		EndTime:         run.EndTime,
+		Issues:          utils.IssueLinks(run.Issues, issueURL),
+	}
+}
+
+// RunFinished sends a notification of a finished run to the configured
+// webhooks and Slack; failures are logged and do not affect the run
+func RunFinished(run utils.RunInfo) {
+	cfg := config.Get()
+	if len(cfg.Notify.Webhooks) == 0 && cfg.Notify.Slack.WebhookURL == "" {
+		return
+	}
+	notify, err := shouldNotify(run, cfg.Notify.On, cfg.Notify.MinDuration)
+	if err != nil {
+		log.Warnf("Invalid notification rule: %v", err)
+		return
+	}
+	if !notify {
+		log.Debug("Notification skipped by rules")
+		return
+	}
+	timeout, err := time.ParseDuration(cfg.Notify.Timeout)
+	if err != nil {
+		log.Warnf("Invalid notification timeout: %s", cfg.Notify.Timeout)
+		return
+	}
+	client := &http.Client{Timeout: timeout}
+
+	if len(cfg.Notify.Webhooks) > 0 {
+		body, err := json.Marshal(NewPayload(run, cfg.Run.IssueURL))
+		if err != nil {
+			log.Warnf("Failed to encode notification: %v", err)
+			return
+		}
+		for _, url := range cfg.Notify.Webhooks {
+			if err := post(client, url, body, cfg.Notify.Retries); err != nil {
+				log.Warnf("Failed to send notification to %s: %v", url, err)
+			}
+		}
+	}
+
+	if url := cfg.Notify.Slack.WebhookURL; url != "" {
+		stderrPath := filepath.Join(run.Directory, cfg.Run.StderrFile)
+		body, err := json.Marshal(slackMessage(run, cfg.Run.IssueURL, stderrPath, cfg.Notify.Slack.StderrLines))
+		if err != nil {
+			log.Warnf("Failed to encode Slack notification: %v", err)
+			return
+		}
+		if err := post(client, url, body, cfg.Notify.Retries); err != nil {
+			log.Warnf("Failed to send Slack notification: %v", err)
+		}
+	}
+}
+
+// outcomes are the outcomes of runs that notifications can be limited to
+var outcomes = []string{"success", "failure", "interrupted", "timeout"}
+
+// outcome classifies a finished run as one of outcomes
+func outcome(run utils.RunInfo) string {
+	switch {
+	case run.Success:
+		return "success"
+	case run.TimedOut:
+		return "timeout"
+	case run.Interrupted:
+		return "interrupted"
+	default:
+		return "failure"
+	}
+}
+
+// shouldNotify reports whether a run is notified under the rules, which
+// apply to all destinations alike
+func shouldNotify(run utils.RunInfo, on []string, minDuration string) (bool, error) {
+	for _, o := range on {
+		if !slices.Contains(outcomes, o) {
+			return false, fmt.Errorf("unknown outcome %q (expected one of %s)", o, strings.Join(outcomes, ", "))
+		}
+	}
+	if !slices.Contains(on, outcome(run)) {
+		return false, nil
+	}
+	if minDuration != "" {
+		d, err := time.ParseDuration(minDuration)
+		if err != nil {
+			return false, fmt.Errorf("invalid minimum duration: %s", minDuration)
+		}
+		if run.EndTime.Sub(run.StartTime) < d {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// initialBackoff is the delay before the first retry, doubled for each
+// further retry
+var initialBackoff = time.Second
+
+// post sends body to url, retrying with exponential backoff on network
+// errors, rate limiting, and server errors
+func post(client *http.Client, url string, body []byte, retries int) error {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := postOnce(client, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= retries {
+			return err
+		}
+		log.Debugf("Retrying notification to %s in %s: %v", url, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postOnce sends body to url once and reports whether a failure may be retried
+func postOnce(client *http.Client, url string, body []byte) (bool, error) {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected status: %s", resp.Status)
+}
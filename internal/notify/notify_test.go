@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRun returns a finished run linked to issues
+func testRun() utils.RunInfo {
+	start := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	return utils.RunInfo{
+		Directory:  "runs/2025-03-24T00:00:00.000_main_1234567",
+		Command:    "python train.py",
+		StartTime:  start,
+		EndTime:    start.Add(90 * time.Second),
+		Success:    true,
+		Branch:     "main",
+		CommitHash: "1234567890abcdef",
+		Hostname:   "gpu1",
+		Issues:     []string{"PROJ-42", "#7"},
+	}
+}
+
+func TestPayloadIssues(t *testing.T) {
+	payload := NewPayload(testRun(), "https://github.com/me/proj/issues/{id}")
+	assert.Equal(t, []utils.IssueLink{
+		{ID: "PROJ-42", URL: "https://github.com/me/proj/issues/PROJ-42"},
+		{ID: "#7", URL: "https://github.com/me/proj/issues/7"},
+	}, payload.Issues)
+
+	payload = NewPayload(testRun(), "")
+	assert.Equal(t, []utils.IssueLink{{ID: "PROJ-42"}, {ID: "#7"}}, payload.Issues)
+}
+
+func TestSlackMessageIssues(t *testing.T) {
+	message := slackMessage(testRun(), "https://tracker.example.com/browse/{id}", "", 0)
+	assert.Contains(t, message.Text, "*Issues*: <https://tracker.example.com/browse/PROJ-42|PROJ-42>, <https://tracker.example.com/browse/7|#7>\n")
+
+	message = slackMessage(testRun(), "", "", 0)
+	assert.Contains(t, message.Text, "*Issues*: PROJ-42, #7\n")
+
+	run := testRun()
+	run.Issues = nil
+	assert.NotContains(t, slackMessage(run, "", "", 0).Text, "Issues")
+}
+
+// failingServer returns a server that responds with status to the first
+// failures requests and with 200 afterwards, counting the requests
+func failingServer(t *testing.T, failures, status int) (*httptest.Server, *int) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= failures {
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func TestPostRetry(t *testing.T) {
+	saved := initialBackoff
+	t.Cleanup(func() { initialBackoff = saved })
+	initialBackoff = time.Millisecond
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		server, attempts := failingServer(t, 2, http.StatusServiceUnavailable)
+		require.NoError(t, post(server.Client(), server.URL, []byte("{}"), 3))
+		assert.Equal(t, 3, *attempts)
+	})
+
+	t.Run("retries rate limiting", func(t *testing.T) {
+		server, attempts := failingServer(t, 1, http.StatusTooManyRequests)
+		require.NoError(t, post(server.Client(), server.URL, []byte("{}"), 1))
+		assert.Equal(t, 2, *attempts)
+	})
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		server, attempts := failingServer(t, 5, http.StatusInternalServerError)
+		err := post(server.Client(), server.URL, []byte("{}"), 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "500")
+		assert.Equal(t, 3, *attempts)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		server, attempts := failingServer(t, 5, http.StatusBadRequest)
+		require.Error(t, post(server.Client(), server.URL, []byte("{}"), 3))
+		assert.Equal(t, 1, *attempts)
+	})
+
+	t.Run("retries network errors", func(t *testing.T) {
+		server, _ := failingServer(t, 0, http.StatusOK)
+		url := server.URL
+		server.Close()
+		require.Error(t, post(server.Client(), url, []byte("{}"), 1))
+	})
+}
+
+func TestShouldNotify(t *testing.T) {
+	failed := testRun()
+	failed.Success = false
+	timedOut := failed
+	timedOut.TimedOut = true
+	interrupted := failed
+	interrupted.Interrupted = true
+
+	tests := []struct {
+		name        string
+		run         utils.RunInfo
+		on          []string
+		minDuration string
+		want        bool
+		wantErr     bool
+	}{
+		{name: "success", run: testRun(), on: []string{"success", "failure"}, want: true},
+		{name: "failure", run: failed, on: []string{"failure"}, want: true},
+		{name: "outcome not selected", run: testRun(), on: []string{"failure"}, want: false},
+		{name: "timeout", run: timedOut, on: []string{"timeout"}, want: true},
+		{name: "timeout is not failure", run: timedOut, on: []string{"failure"}, want: false},
+		{name: "interrupted", run: interrupted, on: []string{"interrupted"}, want: true},
+		{name: "no outcomes", run: testRun(), on: nil, want: false},
+		{name: "longer than minimum", run: testRun(), on: []string{"success"}, minDuration: "1m", want: true},
+		{name: "exactly minimum", run: testRun(), on: []string{"success"}, minDuration: "90s", want: true},
+		{name: "shorter than minimum", run: testRun(), on: []string{"success"}, minDuration: "2m", want: false},
+		{name: "unknown outcome", run: testRun(), on: []string{"success", "crash"}, wantErr: true},
+		{name: "invalid minimum", run: testRun(), on: []string{"success"}, minDuration: "long", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shouldNotify(tt.run, tt.on, tt.minDuration)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
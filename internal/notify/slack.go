@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// maxStderrBytes limits the stderr included in a Slack message, which Slack
+// truncates at around 40,000 characters
+const maxStderrBytes = 3000
+
+// slackPayload is the body of a message sent to a Slack incoming webhook
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// slackMessage formats a finished run as a Slack message, with links to its
+// issues and the last lines of stderr if the run failed
+func slackMessage(run utils.RunInfo, issueURL, stderrPath string, stderrLines int) slackPayload {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s*: `%s`\n", statusEmoji(run), utils.StatusString(run), run.Command)
+	fmt.Fprintf(&b, "*Directory*: `%s`\n", run.Directory)
+	fmt.Fprintf(&b, "*Duration*: %s\n", run.Duration())
+	fmt.Fprintf(&b, "*Branch*: `%s` (`%s`)\n", run.Branch, shortHash(run.CommitHash))
+	if run.Hostname != "" {
+		fmt.Fprintf(&b, "*Host*: `%s`\n", run.Hostname)
+	}
+	if len(run.Issues) > 0 {
+		var issues []string
+		for _, link := range utils.IssueLinks(run.Issues, issueURL) {
+			if link.URL != "" {
+				issues = append(issues, fmt.Sprintf("<%s|%s>", link.URL, link.ID))
+			} else {
+				issues = append(issues, link.ID)
+			}
+		}
+		fmt.Fprintf(&b, "*Issues*: %s\n", strings.Join(issues, ", "))
+	}
+	if !run.Success && stderrLines > 0 {
+		if tail, err := tailFile(stderrPath, stderrLines); err == nil && tail != "" {
+			fmt.Fprintf(&b, "*stderr*:\n```\n%s\n```\n", tail)
+		}
+	}
+	return slackPayload{Text: b.String()}
+}
+
+// statusEmoji returns an emoji code representing the status of a run
+func statusEmoji(run utils.RunInfo) string {
+	switch {
+	case run.Success:
+		return ":white_check_mark:"
+	case run.TimedOut:
+		return ":hourglass:"
+	case run.Interrupted:
+		return ":warning:"
+	default:
+		return ":x:"
+	}
+}
+
+// shortHash abbreviates a commit hash
+func shortHash(hash string) string {
+	return hash[:min(7, len(hash))]
+}
+
+// tailFile returns up to n last lines of a file, limited to maxStderrBytes
+func tailFile(path string, n int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(info.Size()-maxStderrBytes, 0)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		// The first line is likely partial
+		lines = lines[1:]
+	}
+	return strings.Join(lines[max(0, len(lines)-n):], "\n"), nil
+}
@@ -0,0 +1,190 @@
+package objects
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Dir is the directory of the object store in a base directory, where
+// artifacts are stored by their SHA-256 hashes
+const Dir = ".moco/objects"
+
+// objectPath returns the path of an object in the store of a base directory
+func objectPath(baseDir, hash string) string {
+	return filepath.Join(baseDir, Dir, hash[:2], hash[2:])
+}
+
+// ValidateLink checks the kind of links replacing deduplicated artifacts
+func ValidateLink(link string) error {
+	if link != "hardlink" && link != "symlink" {
+		return fmt.Errorf("invalid link: %s (expected hardlink or symlink)", link)
+	}
+	return nil
+}
+
+// Dedup moves artifacts of a run into the object store and replaces them
+// with links to the stored objects, sharing identical artifacts between
+// runs; objects are made read-only so that modifying an artifact in place
+// does not change other runs
+func Dedup(baseDir, runDir string, artifacts []utils.Artifact, link string) error {
+	var count int
+	var saved int64
+	for _, artifact := range artifacts {
+		file := filepath.Join(runDir, filepath.FromSlash(artifact.Path))
+		object := objectPath(baseDir, artifact.SHA256)
+
+		info, err := os.Lstat(file)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue // already deduplicated
+		}
+
+		if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+			return fmt.Errorf("failed to create object directory: %w", err)
+		}
+		_, err = os.Stat(object)
+		exists := err == nil
+		if exists {
+			saved += artifact.Size
+			// Objects are shared by all the runs linking to them
+			if err := os.Chmod(object, 0444); err != nil {
+				return err
+			}
+		} else {
+			// Store the artifact itself as a new object
+			if link == "hardlink" {
+				err = os.Link(file, object)
+			} else {
+				err = os.Rename(file, object)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to store %s: %w", artifact.Path, err)
+			}
+			if err := os.Chmod(object, 0444); err != nil {
+				return err
+			}
+			if link == "hardlink" {
+				count++
+				continue
+			}
+		}
+
+		if err := replaceWithLink(file, object, link); err != nil {
+			return fmt.Errorf("failed to link %s: %w", artifact.Path, err)
+		}
+		count++
+	}
+
+	if count > 0 {
+		log.Infof("Deduplicated %d artifact(s), saving %s", count, utils.FormatSize(saved))
+	}
+	return nil
+}
+
+// replaceWithLink atomically replaces a file with a link to an object;
+// symbolic links are relative so that base directories can be moved
+func replaceWithLink(file, object, link string) error {
+	tmp := file + ".moco-link"
+	if link == "hardlink" {
+		if err := os.Link(object, tmp); err != nil {
+			return err
+		}
+	} else {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		absObject, err := filepath.Abs(object)
+		if err != nil {
+			return err
+		}
+		target, err := filepath.Rel(filepath.Dir(absFile), absObject)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// GC deletes objects that are no longer referenced by any run, i.e., that
+// have no other hard links and are not artifacts of any run
+func GC() error {
+	cfg := config.Get()
+
+	// Artifacts of runs may be symbolic links to objects
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return err
+	}
+	referenced := map[string]bool{}
+	for _, runDir := range runDirs {
+		artifacts, err := utils.ReadArtifacts(runDir)
+		if err != nil {
+			return fmt.Errorf("failed to read artifacts of %s: %w", runDir, err)
+		}
+		for _, artifact := range artifacts {
+			referenced[artifact.SHA256] = true
+		}
+	}
+
+	var count int
+	var freed int64
+	root := filepath.Join(cfg.BaseDir, Dir)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		hash := filepath.Base(filepath.Dir(path)) + d.Name()
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if referenced[hash] || links(info) > 1 {
+			return nil
+		}
+
+		count++
+		freed += info.Size()
+		if cfg.Objects.DryRun {
+			log.Infof("Would delete %s (%s)", hash, utils.FormatSize(info.Size()))
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		// Remove the directory of the prefix when it becomes empty
+		os.Remove(filepath.Dir(path))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.Objects.DryRun {
+		log.Infof("Dry run: %d unreferenced object(s) would free %s", count, utils.FormatSize(freed))
+	} else {
+		log.Infof("Deleted %d unreferenced object(s), freeing %s", count, utils.FormatSize(freed))
+	}
+	return nil
+}
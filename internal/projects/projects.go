@@ -0,0 +1,97 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/charmbracelet/log"
+)
+
+// Add registers projects in the user-level configuration
+func Add(paths []string) error {
+	projects, err := config.UserProjects()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		if info, err := os.Stat(absPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", path)
+		}
+		if slices.Contains(projects, absPath) {
+			log.Infof("Already registered: %s", absPath)
+			continue
+		}
+		projects = append(projects, absPath)
+		log.Infof("Registered project: %s", absPath)
+	}
+	return config.SetUserProjects(projects)
+}
+
+// Remove unregisters projects from the user-level configuration
+func Remove(paths []string) error {
+	projects, err := config.UserProjects()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		i := slices.Index(projects, absPath)
+		if i < 0 {
+			return fmt.Errorf("not registered: %s", absPath)
+		}
+		projects = slices.Delete(projects, i, i+1)
+		log.Infof("Unregistered project: %s", absPath)
+	}
+	return config.SetUserProjects(projects)
+}
+
+// List prints the registered projects
+func List() error {
+	for _, path := range config.Get().Projects.Paths {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// Project is a registered project with its configuration
+type Project struct {
+	Name   string
+	Path   string
+	Config config.Config
+}
+
+// Load loads the configurations of the registered projects
+func Load() []Project {
+	var projects []Project
+	for _, path := range config.Get().Projects.Paths {
+		cfg, err := config.LoadProject(path)
+		if err != nil {
+			log.Warnf("Failed to load configuration of %s: %v", path, err)
+			continue
+		}
+		projects = append(projects, Project{
+			Name:   filepath.Base(path),
+			Path:   path,
+			Config: cfg,
+		})
+	}
+	return projects
+}
+
+// BaseDir returns the base directory of a project
+func (p Project) BaseDir() string {
+	if filepath.IsAbs(p.Config.BaseDir) {
+		return p.Config.BaseDir
+	}
+	return filepath.Join(p.Path, p.Config.BaseDir)
+}
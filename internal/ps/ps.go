@@ -0,0 +1,103 @@
+package ps
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Process states of running runs
+const (
+	stateAlive   = "alive"
+	stateStale   = "stale"
+	stateUnknown = "unknown"
+)
+
+// Process is the process of a run whose summary says it is running
+type Process struct {
+	Run   utils.RunInfo
+	PID   int
+	State string
+}
+
+// Main lists running experiments with their processes
+func Main() error {
+	cfg := config.Get()
+
+	processes, err := FindProcesses(cfg.BaseDir, cfg.SummaryFile)
+	if err != nil {
+		return err
+	}
+	if len(processes) == 0 {
+		log.Info("No running experiments")
+		return nil
+	}
+
+	// Show processes along with the run list columns
+	byDir := map[string]Process{}
+	runs := make([]utils.RunInfo, 0, len(processes))
+	stale := 0
+	for _, process := range processes {
+		byDir[process.Run.Directory] = process
+		runs = append(runs, process.Run)
+		if process.State == stateStale {
+			stale++
+		}
+	}
+	pid := utils.Column{Header: "PID", Value: func(run utils.RunInfo) string {
+		if p := byDir[run.Directory].PID; p > 0 {
+			return strconv.Itoa(p)
+		}
+		return "-"
+	}}
+	state := utils.Column{Header: "Process", Value: func(run utils.RunInfo) string {
+		return byDir[run.Directory].State
+	}}
+	fmt.Println(utils.RenderRunInfos(runs, pid, state))
+
+	if stale > 0 {
+		log.Warnf("%d run(s) are stale: the process is gone but the summary was not finished", stale)
+	}
+	return nil
+}
+
+// FindProcesses returns the processes of runs whose summaries say they are
+// running; processes on other hosts or without a PID file are in an unknown state
+func FindProcesses(baseDir, summaryFile string) ([]Process, error) {
+	idx := index.Open(baseDir, summaryFile)
+	runs, err := idx.Runs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find runs: %w", err)
+	}
+	if err := idx.Save(); err != nil {
+		log.Warnf("Failed to save index: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	var processes []Process
+	for _, run := range runs {
+		if !run.IsRunning {
+			continue
+		}
+		process := Process{Run: run, State: stateUnknown}
+		pid, err := utils.ReadPIDFile(run.Directory)
+		if err == nil {
+			process.PID = pid
+			if run.Hostname == "" || run.Hostname == hostname {
+				process.State = stateStale
+				if utils.ProcessAlive(pid) {
+					process.State = stateAlive
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			log.Warnf("Failed to read PID of %s: %v", run.Directory, err)
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
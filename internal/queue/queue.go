@@ -0,0 +1,397 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// queueDir is the directory in the base directory holding queued jobs
+const queueDir = ".moco-queue"
+
+// Job states, each of which is a subdirectory of the queue directory; a job
+// is claimed by moving its file from pending to running, which is atomic
+const (
+	statePending = "pending"
+	stateRunning = "running"
+	stateDone    = "done"
+)
+
+// Job is a command waiting to run, running, or finished through the queue
+type Job struct {
+	ID       string    `json:"id"`
+	Args     []string  `json:"args"` // arguments of moco run
+	Dir      string    `json:"dir"`  // working directory in which the job was enqueued
+	Enqueued time.Time `json:"enqueued"`
+
+	Started  time.Time `json:"started,omitzero"`
+	Finished time.Time `json:"finished,omitzero"`
+	Hostname string    `json:"hostname,omitempty"`
+	Worker   int       `json:"worker,omitempty"` // PID of the worker running the job
+	ExitCode int       `json:"exit_code"`
+
+	state string
+}
+
+// Command returns the command of the job
+func (j Job) Command() []string {
+	if i := slices.Index(j.Args, "--"); i >= 0 {
+		return j.Args[i+1:]
+	}
+	return j.Args
+}
+
+// Queue is a queue of jobs stored in the base directory
+type Queue struct {
+	dir string
+}
+
+// Open returns the queue of a base directory
+func Open(baseDir string) *Queue {
+	return &Queue{dir: filepath.Join(baseDir, queueDir)}
+}
+
+// Enqueue adds a job running moco run with args in the current directory
+func Enqueue(args []string) error {
+	cfg := config.Get()
+	q := Open(cfg.BaseDir)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	job := Job{Args: args, Dir: dir, Enqueued: time.Now()}
+	if err := q.add(&job); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	log.Infof("Queued job %s: %s", job.ID, shellescape.QuoteCommand(job.Command()))
+	return nil
+}
+
+// add writes a new pending job with a unique ID
+func (q *Queue) add(job *Job) error {
+	pendingDir := filepath.Join(q.dir, statePending)
+	if err := os.MkdirAll(pendingDir, 0755); err != nil {
+		return err
+	}
+	for {
+		// IDs sort in the order of enqueueing
+		job.ID = time.Now().Format("20060102-150405.000000")
+		file, err := os.OpenFile(filepath.Join(pendingDir, job.ID+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = json.NewEncoder(file).Encode(job)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// jobs returns the jobs in a state ordered by ID
+func (q *Queue) jobs(state string) ([]Job, error) {
+	entries, err := os.ReadDir(filepath.Join(q.dir, state))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		job, err := q.read(state, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			// The job may have moved to another state in the meantime
+			log.Debugf("Failed to read job %s: %v", entry.Name(), err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// read reads a job in a state
+func (q *Queue) read(state, id string) (Job, error) {
+	data, err := os.ReadFile(q.path(state, id))
+	if err != nil {
+		return Job{}, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, fmt.Errorf("invalid job file %s: %w", q.path(state, id), err)
+	}
+	job.state = state
+	return job, nil
+}
+
+// write replaces the file of a job in its state
+func (q *Queue) write(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	tmpPath := q.path(job.state, job.ID) + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, q.path(job.state, job.ID))
+}
+
+// move moves a job to another state
+func (q *Queue) move(job *Job, state string) error {
+	if err := os.MkdirAll(filepath.Join(q.dir, state), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(q.path(job.state, job.ID), q.path(state, job.ID)); err != nil {
+		return err
+	}
+	job.state = state
+	return nil
+}
+
+// path returns the file path of a job in a state
+func (q *Queue) path(state, id string) string {
+	return filepath.Join(q.dir, state, id+".json")
+}
+
+// claim takes the oldest pending job for this process, or returns false if
+// no job is pending
+func (q *Queue) claim() (Job, bool, error) {
+	for {
+		pending, err := q.jobs(statePending)
+		if err != nil || len(pending) == 0 {
+			return Job{}, false, err
+		}
+		job := pending[0]
+		if err := q.move(&job, stateRunning); err != nil {
+			if os.IsNotExist(err) {
+				// Claimed by another worker first
+				continue
+			}
+			return Job{}, false, err
+		}
+
+		job.Started = time.Now()
+		job.Hostname, _ = os.Hostname()
+		job.Worker = os.Getpid()
+		return job, true, q.write(job)
+	}
+}
+
+// recover moves jobs left running by workers that are gone back to pending
+func (q *Queue) recover() error {
+	running, err := q.jobs(stateRunning)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	for _, job := range running {
+		if job.Hostname != hostname || utils.ProcessAlive(job.Worker) {
+			continue
+		}
+		log.Warnf("Requeuing job %s whose worker is gone", job.ID)
+		job.Started, job.Hostname, job.Worker = time.Time{}, "", 0
+		if err := q.write(job); err != nil {
+			return err
+		}
+		if err := q.move(&job, statePending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start runs pending jobs with at most the configured number of jobs at a
+// time until the queue is drained
+func Start() error {
+	cfg := config.Get()
+	if cfg.Queue.Workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", cfg.Queue.Workers)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find moco executable: %w", err)
+	}
+
+	q := Open(cfg.BaseDir)
+	if err := q.recover(); err != nil {
+		return fmt.Errorf("failed to recover jobs: %w", err)
+	}
+
+	// Running jobs receive interrupts from the terminal themselves; the
+	// workers only stop taking new jobs
+	var stopping atomic.Bool
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		for range interrupt {
+			if !stopping.Swap(true) {
+				log.Warn("Stopping after the running jobs finish")
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	log.Infof("Starting %d worker(s)", cfg.Queue.Workers)
+	for range cfg.Queue.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stopping.Load() {
+				job, ok, err := q.claim()
+				if err != nil {
+					log.Errorf("Failed to claim job: %v", err)
+					return
+				}
+				if !ok {
+					return
+				}
+				if !q.execute(executable, job) {
+					failed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d job(s) failed", n)
+	}
+	log.Info("Queue drained")
+	return nil
+}
+
+// execute runs a claimed job with moco run and records its result, reporting
+// whether it succeeded
+func (q *Queue) execute(executable string, job Job) bool {
+	log.Infof("Running job %s: %s", job.ID, shellescape.QuoteCommand(job.Command()))
+
+	// Outputs of commands running concurrently would be interleaved, so
+	// they are only written to the log files
+	cmd := exec.Command(executable, append([]string{"run", "--silent"}, job.Args...)...)
+	cmd.Dir = job.Dir
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	job.Finished = time.Now()
+	job.ExitCode = 0
+	if err != nil {
+		job.ExitCode = 1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			job.ExitCode = exitErr.ExitCode()
+		}
+		log.Errorf("Job %s failed: %v", job.ID, err)
+	} else {
+		log.Infof("Job %s finished", job.ID)
+	}
+
+	if err := q.write(job); err != nil {
+		log.Errorf("Failed to record result of job %s: %v", job.ID, err)
+	} else if err := q.move(&job, stateDone); err != nil {
+		log.Errorf("Failed to record result of job %s: %v", job.ID, err)
+	}
+	return err == nil
+}
+
+// List prints the jobs in the queue
+func List() error {
+	cfg := config.Get()
+	q := Open(cfg.BaseDir)
+
+	var all []Job
+	for _, state := range []string{stateDone, stateRunning, statePending} {
+		jobs, err := q.jobs(state)
+		if err != nil {
+			return fmt.Errorf("failed to read queue: %w", err)
+		}
+		all = append(all, jobs...)
+	}
+	if len(all) == 0 {
+		log.Info("No jobs in the queue")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(all))
+	for _, job := range all {
+		rows = append(rows, []string{
+			job.ID,
+			jobStatus(job),
+			job.Enqueued.Format("2006-01-02 15:04:05"),
+			ansi.Truncate(shellescape.QuoteCommand(job.Command()), 60, "…"),
+		})
+	}
+	fmt.Println(utils.RenderTable([]string{"ID", "Status", "Enqueued", "Command"}, rows))
+	return nil
+}
+
+// jobStatus describes the state of a job
+func jobStatus(job Job) string {
+	switch {
+	case job.state == statePending:
+		return "Pending"
+	case job.state == stateRunning:
+		return "Running"
+	case job.ExitCode == 0:
+		return "Done"
+	default:
+		return fmt.Sprintf("Failed (exit: %d)", job.ExitCode)
+	}
+}
+
+// Remove removes pending jobs, or finished jobs if ids is empty
+func Remove(ids []string) error {
+	cfg := config.Get()
+	q := Open(cfg.BaseDir)
+
+	if len(ids) == 0 {
+		done, err := q.jobs(stateDone)
+		if err != nil {
+			return err
+		}
+		for _, job := range done {
+			if err := os.Remove(q.path(stateDone, job.ID)); err != nil {
+				return err
+			}
+		}
+		log.Infof("Removed %d finished job(s)", len(done))
+		return nil
+	}
+
+	for _, id := range ids {
+		err := os.Remove(q.path(statePending, id))
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no pending job: %s", id)
+		}
+		if err != nil {
+			return err
+		}
+		log.Infof("Removed job %s", id)
+	}
+	return nil
+}
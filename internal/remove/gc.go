@@ -0,0 +1,179 @@
+package remove
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// orphan is a run directory without a summary
+type orphan struct {
+	dir    string
+	reason string
+	size   int64
+}
+
+// bookkeepingFiles are written by moco to a run directory before or along
+// with its summary; directories holding only these have no outputs to lose
+var bookkeepingFiles = []string{
+	utils.EventsFile,
+	utils.PIDFile,
+	utils.HeartbeatFile,
+	utils.SummaryJSONFile,
+	config.SnapshotFile,
+}
+
+// GC deletes run directories without a summary, e.g., left by moco crashing
+// before writing it, that hold no outputs and have not been modified within
+// the grace period; directories with an invalid summary or with outputs are
+// only reported, since they may hold results
+func GC() error {
+	cfg := config.Get()
+
+	grace, err := time.ParseDuration(cfg.GC.Grace)
+	if err != nil || grace < 0 {
+		return fmt.Errorf("invalid grace period: %s", cfg.GC.Grace)
+	}
+
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return err
+	}
+	var orphans []orphan
+	for _, runDir := range runDirs {
+		summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+		reason := orphanReason(summaryPath)
+		if reason == "" {
+			continue
+		}
+		if reason == invalidSummary {
+			log.Warnf("Keeping %s with an invalid summary (see moco doctor)", runDir)
+			continue
+		}
+		// A run being set up may not have written its summary yet
+		modTime, size, err := dirUsage(runDir)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", runDir, err)
+		}
+		if time.Since(modTime) < grace {
+			continue
+		}
+		outputs, err := hasOutputs(runDir, cfg.SummaryFile)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", runDir, err)
+		}
+		if outputs {
+			log.Warnf("Keeping %s with %s but other files in it", runDir, reason)
+			continue
+		}
+		orphans = append(orphans, orphan{dir: runDir, reason: reason, size: size})
+	}
+	if len(orphans) == 0 {
+		log.Info("No orphaned run directories found")
+		return nil
+	}
+
+	var total int64
+	log.Infof("Found %d orphaned run directory(s):", len(orphans))
+	for _, o := range orphans {
+		log.Infof("  • %s - %s (%s)", o.dir, o.reason, utils.FormatSize(o.size))
+		total += o.size
+	}
+
+	if cfg.GC.DryRun {
+		log.Infof("Dry run completed, %s would be freed", utils.FormatSize(total))
+		return nil
+	}
+	if !cfg.GC.Yes && !confirmRemove() {
+		log.Info("Remove operation cancelled")
+		return nil
+	}
+
+	for _, o := range orphans {
+		if err := os.RemoveAll(o.dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", o.dir, err)
+		}
+		log.Infof("Removed %s", o.dir)
+	}
+	index.Update(cfg.BaseDir, cfg.SummaryFile)
+	return nil
+}
+
+// invalidSummary is the reason of summaries that exist but can't be parsed
+// or lack the start time or the command
+const invalidSummary = "invalid summary"
+
+// orphanReason returns why a summary file is not valid, or an empty string
+// if it is
+func orphanReason(summaryPath string) string {
+	info, err := os.Stat(summaryPath)
+	if os.IsNotExist(err) {
+		return "no summary"
+	} else if err != nil {
+		return err.Error()
+	}
+	if info.Size() == 0 {
+		return "empty summary"
+	}
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil || runInfo.StartTime.IsZero() || runInfo.Command == "" {
+		return invalidSummary
+	}
+	return ""
+}
+
+// hasOutputs reports whether a run directory holds files other than the
+// summary and bookkeeping files of moco
+func hasOutputs(dir, summaryFile string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != summaryFile && !slices.Contains(bookkeepingFiles, rel) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// dirUsage returns the latest modification time and the total size of the
+// files in a directory, including the directory itself
+func dirUsage(dir string) (time.Time, int64, error) {
+	var modTime time.Time
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return modTime, size, err
+}
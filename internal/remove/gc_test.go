@@ -0,0 +1,52 @@
+package remove_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/remove"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGC(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := config.GetPointer()
+	*cfg = config.GetDefault()
+	cfg.BaseDir = baseDir
+	cfg.GC.Grace = "0s"
+	cfg.GC.Yes = true
+
+	// writeRun creates a run directory with files of the given contents
+	writeRun := func(name string, files map[string]string) string {
+		dir := filepath.Join(baseDir, name)
+		require.NoError(t, os.Mkdir(dir, 0755))
+		for file, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+		}
+		return dir
+	}
+	noSummary := writeRun("2025-01-01T00:00:00.000_main_0123456", map[string]string{
+		utils.EventsFile: "{}\n",
+	})
+	emptySummary := writeRun("2025-01-02T00:00:00.000_main_0123456", map[string]string{
+		cfg.SummaryFile:     "",
+		config.SnapshotFile: "",
+	})
+	invalidSummary := writeRun("2025-01-03T00:00:00.000_main_0123456", map[string]string{
+		cfg.SummaryFile: "# edited by hand\n",
+		"model.pt":      "weights",
+	})
+	outputs := writeRun("2025-01-04T00:00:00.000_main_0123456", map[string]string{
+		"model.pt": "weights",
+	})
+
+	require.NoError(t, remove.GC())
+	assert.NoDirExists(t, noSummary)
+	assert.NoDirExists(t, emptySummary)
+	assert.FileExists(t, filepath.Join(invalidSummary, "model.pt"))
+	assert.FileExists(t, filepath.Join(outputs, "model.pt"))
+}
@@ -0,0 +1,144 @@
+package remove
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/archive"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Options holds settings of deleting runs that are not part of the
+// configuration
+type Options struct {
+	// Delete without asking for confirmation, as with --yes
+	Yes bool
+}
+
+// Main deletes run directories after validating them
+func Main(runDirs []string) error {
+	return MainWithOptions(runDirs, Options{})
+}
+
+// MainWithOptions deletes run directories with options given by the caller
+// rather than the command line
+func MainWithOptions(runDirs []string, opts Options) error {
+	// Get config
+	cfg := config.Get()
+
+	// Validate all targets before deleting anything
+	var runInfos []utils.RunInfo
+	for _, runDir := range runDirs {
+		runInfo, err := validateRunDir(runDir, cfg.SummaryFile)
+		if err != nil {
+			return err
+		}
+		if runInfo.IsRunning && !cfg.Remove.Force {
+			return fmt.Errorf("%s is still running, use --force to remove it anyway", runDir)
+		}
+		runInfos = append(runInfos, runInfo)
+	}
+
+	return removeRuns(runInfos, cfg.Remove.DryRun, cfg.Remove.Yes || opts.Yes)
+}
+
+// Clean deletes finished runs in the base directory matching the age and
+// status filters
+func Clean() error {
+	// Get config
+	cfg := config.Get()
+
+	// Filters have the same meaning as for archive
+	cutoff := time.Now().AddDate(1, 0, 0)
+	if cfg.Clean.OlderThan != "" {
+		var err error
+		cutoff, err = archive.ParseCutoff(cfg.Clean.OlderThan)
+		if err != nil {
+			return fmt.Errorf("invalid olderThan format: %w", err)
+		}
+	}
+	switch cfg.Clean.Status {
+	case "success", "failure", "interrupted", "all":
+	default:
+		return fmt.Errorf("invalid status: %s (expected success, failure, interrupted, or all)", cfg.Clean.Status)
+	}
+
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return err
+	}
+	runInfos := archive.FilterRuns(runDirs, cutoff, cfg.Clean.Status)
+	if len(runInfos) == 0 {
+		log.Info("No runs match the specified criteria")
+		return nil
+	}
+
+	return removeRuns(runInfos, cfg.Clean.DryRun, cfg.Clean.Yes)
+}
+
+// removeRuns deletes runs after confirmation
+func removeRuns(runInfos []utils.RunInfo, dryRun, yes bool) error {
+	cfg := config.Get()
+
+	// Show what would be removed
+	log.Infof("Found %d run(s) to remove:", len(runInfos))
+	for _, runInfo := range runInfos {
+		log.Infof("  • %s - %s", runInfo.Directory, utils.StatusString(runInfo))
+	}
+
+	if dryRun {
+		log.Info("Dry run completed, no files were removed")
+		return nil
+	}
+
+	// Confirm with user
+	if !yes && !confirmRemove() {
+		log.Info("Remove operation cancelled")
+		return nil
+	}
+
+	for _, runInfo := range runInfos {
+		if err := os.RemoveAll(runInfo.Directory); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", runInfo.Directory, err)
+		}
+		log.Infof("Removed %s", runInfo.Directory)
+	}
+
+	// Drop removed runs from the index
+	index.Update(cfg.BaseDir, cfg.SummaryFile)
+	return nil
+}
+
+// validateRunDir checks that a path is a run directory and returns its information
+func validateRunDir(runDir, summaryFile string) (utils.RunInfo, error) {
+	info, err := os.Lstat(runDir)
+	if err != nil {
+		return utils.RunInfo{}, fmt.Errorf("failed to find run: %w", err)
+	}
+	if !info.IsDir() {
+		return utils.RunInfo{}, fmt.Errorf("not a run directory: %s", runDir)
+	}
+	if !utils.RunDirPattern.MatchString(filepath.Base(filepath.Clean(runDir))) {
+		return utils.RunInfo{}, fmt.Errorf("not a run directory (unexpected name): %s", runDir)
+	}
+	runInfo, err := utils.ParseRunInfo(filepath.Join(runDir, summaryFile))
+	if err != nil {
+		return utils.RunInfo{}, fmt.Errorf("not a run directory (%w): %s", err, runDir)
+	}
+	return runInfo, nil
+}
+
+// confirmRemove asks the user to confirm the remove operation
+func confirmRemove() bool {
+	fmt.Print("Do you want to proceed with removing? [y/N]: ")
+	var response string
+	fmt.Scanln(&response)
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes"
+}
@@ -0,0 +1,152 @@
+package repro
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/run"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main reproduces a past run in a temporary worktree of its recorded commit
+func Main(runDir string) error {
+	// Get config
+	cfg := config.GetPointer()
+
+	// Load the original run
+	summaryPath, err := utils.ResolveSummaryPath(runDir, cfg.SummaryFile)
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse summary file: %w", err)
+	}
+	if runInfo.CommitHash == "" {
+		return fmt.Errorf("no commit hash recorded in %s", summaryPath)
+	}
+	commands, err := utils.SplitCommand(runInfo.Command)
+	if err != nil {
+		return fmt.Errorf("failed to parse command: %w", err)
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no command recorded in %s", summaryPath)
+	}
+	patch, fullPatch, err := utils.RecordedPatch(summaryPath, runInfo)
+	if err != nil {
+		return err
+	}
+	hasChanges := patch != utils.NoUncommittedChanges
+	if fullPatch {
+		hasChanges = utils.PatchChanges(patch) != ""
+	}
+
+	if (cfg.Repro.NoRun || cfg.Repro.Branch != "") && cfg.Repro.Worktree == "" {
+		return fmt.Errorf("--no-run and --branch require --worktree")
+	}
+
+	// Paths are resolved relative to the current directory, not the worktree
+	if err := run.ResolvePaths(cfg); err != nil {
+		return err
+	}
+
+	// Check out the recorded commit into the given worktree, which is kept,
+	// or a temporary one
+	worktree, err := addWorktree(cfg.Repro.Worktree, cfg.Repro.Branch, runInfo.CommitHash)
+	if err != nil {
+		return err
+	}
+	if cfg.Repro.Worktree == "" {
+		defer func() {
+			log.Infof("Removing worktree: %s", worktree)
+			if err := utils.RemoveWorktree(worktree); err != nil {
+				log.Warnf("Failed to remove worktree: %v", err)
+			}
+		}()
+	}
+
+	// Apply the recorded uncommitted changes
+	if hasChanges {
+		log.Info("Applying recorded uncommitted changes")
+		if err := utils.ApplyPatch(worktree, patch); err != nil {
+			return err
+		}
+		// The worktree is dirty by construction
+		cfg.Run.Force = true
+	}
+
+	if cfg.Repro.NoRun {
+		log.Infof("Restored the code state of %s in %s", runInfo.Directory, worktree)
+		return nil
+	}
+
+	// Recreate recorded environment variables
+	for name, value := range runInfo.EnvVars {
+		log.Infof("Setting environment variable: %s=%s", name, value)
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set environment variable %s: %w", name, err)
+		}
+	}
+
+	// Data paths are not checked out, so compare them before switching
+	dataDiscrepancies := run.CheckData(runInfo.DataFingerprints)
+
+	// The run directory is created in the worktree so that paths of the
+	// command relative to it refer to the checked-out code
+	opts := run.Options{ReproducedFrom: runInfo.Directory}
+	if !cfg.Run.NoPushd {
+		opts.StageDir = run.StageDir(worktree, cfg.BaseDir)
+	}
+
+	// Run the command from within the worktree
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	opts.DataDir = cwd
+	if err := os.Chdir(worktree); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	defer os.Chdir(cwd)
+
+	// Make sure the worktree actually matches the original code state
+	discrepancies, err := run.CheckCode(runInfo.CommitHash, patch, fullPatch, cfg.Run.AllowDifferentCode)
+	if err != nil {
+		return err
+	}
+
+	opts.CodeDiscrepancies = append(discrepancies, dataDiscrepancies...)
+	return run.MainWithOptions(commands, opts)
+}
+
+// addWorktree checks out a commit into a worktree at path, on a new branch if
+// given, or into a temporary worktree if path is empty, and returns its path
+func addWorktree(path, branch, commit string) (string, error) {
+	if path == "" {
+		tmpDir, err := os.MkdirTemp("", "moco-repro-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		log.Infof("Checking out %s into %s", commit, tmpDir)
+		if err := utils.AddWorktree(tmpDir, commit); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", err
+		}
+		return tmpDir, nil
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve worktree path: %w", err)
+	}
+	log.Infof("Checking out %s into %s", commit, path)
+	if branch != "" {
+		err = utils.AddBranchWorktree(path, branch, commit)
+	} else {
+		err = utils.AddWorktree(path, commit)
+	}
+	return path, err
+}
@@ -0,0 +1,113 @@
+package repro_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/repro"
+	"github.com/bicycle1885/moco/internal/run"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// git runs a git command in the current directory
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// setup creates a repository with a script in it
+func setup(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	git(t, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(".gitignore", []byte("runs/\ndata/\n"), 0644))
+	require.NoError(t, os.WriteFile("train.sh", []byte("echo 1 > result.txt\n"), 0644))
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "first")
+}
+
+// findRepro returns the summary of the run other than runDir in the base directory
+func findRepro(t *testing.T, runDir string) (string, utils.RunInfo) {
+	t.Helper()
+	runDirs, err := utils.FindRunDirs("runs")
+	require.NoError(t, err)
+	require.Len(t, runDirs, 2)
+	reproDir := runDirs[1]
+	if filepath.Clean(reproDir) == filepath.Clean(runDir) {
+		reproDir = runDirs[0]
+	}
+	info, err := utils.ParseRunInfo(filepath.Join(reproDir, "summary.md"))
+	require.NoError(t, err)
+	return reproDir, info
+}
+
+func TestReproRelativePath(t *testing.T) {
+	setup(t)
+
+	// The script is referred to relative to the run directory
+	cfg := config.GetPointer()
+	*cfg = config.GetDefault()
+	cfg.Run.Silent = true
+	cfg.Run.Stdin = false
+	require.NoError(t, run.Main([]string{"sh", "../../train.sh"}))
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	require.NoError(t, err)
+	require.Len(t, runDirs, 1)
+	runDir := runDirs[0]
+
+	require.NoError(t, os.WriteFile("train.sh", []byte("echo 2 > result.txt\n"), 0644))
+	git(t, "commit", "-q", "-am", "second")
+
+	// The reproduction runs the script of the original commit and is stored
+	// in the base directory of the current checkout
+	*cfg = config.GetDefault()
+	cfg.Run.Silent = true
+	cfg.Run.Stdin = false
+	require.NoError(t, repro.Main(runDir))
+
+	reproDir, info := findRepro(t, runDir)
+	result, err := os.ReadFile(filepath.Join(reproDir, "result.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(result))
+	assert.Equal(t, filepath.Clean(runDir), filepath.Clean(info.ReproducedFrom))
+	assert.True(t, info.Succeeded())
+
+	link, err := os.Readlink(filepath.Join("runs", utils.LatestLink))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Base(reproDir), link)
+}
+
+func TestReproData(t *testing.T) {
+	setup(t)
+	require.NoError(t, os.MkdirAll(filepath.Join("data", "raw"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("data", "raw", "train.csv"), []byte("a,b\n1,2\n"), 0644))
+
+	cfg := config.GetPointer()
+	*cfg = config.GetDefault()
+	cfg.Run.Silent = true
+	cfg.Run.Stdin = false
+	cfg.Data.Paths = []string{"data/raw"}
+	require.NoError(t, run.Main([]string{"true"}))
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	require.NoError(t, err)
+	require.Len(t, runDirs, 1)
+	runDir := runDirs[0]
+	original, err := utils.ParseRunInfo(filepath.Join(runDir, "summary.md"))
+	require.NoError(t, err)
+	require.Contains(t, original.DataFingerprints, "data/raw")
+
+	// Ignored data is not in the worktree but is fingerprinted in the checkout
+	require.NoError(t, repro.Main(runDir))
+	_, info := findRepro(t, runDir)
+	assert.Equal(t, original.DataFingerprints, info.DataFingerprints)
+	assert.Empty(t, info.CodeDiscrepancies)
+}
@@ -0,0 +1,108 @@
+package rerun
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/run"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main runs the command of a past run again as a new run
+func Main(runDir string) error {
+	// Get config
+	cfg := config.GetPointer()
+
+	// Load the original run
+	summaryPath, err := utils.ResolveSummaryPath(runDir, cfg.SummaryFile)
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse summary file: %w", err)
+	}
+	commands, err := utils.SplitCommand(runInfo.Command)
+	if err != nil {
+		return fmt.Errorf("failed to parse command: %w", err)
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no command recorded in %s", summaryPath)
+	}
+
+	patch, fullPatch, err := utils.RecordedPatch(summaryPath, runInfo)
+	if err != nil {
+		return err
+	}
+
+	opts := run.Options{RerunOf: runInfo.Directory}
+	// Data paths are not checked out, so compare them in the current directory
+	dataDiscrepancies := run.CheckData(runInfo.DataFingerprints)
+	if !cfg.Run.Checkout {
+		opts.CodeDiscrepancies, err = run.CheckCode(runInfo.CommitHash, patch, fullPatch, cfg.Run.AllowDifferentCode)
+		if err != nil {
+			return err
+		}
+		opts.CodeDiscrepancies = append(opts.CodeDiscrepancies, dataDiscrepancies...)
+		return run.MainWithOptions(commands, opts)
+	}
+
+	// Run in a temporary worktree of the recorded commit
+	if runInfo.CommitHash == "" {
+		return fmt.Errorf("no commit hash recorded in %s", summaryPath)
+	}
+	worktree, err := checkout(cfg, runInfo.CommitHash)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		log.Infof("Removing worktree: %s", worktree)
+		if err := utils.RemoveWorktree(worktree); err != nil {
+			log.Warnf("Failed to remove worktree: %v", err)
+		}
+	}()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	opts.DataDir = cwd
+	// The run directory is created in the worktree so that paths of the
+	// command relative to it refer to the checked-out code
+	if !cfg.Run.NoPushd {
+		opts.StageDir = run.StageDir(worktree, cfg.BaseDir)
+	}
+	if err := os.Chdir(worktree); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	defer os.Chdir(cwd)
+
+	// Recorded uncommitted changes are not applied to the worktree
+	opts.CodeDiscrepancies, err = run.CheckCode(runInfo.CommitHash, patch, fullPatch, cfg.Run.AllowDifferentCode)
+	if err != nil {
+		return err
+	}
+	opts.CodeDiscrepancies = append(opts.CodeDiscrepancies, dataDiscrepancies...)
+	return run.MainWithOptions(commands, opts)
+}
+
+// checkout checks out a commit into a temporary worktree and returns its path
+func checkout(cfg *config.Config, commit string) (string, error) {
+	// Paths are resolved relative to the current directory, not the worktree
+	if err := run.ResolvePaths(cfg); err != nil {
+		return "", err
+	}
+
+	worktree, err := os.MkdirTemp("", "moco-rerun-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	log.Infof("Checking out %s into %s", commit, worktree)
+	if err := utils.AddWorktree(worktree, commit); err != nil {
+		os.RemoveAll(worktree)
+		return "", err
+	}
+	return worktree, nil
+}
@@ -0,0 +1,120 @@
+package rerun_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/rerun"
+	"github.com/bicycle1885/moco/internal/run"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// git runs a git command in the current directory
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// setup creates a repository with a finished run in it and returns the run
+func setup(t *testing.T) string {
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	git(t, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(".gitignore", []byte("runs/\n"), 0644))
+	require.NoError(t, os.WriteFile("train.sh", []byte("echo 1\n"), 0644))
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "first")
+
+	cfg := config.GetPointer()
+	*cfg = config.GetDefault()
+	cfg.Run.Silent = true
+	cfg.Run.Stdin = false
+	require.NoError(t, run.Main([]string{"true"}))
+
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	require.NoError(t, err)
+	require.Len(t, runDirs, 1)
+	return runDirs[0]
+}
+
+func TestRerunDifferentCode(t *testing.T) {
+	t.Run("same code", func(t *testing.T) {
+		runDir := setup(t)
+		require.NoError(t, rerun.Main(runDir))
+	})
+
+	t.Run("moved HEAD", func(t *testing.T) {
+		runDir := setup(t)
+		require.NoError(t, os.WriteFile("train.sh", []byte("echo 2\n"), 0644))
+		git(t, "commit", "-q", "-am", "second")
+
+		err := rerun.Main(runDir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--allow-different-code")
+	})
+
+	t.Run("uncommitted changes", func(t *testing.T) {
+		runDir := setup(t)
+		require.NoError(t, os.WriteFile("train.sh", []byte("echo 2\n"), 0644))
+		config.GetPointer().Run.Force = true
+
+		err := rerun.Main(runDir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--allow-different-code")
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		runDir := setup(t)
+		require.NoError(t, os.WriteFile("train.sh", []byte("echo 2\n"), 0644))
+		git(t, "commit", "-q", "-am", "second")
+		config.GetPointer().Run.AllowDifferentCode = true
+		require.NoError(t, rerun.Main(runDir))
+
+		runDirs, err := utils.FindRunDirs("runs")
+		require.NoError(t, err)
+		require.Len(t, runDirs, 2)
+		var rerunInfo utils.RunInfo
+		for _, dir := range runDirs {
+			if filepath.Clean(dir) != filepath.Clean(runDir) {
+				rerunInfo, err = utils.ParseRunInfo(filepath.Join(dir, "summary.md"))
+				require.NoError(t, err)
+			}
+		}
+		require.Len(t, rerunInfo.CodeDiscrepancies, 1)
+		assert.Contains(t, rerunInfo.CodeDiscrepancies[0], "HEAD is at")
+	})
+}
+
+func TestRerunCheckoutRelativePath(t *testing.T) {
+	setup(t)
+	require.NoError(t, os.WriteFile("train.sh", []byte("echo 1 > result.txt\n"), 0644))
+	git(t, "commit", "-q", "-am", "second")
+	cfg := config.GetPointer()
+	require.NoError(t, run.Main([]string{"sh", "../../train.sh"}))
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	require.NoError(t, err)
+	require.Len(t, runDirs, 2)
+	runDir := runDirs[1]
+
+	require.NoError(t, os.WriteFile("train.sh", []byte("echo 2 > result.txt\n"), 0644))
+	git(t, "commit", "-q", "-am", "third")
+
+	// The script of the recorded commit is run
+	cfg.Run.Checkout = true
+	require.NoError(t, rerun.Main(runDir))
+	runDirs, err = utils.FindRunDirs("runs")
+	require.NoError(t, err)
+	require.Len(t, runDirs, 3)
+	result, err := os.ReadFile(filepath.Join(runDirs[2], "result.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(result))
+}
@@ -0,0 +1,52 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// afterPollInterval is how often the run given by --after is checked
+const afterPollInterval = 5 * time.Second
+
+// waitForRun waits until the run referenced by cfg.Run.After finishes and
+// returns its directory, or an error if it did not succeed
+func waitForRun(cfg config.Config) (string, error) {
+	runDir, err := utils.ResolveRunRef(cfg.BaseDir, cfg.SummaryFile, cfg.Run.After)
+	if err != nil {
+		return "", fmt.Errorf("failed to find run %s: %w", cfg.Run.After, err)
+	}
+	staleAfter, err := time.ParseDuration(cfg.Run.StaleAfter)
+	if err != nil {
+		return "", fmt.Errorf("invalid stale threshold: %s", cfg.Run.StaleAfter)
+	}
+
+	summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+	for logged := false; ; logged = true {
+		runInfo, err := utils.ParseRunInfo(summaryPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse summary file: %w", err)
+		}
+		runs := []utils.RunInfo{runInfo}
+		utils.MarkStale(runs, staleAfter)
+		runInfo = runs[0]
+
+		switch {
+		case runInfo.IsRunning && runInfo.Stale:
+			return "", fmt.Errorf("run %s is stale", runDir)
+		case runInfo.IsRunning:
+			if !logged {
+				log.Infof("Waiting for %s to finish", runDir)
+			}
+			time.Sleep(afterPollInterval)
+		case runInfo.Success:
+			return runDir, nil
+		default:
+			return "", fmt.Errorf("run %s did not succeed: %s", runDir, utils.StatusString(runInfo))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package run
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// ResolvePaths makes the base directory and additional repositories in the
+// configuration absolute, so that they are not resolved relative to a
+// worktree the command is run in
+func ResolvePaths(cfg *config.Config) error {
+	baseDir, err := filepath.Abs(cfg.BaseDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+	cfg.BaseDir = baseDir
+	for i, path := range cfg.Run.ExtraRepos {
+		if path, err := filepath.Abs(path); err == nil {
+			cfg.Run.ExtraRepos[i] = path
+		}
+	}
+	return nil
+}
+
+// StageDir returns the directory in a worktree at the place of the base
+// directory in the current repository, or "" if the base directory is outside
+// the repository
+func StageDir(worktree, baseDir string) string {
+	root, err := utils.RepoRoot()
+	if err != nil {
+		return ""
+	}
+	// The top-level directory reported by Git has symbolic links resolved
+	if resolved, err := filepath.EvalSymlinks(baseDir); err == nil {
+		baseDir = resolved
+	}
+	rel, err := filepath.Rel(root, baseDir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return ""
+	}
+	return filepath.Join(worktree, rel)
+}
+
+// CheckCode compares the current code state with the commit and uncommitted
+// changes of an original run, warning about discrepancies, and fails unless
+// they are allowed; if full is true, patch is a full patch of the run
+func CheckCode(commitHash, patch string, full, allow bool) ([]string, error) {
+	discrepancies, err := utils.CompareCodeState(commitHash, patch, full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare code state: %w", err)
+	}
+	for _, discrepancy := range discrepancies {
+		log.Warnf("Code differs from the original run: %s", discrepancy)
+	}
+	if len(discrepancies) > 0 && !allow {
+		return nil, fmt.Errorf("code differs from the original run, use --allow-different-code to run anyway")
+	}
+	return discrepancies, nil
+}
+
+// CheckData compares the current data with the fingerprints recorded by an
+// original run, warning about discrepancies; data is fingerprinted in the
+// same mode as the original run
+func CheckData(fingerprints map[string]string) []string {
+	var discrepancies []string
+	for _, path := range slices.Sorted(maps.Keys(fingerprints)) {
+		recorded := fingerprints[path]
+		fingerprint, err := utils.FingerprintPath(path, strings.HasPrefix(recorded, "full:"))
+		if err != nil {
+			discrepancies = append(discrepancies, fmt.Sprintf("failed to fingerprint data %s: %v", path, err))
+		} else if fingerprint != recorded {
+			discrepancies = append(discrepancies, fmt.Sprintf("data %s differs from that of the original run", path))
+		}
+	}
+	for _, discrepancy := range discrepancies {
+		log.Warnf("Data differs from the original run: %s", discrepancy)
+	}
+	return discrepancies
+}
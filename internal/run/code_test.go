@@ -0,0 +1,38 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckData(t *testing.T) {
+	dir := t.TempDir()
+	same := filepath.Join(dir, "same.csv")
+	changed := filepath.Join(dir, "changed.csv")
+	missing := filepath.Join(dir, "missing.csv")
+	for _, path := range []string{same, changed, missing} {
+		require.NoError(t, os.WriteFile(path, []byte("a,b\n1,2\n"), 0644))
+	}
+
+	fingerprints := map[string]string{}
+	for _, path := range []string{same, changed, missing} {
+		fingerprint, err := utils.FingerprintPath(path, true)
+		require.NoError(t, err)
+		fingerprints[path] = fingerprint
+	}
+	assert.Empty(t, CheckData(fingerprints))
+
+	require.NoError(t, os.WriteFile(changed, []byte("a,b\n3,4\n"), 0644))
+	require.NoError(t, os.Remove(missing))
+	discrepancies := CheckData(fingerprints)
+	if assert.Len(t, discrepancies, 2) {
+		assert.Equal(t, "data "+changed+" differs from that of the original run", discrepancies[0])
+		assert.Contains(t, discrepancies[1], "failed to fingerprint data "+missing)
+	}
+	assert.Empty(t, CheckData(nil))
+}
@@ -0,0 +1,43 @@
+package run
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+)
+
+// countGPUs returns the number of GPUs visible to the command according to CUDA_VISIBLE_DEVICES
+func countGPUs() int {
+	devices, ok := os.LookupEnv("CUDA_VISIBLE_DEVICES")
+	if !ok {
+		return 0
+	}
+	count := 0
+	for _, device := range strings.Split(devices, ",") {
+		// A negative or empty ID hides the devices that follow it
+		device = strings.TrimSpace(device)
+		if device == "" || strings.HasPrefix(device, "-") {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// estimateCost estimates the compute cost of a run from its duration and the configured rates
+//
+// Rates are per hour: the host rate (or the default rate for hosts without one)
+// is charged for the whole run and the GPU rate for each GPU used.
+func estimateCost(cfg config.Config, hostname string, duration time.Duration, gpus int) (gpuHours, cost float64) {
+	hours := duration.Hours()
+	gpuHours = hours * float64(gpus)
+
+	rate, ok := cfg.Cost.HostRates[hostname]
+	if !ok {
+		rate = cfg.Cost.DefaultRate
+	}
+	cost = hours*rate + gpuHours*cfg.Cost.GPURate
+	return gpuHours, cost
+}
@@ -0,0 +1,155 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
+)
+
+// dvcTimeout limits how long a single dvc command may take
+const dvcTimeout = 5 * time.Minute
+
+// dvcOut is a dependency or output in dvc.lock or a .dvc file
+type dvcOut struct {
+	Path string `yaml:"path"`
+	MD5  string `yaml:"md5"`
+	Hash string `yaml:"hash"` // name of the hash field in DVC 3 (e.g., "md5")
+	ETag string `yaml:"etag"`
+}
+
+// dvcLock is the part of dvc.lock recording hashes of stages
+type dvcLock struct {
+	Stages map[string]struct {
+		Deps []dvcOut `yaml:"deps"`
+		Outs []dvcOut `yaml:"outs"`
+	} `yaml:"stages"`
+}
+
+// dvcFile is the part of a .dvc file recording hashes of tracked data
+type dvcFile struct {
+	Outs []dvcOut `yaml:"outs"`
+}
+
+// captureDVC records data versions of a repository using DVC: the hashes in
+// dvc.lock and .dvc files, and the outputs of dvc status and dvc diff if dvc
+// is installed; it returns nothing if the repository does not use DVC
+func captureDVC(enabled bool) utils.DVCState {
+	var state utils.DVCState
+	if !enabled {
+		return state
+	}
+	root, err := utils.RepoRoot()
+	if err != nil {
+		return state
+	}
+	if _, err := os.Stat(filepath.Join(root, ".dvc")); err != nil {
+		return state
+	}
+
+	state.Hashes, err = dvcHashes(root)
+	if err != nil {
+		log.Warnf("Failed to read DVC hashes: %v", err)
+	}
+
+	if _, err := exec.LookPath("dvc"); err != nil {
+		log.Debug("dvc not found; DVC status is not recorded")
+		return state
+	}
+	log.Info("Checking DVC status")
+	status, err := runDVC(root, "status", "--json")
+	if err != nil {
+		log.Warnf("Failed to run dvc status: %v", err)
+		return state
+	}
+	diff, err := runDVC(root, "diff")
+	if err != nil {
+		log.Warnf("Failed to run dvc diff: %v", err)
+	}
+
+	// dvc status --json prints an empty object if nothing changed
+	var changes map[string]any
+	if err := json.Unmarshal([]byte(status), &changes); err != nil {
+		log.Warnf("Failed to parse dvc status: %v", err)
+		return state
+	}
+	state.Changed = len(changes) > 0
+	state.Status = fmt.Sprintf("$ dvc status --json\n%s\n$ dvc diff\n%s", strings.TrimSpace(status), diff)
+	return state
+}
+
+// runDVC runs a dvc command in a directory and returns its output
+func runDVC(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dvcTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "dvc", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// dvcHashes returns the hashes of paths recorded in dvc.lock and .dvc files
+// tracked by Git, with paths relative to the top-level directory
+func dvcHashes(root string) (map[string]string, error) {
+	hashes := map[string]string{}
+
+	data, err := os.ReadFile(filepath.Join(root, "dvc.lock"))
+	if err == nil {
+		var lock dvcLock
+		if err := yaml.Unmarshal(data, &lock); err != nil {
+			return hashes, fmt.Errorf("invalid dvc.lock: %w", err)
+		}
+		for _, stage := range lock.Stages {
+			for _, out := range append(stage.Deps, stage.Outs...) {
+				addDVCHash(hashes, "", out)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return hashes, err
+	}
+
+	cmd := exec.Command("git", "ls-files", "-z", "--", "*.dvc")
+	cmd.Dir = root
+	files, err := cmd.Output()
+	if err != nil {
+		return hashes, fmt.Errorf("failed to list .dvc files: %w", err)
+	}
+	for file := range strings.SplitSeq(string(files), "\x00") {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			return hashes, err
+		}
+		var tracked dvcFile
+		if err := yaml.Unmarshal(data, &tracked); err != nil {
+			return hashes, fmt.Errorf("invalid %s: %w", file, err)
+		}
+		// Paths in .dvc files are relative to the file
+		for _, out := range tracked.Outs {
+			addDVCHash(hashes, filepath.Dir(file), out)
+		}
+	}
+	return hashes, nil
+}
+
+// addDVCHash adds the hash of a dependency or output relative to dir
+func addDVCHash(hashes map[string]string, dir string, out dvcOut) {
+	hash := out.MD5
+	if hash == "" {
+		hash = out.ETag
+	}
+	if out.Path == "" || hash == "" {
+		return
+	}
+	hashes[filepath.ToSlash(filepath.Join(dir, out.Path))] = hash
+}
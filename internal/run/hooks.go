@@ -0,0 +1,60 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/charmbracelet/log"
+)
+
+// hooksLogFile is the file in the run directory capturing outputs of hooks
+const hooksLogFile = "hooks.log"
+
+// runHooks runs shell commands with {name} placeholders replaced by the
+// shell-quoted values of vars in dir, the working directory of the command,
+// appending their outputs to the hooks log of the run; it stops at the first
+// failing hook, whose output is shown even if silent
+func runHooks(hooks []string, vars map[string]string, expDir, dir string, silent bool) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	logFile, err := os.OpenFile(filepath.Join(expDir, hooksLogFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open hooks log: %w", err)
+	}
+	defer logFile.Close()
+	var output io.Writer = logFile
+	if !silent {
+		output = io.MultiWriter(os.Stderr, logFile)
+	}
+
+	var replacements []string
+	for name, value := range vars {
+		replacements = append(replacements, "{"+name+"}", shellescape.Quote(value))
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	for _, hook := range hooks {
+		hook = replacer.Replace(hook)
+		log.Infof("Running hook: %s", hook)
+		fmt.Fprintf(logFile, "$ %s\n", hook)
+		var buf bytes.Buffer
+		cmd := exec.Command("sh", "-c", hook)
+		cmd.Dir = dir
+		cmd.Stdout = io.MultiWriter(output, &buf)
+		cmd.Stderr = cmd.Stdout
+		if err := cmd.Run(); err != nil {
+			if silent {
+				os.Stderr.Write(buf.Bytes())
+			}
+			return fmt.Errorf("hook %q failed: %w", hook, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// combinedLogFile is the file in the run directory interleaving the outputs
+const combinedLogFile = "output.log"
+
+// logTimestampFormat is the format of timestamps prefixed to lines of logs
+const logTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// maxLineSize is the size beyond which an incomplete line is written as if
+// it were complete, so that output without newlines is not buffered without
+// bound
+const maxLineSize = 64 * 1024
+
+// lineWriter buffers written data and passes each complete line, including
+// its newline, to a function; the rest is passed by Flush
+type lineWriter struct {
+	buf   []byte
+	write func(line []byte) error
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.write(w.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	for len(w.buf) >= maxLineSize {
+		line := append(w.buf[:maxLineSize:maxLineSize], '\n')
+		w.buf = w.buf[maxLineSize:]
+		if err := w.write(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush passes the last incomplete line, if any, terminated with a newline
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n')
+	w.buf = nil
+	return w.write(line)
+}
+
+// combinedLog interleaves lines written to the output streams of a command
+// in a single log, prefixing each line with a timestamp and the stream
+type combinedLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// stream returns a writer of a stream tagged with a name in the log
+func (l *combinedLog) stream(name string) *lineWriter {
+	return &lineWriter{write: func(line []byte) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		_, err := fmt.Fprintf(l.w, "%s [%s] %s", time.Now().Format(logTimestampFormat), name, line)
+		return err
+	}}
+}
+
+// timestampWriter returns a writer prefixing each line with a timestamp
+func timestampWriter(w io.Writer) *lineWriter {
+	return &lineWriter{write: func(line []byte) error {
+		_, err := fmt.Fprintf(w, "%s %s", time.Now().Format(logTimestampFormat), line)
+		return err
+	}}
+}
+
+// captureFile is a file capturing an output stream of a command; if maxSize
+// is positive, the file is rotated when it reaches the size, keeping at most
+// rotations older files (path.1 being the newest), or the rest of the output
+// is discarded if rotations is zero
+type captureFile struct {
+	path      string
+	maxSize   int64
+	rotations int
+	file      *os.File
+	size      int64
+	truncated bool // whether any output has been discarded
+}
+
+func createCaptureFile(path string, maxSize int64, rotations int) (*captureFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &captureFile{path: path, maxSize: maxSize, rotations: rotations, file: file}, nil
+}
+
+func (f *captureFile) Write(p []byte) (int, error) {
+	n := len(p)
+	for f.maxSize > 0 && f.size+int64(len(p)) > f.maxSize {
+		// Fill the file up to the limit
+		room := f.maxSize - f.size
+		if err := f.write(p[:room]); err != nil {
+			return n, err
+		}
+		p = p[room:]
+		if f.rotations == 0 {
+			f.truncated = true
+			return n, nil
+		}
+		if err := f.rotate(); err != nil {
+			return n, err
+		}
+	}
+	return n, f.write(p)
+}
+
+func (f *captureFile) write(p []byte) error {
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return err
+}
+
+// rotate renames the file and older files to the next numbers and starts a
+// new file; the oldest file beyond the number of rotations is removed
+func (f *captureFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(fmt.Sprintf("%s.%d", f.path, f.rotations)); err == nil {
+		f.truncated = true
+	}
+	for i := f.rotations - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return err
+	}
+	file, err := os.Create(f.path)
+	if err != nil {
+		return err
+	}
+	f.file, f.size = file, 0
+	return nil
+}
+
+func (f *captureFile) Close() error {
+	return f.file.Close()
+}
+
+// ansiFilter removes ANSI escape sequences from the output and keeps only
+// the last update of lines rewritten with carriage returns, such as progress
+// bars; lines are written when complete
+type ansiFilter struct {
+	w     io.Writer
+	line  []byte
+	state int  // state of parsing escape sequences
+	cr    bool // whether a carriage return is pending
+}
+
+// States of parsing escape sequences
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+	ansiOSC
+	ansiOSCEscape
+)
+
+func (f *ansiFilter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch f.state {
+		case ansiEscape:
+			switch b {
+			case '[':
+				f.state = ansiCSI
+			case ']':
+				f.state = ansiOSC
+			default:
+				f.state = ansiText
+			}
+			continue
+		case ansiCSI:
+			// A CSI sequence ends with a byte in @ to ~
+			if b >= 0x40 && b <= 0x7e {
+				f.state = ansiText
+			}
+			continue
+		case ansiOSC:
+			// An OSC sequence ends with BEL or ST (ESC \)
+			if b == '\a' {
+				f.state = ansiText
+			} else if b == 0x1b {
+				f.state = ansiOSCEscape
+			}
+			continue
+		case ansiOSCEscape:
+			f.state = ansiText
+			continue
+		}
+
+		switch b {
+		case 0x1b:
+			f.state = ansiEscape
+		case '\n':
+			f.line = append(f.line, '\n')
+			if _, err := f.w.Write(f.line); err != nil {
+				return len(p), err
+			}
+			f.line, f.cr = f.line[:0], false
+		case '\r':
+			f.cr = true
+		default:
+			// Text after a carriage return overwrites the line
+			if f.cr {
+				f.line, f.cr = f.line[:0], false
+			}
+			f.line = append(f.line, b)
+			if len(f.line) >= maxLineSize {
+				if _, err := f.w.Write(append(f.line, '\n')); err != nil {
+					return len(p), err
+				}
+				f.line = f.line[:0]
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the last incomplete line, if any
+func (f *ansiFilter) Flush() error {
+	if len(f.line) == 0 {
+		return nil
+	}
+	_, err := f.w.Write(f.line)
+	f.line = f.line[:0]
+	return err
+}
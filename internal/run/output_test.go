@@ -0,0 +1,74 @@
+package run
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{write: func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	}}
+
+	_, err := w.Write([]byte("a\nb"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\n"}, lines)
+
+	// Long output without newlines is written in lines of the maximum size
+	_, err = w.Write(bytes.Repeat([]byte("x"), 2*maxLineSize))
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "b"+strings.Repeat("x", maxLineSize-1)+"\n", lines[1])
+	assert.Len(t, lines[2], maxLineSize+1)
+	assert.Len(t, w.buf, 1)
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "x\n", lines[3])
+}
+
+func TestAnsiFilterLongLine(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ansiFilter{w: &buf}
+	_, err := f.Write(bytes.Repeat([]byte("\x1b[1mx"), maxLineSize+1))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", maxLineSize)+"\n", buf.String())
+	require.NoError(t, f.Flush())
+	assert.Equal(t, strings.Repeat("x", maxLineSize)+"\nx", buf.String())
+}
+
+func TestCaptureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.log")
+
+	// The rest of the output is discarded without rotations
+	f, err := createCaptureFile(path, 4, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("abcdef"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.True(t, f.truncated)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(data))
+
+	// and kept in older files with rotations
+	f, err = createCaptureFile(path, 4, 1)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("abcdef"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.False(t, f.truncated)
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(data))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ef", string(data))
+}
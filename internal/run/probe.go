@@ -0,0 +1,138 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// probeTimeout limits how long a single version probe may take
+const probeTimeout = 10 * time.Second
+
+// probeVersions runs the configured version probes of programs and files
+// involved in the command, recording each version under the pattern of its
+// probe
+//
+// Patterns are matched against the arguments of the command like ignore
+// patterns of dirty files, so "python" matches "/usr/bin/python" and
+// "requirements*.txt" matches "-r envs/requirements-dev.txt".
+func probeVersions(commands []string, probes map[string]string) map[string]string {
+	versions := map[string]string{}
+	for _, pattern := range slices.Sorted(maps.Keys(probes)) {
+		probe := probes[pattern]
+		if probe == "" {
+			continue
+		}
+		matched := slices.ContainsFunc(commands, func(arg string) bool {
+			return utils.MatchPathPattern(pattern, filepath.ToSlash(arg))
+		})
+		if !matched {
+			continue
+		}
+
+		version, err := runProbe(probe)
+		if err != nil {
+			log.Warnf("Failed to probe version of %s: %v", pattern, err)
+			continue
+		}
+		versions[pattern] = version
+	}
+	return versions
+}
+
+// captureGPUInfo runs the configured GPU information commands and returns
+// their outputs, each preceded by the command; commands that fail (e.g., on
+// machines without GPUs) are skipped
+func captureGPUInfo(commands []string) string {
+	var b strings.Builder
+	for _, command := range commands {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		output, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+		cancel()
+		if err != nil {
+			log.Debugf("Skipped GPU information %q: %v", command, err)
+			continue
+		}
+		fmt.Fprintf(&b, "$ %s\n%s", command, output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// runProbe executes a probe command in a shell and returns the first line of its output
+func runProbe(probe string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	// Some programs (e.g., python2) print their version to stderr
+	output, err := exec.CommandContext(ctx, "sh", "-c", probe).CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	for line := range strings.SplitSeq(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return strings.ReplaceAll(line, "`", "'"), nil
+		}
+	}
+	return "unknown", nil
+}
+
+// snapshotTimeout limits how long a single environment snapshot command may take
+const snapshotTimeout = 5 * time.Minute
+
+// snapshotFiles names the files of well-known environment snapshot commands
+var snapshotFiles = map[string]string{
+	"pip freeze":       "pip-freeze.txt",
+	"conda env export": "environment.yml",
+	"conda list":       "conda-list.txt",
+}
+
+// nonWordRegex matches runs of characters replaced in file names of snapshots
+var nonWordRegex = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+
+// snapshotFileName returns the file name to save the output of a snapshot command
+func snapshotFileName(command string) string {
+	if name, ok := snapshotFiles[strings.Join(strings.Fields(command), " ")]; ok {
+		return name
+	}
+	name := strings.Trim(nonWordRegex.ReplaceAllString(command, "-"), "-")
+	return name + ".txt"
+}
+
+// captureEnv runs the environment snapshot commands and saves their outputs
+// in the run directory, returning the saved file of each command
+func captureEnv(commands []string, runDir string) map[string]string {
+	files := map[string]string{}
+	for _, command := range commands {
+		log.Infof("Capturing environment: %s", command)
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+		output, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+		cancel()
+		if err != nil {
+			log.Warnf("Failed to capture environment with %q: %v", command, err)
+			continue
+		}
+
+		name := snapshotFileName(command)
+		if err := os.WriteFile(filepath.Join(runDir, name), output, 0644); err != nil {
+			log.Warnf("Failed to save environment snapshot: %v", err)
+			continue
+		}
+		files[command] = name
+	}
+	return files
+}
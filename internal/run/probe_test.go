@@ -0,0 +1,34 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeVersions(t *testing.T) {
+	probes := map[string]string{
+		"python":            "echo Python 3.12.1",
+		"python3*":          "echo Python 3.13.0",
+		"requirements*.txt": "echo pip 24.0",
+		"configs/*.toml":    "echo toml",
+		"julia":             "echo julia 1.11.0",
+		"nvcc":              "",
+	}
+
+	versions := probeVersions([]string{"/usr/bin/python3.13", "train.py", "-r", "envs/requirements-dev.txt", "nvcc"}, probes)
+	assert.Equal(t, map[string]string{
+		"python3*":          "Python 3.13.0",
+		"requirements*.txt": "pip 24.0",
+	}, versions)
+
+	versions = probeVersions([]string{"python", "train.py", "--config", "configs/base.toml"}, probes)
+	assert.Equal(t, map[string]string{
+		"python":         "Python 3.12.1",
+		"configs/*.toml": "toml",
+	}, versions)
+
+	// Patterns with a slash are matched against whole arguments
+	versions = probeVersions([]string{"python", "train.py", "--config", "old/configs/base.toml"}, probes)
+	assert.NotContains(t, versions, "configs/*.toml")
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package run
+
+import "os/exec"
+
+// setProcessGroup does nothing since process groups are not supported here
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,45 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressFrames are the frames of the spinner shown while a silent run is
+// in progress
+var progressFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// startProgress shows a spinner and the elapsed time on the terminal until
+// the returned function is called; nothing is shown unless the standard
+// error is a terminal
+func startProgress(startTime time.Time) func() {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			elapsed := time.Since(startTime).Round(time.Second)
+			fmt.Fprintf(os.Stderr, "\r\033[K%s Running for %s", progressFrames[i%len(progressFrames)], elapsed)
+			select {
+			case <-done:
+				// Clear the line for the messages that follow
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package run
+
+import (
+	"os"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// resourceUsage returns the CPU times of a finished command; other resource
+// usage is not available here
+func resourceUsage(state *os.ProcessState) *utils.ResourceUsage {
+	if state == nil {
+		return nil
+	}
+	return &utils.ResourceUsage{
+		UserSeconds:   state.UserTime().Seconds(),
+		SystemSeconds: state.SystemTime().Seconds(),
+	}
+}
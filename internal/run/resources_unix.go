@@ -0,0 +1,39 @@
+//go:build unix
+
+package run
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// blockSize is the unit of block I/O counts in rusage
+const blockSize = 512
+
+// resourceUsage returns the resource usage of a finished command, including
+// its descendants that it waited for, or nil if unavailable
+func resourceUsage(state *os.ProcessState) *utils.ResourceUsage {
+	if state == nil {
+		return nil
+	}
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return nil
+	}
+
+	// The peak resident set size is in kilobytes except on macOS
+	maxMemory := int64(rusage.Maxrss)
+	if runtime.GOOS != "darwin" {
+		maxMemory *= 1024
+	}
+	return &utils.ResourceUsage{
+		UserSeconds:   state.UserTime().Seconds(),
+		SystemSeconds: state.SystemTime().Seconds(),
+		MaxMemory:     maxMemory,
+		ReadBytes:     int64(rusage.Inblock) * blockSize,
+		WriteBytes:    int64(rusage.Oublock) * blockSize,
+	}
+}
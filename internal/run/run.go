@@ -3,33 +3,149 @@ package run
 import (
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"al.essio.dev/pkg/shellescape"
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/notify"
+	"github.com/bicycle1885/moco/internal/objects"
 	"github.com/bicycle1885/moco/internal/utils"
 	"github.com/charmbracelet/log"
 )
 
+// killGracePeriod is how long a timed-out command may take to exit after
+// SIGTERM before it is killed
+const killGracePeriod = 10 * time.Second
+
+// notStartedExitCode is recorded for commands that could not be started,
+// following the shell convention for commands that cannot be found
+const notStartedExitCode = 127
+
+// Options holds settings of a run that are not part of the configuration
+type Options struct {
+	// Directory of the run being reproduced, if any
+	ReproducedFrom string
+	// Directory of the run being rerun, if any
+	RerunOf string
+	// ID of the sweep the run belongs to and its parameter values, if any
+	Sweep       string
+	SweepParams map[string]string
+	// Differences from the code state of the run being reproduced
+	CodeDiscrepancies []string
+	// Directory in which the run directory is created, if not the base
+	// directory, so that the command runs there (e.g., in a worktree); the run
+	// directory is moved into the base directory once the command finishes
+	StageDir string
+	// Directory relative data paths are resolved against, if not the current
+	// directory (e.g., the checkout a worktree was created from)
+	DataDir string
+}
+
 // Run executes a command with experiment tracking
 func Main(commands []string) error {
+	return MainWithOptions(commands, Options{})
+}
+
+// MainWithOptions executes a command with experiment tracking and extra options
+func MainWithOptions(commands []string, opts Options) error {
 	// Get config
 	cfg := config.Get()
 
+	// Wait for the run this run depends on before recording the code state
+	after := ""
+	if cfg.Run.After != "" {
+		var err error
+		after, err = waitForRun(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check git repository status
 	repo, err := utils.GetRepoStatus()
 	if err != nil {
 		return fmt.Errorf("git repository error: %w", err)
 	}
 
-	// Validate git status
+	// Validate git status; changes only to ignored paths are tolerated
 	if repo.IsDirty && !cfg.Run.Force {
-		return fmt.Errorf("git repository has uncommitted changes, use --force to run anyway")
+		dirtyFiles := utils.DirtyFiles(repo.ChangedFiles, cfg.Run.IgnoreDirtyPaths)
+		if len(dirtyFiles) > 0 {
+			return fmt.Errorf("git repository has uncommitted changes (%s), use --force to run anyway",
+				strings.Join(dirtyFiles, ", "))
+		}
+	}
+
+	// Validate data fingerprinting mode
+	if cfg.Data.Mode != "fast" && cfg.Data.Mode != "full" {
+		return fmt.Errorf("invalid data fingerprint mode: %s (expected fast or full)", cfg.Data.Mode)
+	}
+
+	// Validate tags
+	for _, tag := range cfg.Run.Tags {
+		if err := utils.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	// Validate run name
+	name := ""
+	if cfg.Run.Name != "" {
+		name, err = utils.SanitizeRunName(cfg.Run.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate artifact patterns
+	if err := utils.ValidateArtifactPatterns(cfg.Run.Artifacts); err != nil {
+		return err
+	}
+	if err := utils.ValidateInputPatterns(cfg.Run.Include); err != nil {
+		return err
+	}
+	if cfg.Objects.Dedup {
+		if err := objects.ValidateLink(cfg.Objects.Link); err != nil {
+			return err
+		}
+	}
+
+	// Validate log size limit
+	var maxLogSize int64
+	if cfg.Run.MaxLogSize != "" {
+		maxLogSize, err = utils.ParseSize(cfg.Run.MaxLogSize)
+		if err != nil || maxLogSize <= 0 {
+			return fmt.Errorf("invalid maximum log size: %s", cfg.Run.MaxLogSize)
+		}
+	}
+	if cfg.Run.LogRotations < 0 {
+		return fmt.Errorf("invalid number of log rotations: %d", cfg.Run.LogRotations)
+	}
+
+	// Validate timeout
+	var timeout time.Duration
+	if cfg.Run.Timeout != "" {
+		timeout, err = time.ParseDuration(cfg.Run.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", cfg.Run.Timeout)
+		}
+	}
+
+	// Validate heartbeat interval
+	heartbeatInterval, err := time.ParseDuration(cfg.Run.HeartbeatInterval)
+	if err != nil {
+		return fmt.Errorf("invalid heartbeat interval: %s", cfg.Run.HeartbeatInterval)
 	}
 
 	// Create experiment directory with millisecond timestamp
@@ -48,32 +164,150 @@ func Main(commands []string) error {
 	if cfg.Run.Message != "" {
 		message = cfg.Run.Message
 	} else if cfg.Run.PromptMessage {
-		message, err = getUserInput()
+		message, err = utils.GetUserInput()
 		if err != nil {
 			return err
 		}
 	}
 
+	// Link issues given explicitly or mentioned in the commit message
+	issues, err := collectIssues(cfg.Run.Issues, repo.CommitMessage, cfg.Run.IssuePattern)
+	if err != nil {
+		return err
+	}
+
 	// Create unique experiment directory
 	startTime := time.Now()
-	dirName := fmt.Sprintf("%s_%s_%s", startTime.Format("2006-01-02T15:04:05.000"), utils.SanitizeBranchName(repo.Branch), repo.ShortHash)
-	expDir := filepath.Join(baseDir, dirName)
+	timestamp, err := utils.FormatRunDirTime(startTime, cfg.Run.DirTimezone, cfg.Run.DirPrecision)
+	if err != nil {
+		return err
+	}
+	dirName := fmt.Sprintf("%s_%s_%s", timestamp, utils.SanitizeBranchName(repo.Branch), repo.ShortHash)
+	if name != "" {
+		dirName += "_" + name
+	}
+	runsDir := baseDir
+	if opts.StageDir != "" {
+		runsDir = opts.StageDir
+		if err := os.MkdirAll(runsDir, 0755); err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+	}
+	expDir := filepath.Join(runsDir, dirName)
 
 	log.Infof("Creating experiment directory: %s", expDir)
 	if err := os.Mkdir(expDir, 0755); err != nil {
 		return fmt.Errorf("failed to create experiment directory: %w", err)
 	}
+	recordEvent(expDir, utils.Event{Type: utils.EventCreated, Time: startTime})
+	// A staged run is linked once it is moved into the base directory
+	if cfg.Run.LatestLink && opts.StageDir == "" {
+		if err := utils.UpdateRunLink(baseDir, utils.LatestLink, expDir); err != nil {
+			log.Warnf("Failed to update %s link: %v", utils.LatestLink, err)
+		}
+	}
+
+	// Record the configuration in effect, including flags
+	if err := config.WriteSnapshot(filepath.Join(expDir, config.SnapshotFile), cfg); err != nil {
+		log.Warnf("Failed to write configuration snapshot: %v", err)
+	}
+
+	// Save the full patch of uncommitted changes so that the exact code state
+	// can be restored with git apply
+	patchFile := ""
+	if repo.IsDirty {
+		if err := writePatch(expDir, runsDir); err != nil {
+			log.Warnf("Failed to save uncommitted changes: %v", err)
+		} else {
+			patchFile = utils.PatchFile
+		}
+	}
+
+	// Create the TensorBoard log directory for the command to write to
+	if cfg.Run.TensorBoardDir != "" {
+		if err := os.MkdirAll(filepath.Join(expDir, cfg.Run.TensorBoardDir), 0755); err != nil {
+			cleanupRun(expDir)
+			return fmt.Errorf("failed to create TensorBoard directory: %w", err)
+		}
+	}
+
+	// Snapshot input files so that they are preserved even if they change
+	var inputs []utils.Artifact
+	if len(cfg.Run.Include) > 0 {
+		inputs, err = utils.CopyInputs(".", expDir, cfg.Run.Include)
+		if err != nil {
+			cleanupRun(expDir)
+			return fmt.Errorf("failed to copy input files: %w", err)
+		}
+	}
 
-	// Set up signal handling for clean termination
+	// Render templates of files that the command reads
+	if len(cfg.Run.Templates) > 0 {
+		absExpDir, err := filepath.Abs(expDir)
+		if err != nil {
+			cleanupRun(expDir)
+			return fmt.Errorf("failed to resolve experiment directory: %w", err)
+		}
+		data := templateData{
+			RunDir:      absExpDir,
+			Name:        cfg.Run.Name,
+			Branch:      repo.Branch,
+			Commit:      repo.FullHash,
+			ShortCommit: repo.ShortHash,
+			StartTime:   startTime,
+			Sweep:       opts.Sweep,
+			Params:      opts.SweepParams,
+		}
+		rendered, err := renderTemplates(cfg.Run.Templates, data, expDir)
+		if err != nil {
+			cleanupRun(expDir)
+			return err
+		}
+		log.Infof("Rendered templates: %s", strings.Join(rendered, ", "))
+	}
+
+	// Set up signal handling for clean termination; the command runs in its
+	// own process group, so signals including SIGHUP from closing the
+	// terminal are forwarded to it
 	interrupted := false
+	timedOut := false
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Write metadata to summary file
 	summaryPath := filepath.Join(expDir, cfg.SummaryFile)
-	if err := utils.WriteSummaryFileInit(summaryPath, startTime, repo, commands, message); err != nil {
+	meta := utils.RunMetadata{
+		StartTime:        startTime,
+		Repo:             repo,
+		Command:          commands,
+		Name:             cfg.Run.Name,
+		Message:          message,
+		Issues:           issues,
+		Tags:             cfg.Run.Tags,
+		ExtraRepos:       getExtraRepos(cfg.Run.ExtraRepos),
+		ToolVersions:     probeVersions(commands, cfg.Run.VersionProbes),
+		DataFingerprints: fingerprintData(cfg.Data.Paths, opts.DataDir, cfg.Data.Mode == "full"),
+		EnvVars:          recordEnv(cfg.Run.RecordEnv),
+		EnvSnapshots:     captureEnv(cfg.Run.CaptureEnv, expDir),
+		GPUInfo:          captureGPUInfo(cfg.Run.GPUInfo),
+		PatchFile:        patchFile,
+		TensorBoardDir:   cfg.Run.TensorBoardDir,
+		Inputs:           inputs,
+		DVC:              captureDVC(cfg.Run.DVC),
+
+		ReproducedFrom:    opts.ReproducedFrom,
+		RerunOf:           opts.RerunOf,
+		After:             after,
+		Sweep:             opts.Sweep,
+		SweepParams:       opts.SweepParams,
+		CodeDiscrepancies: opts.CodeDiscrepancies,
+	}
+	if err := utils.WriteSummaryFileInit(summaryPath, meta); err != nil {
 		return fmt.Errorf("failed to write summary: %w", err)
 	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		log.Warnf("Failed to write %s: %v", utils.SummaryJSONFile, err)
+	}
 
 	// Set up output files
 	stdoutPath := filepath.Join(expDir, cfg.Run.StdoutFile)
@@ -87,134 +321,526 @@ func Main(commands []string) error {
 		cmd.Dir = expDir
 	}
 
-	// Set up files for capturing output
-	stdoutFile, err := os.Create(stdoutPath)
+	// Run the command in its own process group so that signals can be sent
+	// to all of its processes (e.g., by moco kill)
+	setProcessGroup(cmd)
+
+	// Failures from here on are recorded in the summary like those of the
+	// command, so that the run is not left looking like it is running
+	var stdoutFile, stderrFile *captureFile
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+	// Logs limited to the maximum size, which are reported if truncated
+	var captures []*captureFile
+	capture := func(path string) (*captureFile, error) {
+		file, err := createCaptureFile(path, maxLogSize, cfg.Run.LogRotations)
+		if err != nil {
+			return nil, err
+		}
+		captures = append(captures, file)
+		closers = append(closers, file)
+		return file, nil
+	}
+	// Buffering writers whose last incomplete lines are written at the end,
+	// in the order of flushing
+	var flushers []interface{ Flush() error }
+	stopStdin := func() {}
+	// Hooks run in the working directory of the command, so they are given
+	// the absolute path of the run directory
+	hookRunDir, err := filepath.Abs(expDir)
 	if err != nil {
-		return fmt.Errorf("failed to create stdout file: %w", err)
+		hookRunDir = expDir
 	}
-	defer stdoutFile.Close()
+	hookVars := map[string]string{
+		"run_dir": hookRunDir,
+		"command": shellescape.QuoteCommand(commands),
+	}
+	start := func() error {
+		// Set up files for capturing output
+		stdoutFile, err = capture(stdoutPath)
+		if err != nil {
+			return fmt.Errorf("failed to create stdout file: %w", err)
+		}
 
-	stderrFile, err := os.Create(stderrPath)
-	if err != nil {
-		return fmt.Errorf("failed to create stderr file: %w", err)
+		stderrFile, err = capture(stderrPath)
+		if err != nil {
+			return fmt.Errorf("failed to create stderr file: %w", err)
+		}
+
+		// Prefix lines in the files with timestamps if required
+		var stdoutWriter io.Writer = stdoutFile
+		var stderrWriter io.Writer = stderrFile
+		if cfg.Run.TimestampLogs {
+			stdoutLines, stderrLines := timestampWriter(stdoutFile), timestampWriter(stderrFile)
+			flushers = append(flushers, stdoutLines, stderrLines)
+			stdoutWriter, stderrWriter = stdoutLines, stderrLines
+		}
+
+		// Interleave both outputs in a single log if required
+		if cfg.Run.CombinedLog {
+			combinedFile, err := capture(filepath.Join(expDir, combinedLogFile))
+			if err != nil {
+				return fmt.Errorf("failed to create combined log: %w", err)
+			}
+			combined := &combinedLog{w: combinedFile}
+			stdoutLines, stderrLines := combined.stream("stdout"), combined.stream("stderr")
+			flushers = append(flushers, stdoutLines, stderrLines)
+			stdoutWriter = io.MultiWriter(stdoutWriter, stdoutLines)
+			stderrWriter = io.MultiWriter(stderrWriter, stderrLines)
+		}
+
+		// Clean up terminal control in the logs if required, which is done
+		// before the other writers so that they see the cleaned lines
+		if cfg.Run.StripANSI {
+			stdoutFilter, stderrFilter := &ansiFilter{w: stdoutWriter}, &ansiFilter{w: stderrWriter}
+			flushers = append([]interface{ Flush() error }{stdoutFilter, stderrFilter}, flushers...)
+			stdoutWriter, stderrWriter = stdoutFilter, stderrFilter
+		}
+
+		// When capturing command output, check the Silent flag
+		if cfg.Run.Silent {
+			// Write output only to files, not to stdout/stderr
+			cmd.Stdout = stdoutWriter
+			cmd.Stderr = stderrWriter
+		} else {
+			// Standard behavior: write to both files and stdout/stderr
+			cmd.Stdout = io.MultiWriter(os.Stdout, stdoutWriter)
+			cmd.Stderr = io.MultiWriter(os.Stderr, stderrWriter)
+		}
+
+		// Forward input to the command, e.g., for interactive programs
+		if cfg.Run.Stdin {
+			var openLog func() (io.Writer, error)
+			if cfg.Run.StdinLog {
+				openLog = func() (io.Writer, error) {
+					return capture(filepath.Join(expDir, stdinLogFile))
+				}
+			}
+			stop, err := forwardStdin(cmd, openLog)
+			if err != nil {
+				return fmt.Errorf("failed to forward standard input: %w", err)
+			}
+			stopStdin = stop
+		}
+
+		// Run hooks before the command, which is not started if any fails
+		if err := runHooks(cfg.Run.PreHooks, hookVars, expDir, cmd.Dir, cfg.Run.Silent); err != nil {
+			return fmt.Errorf("failed to run pre-run hooks: %w", err)
+		}
+
+		// Start the command
+		log.Infof("Starting command: %s", shellescape.QuoteCommand(commands))
+		return cmd.Start()
 	}
-	defer stderrFile.Close()
 
-	// When capturing command output, check the Silent flag
-	if cfg.Run.Silent {
-		// Write output only to files, not to stdout/stderr
-		cmd.Stdout = stdoutFile
-		cmd.Stderr = stderrFile
+	exitCode := notStartedExitCode
+	startErr := start()
+	if startErr != nil {
+		log.Errorf("Command not started: %v", startErr)
 	} else {
-		// Standard behavior: write to both files and stdout/stderr
-		cmd.Stdout = io.MultiWriter(os.Stdout, stdoutFile)
-		cmd.Stderr = io.MultiWriter(os.Stderr, stderrFile)
-	}
+		exitCode = 0
+		if err := utils.WritePIDFile(expDir, cmd.Process.Pid); err != nil {
+			log.Warnf("Failed to write PID file: %v", err)
+		}
+		recordEvent(expDir, utils.Event{Type: utils.EventStarted, PID: cmd.Process.Pid})
+		stopHeartbeat := startHeartbeat(expDir, heartbeatInterval)
+		stopProgress := func() {}
+		if cfg.Run.Silent && cfg.Run.Progress {
+			stopProgress = startProgress(startTime)
+		}
 
-	// Start the command
-	log.Infof("Starting command: %s", shellescape.QuoteCommand(commands))
-	if err := cmd.Start(); err != nil {
-		log.Errorf("Failed to start command: %v", err)
-		// Clean up on failure to avoid leaving empty directories
-		cleanupRun(expDir)
-		return err
-	}
+		// Wait for either command completion or signal
+		doneChan := make(chan error, 1)
 
-	// Wait for either command completion or signal
-	exitCode := 0
-	doneChan := make(chan error, 1)
+		go func() {
+			doneChan <- cmd.Wait()
+		}()
 
-	go func() {
-		doneChan <- cmd.Wait()
-	}()
+		// A nil channel never fires, so no timeout is applied if not set
+		var timeoutChan <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timeoutChan = timer.C
+		}
 
-	select {
-	case err := <-doneChan:
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
-			} else {
-				exitCode = 1
+		select {
+		case err := <-doneChan:
+			stopProgress()
+			if err != nil {
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					exitCode = exitErr.ExitCode()
+					// Follow the shell convention for processes killed by a signal
+					if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+						exitCode = 128 + int(status.Signal())
+						// Termination requested from outside counts as an interruption
+						switch status.Signal() {
+						case syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM:
+							interrupted = true
+						}
+					}
+				} else {
+					exitCode = 1
+				}
 			}
-		}
-	case sig := <-signalChan:
-		interrupted = true
-		log.Warnf("Received signal: %v", sig)
-
-		if cmd.Process != nil {
-			// Check if the process is still running before sending the signal
-			// by sending signal 0, which doesn't actually send a signal but checks if process exists
-			err := cmd.Process.Signal(syscall.Signal(0))
-			if err == nil {
-				// Process is still running, send the termination signal
-				if err := cmd.Process.Signal(sig); err != nil {
-					log.Errorf("Failed to send signal to process: %v", err)
+		case sig := <-signalChan:
+			stopProgress()
+			interrupted = true
+			log.Warnf("Received signal: %v", sig)
+			recordEvent(expDir, utils.Event{Type: utils.EventSignal, Signal: sig.String()})
+
+			if cmd.Process != nil {
+				// Check if the process is still running before sending the signal
+				// by sending signal 0, which doesn't actually send a signal but checks if process exists
+				err := cmd.Process.Signal(syscall.Signal(0))
+				if err == nil {
+					// Process is still running, send the termination signal to its group
+					if err := utils.SignalProcessGroup(cmd.Process.Pid, sig.(syscall.Signal)); err != nil {
+						log.Errorf("Failed to send signal to process: %v", err)
+					}
+				} else {
+					log.Debugf("Process already terminated, no signal sent")
 				}
-			} else {
-				log.Debugf("Process already terminated, no signal sent")
 			}
+
+			<-doneChan
+			exitCode = 130 // Convention for interrupted commands
+		case <-timeoutChan:
+			stopProgress()
+			timedOut = true
+			log.Warnf("Command timed out after %s", timeout)
+			recordEvent(expDir, utils.Event{Type: utils.EventTimeout, Message: timeout.String()})
+			terminateGroup(cmd.Process.Pid, doneChan)
+			exitCode = 124 // Convention of timeout(1)
+		}
+
+		stopHeartbeat()
+	}
+	stopStdin()
+	recordEvent(expDir, utils.Event{Type: utils.EventFinished, ExitCode: &exitCode})
+	for _, w := range flushers {
+		if err := w.Flush(); err != nil {
+			log.Warnf("Failed to write output: %v", err)
 		}
+	}
 
-		<-doneChan
-		exitCode = 130 // Convention for interrupted commands
+	// Move a staged run directory into the base directory before its results
+	// are recorded, since deduplicated artifacts are linked relative to it
+	if opts.StageDir != "" {
+		for _, c := range closers {
+			c.Close()
+		}
+		closers = nil
+		finalDir := filepath.Join(baseDir, dirName)
+		log.Infof("Moving experiment directory: %s", finalDir)
+		if err := utils.MoveDir(expDir, finalDir); err != nil {
+			log.Errorf("Failed to move experiment directory: %v", err)
+		} else {
+			expDir = finalDir
+			summaryPath = filepath.Join(expDir, cfg.SummaryFile)
+			if hookRunDir, err := filepath.Abs(expDir); err == nil {
+				hookVars["run_dir"] = hookRunDir
+			}
+			// Post-run hooks run in the working directory of the command
+			if !cfg.Run.NoPushd {
+				cmd.Dir = expDir
+			}
+			if cfg.Run.LatestLink {
+				if err := utils.UpdateRunLink(baseDir, utils.LatestLink, expDir); err != nil {
+					log.Warnf("Failed to update %s link: %v", utils.LatestLink, err)
+				}
+			}
+		}
+		os.Remove(opts.StageDir) // Only if empty
 	}
 
-	if exitCode == 0 {
+	// Interpret the exit code
+	success := startErr == nil && slices.Contains(cfg.Run.SuccessExitCodes, exitCode)
+	reason := cfg.Run.ExitCodeNames[strconv.Itoa(exitCode)]
+	if startErr != nil {
+		reason = "not started"
+	} else if success {
 		log.Info("Command finished successfully")
+	} else if reason != "" {
+		log.Infof("Command finished with exit code %d (%s)", exitCode, reason)
 	} else {
 		log.Infof("Command finished with exit code %d", exitCode)
 	}
 
 	// Record execution results
 	endTime := time.Now()
-	if err := utils.WriteSummaryFileEnd(summaryPath, startTime, endTime, exitCode, interrupted); err != nil {
+	result := utils.RunResult{
+		EndTime:     endTime,
+		ExitCode:    exitCode,
+		ExitReason:  reason,
+		Success:     success,
+		Interrupted: interrupted,
+		TimedOut:    timedOut,
+		Currency:    cfg.Cost.Currency,
+		Resources:   resourceUsage(cmd.ProcessState),
+	}
+	for _, file := range captures {
+		if file.truncated {
+			result.TruncatedLogs = append(result.TruncatedLogs, filepath.Base(file.path))
+		}
+	}
+	if len(result.TruncatedLogs) > 0 {
+		log.Warnf("Output exceeding the maximum log size of %s was discarded", cfg.Run.MaxLogSize)
+	}
+	if result.Metrics, err = utils.ReadMetrics(expDir); err != nil {
+		log.Warnf("Failed to read metrics: %v", err)
+	}
+	if len(cfg.Run.Artifacts) > 0 {
+		if result.Artifacts, err = utils.CollectArtifacts(expDir, cfg.Run.Artifacts); err != nil {
+			log.Warnf("Failed to collect artifacts: %v", err)
+		} else if err := utils.WriteArtifacts(expDir, result.Artifacts); err != nil {
+			log.Warnf("Failed to write %s: %v", utils.ArtifactsFile, err)
+		} else if cfg.Objects.Dedup {
+			if err := objects.Dedup(baseDir, expDir, result.Artifacts, cfg.Objects.Link); err != nil {
+				log.Warnf("Failed to deduplicate artifacts: %v", err)
+			}
+		}
+	}
+	hostname, _ := os.Hostname()
+	result.GPUHours, result.Cost = estimateCost(cfg, hostname, endTime.Sub(startTime), countGPUs())
+	if err := utils.WriteSummaryFileEnd(summaryPath, startTime, result); err != nil {
 		return fmt.Errorf("failed to write summary: %w", err)
 	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		log.Warnf("Failed to write %s: %v", utils.SummaryJSONFile, err)
+	}
+	if err := utils.RemovePIDFile(expDir); err != nil {
+		log.Warnf("Failed to remove PID file: %v", err)
+	}
+	if err := utils.RemoveHeartbeat(expDir); err != nil {
+		log.Warnf("Failed to remove heartbeat file: %v", err)
+	}
+	index.Update(baseDir, cfg.SummaryFile)
+	if success && cfg.Run.SuccessLink {
+		if err := utils.UpdateRunLink(baseDir, utils.LatestSuccessLink, expDir); err != nil {
+			log.Warnf("Failed to update %s link: %v", utils.LatestSuccessLink, err)
+		}
+	}
+
+	runInfo := utils.RunInfo{
+		Directory:   expDir,
+		Command:     shellescape.QuoteCommand(commands),
+		StartTime:   startTime,
+		EndTime:     endTime,
+		ExitStatus:  exitCode,
+		ExitReason:  reason,
+		Success:     success,
+		Interrupted: interrupted,
+		TimedOut:    timedOut,
+		Branch:      repo.Branch,
+		CommitHash:  repo.FullHash,
+		Hostname:    hostname,
+		Issues:      issues,
+		Metrics:     result.Metrics,
+	}
+
+	// Run hooks after the command; failures do not change the result
+	hookVars["exit_code"] = strconv.Itoa(exitCode)
+	hookVars["duration"] = runInfo.Duration()
+	hookVars["status"] = utils.StatusString(runInfo)
+	if err := runHooks(cfg.Run.PostHooks, hookVars, expDir, cmd.Dir, cfg.Run.Silent); err != nil {
+		log.Warnf("Failed to run post-run hooks: %v", err)
+	}
+	notify.RunFinished(runInfo)
 
 	// Handle cleanup on failure
-	if exitCode != 0 && cfg.Run.CleanupOnFail {
+	removed := !success && cfg.Run.CleanupOnFail
+	if removed {
 		cleanupRun(expDir)
 	}
 
-	if exitCode != 0 {
+	// Attach a record of the run to the commit
+	if cfg.Run.GitNotes {
+		if err := utils.AddGitNote(repo.FullHash, formatNote(runInfo, removed)); err != nil {
+			log.Warnf("Failed to add git note: %v", err)
+		}
+	}
+
+	if startErr != nil {
+		return startErr
+	}
+	if !success {
 		return fmt.Errorf("command failed with exit code %d", exitCode)
 	}
 
 	return nil
 }
 
-func cleanupRun(expDir string) {
-	// it is very unlikely that this will fail, so we don't check the error, or should we?
-	log.Infof("Cleaning up directory: %s", expDir)
-	os.RemoveAll(expDir)
+// terminateGroup sends SIGTERM to the process group of a command, and SIGKILL
+// if the command has not exited within the grace period, then waits for it
+func terminateGroup(pid int, done <-chan error) {
+	if err := utils.SignalProcessGroup(pid, syscall.SIGTERM); err != nil {
+		log.Errorf("Failed to send signal to process: %v", err)
+	}
+	select {
+	case <-done:
+		return
+	case <-time.After(killGracePeriod):
+	}
+	log.Warnf("Command did not exit within %s, killing it", killGracePeriod)
+	if err := utils.SignalProcessGroup(pid, syscall.SIGKILL); err != nil {
+		log.Errorf("Failed to send signal to process: %v", err)
+	}
+	<-done
 }
 
-// getUserInput prompts the user for input using the configured editor
-func getUserInput() (string, error) {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
+// recordEvent appends an event to the event log of a run
+func recordEvent(runDir string, event utils.Event) {
+	if err := utils.AppendEvent(runDir, event); err != nil {
+		log.Warnf("Failed to record %s event: %v", event.Type, err)
 	}
+}
 
-	tmpfile, err := os.CreateTemp("", "input-*.tmp")
-	if err != nil {
-		return "", err
+// startHeartbeat touches the heartbeat file of a run every interval until
+// the returned function is called; a non-positive interval disables it
+func startHeartbeat(runDir string, interval time.Duration) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	if err := utils.TouchHeartbeat(runDir); err != nil {
+		log.Warnf("Failed to write heartbeat file: %v", err)
 	}
-	defer os.Remove(tmpfile.Name())
 
-	cmd := exec.Command(editor, tmpfile.Name())
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := utils.TouchHeartbeat(runDir); err != nil {
+					log.Debugf("Failed to write heartbeat file: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// formatNote formats a condensed record of a finished run for git notes,
+// leaving out the run directory if it has been removed
+func formatNote(run utils.RunInfo, removed bool) string {
+	lines := []string{fmt.Sprintf("moco: %s in %s", utils.StatusString(run), run.Duration())}
+	if !removed {
+		lines = append(lines, "Run: "+run.Directory)
+	}
+	lines = append(lines, "Command: "+run.Command)
+	if len(run.Metrics) > 0 {
+		names := slices.Sorted(maps.Keys(run.Metrics))
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = name + "=" + utils.FormatMetric(run.Metrics[name])
+		}
+		lines = append(lines, "Metrics: "+strings.Join(values, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	if err := cmd.Run(); err != nil {
-		return "", err
+// collectIssues merges explicitly given issue IDs with those found in a commit message
+func collectIssues(issues []string, commitMessage, pattern string) ([]string, error) {
+	var collected []string
+	add := func(issue string) {
+		if issue != "" && !slices.Contains(collected, issue) {
+			collected = append(collected, issue)
+		}
+	}
+	for _, issue := range issues {
+		add(strings.TrimSpace(issue))
 	}
 
-	content, err := os.ReadFile(tmpfile.Name())
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue pattern: %w", err)
+		}
+		for _, issue := range re.FindAllString(commitMessage, -1) {
+			add(issue)
+		}
+	}
+	return collected, nil
+}
+
+// getExtraRepos retrieves the states of additional repositories
+func getExtraRepos(paths []string) []utils.ExtraRepo {
+	var repos []utils.ExtraRepo
+	for _, path := range paths {
+		status, err := utils.GetRepoStatusAt(path)
+		if err != nil {
+			log.Warnf("Failed to get status of repository %s: %v", path, err)
+			continue
+		}
+		repos = append(repos, utils.ExtraRepo{
+			Path:       path,
+			Branch:     status.Branch,
+			CommitHash: status.FullHash,
+			IsDirty:    status.IsDirty,
+		})
+	}
+	return repos
+}
+
+// fingerprintData computes fingerprints of the declared data paths, relative
+// ones of which are resolved against dir if given
+func fingerprintData(paths []string, dir string, full bool) map[string]string {
+	fingerprints := map[string]string{}
+	for _, path := range paths {
+		log.Infof("Fingerprinting data: %s", path)
+		// Fingerprints are recorded under the paths as configured
+		target := path
+		if dir != "" && !filepath.IsAbs(path) {
+			target = filepath.Join(dir, path)
+		}
+		fingerprint, err := utils.FingerprintPath(target, full)
+		if err != nil {
+			log.Warnf("Failed to fingerprint %s: %v", path, err)
+			continue
+		}
+		fingerprints[path] = fingerprint
+	}
+	return fingerprints
+}
+
+// recordEnv looks up the values of environment variables to be recorded
+func recordEnv(names []string) map[string]string {
+	env := map[string]string{}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// writePatch saves the full patch of uncommitted changes, excluding the base
+// directory, in the run directory
+func writePatch(expDir, baseDir string) error {
+	patch, err := utils.GetFullPatch(baseDir)
 	if err != nil {
-		return "", err
+		return err
 	}
+	return os.WriteFile(filepath.Join(expDir, utils.PatchFile), []byte(patch), 0644)
+}
 
-	return string(content), nil
+func cleanupRun(expDir string) {
+	// The event is seen only by tools following the log while the run exists
+	recordEvent(expDir, utils.Event{Type: utils.EventCleanup})
+	// it is very unlikely that this will fail, so we don't check the error, or should we?
+	log.Infof("Cleaning up directory: %s", expDir)
+	os.RemoveAll(expDir)
 }
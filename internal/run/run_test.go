@@ -0,0 +1,31 @@
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatNote(t *testing.T) {
+	start := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	run := utils.RunInfo{
+		Directory: "runs/2025-03-24T00:00:00.000_main_1234567",
+		Command:   "python train.py",
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+		Success:   true,
+		Metrics:   map[string]float64{"loss": 0.25, "accuracy": 0.9},
+	}
+	note := formatNote(run, false)
+	assert.Contains(t, note, "Run: runs/2025-03-24T00:00:00.000_main_1234567\n")
+	assert.Contains(t, note, "Command: python train.py\n")
+	assert.Contains(t, note, "Metrics: accuracy=0.9, loss=0.25")
+
+	run.Success = false
+	run.Metrics = nil
+	note = formatNote(run, true)
+	assert.NotContains(t, note, "Run:")
+	assert.NotContains(t, note, "Metrics:")
+}
@@ -0,0 +1,99 @@
+package run
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/term"
+)
+
+// stdinLogFile is the file in the run directory capturing the input
+// forwarded to the command
+const stdinLogFile = "stdin.log"
+
+// forwardStdin connects the standard input to the command and returns a
+// function to call when the command has exited
+//
+// A terminal is handed over to the command by making its process group the
+// foreground one, so that interactive programs (e.g., pdb) see a terminal;
+// other input is forwarded through a pipe and also written to the log
+// opened by openLog unless it is nil.
+func forwardStdin(cmd *exec.Cmd, openLog func() (io.Writer, error)) (func(), error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		// Reading from the terminal in the background would stop moco
+		if inBackground() {
+			return func() {}, nil
+		}
+		if openLog != nil {
+			log.Warn("Input from a terminal is not recorded in " + stdinLogFile)
+		}
+		cmd.Stdin = os.Stdin
+		setForeground(cmd)
+		return restoreForeground, nil
+	}
+
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = pipe
+	if openLog != nil {
+		logFile, err := openLog()
+		if err != nil {
+			return nil, err
+		}
+		w = io.MultiWriter(pipe, logFile)
+	}
+
+	// The copy ends at the end of the input or when the command exits, so
+	// that the rest of the input is left for later runs (e.g., of a sweep)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer pipe.Close()
+		chunks := stdinChunks()
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					return
+				}
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}, nil
+}
+
+// stdinChunks returns the channel of chunks read from the standard input,
+// which is closed at the end of the input; a single reader is shared by all
+// runs in the process and reads only as much as is consumed
+var stdinChunks = sync.OnceValue(func() <-chan []byte {
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				chunks <- append([]byte(nil), buf[:n]...)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return chunks
+})
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package run
+
+import "os/exec"
+
+// inBackground reports whether moco is in the background, which can't be
+// told here
+func inBackground() bool {
+	return false
+}
+
+// setForeground does nothing since commands share the console here
+func setForeground(cmd *exec.Cmd) {}
+
+// restoreForeground does nothing since commands share the console here
+func restoreForeground() {}
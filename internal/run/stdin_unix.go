@@ -0,0 +1,59 @@
+//go:build unix
+
+package run
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/charmbracelet/log"
+)
+
+// inBackground reports whether moco is in a background process group of
+// the terminal connected to the standard input
+func inBackground() bool {
+	pgrp, err := foregroundGroup()
+	if err != nil {
+		return false
+	}
+	return pgrp != syscall.Getpgrp()
+}
+
+// foregroundGroup returns the foreground process group of the terminal
+// connected to the standard input
+func foregroundGroup() (int, error) {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// setForeground makes the process group of a command the foreground one of
+// the terminal connected to the standard input when it starts
+func setForeground(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(os.Stdin.Fd())
+}
+
+// restoreForeground makes the process group of moco the foreground one of
+// the terminal again after the command has exited
+func restoreForeground() {
+	// Changing the foreground group from the background stops the process
+	// with SIGTTOU unless it is ignored
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	pgrp := int32(syscall.Getpgrp())
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		log.Warnf("Failed to take back the terminal: %v", errno)
+	}
+}
@@ -0,0 +1,71 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateSuffix is removed from the names of rendered template files
+const templateSuffix = ".tmpl"
+
+// templateData holds the variables available in templates
+type templateData struct {
+	RunDir      string            // absolute path of the run directory
+	Name        string            // name of the run, if any
+	Branch      string            // branch of the repository
+	Commit      string            // full commit hash
+	ShortCommit string            // abbreviated commit hash
+	StartTime   time.Time         // start time of the run
+	Sweep       string            // ID of the sweep, if any
+	Params      map[string]string // parameter values of the sweep, if any
+}
+
+// renderedName returns the name of the file rendered from a template in the
+// run directory
+func renderedName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), templateSuffix)
+}
+
+// renderTemplates renders Go templates into the run directory; all
+// templates are parsed before anything is written, and referring to an
+// undefined parameter is an error
+func renderTemplates(paths []string, data templateData, expDir string) ([]string, error) {
+	var tmpls []*template.Template
+	seen := map[string]string{}
+	for _, path := range paths {
+		name := renderedName(path)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("templates %s and %s are rendered to the same file %s", other, path, name)
+		}
+		seen[name] = path
+
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		tmpl, err := template.New(path).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+
+	var rendered []string
+	for _, tmpl := range tmpls {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("failed to render template: %w", err)
+		}
+		name := renderedName(tmpl.Name())
+		if err := os.WriteFile(filepath.Join(expDir, name), b.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, name)
+	}
+	return rendered, nil
+}
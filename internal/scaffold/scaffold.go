@@ -0,0 +1,108 @@
+package scaffold
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/charmbracelet/log"
+)
+
+// header explains how to use the generated configuration file
+const header = `# Moco configuration
+#
+# All settings are commented out with their default values; uncomment and
+# edit the ones to change. Run "moco config" to see the effective settings.
+
+`
+
+// Main writes a configuration file with the default settings and, for
+// projects, creates the base directory and optionally ignores it in git
+func Main() error {
+	cfg := config.Get()
+
+	path := ".moco.toml"
+	if cfg.Init.User {
+		var err error
+		path, err = config.UserFile()
+		if err != nil {
+			return fmt.Errorf("failed to locate user config directory: %w", err)
+		}
+	}
+	if err := writeConfig(path, cfg.Init.Force); err != nil {
+		return err
+	}
+	log.Infof("Wrote %s", path)
+	if cfg.Init.User {
+		return nil
+	}
+
+	// Settings of an invalid file that was replaced are not used
+	if config.Err() != nil {
+		project, err := config.LoadProject(".")
+		if err != nil {
+			return err
+		}
+		cfg.BaseDir = project.BaseDir
+	}
+
+	if err := os.MkdirAll(cfg.BaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %w", err)
+	}
+	log.Infof("Created %s", cfg.BaseDir)
+
+	if cfg.Init.Gitignore {
+		added, err := ignore(".gitignore", filepath.ToSlash(filepath.Clean(cfg.BaseDir))+"/")
+		if err != nil {
+			return fmt.Errorf("failed to update .gitignore: %w", err)
+		}
+		if added {
+			log.Infof("Added %s/ to .gitignore", cfg.BaseDir)
+		}
+	}
+	return nil
+}
+
+// writeConfig writes the configuration template, refusing to overwrite an
+// existing file unless forced
+func writeConfig(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(header+config.Template()), 0644)
+}
+
+// ignore appends a pattern to a gitignore file unless it is already there,
+// reporting whether it was added
+func ignore(path, pattern string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == pattern || line == strings.TrimSuffix(pattern, "/") || line == "/"+pattern {
+			return false, nil
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		pattern = "\n" + pattern
+	}
+	if _, err := fmt.Fprintln(file, pattern); err != nil {
+		return false, err
+	}
+	return true, file.Close()
+}
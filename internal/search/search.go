@@ -0,0 +1,205 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fields maps field qualifiers to the values of a run they are matched against
+var fields = map[string]func(run utils.RunInfo) []string{
+	"name":    func(run utils.RunInfo) []string { return []string{run.Name} },
+	"message": func(run utils.RunInfo) []string { return []string{run.Message} },
+	"command": func(run utils.RunInfo) []string { return []string{run.Command} },
+	"branch":  func(run utils.RunInfo) []string { return []string{run.Branch} },
+	"commit":  func(run utils.RunInfo) []string { return []string{run.CommitHash} },
+	"issue":   func(run utils.RunInfo) []string { return run.Issues },
+	"tag":     func(run utils.RunInfo) []string { return run.Tags },
+	"note": func(run utils.RunInfo) []string {
+		texts := make([]string, len(run.Notes))
+		for i, note := range run.Notes {
+			texts[i] = note.Text
+		}
+		return texts
+	},
+}
+
+// term is a single search term, optionally qualified with a field
+type term struct {
+	field   string
+	pattern *regexp.Regexp
+}
+
+// Main searches runs selected by the list filters for those matching all
+// terms of a query and shows them with excerpts of matching lines
+func Main(query []string) error {
+	cfg := config.Get()
+
+	terms, err := parseQuery(query, cfg.Search.Regex)
+	if err != nil {
+		return err
+	}
+
+	// Runs are parsed through the index, and the limit applies to matches
+	limit := cfg.List.Limit
+	cfg.List.Limit = 0
+	cfg.List.Archived = false
+	cfg.List.AllProjects = false
+	runs, err := list.Find(cfg)
+	if err != nil {
+		return err
+	}
+
+	files := []string{cfg.SummaryFile}
+	if cfg.Search.Logs {
+		files = append(files, cfg.Run.StdoutFile, cfg.Run.StderrFile)
+	}
+	var matches []match
+	for _, run := range runs {
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+		m, ok, err := matchRun(terms, run, files)
+		if err != nil {
+			log.Warnf("Failed to search %s: %v", run.Directory, err)
+			continue
+		}
+		if ok {
+			matches = append(matches, m)
+		}
+	}
+
+	if len(matches) == 0 {
+		log.Info("No runs match the query")
+		return nil
+	}
+	matched := make([]utils.RunInfo, len(matches))
+	for i, m := range matches {
+		matched[i] = m.run
+	}
+	fmt.Println(utils.RenderRunInfos(matched))
+	for _, m := range matches {
+		if len(m.excerpts) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s\n", m.run.Directory)
+		for _, excerpt := range m.excerpts {
+			fmt.Printf("  %s\n", excerpt)
+		}
+		if m.more > 0 {
+			fmt.Printf("  ... and %d more lines\n", m.more)
+		}
+	}
+	return nil
+}
+
+// parseQuery parses search terms such as "message:baseline" or "lr=0.1"
+//
+// Terms are matched case-insensitively as substrings, or as regular
+// expressions if regex is true.
+func parseQuery(query []string, regex bool) ([]term, error) {
+	var terms []term
+	for _, arg := range query {
+		for _, s := range strings.Fields(arg) {
+			t := term{}
+			if field, value, found := strings.Cut(s, ":"); found {
+				if _, known := fields[field]; known {
+					t.field = field
+					s = value
+				}
+			}
+
+			if !regex {
+				s = regexp.QuoteMeta(s)
+			}
+			pattern, err := regexp.Compile("(?i)" + s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+			}
+			t.pattern = pattern
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	return terms, nil
+}
+
+// maxExcerpts is the maximum number of matching lines shown for a run
+const maxExcerpts = 5
+
+// maxExcerptLength is the maximum length of a matching line shown
+const maxExcerptLength = 200
+
+// match is a run matching a query with excerpts of matching lines
+type match struct {
+	run      utils.RunInfo
+	excerpts []string
+	more     int // number of matching lines not in excerpts
+}
+
+// matchRun reports whether a run matches all terms; plain terms must each
+// match a line of any of the files in the run directory, which are read line
+// by line so that large logs are not loaded at once
+func matchRun(terms []term, run utils.RunInfo, files []string) (match, bool, error) {
+	m := match{run: run}
+	var plain []term
+	for _, t := range terms {
+		if t.field == "" {
+			plain = append(plain, t)
+		} else if !slices.ContainsFunc(fields[t.field](run), t.pattern.MatchString) {
+			return m, false, nil
+		}
+	}
+	if len(plain) == 0 {
+		return m, true, nil
+	}
+
+	found := make([]bool, len(plain))
+	for _, name := range files {
+		file, err := os.Open(filepath.Join(run.Directory, name))
+		if os.IsNotExist(err) && name != files[0] {
+			continue
+		} else if err != nil {
+			return m, false, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 1<<20)
+		for lineno := 1; scanner.Scan(); lineno++ {
+			line := scanner.Text()
+			matched := false
+			for i, t := range plain {
+				if t.pattern.MatchString(line) {
+					found[i] = true
+					matched = true
+				}
+			}
+			if !matched {
+				continue
+			}
+			if len(m.excerpts) < maxExcerpts {
+				line = ansi.Truncate(strings.TrimSpace(line), maxExcerptLength, "…")
+				m.excerpts = append(m.excerpts, fmt.Sprintf("%s:%d: %s", name, lineno, line))
+			} else {
+				m.more++
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return m, false, err
+		}
+	}
+	return m, !slices.Contains(found, false), nil
+}
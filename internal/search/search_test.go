@@ -0,0 +1,65 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	terms, err := parseQuery([]string{"note:diverged tag:best", "lr=0.1", "unknown:x"}, false)
+	require.NoError(t, err)
+	require.Len(t, terms, 4)
+	assert.Equal(t, "note", terms[0].field)
+	assert.Equal(t, "tag", terms[1].field)
+	assert.Equal(t, "", terms[2].field)
+	assert.True(t, terms[2].pattern.MatchString("LR=0.1"))
+	assert.False(t, terms[2].pattern.MatchString("lr=001"))
+	assert.Equal(t, "", terms[3].field)
+
+	_, err = parseQuery([]string{" "}, false)
+	assert.Error(t, err)
+	_, err = parseQuery([]string{"("}, true)
+	assert.Error(t, err)
+}
+
+func TestMatchRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "summary.md"), []byte("# Summary\nlr=0.1\n"), 0644))
+	run := utils.RunInfo{
+		Directory: dir,
+		Message:   "baseline",
+		Tags:      []string{"best", "v2"},
+		Notes:     []utils.Note{{Text: "Loss diverged after epoch 3"}},
+	}
+
+	tests := []struct {
+		query []string
+		want  bool
+	}{
+		{[]string{"tag:best"}, true},
+		{[]string{"tag:v3"}, false},
+		{[]string{"note:diverged"}, true},
+		{[]string{"note:converged"}, false},
+		{[]string{"message:baseline", "note:epoch"}, true},
+		{[]string{"tag:best", "lr=0.1"}, true},
+		{[]string{"tag:best", "lr=0.2"}, false},
+	}
+	for _, tt := range tests {
+		terms, err := parseQuery(tt.query, false)
+		require.NoError(t, err)
+		_, ok, err := matchRun(terms, run, []string{"summary.md"})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, ok, "query %v", tt.query)
+	}
+
+	terms, err := parseQuery([]string{"lr=0.1"}, false)
+	require.NoError(t, err)
+	m, _, err := matchRun(terms, run, []string{"summary.md"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summary.md:2: lr=0.1"}, m.excerpts)
+}
@@ -0,0 +1,235 @@
+package selfupdate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/charmbracelet/log"
+)
+
+// releasesURL is the GitHub API endpoint listing releases of moco
+const releasesURL = "https://api.github.com/repos/bicycle1885/moco/releases"
+
+// checksumsAsset is the name of the release asset listing SHA-256 checksums
+// of the other assets in the format of sha256sum
+const checksumsAsset = "checksums.txt"
+
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// Release is a GitHub release
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a GitHub release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Main updates the running binary to the latest release of the configured channel
+func Main(currentVersion string) error {
+	cfg := config.Get()
+
+	channel := cfg.SelfUpdate.Channel
+	if channel != "stable" && channel != "prerelease" {
+		return fmt.Errorf("invalid channel: %s (expected stable or prerelease)", channel)
+	}
+
+	// Find the latest release
+	releases, err := fetchReleases()
+	if err != nil {
+		return fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	release, found := latestRelease(releases, channel == "prerelease")
+	if !found {
+		return fmt.Errorf("no release found in the %s channel", channel)
+	}
+	newer, err := isNewer(currentVersion, release.TagName)
+	switch {
+	case err != nil:
+		// Builds without a release version (e.g., dev) cannot tell a downgrade
+		if !cfg.SelfUpdate.Force && !cfg.SelfUpdate.Check {
+			return fmt.Errorf("%w; use --force to install %s anyway", err, release.TagName)
+		}
+		log.Warnf("%v", err)
+		log.Infof("Latest release: %s (current: %s)", release.TagName, currentVersion)
+	case newer:
+		log.Infof("New version available: %s (current: %s)", release.TagName, currentVersion)
+	case !cfg.SelfUpdate.Force:
+		log.Infof("Already up to date (current: %s, latest: %s)", currentVersion, release.TagName)
+		return nil
+	default:
+		log.Warnf("Installing %s, which is not newer than the current version %s", release.TagName, currentVersion)
+	}
+	if cfg.SelfUpdate.Check {
+		return nil
+	}
+
+	// Find the binary for this platform and its checksum
+	binaryName := fmt.Sprintf("moco_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	binary, found := findAsset(release, binaryName)
+	if !found {
+		return fmt.Errorf("no binary for %s/%s in release %s", runtime.GOOS, runtime.GOARCH, release.TagName)
+	}
+	checksums, found := findAsset(release, checksumsAsset)
+	if !found {
+		return fmt.Errorf("no checksums in release %s", release.TagName)
+	}
+	expected, err := fetchChecksum(checksums.URL, binaryName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksum: %w", err)
+	}
+
+	// Download the binary next to the running one so that it can be renamed atomically
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(executable), ".moco-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	log.Infof("Downloading %s", binary.URL)
+	actual, err := download(binary.URL, tmpFile)
+	tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download binary: %w", err)
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", binaryName, expected, actual)
+	}
+
+	// Replace the running binary
+	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to make binary executable: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), executable); err != nil {
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+
+	log.Infof("Updated %s to %s", executable, release.TagName)
+	return nil
+}
+
+// isNewer reports whether the release latest is strictly newer than the current version
+func isNewer(current, latest string) (bool, error) {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false, fmt.Errorf("release %s has no semantic version", latest)
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false, fmt.Errorf("current version %s is not a release version", current)
+	}
+	return l.compare(c) > 0, nil
+}
+
+// fetchReleases retrieves the releases of moco, newest first
+func fetchReleases() ([]Release, error) {
+	resp, err := get(releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+	return releases, nil
+}
+
+// latestRelease returns the newest published release, including pre-releases if requested
+func latestRelease(releases []Release, prerelease bool) (Release, bool) {
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && !prerelease) {
+			continue
+		}
+		return release, true
+	}
+	return Release{}, false
+}
+
+// findAsset finds an asset of a release by name
+func findAsset(release Release, name string) (Asset, bool) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// fetchChecksum retrieves the checksum of a file from a checksums file
+func fetchChecksum(url, name string) (string, error) {
+	resp, err := get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Each line is "<checksum>  <file name>"
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// download writes the content at url to w and returns its SHA-256 checksum
+func download(url string, w io.Writer) (string, error) {
+	resp, err := get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// get sends a GET request and fails on non-2xx responses
+func get(url string) (*http.Response, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
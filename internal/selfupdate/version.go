@@ -0,0 +1,129 @@
+package selfupdate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version such as v1.2.3-rc.1+build
+type version struct {
+	core       [3]uint64
+	prerelease []string
+}
+
+// parseVersion parses a semantic version with an optional "v" prefix
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(s, "v")
+	// Build metadata does not affect precedence
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdentifiers(s[i+1:]) {
+			return version{}, false
+		}
+		s = s[:i]
+	}
+	var v version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if !validIdentifiers(s[i+1:]) {
+			return version{}, false
+		}
+		v.prerelease = strings.Split(s[i+1:], ".")
+		for _, id := range v.prerelease {
+			if isNumeric(id) && len(id) > 1 && id[0] == '0' {
+				return version{}, false
+			}
+		}
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return version{}, false
+	}
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return version{}, false
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return version{}, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0, or 1 if v has lower, equal, or higher precedence than w
+func (v version) compare(w version) int {
+	for i := range v.core {
+		if v.core[i] != w.core[i] {
+			return cmpUint(v.core[i], w.core[i])
+		}
+	}
+	// A pre-release has lower precedence than the release itself
+	switch {
+	case len(v.prerelease) == 0 && len(w.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(w.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(w.prerelease); i++ {
+		a, b := v.prerelease[i], w.prerelease[i]
+		if a == b {
+			continue
+		}
+		// Numeric identifiers have lower precedence than alphanumeric ones
+		switch aNum, bNum := isNumeric(a), isNumeric(b); {
+		case aNum && bNum:
+			x, _ := strconv.ParseUint(a, 10, 64)
+			y, _ := strconv.ParseUint(b, 10, 64)
+			return cmpUint(x, y)
+		case aNum:
+			return -1
+		case bNum:
+			return 1
+		case a < b:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return cmpUint(uint64(len(v.prerelease)), uint64(len(w.prerelease)))
+}
+
+// validIdentifiers checks dot-separated identifiers of a pre-release or build metadata
+func validIdentifiers(s string) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
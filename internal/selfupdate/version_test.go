@@ -0,0 +1,78 @@
+package selfupdate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, s := range []string{"v1.2.3", "1.2.3", "v0.0.0", "v1.2.3-rc.1", "v1.2.3-alpha-1+build.5", "v1.2.3+20240101"} {
+		_, ok := parseVersion(s)
+		assert.True(t, ok, s)
+	}
+	for _, s := range []string{"dev", "", "v1.2", "v1.2.3.4", "v01.2.3", "v1.2.3-", "v1.2.3-rc..1", "v1.2.3-01", "v1.2.3+", "v1.x.3"} {
+		_, ok := parseVersion(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// Ordered by precedence, as in the semantic versioning specification
+	ordered := []string{
+		"v0.9.9",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.0.1",
+		"v1.2.0",
+		"v1.10.0",
+		"v2.0.0",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			v, ok := parseVersion(a)
+			require.True(t, ok, a)
+			w, ok := parseVersion(b)
+			require.True(t, ok, b)
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			assert.Equal(t, want, v.compare(w), "%s vs %s", a, b)
+		}
+	}
+
+	// Build metadata is ignored
+	v, _ := parseVersion("v1.0.0+a")
+	w, _ := parseVersion("v1.0.0+b")
+	assert.Equal(t, 0, v.compare(w))
+}
+
+func TestIsNewer(t *testing.T) {
+	newer, err := isNewer("v1.0.0", "v1.1.0")
+	require.NoError(t, err)
+	assert.True(t, newer)
+
+	newer, err = isNewer("v1.1.0", "v1.1.0")
+	require.NoError(t, err)
+	assert.False(t, newer)
+
+	// A newer pre-release must not be downgraded to the latest stable release
+	newer, err = isNewer("v1.2.0-rc.1", "v1.1.0")
+	require.NoError(t, err)
+	assert.False(t, newer)
+
+	_, err = isNewer("dev", "v1.1.0")
+	assert.Error(t, err)
+	_, err = isNewer("v1.1.0", "nightly")
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/status"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// handleAPIRuns returns runs filtered by query parameters as list --format json
+func (s *server) handleAPIRuns(w http.ResponseWriter, r *http.Request) {
+	cfg, err := s.listConfig(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	runs, err := list.Find(cfg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, list.NewOutput(runs))
+}
+
+// handleAPIRun returns the information of a run
+func (s *server) handleAPIRun(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !utils.RunDirPattern.MatchString(name) {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	runInfo, err := utils.ParseRunInfo(filepath.Join(s.cfg.BaseDir, name, s.cfg.SummaryFile))
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Runs whose heartbeat stopped are no longer running
+	staleAfter, err := time.ParseDuration(s.cfg.Run.StaleAfter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	runs := []utils.RunInfo{runInfo}
+	utils.MarkStale(runs, staleAfter)
+	writeJSON(w, runs[0])
+}
+
+// handleAPIStatus returns the status of the project as status --format json
+func (s *server) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg
+	if level := r.URL.Query().Get("level"); level != "" {
+		cfg.Status.Level = level
+	}
+	report, err := status.Collect(cfg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// handleMetrics returns metrics of the project for Prometheus
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	if err := status.WritePrometheus(&b, s.cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// writeJSON writes a value as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
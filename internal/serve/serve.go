@@ -0,0 +1,242 @@
+package serve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// maxLogBytes limits how much of the end of a log file is shown
+const maxLogBytes = 1 << 20
+
+// Main serves the web UI until the server fails
+func Main() error {
+	cfg := config.Get()
+
+	listener, err := net.Listen("tcp", cfg.Serve.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.Serve.Addr, err)
+	}
+	if cfg.Serve.APIOnly {
+		log.Infof("Serving API for runs in %s at http://%s/api", cfg.BaseDir, listener.Addr())
+	} else {
+		log.Infof("Serving runs in %s at http://%s", cfg.BaseDir, listener.Addr())
+	}
+	return http.Serve(listener, newHandler(cfg))
+}
+
+// server serves runs in the base directory of a configuration
+type server struct {
+	cfg      config.Config
+	markdown goldmark.Markdown
+}
+
+// newHandler returns the handler of the API and, unless disabled, the UI
+func newHandler(cfg config.Config) http.Handler {
+	s := &server{
+		cfg:      cfg,
+		markdown: goldmark.New(goldmark.WithExtensions(extension.GFM)),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/runs", s.handleAPIRuns)
+	mux.HandleFunc("GET /api/runs/{name}", s.handleAPIRun)
+	mux.HandleFunc("GET /api/status", s.handleAPIStatus)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	if !cfg.Serve.APIOnly {
+		mux.HandleFunc("GET /{$}", s.handleRuns)
+		mux.HandleFunc("GET /runs/{name}", s.handleRun)
+		mux.HandleFunc("GET /runs/{name}/logs/{stream}", s.handleLog)
+	}
+	return mux
+}
+
+// filters are the filters of the run table, named as the list flags
+var filters = []string{"branch", "status", "since", "command", "issue", "tag", "sweep"}
+
+// listConfig returns the configuration listing runs as requested by query
+// parameters
+func (s *server) listConfig(query url.Values) (config.Config, error) {
+	cfg := s.cfg
+	cfg.List.Archived = false
+	cfg.List.AllProjects = false
+	cfg.List.Branch = query.Get("branch")
+	cfg.List.Status = query.Get("status")
+	cfg.List.Since = query.Get("since")
+	cfg.List.Command = query.Get("command")
+	cfg.List.Issue = query.Get("issue")
+	cfg.List.Tag = query.Get("tag")
+	cfg.List.Sweep = query.Get("sweep")
+	if sortBy := query.Get("sort"); sortBy != "" {
+		cfg.List.SortBy = sortBy
+	}
+	// Newest runs come first unless ascending order is requested
+	cfg.List.Reverse = query.Get("order") != "asc"
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid limit: %s", limit)
+		}
+		cfg.List.Limit = n
+	}
+	return cfg, nil
+}
+
+// handleRuns shows the table of runs filtered by query parameters
+func (s *server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cfg, err := s.listConfig(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	runs, err := list.Find(cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := make(map[string]string)
+	for _, name := range append(filters, "sort", "order", "limit") {
+		values[name] = query.Get(name)
+	}
+	s.render(w, runsTemplate, map[string]any{
+		"Title":    "Runs",
+		"Runs":     runs,
+		"Filters":  filters,
+		"Values":   values,
+		"IssueURL": s.cfg.Run.IssueURL,
+	})
+}
+
+// handleRun shows the rendered summary of a run
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+	runDir, ok := s.runDir(w, r)
+	if !ok {
+		return
+	}
+	summaryPath := filepath.Join(runDir, s.cfg.SummaryFile)
+	content, err := os.ReadFile(summaryPath)
+	if err != nil {
+		s.fileError(w, err)
+		return
+	}
+	var issues []utils.IssueLink
+	if runInfo, err := utils.ParseRunInfo(summaryPath); err == nil {
+		issues = utils.IssueLinks(runInfo.Issues, s.cfg.Run.IssueURL)
+	}
+	var summary bytes.Buffer
+	if err := s.markdown.Convert(content, &summary); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.render(w, runTemplate, map[string]any{
+		"Title":   filepath.Base(runDir),
+		"Name":    filepath.Base(runDir),
+		"Summary": template.HTML(summary.String()),
+		"Issues":  issues,
+	})
+}
+
+// handleLog shows the end of the stdout or stderr log of a run
+func (s *server) handleLog(w http.ResponseWriter, r *http.Request) {
+	runDir, ok := s.runDir(w, r)
+	if !ok {
+		return
+	}
+	var file string
+	switch stream := r.PathValue("stream"); stream {
+	case "stdout":
+		file = s.cfg.Run.StdoutFile
+	case "stderr":
+		file = s.cfg.Run.StderrFile
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	content, truncated, err := readTail(filepath.Join(runDir, file), maxLogBytes)
+	if err != nil {
+		s.fileError(w, err)
+		return
+	}
+
+	s.render(w, logTemplate, map[string]any{
+		"Title":     filepath.Base(runDir) + " " + file,
+		"Name":      filepath.Base(runDir),
+		"File":      file,
+		"Content":   content,
+		"Truncated": truncated,
+	})
+}
+
+// runDir returns the directory of the run named in the path, writing an
+// error response if there is no such run
+func (s *server) runDir(w http.ResponseWriter, r *http.Request) (string, bool) {
+	// Only names of run directories are accepted so that no other files
+	// can be reached
+	name := r.PathValue("name")
+	if !utils.RunDirPattern.MatchString(name) {
+		http.NotFound(w, r)
+		return "", false
+	}
+	return filepath.Join(s.cfg.BaseDir, name), true
+}
+
+// fileError writes an error response for a failure to read a file of a run
+func (s *server) fileError(w http.ResponseWriter, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// render writes a page, buffering it so that errors can be reported
+func (s *server) render(w http.ResponseWriter, t *template.Template, data map[string]any) {
+	var b bytes.Buffer
+	if err := t.ExecuteTemplate(&b, "page", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// readTail reads up to n last bytes of a file and reports whether the
+// beginning was cut off
+func readTail(path string, n int64) (string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	offset := max(info.Size()-n, 0)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", false, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), offset > 0, nil
+}
@@ -0,0 +1,51 @@
+package serve
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get returns the body of a page, failing unless it is found
+func get(t *testing.T, server *httptest.Server, path string) string {
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestIssueLinks(t *testing.T) {
+	cfg := config.GetDefault()
+	cfg.BaseDir = t.TempDir()
+	cfg.Run.IssueURL = "https://github.com/me/proj/issues/{id}"
+
+	name := "2025-03-24T00:00:00.000_main_1234567"
+	runDir := filepath.Join(cfg.BaseDir, name)
+	require.NoError(t, os.Mkdir(runDir, 0755))
+	start := time.Date(2025, 3, 24, 0, 0, 0, 0, time.Local)
+	summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+	require.NoError(t, utils.WriteSummaryFileInit(summaryPath, utils.RunMetadata{
+		StartTime: start,
+		Command:   []string{"train"},
+		Issues:    []string{"#7", "PROJ-42"},
+	}))
+	require.NoError(t, utils.WriteSummaryFileEnd(summaryPath, start, utils.RunResult{EndTime: start, Success: true}))
+
+	server := httptest.NewServer(newHandler(cfg))
+	defer server.Close()
+	links := `<a href="https://github.com/me/proj/issues/7">#7</a>, <a href="https://github.com/me/proj/issues/PROJ-42">PROJ-42</a>`
+	assert.Contains(t, get(t, server, "/"), links)
+	assert.Contains(t, get(t, server, "/runs/"+name), "Issues: "+links)
+}
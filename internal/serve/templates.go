@@ -0,0 +1,116 @@
+package serve
+
+import (
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// funcs are the functions available in templates
+var funcs = template.FuncMap{
+	"base":   filepath.Base,
+	"status": utils.StatusString,
+	"statusClass": func(run utils.RunInfo) string {
+		switch {
+		case run.IsRunning && !run.Stale:
+			return "running"
+		case run.Success:
+			return "success"
+		default:
+			return "failure"
+		}
+	},
+	"duration":   func(run utils.RunInfo) string { return run.Duration() },
+	"join":       strings.Join,
+	"issueLinks": utils.IssueLinks,
+}
+
+// layoutTemplate is the frame shared by all pages
+const layoutTemplate = `{{define "page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - moco</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em 2em; color: #222; }
+a { color: #0b5cad; text-decoration: none; }
+a:hover { text-decoration: underline; }
+header { margin-bottom: 1em; }
+header a { font-weight: bold; font-size: 1.2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; text-align: left; border-bottom: 1px solid #ddd; vertical-align: top; }
+td.number { text-align: right; }
+form { margin-bottom: 1em; }
+form label { margin-right: 0.8em; }
+form input { width: 8em; }
+code, pre { font-family: monospace; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.success { color: #1a7f37; }
+.failure { color: #cf222e; }
+.running { color: #9a6700; }
+.tag { background: #eef; border-radius: 3px; padding: 0 0.3em; margin-right: 0.2em; }
+</style>
+</head>
+<body>
+<header><a href="/">moco</a></header>
+{{template "content" .}}
+</body>
+</html>
+{{end}}
+{{define "issues"}}{{range $i, $link := .}}{{if $i}}, {{end}}{{if $link.URL}}<a href="{{$link.URL}}">{{$link.ID}}</a>{{else}}{{$link.ID}}{{end}}{{end}}{{end}}`
+
+var runsTemplate = template.Must(template.New("runs").Funcs(funcs).Parse(layoutTemplate + `
+{{define "content"}}
+<form method="get" action="/">
+{{range .Filters}}<label>{{.}} <input name="{{.}}" value="{{index $.Values .}}"></label>{{end}}
+<label>sort <select name="sort">
+{{$sort := index .Values "sort"}}
+<option value="date"{{if eq $sort "date"}} selected{{end}}>date</option>
+<option value="branch"{{if eq $sort "branch"}} selected{{end}}>branch</option>
+<option value="status"{{if eq $sort "status"}} selected{{end}}>status</option>
+<option value="duration"{{if eq $sort "duration"}} selected{{end}}>duration</option>
+<option value="cpu"{{if eq $sort "cpu"}} selected{{end}}>cpu</option>
+<option value="memory"{{if eq $sort "memory"}} selected{{end}}>memory</option>
+</select></label>
+<label>order <select name="order">
+<option value="desc">descending</option>
+<option value="asc"{{if eq (index .Values "order") "asc"}} selected{{end}}>ascending</option>
+</select></label>
+<label>limit <input name="limit" value="{{index .Values "limit"}}"></label>
+<button type="submit">Filter</button>
+</form>
+{{if .Runs}}
+<table>
+<tr><th>Directory</th><th>Status</th><th>Duration</th><th>Branch</th><th>Command</th><th>Tags</th><th>Issues</th></tr>
+{{range .Runs}}
+<tr>
+<td><a href="/runs/{{base .Directory}}">{{base .Directory}}</a></td>
+<td class="{{statusClass .}}">{{status .}}</td>
+<td class="number">{{duration .}}</td>
+<td>{{.Branch}}</td>
+<td><code>{{.Command}}</code></td>
+<td>{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</td>
+<td>{{template "issues" issueLinks .Issues $.IssueURL}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No runs match the criteria.</p>
+{{end}}
+{{end}}`))
+
+var runTemplate = template.Must(template.New("run").Funcs(funcs).Parse(layoutTemplate + `
+{{define "content"}}
+<p><a href="/runs/{{.Name}}/logs/stdout">stdout</a> | <a href="/runs/{{.Name}}/logs/stderr">stderr</a></p>
+{{if .Issues}}<p>Issues: {{template "issues" .Issues}}</p>{{end}}
+{{.Summary}}
+{{end}}`))
+
+var logTemplate = template.Must(template.New("log").Funcs(funcs).Parse(layoutTemplate + `
+{{define "content"}}
+<h2><a href="/runs/{{.Name}}">{{.Name}}</a> / {{.File}}</h2>
+{{if .Truncated}}<p>Only the end of the log is shown.</p>{{end}}
+<pre>{{.Content}}</pre>
+{{end}}`))
@@ -0,0 +1,97 @@
+package shellinit
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Shell code shared by bash and zsh
+const posixInit = `# moco shell integration
+# Path of the most recent run
+mlast() {
+  command moco list --reverse --limit 1 --format plain 2>/dev/null
+}
+
+# Show the summary of a run (defaults to the most recent run)
+mshow() {
+  command moco show "${1:-$(mlast)}"
+}
+
+# Change directory to a run (defaults to the most recent run)
+mcd() {
+  local dir="${1:-$(mlast)}"
+  if [ -z "$dir" ]; then
+    echo "mcd: no runs found" >&2
+    return 1
+  fi
+  cd "$dir"
+}
+
+# Prompt segment showing the number of running runs (empty if none)
+moco_prompt() {
+  local n
+  n=$(command moco list --status running --format plain 2>/dev/null | wc -l | tr -d ' ')
+  if [ "$n" -gt 0 ]; then
+    printf '[moco:%s running]' "$n"
+  fi
+}
+`
+
+const bashInit = posixInit + `
+# Example: PS1='$(moco_prompt) '"$PS1"
+`
+
+const zshInit = posixInit + `
+# Example: setopt PROMPT_SUBST; PROMPT='$(moco_prompt) '"$PROMPT"
+`
+
+const fishInit = `# moco shell integration
+# Path of the most recent run
+function mlast
+    command moco list --reverse --limit 1 --format plain 2>/dev/null
+end
+
+# Show the summary of a run (defaults to the most recent run)
+function mshow
+    set -l dir $argv[1]
+    test -z "$dir"; and set dir (mlast)
+    command moco show $dir
+end
+
+# Change directory to a run (defaults to the most recent run)
+function mcd
+    set -l dir $argv[1]
+    test -z "$dir"; and set dir (mlast)
+    if test -z "$dir"
+        echo "mcd: no runs found" >&2
+        return 1
+    end
+    cd $dir
+end
+
+# Prompt segment showing the number of running runs (empty if none)
+function moco_prompt
+    set -l n (command moco list --status running --format plain 2>/dev/null | count)
+    if test $n -gt 0
+        printf '[moco:%s running]' $n
+    end
+end
+
+# Example: call moco_prompt from fish_prompt
+`
+
+// Main prints shell integration code for the given shell
+func Main(shell string) error {
+	switch shell {
+	case "bash":
+		io.WriteString(os.Stdout, bashInit)
+	case "zsh":
+		io.WriteString(os.Stdout, zshInit)
+	case "fish":
+		io.WriteString(os.Stdout, fishInit)
+	default:
+		return fmt.Errorf("unsupported shell: %s (expected bash, zsh, or fish)", shell)
+	}
+	return nil
+}
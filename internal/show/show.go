@@ -4,27 +4,18 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
 	"github.com/charmbracelet/glamour"
 )
 
 func Main(run string) error {
 	cfg := config.Get()
 
-	var summaryPath string
-	stat, err := os.Stat(run)
-	if err == nil {
-		if stat.IsDir() {
-			// Assume run is a directory containing a summary file
-			summaryPath = filepath.Join(run, cfg.SummaryFile)
-		} else {
-			// Assume run is a summary file
-			summaryPath = run
-		}
-	} else {
+	summaryPath, err := utils.ResolveSummaryPath(run, cfg.SummaryFile)
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,67 @@
+package status
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+)
+
+// WritePrometheus writes metrics of the project in the Prometheus text
+// exposition format
+func WritePrometheus(w io.Writer, cfg config.Config) error {
+	stats, err := getProjectStats(cfg.BaseDir, cfg.SummaryFile, cfg.Run.StaleAfter)
+	if err != nil {
+		return fmt.Errorf("failed to get project statistics: %w", err)
+	}
+
+	fmt.Fprintln(w, "# HELP moco_runs Number of runs by status.")
+	fmt.Fprintln(w, "# TYPE moco_runs gauge")
+	fmt.Fprintf(w, "moco_runs{status=\"running\"} %d\n", stats.RunningCount)
+	fmt.Fprintf(w, "moco_runs{status=\"stale\"} %d\n", stats.StaleCount)
+	fmt.Fprintf(w, "moco_runs{status=\"success\"} %d\n", stats.SuccessCount)
+	fmt.Fprintf(w, "moco_runs{status=\"failure\"} %d\n", stats.FailureCount)
+
+	fmt.Fprintln(w, "# HELP moco_disk_usage_bytes Disk usage of the base directory.")
+	fmt.Fprintln(w, "# TYPE moco_disk_usage_bytes gauge")
+	fmt.Fprintf(w, "moco_disk_usage_bytes %d\n", stats.DiskUsage)
+
+	fmt.Fprintln(w, "# HELP moco_gpu_hours Recorded GPU hours of finished runs.")
+	fmt.Fprintln(w, "# TYPE moco_gpu_hours gauge")
+	fmt.Fprintf(w, "moco_gpu_hours %g\n", stats.GPUHours)
+
+	var lastStart, lastSuccess, lastFailure time.Time
+	for _, run := range stats.RecentRuns {
+		lastStart = latest(lastStart, run.StartTime)
+		if run.IsRunning {
+			continue
+		}
+		if run.Success {
+			lastSuccess = latest(lastSuccess, run.EndTime)
+		} else {
+			lastFailure = latest(lastFailure, run.EndTime)
+		}
+	}
+	writeTimestamp(w, "moco_last_run_start_timestamp_seconds", "Start time of the latest run.", lastStart)
+	writeTimestamp(w, "moco_last_success_timestamp_seconds", "End time of the latest successful run.", lastSuccess)
+	writeTimestamp(w, "moco_last_failure_timestamp_seconds", "End time of the latest failed run.", lastFailure)
+	return nil
+}
+
+// writeTimestamp writes a timestamp metric, omitting its sample if t is zero
+func writeTimestamp(w io.Writer, name, help string, t time.Time) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	if !t.IsZero() {
+		fmt.Fprintf(w, "%s %d\n", name, t.Unix())
+	}
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
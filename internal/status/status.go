@@ -1,35 +1,148 @@
 package status
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
-	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/projects"
+	"github.com/bicycle1885/moco/internal/tui"
 	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 )
 
+// runningStyle highlights running runs in the watch mode
+var runningStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+
 // ProjectStats contains project statistics
 type ProjectStats struct {
 	DiskUsage    int64           `json:"disk_usage"`
 	RunningCount int             `json:"running_count"`
+	StaleCount   int             `json:"stale_count"`
 	FailureCount int             `json:"failure_count"`
 	SuccessCount int             `json:"success_count"`
 	TotalRuns    int             `json:"total_runs"`
+	GPUHours     float64         `json:"gpu_hours"`
+	Cost         float64         `json:"cost"`
 	RecentRuns   []utils.RunInfo `json:"recent_runs,omitempty"`
+
+	// Disk usage and number of runs by branch and by month of the start
+	// time (e.g., 2025-03)
+	ByBranch map[string]Usage `json:"by_branch,omitempty"`
+	ByMonth  map[string]Usage `json:"by_month,omitempty"`
+
+	// Number of runs and their results by day over the recent weeks
+	Trend []Day `json:"trend,omitempty"`
+
+	// Results and durations of runs by normalized command
+	ByCommand map[string]CommandStats `json:"by_command,omitempty"`
+
+	// Disk usage of each run by directory
+	runSizes map[string]int64
+}
+
+// Day is the number of runs started on a day by result; running runs are
+// counted only in Runs
+type Day struct {
+	Date      string `json:"date"`
+	Runs      int    `json:"runs"`
+	Successes int    `json:"successes"`
+	Failures  int    `json:"failures"`
+}
+
+// CommandStats is the results of runs of a command; the success rate and the
+// mean duration in seconds are of finished runs
+type CommandStats struct {
+	Runs         int     `json:"runs"`
+	Successes    int     `json:"successes"`
+	Failures     int     `json:"failures"`
+	SuccessRate  float64 `json:"success_rate"`
+	MeanDuration float64 `json:"mean_duration"`
+}
+
+// Usage is the disk usage of a group of runs
+type Usage struct {
+	Runs      int   `json:"runs"`
+	DiskUsage int64 `json:"disk_usage"`
 }
 
 const maxRecentRuns = 5
 
+// Report is the status of a project as output in JSON
+type Report struct {
+	Branch  string `json:"branch"`
+	Commit  string `json:"commit"`
+	IsDirty bool   `json:"is_dirty"`
+	ProjectStats
+
+	// Disk budget in bytes, if set, and runs to archive to get back under it
+	DiskBudget        int64    `json:"disk_budget,omitempty"`
+	ArchiveCandidates []string `json:"archive_candidates,omitempty"`
+}
+
 // Show displays project status
 func Main() error {
 	// Get config and repository status
 	cfg := config.Get()
+	if !slices.Contains([]string{"text", "markdown", "json", "yaml", "prometheus"}, cfg.Status.Format) {
+		return fmt.Errorf("invalid output format: %s", cfg.Status.Format)
+	}
+	if !slices.Contains([]string{"first", "full"}, cfg.Status.CommandKey) {
+		return fmt.Errorf("invalid command key: %s (must be first or full)", cfg.Status.CommandKey)
+	}
+	if cfg.Status.Watch {
+		if cfg.Status.Format != "text" || cfg.Status.AllProjects {
+			return fmt.Errorf("--watch is supported only with the text format for the current project")
+		}
+		return tui.WatchText("moco status", func() (string, error) {
+			return renderWatch(cfg)
+		})
+	}
+	if cfg.Status.AllProjects {
+		if cfg.Status.Format != "text" {
+			return fmt.Errorf("%s format is not supported with --all-projects", cfg.Status.Format)
+		}
+		return outputAllProjects(cfg.Status.Level)
+	}
+	if cfg.Status.Format == "prometheus" {
+		return WritePrometheus(os.Stdout, cfg)
+	}
+	if cfg.Status.Format == "json" {
+		report, err := Collect(cfg)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if cfg.Status.Format == "yaml" {
+		report, err := Collect(cfg)
+		if err != nil {
+			return err
+		}
+		data, err := utils.MarshalYAML(report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
 	repo, err := utils.GetRepoStatus()
 	if err != nil {
 		return fmt.Errorf("failed to get git status: %w", err)
@@ -37,19 +150,162 @@ func Main() error {
 
 	// Get project statistics
 	level := cfg.Status.Level
-	stats, err := getProjectStats(cfg.BaseDir)
+	stats, err := getProjectStats(cfg.BaseDir, cfg.SummaryFile, cfg.Run.StaleAfter)
 	if err != nil {
 		return fmt.Errorf("failed to get project statistics: %w", err)
 	}
 
 	// Display status based on detail level
-	return outputStatusText(repo, stats, level)
+	stats.Trend = computeTrend(stats.RecentRuns, cfg.Status.TrendWeeks, time.Now())
+	stats.ByCommand = groupCommands(stats.RecentRuns, cfg.Status.CommandKey)
+	if cfg.Status.Format == "markdown" {
+		outputStatusMarkdown(os.Stdout, repo, stats, level)
+	} else if err := outputStatusText(os.Stdout, repo, stats, level); err != nil {
+		return err
+	}
+	return warnBudget(cfg.Status.DiskBudget, stats)
 }
 
-// getProjectStats computes statistics about runs
-func getProjectStats(baseDir string) (ProjectStats, error) {
+// renderWatch renders the status as text for the watch mode, listing the
+// running runs first with their elapsed times
+func renderWatch(cfg config.Config) (string, error) {
+	repo, err := utils.GetRepoStatus()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git status: %w", err)
+	}
+	stats, err := getProjectStats(cfg.BaseDir, cfg.SummaryFile, cfg.Run.StaleAfter)
+	if err != nil {
+		return "", fmt.Errorf("failed to get project statistics: %w", err)
+	}
+
+	var b strings.Builder
+	var running []utils.RunInfo
+	for _, run := range stats.RecentRuns {
+		if run.IsRunning && !run.Stale {
+			running = append(running, run)
+		}
+	}
+	if len(running) > 0 {
+		fmt.Fprintf(&b, "Running Experiments (%d):\n", len(running))
+		fmt.Fprintln(&b, runningStyle.Render(utils.RenderRunInfos(running)))
+		fmt.Fprintln(&b)
+	}
+	stats.Trend = computeTrend(stats.RecentRuns, cfg.Status.TrendWeeks, time.Now())
+	stats.ByCommand = groupCommands(stats.RecentRuns, cfg.Status.CommandKey)
+	if err := outputStatusText(&b, repo, stats, cfg.Status.Level); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Collect returns the status of the project, with as many recent runs as
+// shown at the configured level of detail
+func Collect(cfg config.Config) (Report, error) {
+	repo, err := utils.GetRepoStatus()
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get git status: %w", err)
+	}
+	stats, err := getProjectStats(cfg.BaseDir, cfg.SummaryFile, cfg.Run.StaleAfter)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get project statistics: %w", err)
+	}
+	report := Report{
+		Branch:  repo.Branch,
+		Commit:  repo.FullHash,
+		IsDirty: repo.IsDirty,
+	}
+	if cfg.Status.DiskBudget != "" {
+		budget, candidates, err := checkBudget(cfg.Status.DiskBudget, stats)
+		if err != nil {
+			return Report{}, err
+		}
+		report.DiskBudget = budget
+		for _, run := range candidates {
+			report.ArchiveCandidates = append(report.ArchiveCandidates, filepath.Clean(run.Directory))
+		}
+	}
+	stats.Trend = computeTrend(stats.RecentRuns, cfg.Status.TrendWeeks, time.Now())
+	stats.ByCommand = groupCommands(stats.RecentRuns, cfg.Status.CommandKey)
+	if cfg.Status.Level == "minimal" {
+		stats.RecentRuns = nil
+	} else {
+		stats.RecentRuns = stats.RecentRuns[:min(maxRecentRuns, len(stats.RecentRuns))]
+	}
+	report.ProjectStats = stats
+	return report, nil
+}
+
+// checkBudget parses a disk budget and returns the oldest successful runs
+// whose archiving would bring the disk usage back under it, if exceeded
+func checkBudget(diskBudget string, stats ProjectStats) (int64, []utils.RunInfo, error) {
+	budget, err := utils.ParseSize(diskBudget)
+	if err != nil || budget <= 0 {
+		return 0, nil, fmt.Errorf("invalid disk budget: %s", diskBudget)
+	}
+	if stats.DiskUsage <= budget {
+		return budget, nil, nil
+	}
+
+	runs := slices.Clone(stats.RecentRuns)
+	slices.SortStableFunc(runs, func(a, b utils.RunInfo) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+	var candidates []utils.RunInfo
+	usage := stats.DiskUsage
+	for _, run := range runs {
+		if usage <= budget {
+			break
+		}
+		if run.IsRunning || !run.Success {
+			continue
+		}
+		candidates = append(candidates, run)
+		usage -= stats.runSizes[filepath.Clean(run.Directory)]
+	}
+	return budget, candidates, nil
+}
+
+// warnBudget warns if the disk usage exceeds the budget, suggesting runs to
+// archive
+func warnBudget(diskBudget string, stats ProjectStats) error {
+	if diskBudget == "" {
+		return nil
+	}
+	budget, candidates, err := checkBudget(diskBudget, stats)
+	if err != nil || stats.DiskUsage <= budget {
+		return err
+	}
+	log.Warnf("Disk usage %s exceeds the budget of %s", utils.FormatSize(stats.DiskUsage), utils.FormatSize(budget))
+	if len(candidates) == 0 {
+		log.Warn("No successful runs to archive; consider moco clean")
+		return nil
+	}
+	var freed int64
+	dirs := make([]string, len(candidates))
+	for i, run := range candidates {
+		freed += stats.runSizes[filepath.Clean(run.Directory)]
+		dirs[i] = filepath.Clean(run.Directory)
+	}
+	log.Warnf("Archiving the %d oldest successful run(s) would free %s:", len(candidates), utils.FormatSize(freed))
+	log.Warnf("  moco archive --delete %s", strings.Join(dirs, " "))
+	if stats.DiskUsage-freed > budget {
+		log.Warn("This is not enough to get back under the budget; consider moco clean")
+	}
+	return nil
+}
+
+// getProjectStats computes statistics about runs; running runs whose
+// heartbeat is older than staleAfter are counted as stale
+func getProjectStats(baseDir, summaryFile, staleAfter string) (ProjectStats, error) {
 	stats := ProjectStats{
 		RecentRuns: []utils.RunInfo{},
+		ByBranch:   map[string]Usage{},
+		ByMonth:    map[string]Usage{},
+		runSizes:   map[string]int64{},
+	}
+	staleThreshold, err := time.ParseDuration(staleAfter)
+	if err != nil {
+		return stats, fmt.Errorf("invalid stale threshold: %s", staleAfter)
 	}
 
 	// Ensure base directory exists
@@ -57,14 +313,12 @@ func getProjectStats(baseDir string) (ProjectStats, error) {
 		return stats, nil // Return empty stats if directory doesn't exist
 	}
 
-	// Get config
-	cfg := config.Get()
-
-	// Pattern for runs directories
-	pattern := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3})_(.+)_([a-f0-9]{7})$`)
+	// Summaries are read through the index
+	idx := index.Open(baseDir, summaryFile)
+	seen := map[string]bool{}
 
 	// Walk the base directory to gather stats
-	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -78,7 +332,7 @@ func getProjectStats(baseDir string) (ProjectStats, error) {
 		}
 
 		// Add directory size to total
-		size, err := dirSize(path)
+		size, err := utils.DirSize(path)
 		if err != nil {
 			return fmt.Errorf("failed to get directory size: %w", err)
 		}
@@ -86,14 +340,14 @@ func getProjectStats(baseDir string) (ProjectStats, error) {
 
 		// Check if it's a run directory
 		dirName := filepath.Base(path)
-		matches := pattern.FindStringSubmatch(dirName)
-		if len(matches) != 4 {
+		if !utils.RunDirPattern.MatchString(dirName) {
 			return nil // Not a run directory
 		}
 
 		// Parse summary file for status
-		summaryPath := filepath.Join(path, cfg.SummaryFile)
-		runInfo, err := utils.ParseRunInfo(summaryPath)
+		stats.runSizes[filepath.Clean(path)] = size
+		seen[dirName] = true
+		runInfo, err := idx.Get(path)
 		if err != nil {
 			log.Warnf("Failed to parse summary file: %v", err)
 			return nil
@@ -108,13 +362,25 @@ func getProjectStats(baseDir string) (ProjectStats, error) {
 	if err != nil {
 		return stats, fmt.Errorf("error walking directory: %w", err)
 	}
+	idx.Prune(seen)
+	if err := idx.Save(); err != nil {
+		log.Warnf("Failed to save index: %v", err)
+	}
 
-	// Count running, success, and failure runs
+	// Count running, stale, success, and failure runs
+	utils.MarkStale(stats.RecentRuns, staleThreshold)
 	for _, run := range stats.RecentRuns {
+		size := stats.runSizes[filepath.Clean(run.Directory)]
+		addUsage(stats.ByBranch, run.Branch, Usage{Runs: 1, DiskUsage: size})
+		addUsage(stats.ByMonth, run.StartTime.Format("2006-01"), Usage{Runs: 1, DiskUsage: size})
 		stats.TotalRuns++
-		if run.IsRunning {
+		stats.GPUHours += run.GPUHours
+		stats.Cost += run.Cost
+		if run.Stale {
+			stats.StaleCount++
+		} else if run.IsRunning {
 			stats.RunningCount++
-		} else if run.ExitStatus == 0 {
+		} else if run.Success {
 			stats.SuccessCount++
 		} else {
 			stats.FailureCount++
@@ -127,70 +393,179 @@ func getProjectStats(baseDir string) (ProjectStats, error) {
 	return stats, nil
 }
 
-// dirSize computes the size of a directory
-func dirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
+// outputStatusText outputs status in text format
+func outputStatusText(w io.Writer, repo utils.RepoStatus, stats ProjectStats, detailLevel string) error {
+	// Output git information
+	fmt.Fprintln(w, "Git Repository Status:")
+	fmt.Fprintf(w, "  Branch: %s\n", repo.Branch)
+	fmt.Fprintf(w, "  Commit: %s\n", repo.ShortHash)
+	if repo.IsDirty {
+		fmt.Fprintln(w, "  Status: Dirty (has uncommitted changes or untracked files)")
+	} else {
+		fmt.Fprintln(w, "  Status: Clean")
+	}
+
+	// Show detailed info if requested
+	if detailLevel == "full" {
+		fmt.Fprintln(w, "\nDetailed Git Information:")
+		if repo.CommitMessage != "" {
+			fmt.Fprintf(w, "  Last commit: %s\n", strings.Split(repo.CommitMessage, "\n")[0])
+			fmt.Fprintf(w, "  Author: %s\n", repo.CommitAuthor)
+			fmt.Fprintf(w, "  Date: %s\n", repo.CommitDate.Format(time.RFC1123))
 		}
-		return err
-	})
-	return size, err
-}
 
-// formatSize formats a file size in bytes to human-readable format
-func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+		// Output basic project stats
+		fmt.Fprintln(w, "\nProject Statistics:")
+		fmt.Fprintf(w, "  Total runs: %d\n", stats.TotalRuns)
+		fmt.Fprintf(w, "  Success rate: %.1f%% (%d/%d)\n",
+			percentOrZero(stats.SuccessCount, stats.SuccessCount+stats.FailureCount),
+			stats.SuccessCount, stats.SuccessCount+stats.FailureCount)
+		if stats.StaleCount > 0 {
+			fmt.Fprintf(w, "  Stale runs: %d\n", stats.StaleCount)
+		}
+		fmt.Fprintf(w, "  Disk usage: %s\n", utils.FormatSize(stats.DiskUsage))
+		printCost(w, stats)
+		printTrend(w, stats.Trend)
+		printCommands(w, stats.ByCommand)
+		printUsage(w, stats)
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+
+	// Show recent runs if requested
+	if detailLevel != "minimal" && len(stats.RecentRuns) > 0 {
+		fmt.Fprintln(w, "\nRecent Runs:")
+		fmt.Fprintln(w, utils.RenderRunInfos(stats.RecentRuns[:min(maxRecentRuns, len(stats.RecentRuns))]))
+		nRemainingRuns := len(stats.RecentRuns) - maxRecentRuns
+		if nRemainingRuns > 0 {
+			fmt.Fprintf(w, " and %d more run(s)\n", nRemainingRuns)
+		}
 	}
-	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+
+	return nil
 }
 
-// outputStatusText outputs status in text format
-func outputStatusText(repo utils.RepoStatus, stats ProjectStats, detailLevel string) error {
-	// Output git information
-	fmt.Println("Git Repository Status:")
-	fmt.Printf("  Branch: %s\n", repo.Branch)
-	fmt.Printf("  Commit: %s\n", repo.ShortHash)
+// outputStatusMarkdown outputs status as a Markdown document with the same
+// information as the text format except the activity sparkline, e.g., for
+// reports and pull requests
+func outputStatusMarkdown(w io.Writer, repo utils.RepoStatus, stats ProjectStats, detailLevel string) {
+	fmt.Fprintln(w, "## Git Repository")
+	fmt.Fprintf(w, "- **Branch**: %s\n", repo.Branch)
+	fmt.Fprintf(w, "- **Commit**: `%s`\n", repo.ShortHash)
 	if repo.IsDirty {
-		fmt.Println("  Status: Dirty (has uncommitted changes or untracked files)")
+		fmt.Fprintln(w, "- **Status**: Dirty (has uncommitted changes or untracked files)")
 	} else {
-		fmt.Println("  Status: Clean")
+		fmt.Fprintln(w, "- **Status**: Clean")
 	}
 
-	// Show detailed info if requested
 	if detailLevel == "full" {
-		fmt.Println("\nDetailed Git Information:")
 		if repo.CommitMessage != "" {
-			fmt.Printf("  Last commit: %s\n", strings.Split(repo.CommitMessage, "\n")[0])
-			fmt.Printf("  Author: %s\n", repo.CommitAuthor)
-			fmt.Printf("  Date: %s\n", repo.CommitDate.Format(time.RFC1123))
+			fmt.Fprintf(w, "- **Last commit**: %s\n", strings.Split(repo.CommitMessage, "\n")[0])
+			fmt.Fprintf(w, "- **Author**: %s\n", repo.CommitAuthor)
+			fmt.Fprintf(w, "- **Date**: %s\n", repo.CommitDate.Format(time.RFC1123))
 		}
 
-		// Output basic project stats
-		fmt.Println("\nProject Statistics:")
-		fmt.Printf("  Total runs: %d\n", stats.TotalRuns)
-		fmt.Printf("  Success rate: %.1f%% (%d/%d)\n",
+		fmt.Fprintln(w, "\n## Project Statistics")
+		fmt.Fprintf(w, "- **Total runs**: %d\n", stats.TotalRuns)
+		fmt.Fprintf(w, "- **Success rate**: %.1f%% (%d/%d)\n",
 			percentOrZero(stats.SuccessCount, stats.SuccessCount+stats.FailureCount),
 			stats.SuccessCount, stats.SuccessCount+stats.FailureCount)
-		fmt.Printf("  Disk usage: %s\n", formatSize(stats.DiskUsage))
+		if stats.StaleCount > 0 {
+			fmt.Fprintf(w, "- **Stale runs**: %d\n", stats.StaleCount)
+		}
+		fmt.Fprintf(w, "- **Disk usage**: %s\n", utils.FormatSize(stats.DiskUsage))
+		if stats.GPUHours > 0 {
+			fmt.Fprintf(w, "- **GPU hours**: %.2f\n", stats.GPUHours)
+		}
+		if stats.Cost > 0 {
+			fmt.Fprintf(w, "- **Estimated cost**: %.2f %s\n", stats.Cost, config.Get().Cost.Currency)
+		}
+		if len(stats.ByCommand) > 0 {
+			fmt.Fprintln(w, "\n## Runs by Command")
+			fmt.Fprint(w, utils.RenderMarkdownTable(commandHeaders, commandRows(stats.ByCommand)))
+		}
+		if len(stats.ByBranch) > 0 {
+			branches, months := usageKeys(stats)
+			fmt.Fprintln(w, "\n## Disk Usage by Branch")
+			fmt.Fprint(w, utils.RenderMarkdownTable([]string{"Branch", "Runs", "Disk usage"}, usageRows(branches, stats.ByBranch)))
+			fmt.Fprintln(w, "\n## Disk Usage by Month")
+			fmt.Fprint(w, utils.RenderMarkdownTable([]string{"Month", "Runs", "Disk usage"}, usageRows(months, stats.ByMonth)))
+		}
 	}
 
-	// Show recent runs if requested
 	if detailLevel != "minimal" && len(stats.RecentRuns) > 0 {
+		fmt.Fprintln(w, "\n## Recent Runs")
+		fmt.Fprint(w, utils.RenderRunInfosMarkdown(stats.RecentRuns[:min(maxRecentRuns, len(stats.RecentRuns))]))
+		if n := len(stats.RecentRuns) - maxRecentRuns; n > 0 {
+			fmt.Fprintf(w, "\nand %d more run(s)\n", n)
+		}
+	}
+}
+
+// outputAllProjects outputs the status of all registered projects
+func outputAllProjects(detailLevel string) error {
+	total := ProjectStats{ByBranch: map[string]Usage{}, ByMonth: map[string]Usage{}}
+
+	fmt.Println("Projects:")
+	for _, project := range projects.Load() {
+		stats, err := getProjectStats(project.BaseDir(), project.Config.SummaryFile, project.Config.Run.StaleAfter)
+		if err != nil {
+			log.Warnf("Failed to get statistics of %s: %v", project.Path, err)
+			continue
+		}
+
+		state := "unknown"
+		if repo, err := utils.GetRepoStatusAt(project.Path); err == nil {
+			state = fmt.Sprintf("%s@%s", repo.Branch, repo.ShortHash)
+			if repo.IsDirty {
+				state += " (dirty)"
+			}
+		}
+		fmt.Printf("  %s: %s, %d run(s), %d running\n", project.Name, state, stats.TotalRuns, stats.RunningCount)
+
+		// Aggregate statistics
+		total.DiskUsage += stats.DiskUsage
+		total.RunningCount += stats.RunningCount
+		total.StaleCount += stats.StaleCount
+		total.FailureCount += stats.FailureCount
+		total.SuccessCount += stats.SuccessCount
+		total.TotalRuns += stats.TotalRuns
+		total.GPUHours += stats.GPUHours
+		total.Cost += stats.Cost
+		for branch, usage := range stats.ByBranch {
+			addUsage(total.ByBranch, project.Name+":"+branch, usage)
+		}
+		for month, usage := range stats.ByMonth {
+			addUsage(total.ByMonth, month, usage)
+		}
+		for _, run := range stats.RecentRuns {
+			run.Project = project.Name
+			total.RecentRuns = append(total.RecentRuns, run)
+		}
+	}
+
+	if detailLevel == "full" {
+		fmt.Println("\nProject Statistics:")
+		fmt.Printf("  Total runs: %d\n", total.TotalRuns)
+		fmt.Printf("  Success rate: %.1f%% (%d/%d)\n",
+			percentOrZero(total.SuccessCount, total.SuccessCount+total.FailureCount),
+			total.SuccessCount, total.SuccessCount+total.FailureCount)
+		if total.StaleCount > 0 {
+			fmt.Printf("  Stale runs: %d\n", total.StaleCount)
+		}
+		fmt.Printf("  Disk usage: %s\n", utils.FormatSize(total.DiskUsage))
+		printCost(os.Stdout, total)
+		printTrend(os.Stdout, computeTrend(total.RecentRuns, config.Get().Status.TrendWeeks, time.Now()))
+		printCommands(os.Stdout, groupCommands(total.RecentRuns, config.Get().Status.CommandKey))
+		printUsage(os.Stdout, total)
+	}
+
+	if detailLevel != "minimal" && len(total.RecentRuns) > 0 {
+		// Show most recent runs first across projects
+		slices.SortStableFunc(total.RecentRuns, func(a, b utils.RunInfo) int {
+			return b.StartTime.Compare(a.StartTime)
+		})
 		fmt.Println("\nRecent Runs:")
-		fmt.Println(utils.RenderRunInfos(stats.RecentRuns[:min(maxRecentRuns, len(stats.RecentRuns))]))
-		nRemainingRuns := len(stats.RecentRuns) - maxRecentRuns
+		fmt.Println(utils.RenderRunInfos(total.RecentRuns[:min(maxRecentRuns, len(total.RecentRuns))]))
+		nRemainingRuns := len(total.RecentRuns) - maxRecentRuns
 		if nRemainingRuns > 0 {
 			fmt.Printf(" and %d more run(s)\n", nRemainingRuns)
 		}
@@ -199,6 +574,233 @@ func outputStatusText(repo utils.RepoStatus, stats ProjectStats, detailLevel str
 	return nil
 }
 
+// printCost prints GPU hours and estimated cost if any are recorded
+func printCost(w io.Writer, stats ProjectStats) {
+	if stats.GPUHours > 0 {
+		fmt.Fprintf(w, "  GPU hours: %.2f\n", stats.GPUHours)
+	}
+	if stats.Cost > 0 {
+		fmt.Fprintf(w, "  Estimated cost: %.2f %s\n", stats.Cost, config.Get().Cost.Currency)
+	}
+}
+
+// computeTrend counts runs by day over the last weeks up to today
+func computeTrend(runs []utils.RunInfo, weeks int, now time.Time) []Day {
+	if weeks <= 0 {
+		return nil
+	}
+	days := make([]Day, weeks*7)
+	index := map[string]int{}
+	for i := range days {
+		days[i].Date = now.AddDate(0, 0, i-len(days)+1).Format(time.DateOnly)
+		index[days[i].Date] = i
+	}
+	for _, run := range runs {
+		i, ok := index[run.StartTime.In(now.Location()).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		days[i].Runs++
+		if run.IsRunning {
+			continue
+		} else if run.Success {
+			days[i].Successes++
+		} else {
+			days[i].Failures++
+		}
+	}
+	return days
+}
+
+// sparkBars are the bars of sparklines from the lowest to the highest
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Styles of days in the trend by success rate
+var (
+	successDayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	mixedDayStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	failureDayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	emptyDayStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// printTrend prints a sparkline of runs per day, colored by success rate,
+// and the success rate of each week
+func printTrend(w io.Writer, trend []Day) {
+	if len(trend) == 0 {
+		return
+	}
+	peak := 0
+	for _, day := range trend {
+		peak = max(peak, day.Runs)
+	}
+
+	var line strings.Builder
+	for _, day := range trend {
+		if day.Runs == 0 {
+			line.WriteString(emptyDayStyle.Render(string(sparkBars[0])))
+			continue
+		}
+		bar := string(sparkBars[(day.Runs*len(sparkBars)-1)/peak])
+		switch {
+		case day.Failures == 0:
+			line.WriteString(successDayStyle.Render(bar))
+		case day.Successes == 0:
+			line.WriteString(failureDayStyle.Render(bar))
+		default:
+			line.WriteString(mixedDayStyle.Render(bar))
+		}
+	}
+	fmt.Fprintf(w, "\nActivity (runs per day since %s, at most %d):\n", trend[0].Date, peak)
+	fmt.Fprintf(w, "  %s\n", line.String())
+
+	var rates []string
+	for week := range slices.Chunk(trend, 7) {
+		successes, finished := 0, 0
+		for _, day := range week {
+			successes += day.Successes
+			finished += day.Successes + day.Failures
+		}
+		if finished == 0 {
+			rates = append(rates, "-")
+		} else {
+			rates = append(rates, fmt.Sprintf("%.0f%%", percentOrZero(successes, finished)))
+		}
+	}
+	fmt.Fprintf(w, "  Success rate by week: %s\n", strings.Join(rates, " "))
+}
+
+// interpreters are commands whose runs are grouped by the script they run
+var interpreters = []string{"python", "python3", "Rscript", "julia", "node", "ruby", "perl", "bash", "sh"}
+
+// commandKey normalizes a command to the first token, followed by the script
+// or module for interpreters (e.g., python train.py), or keeps the full command
+func commandKey(command, key string) string {
+	if key == "full" {
+		return command
+	}
+	args, err := utils.SplitCommand(command)
+	if err != nil || len(args) == 0 {
+		return command
+	}
+	name := filepath.Base(args[0])
+	if slices.Contains(interpreters, name) {
+		for i, arg := range args[1:] {
+			switch {
+			case arg == "-c" || arg == "-e":
+				// Inline programs vary from run to run
+				return name + " " + arg
+			case arg == "-m" && i+2 < len(args):
+				return name + " -m " + args[i+2]
+			case !strings.HasPrefix(arg, "-"):
+				return name + " " + arg
+			}
+		}
+	}
+	return name
+}
+
+// groupCommands counts results and computes mean durations of runs by
+// normalized command
+func groupCommands(runs []utils.RunInfo, key string) map[string]CommandStats {
+	groups := map[string]CommandStats{}
+	durations := map[string]time.Duration{}
+	for _, run := range runs {
+		command := commandKey(run.Command, key)
+		group := groups[command]
+		group.Runs++
+		if run.Succeeded() {
+			group.Successes++
+		} else if run.Failed() {
+			group.Failures++
+		}
+		if !run.IsRunning && !run.EndTime.IsZero() {
+			durations[command] += run.EndTime.Sub(run.StartTime)
+		}
+		groups[command] = group
+	}
+	for command, group := range groups {
+		if finished := group.Successes + group.Failures; finished > 0 {
+			group.SuccessRate = float64(group.Successes) / float64(finished)
+			group.MeanDuration = durations[command].Seconds() / float64(finished)
+		}
+		groups[command] = group
+	}
+	return groups
+}
+
+// printCommands prints a table of results and mean durations by command,
+// most runs first
+func printCommands(w io.Writer, groups map[string]CommandStats) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nRuns by Command:")
+	fmt.Fprintln(w, utils.RenderTable(commandHeaders, commandRows(groups)))
+}
+
+// commandHeaders are the headers of tables of runs by command
+var commandHeaders = []string{"Command", "Runs", "Failures", "Success rate", "Mean duration"}
+
+// commandRows returns table rows of runs by command, most frequent first
+func commandRows(groups map[string]CommandStats) [][]string {
+	commands := slices.Collect(maps.Keys(groups))
+	slices.SortFunc(commands, func(a, b string) int {
+		return cmp.Or(cmp.Compare(groups[b].Runs, groups[a].Runs), cmp.Compare(a, b))
+	})
+	rows := make([][]string, len(commands))
+	for i, command := range commands {
+		group := groups[command]
+		rate, duration := "-", "-"
+		if group.Successes+group.Failures > 0 {
+			rate = fmt.Sprintf("%.0f%%", group.SuccessRate*100)
+			duration = time.Duration(group.MeanDuration * float64(time.Second)).Round(time.Second).String()
+		}
+		rows[i] = []string{command, strconv.Itoa(group.Runs), strconv.Itoa(group.Failures), rate, duration}
+	}
+	return rows
+}
+
+// addUsage adds usage to a group
+func addUsage(groups map[string]Usage, key string, usage Usage) {
+	group := groups[key]
+	group.Runs += usage.Runs
+	group.DiskUsage += usage.DiskUsage
+	groups[key] = group
+}
+
+// printUsage prints tables of disk usage by branch, largest first, and by
+// month, in chronological order
+func printUsage(w io.Writer, stats ProjectStats) {
+	if len(stats.ByBranch) == 0 {
+		return
+	}
+	branches, months := usageKeys(stats)
+	fmt.Fprintln(w, "\nDisk Usage by Branch:")
+	fmt.Fprintln(w, utils.RenderTable([]string{"Branch", "Runs", "Disk usage"}, usageRows(branches, stats.ByBranch)))
+
+	fmt.Fprintln(w, "\nDisk Usage by Month:")
+	fmt.Fprintln(w, utils.RenderTable([]string{"Month", "Runs", "Disk usage"}, usageRows(months, stats.ByMonth)))
+}
+
+// usageKeys returns branches, largest first, and months, in chronological
+// order, of the disk usage of a project
+func usageKeys(stats ProjectStats) ([]string, []string) {
+	branches := slices.Collect(maps.Keys(stats.ByBranch))
+	slices.SortFunc(branches, func(a, b string) int {
+		return cmp.Or(cmp.Compare(stats.ByBranch[b].DiskUsage, stats.ByBranch[a].DiskUsage), cmp.Compare(a, b))
+	})
+	return branches, slices.Sorted(maps.Keys(stats.ByMonth))
+}
+
+// usageRows returns table rows of groups in the order of keys
+func usageRows(keys []string, groups map[string]Usage) [][]string {
+	rows := make([][]string, len(keys))
+	for i, key := range keys {
+		rows[i] = []string{key, strconv.Itoa(groups[key].Runs), utils.FormatSize(groups[key].DiskUsage)}
+	}
+	return rows
+}
+
 // percentOrZero calculates percentage and returns 0 if denominator is 0
 func percentOrZero(numerator, denominator int) float64 {
 	if denominator == 0 {
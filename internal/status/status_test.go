@@ -0,0 +1,38 @@
+package status
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputStatusMarkdown(t *testing.T) {
+	repo := utils.RepoStatus{Branch: "main", ShortHash: "1234567"}
+	start := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	run := utils.RunInfo{ID: "0123abcd", Directory: "runs/a", Command: "python train.py", StartTime: start, EndTime: start.Add(time.Minute), Success: true}
+	stats := ProjectStats{
+		TotalRuns:    1,
+		SuccessCount: 1,
+		DiskUsage:    2048,
+		RecentRuns:   []utils.RunInfo{run},
+		ByBranch:     map[string]Usage{"main": {Runs: 1, DiskUsage: 2048}},
+		ByMonth:      map[string]Usage{"2025-03": {Runs: 1, DiskUsage: 2048}},
+		ByCommand:    map[string]CommandStats{"python train.py": {Runs: 1, Successes: 1, SuccessRate: 1, MeanDuration: 60}},
+	}
+
+	var b bytes.Buffer
+	outputStatusMarkdown(&b, repo, stats, "minimal")
+	assert.Equal(t, "## Git Repository\n- **Branch**: main\n- **Commit**: `1234567`\n- **Status**: Clean\n", b.String())
+
+	b.Reset()
+	outputStatusMarkdown(&b, repo, stats, "full")
+	output := b.String()
+	assert.Contains(t, output, "- **Success rate**: 100.0% (1/1)\n")
+	assert.Contains(t, output, "## Runs by Command\n| Command | Runs | Failures | Success rate | Mean duration |\n| --- | --- | --- | --- | --- |\n| python train.py | 1 | 0 | 100% | 1m0s |\n")
+	assert.Contains(t, output, "## Disk Usage by Branch\n| Branch | Runs | Disk usage |\n| --- | --- | --- |\n| main | 1 | 2.0 KiB |\n")
+	assert.Contains(t, output, "## Disk Usage by Month\n")
+	assert.Contains(t, output, "## Recent Runs\n| ID | Directory |")
+}
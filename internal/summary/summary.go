@@ -0,0 +1,167 @@
+package summary
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Sections whose code blocks are carried over to the regenerated summary
+const (
+	gitStatusSection          = "Git Status"
+	commitDetailsSection      = "Latest Commit Details"
+	uncommittedChangesSection = "Uncommitted Changes"
+	environmentInfoSection    = "Environment Info"
+	dvcStatusSection          = "DVC Status"
+)
+
+// Regenerate rebuilds summary files of runs with the current template
+func Regenerate(runs []string) error {
+	cfg := config.Get()
+
+	if cfg.Summary.All {
+		var err error
+		runs, err = utils.FindRunDirs(cfg.BaseDir)
+		if err != nil {
+			return fmt.Errorf("failed to find runs: %w", err)
+		}
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs specified (use --all to regenerate all runs)")
+	}
+
+	failed := 0
+	for _, run := range runs {
+		summaryPath, err := utils.ResolveSummaryPath(run, cfg.SummaryFile)
+		if err == nil {
+			err = regenerate(summaryPath)
+		}
+		if err != nil {
+			log.Errorf("Failed to regenerate summary of %s: %v", run, err)
+			failed++
+			continue
+		}
+		log.Infof("Regenerated %s", summaryPath)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to regenerate %d of %d summaries", failed, len(runs))
+	}
+	return nil
+}
+
+// regenerate rewrites a summary file from the data recorded in it
+func regenerate(summaryPath string) error {
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return err
+	}
+	commands, err := utils.SplitCommand(runInfo.Command)
+	if err != nil {
+		return fmt.Errorf("failed to parse command: %w", err)
+	}
+
+	// Read recorded outputs of git and system commands
+	blocks := map[string]string{}
+	for _, section := range []string{gitStatusSection, commitDetailsSection, uncommittedChangesSection, environmentInfoSection} {
+		block, err := utils.ReadSummarySection(summaryPath, section)
+		if err != nil {
+			return err
+		}
+		blocks[section] = block
+	}
+
+	// DVC status is recorded only for repositories using DVC
+	dvc := utils.DVCState{Hashes: runInfo.DVCHashes, Changed: runInfo.DataChanged}
+	if block, err := utils.ReadSummarySection(summaryPath, dvcStatusSection); err == nil {
+		dvc.Status = block
+	}
+
+	meta := utils.RunMetadata{
+		StartTime:        runInfo.StartTime,
+		Repo:             utils.RepoStatus{Branch: runInfo.Branch, FullHash: runInfo.CommitHash},
+		Command:          commands,
+		Name:             runInfo.Name,
+		Message:          runInfo.Message,
+		Issues:           runInfo.Issues,
+		ExtraRepos:       runInfo.ExtraRepos,
+		ToolVersions:     runInfo.ToolVersions,
+		DataFingerprints: runInfo.DataFingerprints,
+		EnvVars:          runInfo.EnvVars,
+		EnvSnapshots:     runInfo.EnvSnapshots,
+		PatchFile:        runInfo.PatchFile,
+		TensorBoardDir:   runInfo.TensorBoardDir,
+		Inputs:           runInfo.Inputs,
+		DVC:              dvc,
+
+		ReproducedFrom:    runInfo.ReproducedFrom,
+		RerunOf:           runInfo.RerunOf,
+		After:             runInfo.After,
+		Sweep:             runInfo.Sweep,
+		SweepParams:       runInfo.SweepParams,
+		Tags:              runInfo.Tags,
+		CodeDiscrepancies: runInfo.CodeDiscrepancies,
+
+		Hostname:           runInfo.Hostname,
+		GitStatus:          blocks[gitStatusSection],
+		CommitDetails:      blocks[commitDetailsSection],
+		UncommittedChanges: blocks[uncommittedChangesSection],
+		SystemInfo:         blocks[environmentInfoSection],
+	}
+
+	// Write to a temporary file in the same directory so that the working
+	// directory is recorded correctly and the original is kept on failure
+	tmpFile, err := os.CreateTemp(filepath.Dir(summaryPath), ".summary-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if err := utils.WriteSummaryFileInit(tmpFile.Name(), meta); err != nil {
+		return err
+	}
+	if !runInfo.IsRunning {
+		result := utils.RunResult{
+			EndTime:     runInfo.EndTime,
+			ExitCode:    runInfo.ExitStatus,
+			Interrupted: runInfo.Interrupted,
+			TimedOut:    runInfo.TimedOut,
+			Success:     runInfo.Success,
+			ExitReason:  runInfo.ExitReason,
+			GPUHours:    runInfo.GPUHours,
+			Cost:        runInfo.Cost,
+			Currency:    runInfo.Currency,
+			Resources:   runInfo.Resources,
+			Metrics:     runInfo.Metrics,
+			Artifacts:   runInfo.Artifacts,
+
+			TruncatedLogs: runInfo.TruncatedLogs,
+		}
+		err := utils.WriteSummaryFileEnd(tmpFile.Name(), runInfo.StartTime, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, note := range runInfo.Notes {
+		if err := utils.AppendSummaryNote(tmpFile.Name(), note); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), summaryPath); err != nil {
+		return err
+	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", utils.SummaryJSONFile, err)
+	}
+	return nil
+}
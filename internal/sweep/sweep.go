@@ -0,0 +1,138 @@
+package sweep
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/run"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// spec is a sweep specification file
+type spec struct {
+	Params map[string][]any `toml:"params"`
+}
+
+// Main runs a command for each combination of parameter values as tracked
+// runs sharing a sweep ID
+func Main(commands []string) error {
+	cfg := config.Get()
+
+	params, err := loadParams(cfg.Sweep.Params, cfg.Sweep.Spec)
+	if err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return fmt.Errorf("no parameters given, use --param or --spec")
+	}
+	// Parameters may be used only in templates instead of the command
+	for _, param := range params {
+		placeholder := "{" + param.Name + "}"
+		if len(cfg.Run.Templates) == 0 && !slices.ContainsFunc(commands, func(arg string) bool { return strings.Contains(arg, placeholder) }) {
+			return fmt.Errorf("parameter %s is not used in the command (expected %s)", param.Name, placeholder)
+		}
+	}
+
+	grid := utils.ExpandGrid(params)
+	if cfg.Sweep.DryRun {
+		for _, values := range grid {
+			fmt.Println(shellescape.QuoteCommand(utils.SubstituteParams(commands, values)))
+		}
+		return nil
+	}
+
+	// Stop the sweep when interrupted; the running command is terminated by run
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	sweepID := time.Now().Format("20060102-150405")
+	log.Infof("Starting sweep %s with %d runs", sweepID, len(grid))
+	failed := 0
+	for i, values := range grid {
+		log.Infof("Sweep run %d/%d: %s", i+1, len(grid), formatValues(params, values))
+		opts := run.Options{Sweep: sweepID, SweepParams: values}
+		if err := run.MainWithOptions(utils.SubstituteParams(commands, values), opts); err != nil {
+			log.Errorf("Sweep run %d/%d failed: %v", i+1, len(grid), err)
+			failed++
+		}
+
+		select {
+		case <-interrupt:
+			return fmt.Errorf("sweep %s interrupted after %d of %d runs", sweepID, i+1, len(grid))
+		default:
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d runs of sweep %s failed", failed, len(grid), sweepID)
+	}
+	log.Infof("Sweep %s finished: %d runs", sweepID, len(grid))
+	return nil
+}
+
+// loadParams collects parameters given as flags and in a specification file
+func loadParams(flags []string, specPath string) ([]utils.SweepParam, error) {
+	var params []utils.SweepParam
+	for _, flag := range flags {
+		param, err := utils.ParseSweepParam(flag)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+
+	if specPath != "" {
+		data, err := os.ReadFile(specPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sweep specification: %w", err)
+		}
+		var s spec
+		if err := toml.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse sweep specification: %w", err)
+		}
+		// Tables are unordered, so parameters are sorted by name
+		names := make([]string, 0, len(s.Params))
+		for name := range s.Params {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			param := utils.SweepParam{Name: name}
+			for _, value := range s.Params[name] {
+				param.Values = append(param.Values, fmt.Sprint(value))
+			}
+			params = append(params, param)
+		}
+	}
+
+	// Each parameter must be defined once with at least one value
+	seen := map[string]bool{}
+	for _, param := range params {
+		if seen[param.Name] {
+			return nil, fmt.Errorf("parameter %s is defined more than once", param.Name)
+		}
+		if len(param.Values) == 0 {
+			return nil, fmt.Errorf("parameter %s has no values", param.Name)
+		}
+		seen[param.Name] = true
+	}
+	return params, nil
+}
+
+// formatValues formats parameter values in the order of the parameters
+func formatValues(params []utils.SweepParam, values map[string]string) string {
+	pairs := make([]string, len(params))
+	for i, param := range params {
+		pairs[i] = param.Name + "=" + values[param.Name]
+	}
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,105 @@
+package tag
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Add adds tags to a run
+func Add(run string, tags []string) error {
+	for _, tag := range tags {
+		if err := utils.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	return update(run, func(current []string) []string {
+		for _, tag := range tags {
+			if !slices.Contains(current, tag) {
+				current = append(current, tag)
+			}
+		}
+		return current
+	})
+}
+
+// Remove removes tags from a run
+func Remove(run string, tags []string) error {
+	return update(run, func(current []string) []string {
+		return slices.DeleteFunc(current, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+	})
+}
+
+// List prints the tags of a run, or all tags with their number of runs if
+// no run is given
+func List(run string) error {
+	cfg := config.Get()
+	if run != "" {
+		summaryPath, err := utils.ResolveSummaryPath(run, cfg.SummaryFile)
+		if err != nil {
+			return fmt.Errorf("failed to find run: %w", err)
+		}
+		runInfo, err := utils.ParseRunInfo(summaryPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse summary file: %w", err)
+		}
+		for _, tag := range runInfo.Tags {
+			fmt.Println(tag)
+		}
+		return nil
+	}
+
+	runDirs, err := utils.FindRunDirs(cfg.BaseDir)
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, runDir := range runDirs {
+		runInfo, err := utils.ParseRunInfo(filepath.Join(runDir, cfg.SummaryFile))
+		if err != nil {
+			log.Warnf("Failed to parse summary file in %s: %v", runDir, err)
+			continue
+		}
+		for _, tag := range runInfo.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		fmt.Printf("%s\t%d\n", tag, counts[tag])
+	}
+	return nil
+}
+
+// update rewrites the tags of a run
+func update(run string, f func([]string) []string) error {
+	summaryPath, err := utils.ResolveSummaryPath(run, config.Get().SummaryFile)
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+	runInfo, err := utils.ParseRunInfo(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse summary file: %w", err)
+	}
+
+	tags := f(slices.Clone(runInfo.Tags))
+	if err := utils.WriteSummaryTags(summaryPath, tags); err != nil {
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	if err := utils.WriteSummaryJSON(summaryPath); err != nil {
+		log.Warnf("Failed to write %s: %v", utils.SummaryJSONFile, err)
+	}
+	log.Infof("Tags of %s: %v", runInfo.Directory, tags)
+	return nil
+}
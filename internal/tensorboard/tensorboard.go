@@ -0,0 +1,71 @@
+package tensorboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/list"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Main launches TensorBoard on the log directories of runs selected by the
+// list filters, passing args to TensorBoard
+func Main(args []string) error {
+	cfg := config.Get()
+	cfg.List.Archived = false
+	cfg.List.AllProjects = false
+
+	runs, err := list.Find(cfg)
+	if err != nil {
+		return err
+	}
+	spec := logdirSpec(runs)
+	if spec == "" {
+		return fmt.Errorf("no TensorBoard log directories found in the selected runs")
+	}
+
+	commands, err := utils.SplitCommand(cfg.TensorBoard.Command)
+	if err != nil || len(commands) == 0 {
+		return fmt.Errorf("invalid TensorBoard command: %s", cfg.TensorBoard.Command)
+	}
+	commands = append(commands, "--logdir_spec", spec)
+	commands = append(commands, args...)
+	if cfg.TensorBoard.DryRun {
+		fmt.Println(shellescape.QuoteCommand(commands))
+		return nil
+	}
+
+	log.Infof("Starting command: %s", shellescape.QuoteCommand(commands))
+	cmd := exec.Command(commands[0], commands[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// logdirSpec returns the value of --logdir_spec naming the log directory of
+// each run by its run directory
+func logdirSpec(runs []utils.RunInfo) string {
+	var entries []string
+	for _, run := range runs {
+		if run.TensorBoardDir == "" {
+			continue
+		}
+		dir := filepath.Join(run.Directory, run.TensorBoardDir)
+		if _, err := os.Stat(dir); err != nil {
+			log.Warnf("TensorBoard directory not found: %s", dir)
+			continue
+		}
+		// TensorBoard splits names from paths at the first colon and
+		// entries at commas
+		name := strings.NewReplacer(":", "", ",", "_").Replace(filepath.Base(run.Directory))
+		entries = append(entries, name+":"+dir)
+	}
+	return strings.Join(entries, ",")
+}
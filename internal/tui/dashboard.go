@@ -0,0 +1,515 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bicycle1885/moco/internal/archive"
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/kill"
+	"github.com/bicycle1885/moco/internal/remove"
+	"github.com/bicycle1885/moco/internal/tag"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxTailBytes limits how much of a log file is read to show its tail
+const maxTailBytes = 64 * 1024
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	titleStyle    = lipgloss.NewStyle().Bold(true).Underline(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+// dashboard is the state of the full-screen dashboard
+type dashboard struct {
+	cfg    config.Config
+	screen screen
+	load   func() ([]utils.RunInfo, error)
+
+	repo    utils.RepoStatus
+	repoErr error
+	all     []utils.RunInfo // runs in the order returned by load
+	runs    []utils.RunInfo // runs matching the filter
+
+	filter    string
+	filtering bool // editing the filter
+
+	tags    string // tags being entered for the selected run
+	tagging bool
+
+	selected   int
+	showStderr bool
+	message    string
+	confirm    string // action waiting for confirmation ("archive", "delete", or "kill")
+
+	summary       []string // lines of the summary being viewed, if any
+	summaryScroll int
+}
+
+// Main runs the full-screen dashboard of the runs in the base directory
+// until the user quits
+func Main() error {
+	cfg := config.Get()
+
+	// Only changed summaries are parsed again through the index
+	idx := index.Open(cfg.BaseDir, cfg.SummaryFile)
+	return Pick(func() ([]utils.RunInfo, error) {
+		return loadRuns(idx, cfg.BaseDir)
+	})
+}
+
+// Pick runs the full-screen dashboard of the runs returned by load, which is
+// called again on each refresh, until the user quits
+func Pick(load func() ([]utils.RunInfo, error)) error {
+	interval, err := refreshInterval()
+	if err != nil {
+		return err
+	}
+	terminal, err := NewTerminal()
+	if err != nil {
+		return err
+	}
+	return show(terminal, &dashboard{cfg: config.Get(), screen: terminal, load: load}, interval)
+}
+
+// reload reads the repository status and runs again, keeping the selection
+func (d *dashboard) reload() {
+	d.repo, d.repoErr = utils.GetRepoStatus()
+
+	runs, err := d.load()
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+	d.all = runs
+	d.applyFilter()
+}
+
+// applyFilter selects the runs matching the filter, keeping the selection
+func (d *dashboard) applyFilter() {
+	selectedDir := ""
+	if run, ok := d.selectedRun(); ok {
+		selectedDir = run.Directory
+	}
+
+	d.runs = nil
+	for _, run := range d.all {
+		if matchFilter(run, d.filter) {
+			d.runs = append(d.runs, run)
+		}
+	}
+
+	d.selected = max(0, min(d.selected, len(d.runs)-1))
+	for i, run := range d.runs {
+		if run.Directory == selectedDir {
+			d.selected = i
+			break
+		}
+	}
+}
+
+// matchFilter reports whether the directory, branch, command, status, or a
+// tag of a run contains the filter text, ignoring case
+func matchFilter(run utils.RunInfo, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	fields := append([]string{run.Directory, run.Branch, run.Command, utils.StatusString(run)}, run.Tags...)
+	return slices.ContainsFunc(fields, func(field string) bool {
+		return strings.Contains(strings.ToLower(field), filter)
+	})
+}
+
+// loadRuns returns the runs in the base directory, most recent first
+func loadRuns(idx *index.Index, baseDir string) ([]utils.RunInfo, error) {
+	runDirs, err := utils.FindRunDirs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var runs []utils.RunInfo
+	for _, runDir := range runDirs {
+		runInfo, err := idx.Get(runDir)
+		if err != nil {
+			continue
+		}
+		runs = append(runs, runInfo)
+	}
+	slices.Reverse(runs)
+	return runs, nil
+}
+
+// handleKey updates the state for a key press and reports whether to quit,
+// with an error if the dashboard cannot continue
+func (d *dashboard) handleKey(key string) (bool, error) {
+	if key == KeyCtrlC {
+		return true, nil
+	}
+
+	// Confirmation of archiving, deleting, or killing
+	if d.confirm != "" {
+		action := d.confirm
+		d.confirm = ""
+		if key != "y" && key != "Y" {
+			d.message = "Cancelled"
+			return false, nil
+		}
+		switch action {
+		case "archive":
+			return false, d.runAction("archive", "Archived", func(run utils.RunInfo) error {
+				return archive.MainWithOptions([]string{run.Directory}, archive.Options{Yes: true})
+			})
+		case "delete":
+			return false, d.runAction("delete", "Deleted", func(run utils.RunInfo) error {
+				return remove.MainWithOptions([]string{run.Directory}, remove.Options{Yes: true})
+			})
+		case "kill":
+			return false, d.runAction("kill", "Killed", func(run utils.RunInfo) error {
+				return kill.Main(run.Directory)
+			})
+		}
+		return false, nil
+	}
+
+	// Entering tags to add
+	if d.tagging {
+		switch key {
+		case KeyEnter:
+			d.tagging = false
+			tags := strings.FieldsFunc(d.tags, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+			if len(tags) == 0 {
+				return false, nil
+			}
+			return false, d.runAction("tag", "Tagged", func(run utils.RunInfo) error {
+				return tag.Add(run.Directory, tags)
+			})
+		case KeyEscape:
+			d.tagging = false
+		case KeyBackspace:
+			runes := []rune(d.tags)
+			d.tags = string(runes[:max(0, len(runes)-1)])
+		default:
+			if !strings.ContainsFunc(key, unicode.IsControl) {
+				d.tags += key
+			}
+		}
+		return false, nil
+	}
+
+	// Editing the filter
+	if d.filtering {
+		switch key {
+		case KeyEnter:
+			d.filtering = false
+		case KeyEscape:
+			d.filtering = false
+			d.filter = ""
+		case KeyBackspace:
+			runes := []rune(d.filter)
+			d.filter = string(runes[:max(0, len(runes)-1)])
+		default:
+			if !strings.ContainsFunc(key, unicode.IsControl) {
+				d.filter += key
+			}
+		}
+		d.applyFilter()
+		return false, nil
+	}
+
+	// Summary view
+	if d.summary != nil {
+		_, height := d.screen.Size()
+		page := max(1, height-2)
+		switch key {
+		case "q", KeyEscape:
+			d.summary = nil
+		case "j", KeyDown:
+			d.summaryScroll++
+		case "k", KeyUp:
+			d.summaryScroll--
+		case " ", KeyPgDown:
+			d.summaryScroll += page
+		case "b", KeyPgUp:
+			d.summaryScroll -= page
+		case "g":
+			d.summaryScroll = 0
+		case "G":
+			d.summaryScroll = len(d.summary)
+		}
+		d.summaryScroll = max(0, min(d.summaryScroll, len(d.summary)-page))
+		return false, nil
+	}
+
+	d.message = ""
+	switch key {
+	case "q":
+		return true, nil
+	case "j", KeyDown:
+		d.selected = min(d.selected+1, len(d.runs)-1)
+	case "k", KeyUp:
+		d.selected = max(d.selected-1, 0)
+	case "g":
+		d.selected = 0
+	case "G":
+		d.selected = max(len(d.runs)-1, 0)
+	case KeyTab:
+		d.showStderr = !d.showStderr
+	case "r":
+		d.reload()
+	case "s", KeyEnter:
+		d.openSummary()
+	case "/":
+		d.filtering = true
+	case "a", "d":
+		action := "archive"
+		if key == "d" {
+			action = "delete"
+		}
+		if run, ok := d.selectedRun(); ok {
+			if run.IsRunning {
+				d.message = fmt.Sprintf("Cannot %s a running run", action)
+			} else {
+				d.confirm = action
+				d.message = fmt.Sprintf("%s %s? [y/N]", strings.ToUpper(action[:1])+action[1:], run.Directory)
+			}
+		}
+	case "x":
+		if run, ok := d.selectedRun(); ok {
+			if !run.IsRunning {
+				d.message = "Cannot kill a run that is not running"
+			} else {
+				d.confirm = "kill"
+				d.message = fmt.Sprintf("Kill %s? [y/N]", run.Directory)
+			}
+		}
+	case "t":
+		if _, ok := d.selectedRun(); ok {
+			d.tagging = true
+			d.tags = ""
+		}
+	}
+	return false, nil
+}
+
+// selectedRun returns the selected run if any
+func (d *dashboard) selectedRun() (utils.RunInfo, bool) {
+	if d.selected < 0 || d.selected >= len(d.runs) {
+		return utils.RunInfo{}, false
+	}
+	return d.runs[d.selected], true
+}
+
+// openSummary loads the summary of the selected run for viewing
+func (d *dashboard) openSummary() {
+	run, ok := d.selectedRun()
+	if !ok {
+		return
+	}
+	content, err := os.ReadFile(filepath.Join(run.Directory, run.File))
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	text := string(content)
+	if !d.cfg.Show.Raw {
+		width, _ := d.screen.Size()
+		renderer, err := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(width-4))
+		if err == nil {
+			if rendered, err := renderer.Render(text); err == nil {
+				text = rendered
+			}
+		}
+	}
+	d.summary = strings.Split(strings.TrimRight(text, "\n"), "\n")
+	d.summaryScroll = 0
+}
+
+// runAction runs an action on the selected run outside of the full-screen
+// mode so that commands can log to the normal screen, and reports its
+// outcome; an error is returned only if the full-screen mode cannot be
+// entered again
+func (d *dashboard) runAction(verb, done string, action func(utils.RunInfo) error) error {
+	run, ok := d.selectedRun()
+	if !ok {
+		return nil
+	}
+
+	d.screen.Stop()
+	err := action(run)
+	if err := d.screen.Start(); err != nil {
+		return err
+	}
+
+	if err != nil {
+		d.message = fmt.Sprintf("Failed to %s: %v", verb, err)
+	} else {
+		d.message = fmt.Sprintf("%s %s", done, run.Directory)
+	}
+	d.reload()
+	return nil
+}
+
+// render returns the content of the dashboard on the screen
+func (d *dashboard) render() string {
+	width, height := d.screen.Size()
+	bodyHeight := max(1, height-2)
+
+	var body string
+	if d.summary != nil {
+		end := min(d.summaryScroll+bodyHeight, len(d.summary))
+		body = fitLines(d.summary[d.summaryScroll:end], width, bodyHeight)
+	} else {
+		listWidth := min(max(width/2, 40), width)
+		logWidth := max(width-listWidth-3, 0)
+		list := fitLines(d.runLines(listWidth, bodyHeight), listWidth, bodyHeight)
+		separator := strings.TrimRight(strings.Repeat(" │\n", bodyHeight), "\n")
+		logs := fitLines(d.logLines(bodyHeight), logWidth, bodyHeight)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, list, separator+" ", logs)
+	}
+
+	footer := d.message
+	if d.filtering {
+		footer = "Filter: " + d.filter + "█"
+	} else if d.tagging {
+		footer = "Tags: " + d.tags + "█"
+	} else if footer == "" {
+		if d.summary != nil {
+			footer = helpStyle.Render("j/k: scroll  space/b: page  g/G: top/bottom  q: back")
+		} else {
+			footer = helpStyle.Render("j/k: select  enter: summary  tab: stdout/stderr  /: filter  t: tag  x: kill  a: archive  d: delete  r: refresh  q: quit")
+		}
+	}
+
+	return ansi.Truncate(d.header(), width, "") + "\n" + body + "\n" + ansi.Truncate(footer, width, "")
+}
+
+// header returns the status line of the repository and runs
+func (d *dashboard) header() string {
+	repo := "not a git repository"
+	if d.repoErr == nil {
+		state := "clean"
+		if d.repo.IsDirty {
+			state = "dirty"
+		}
+		repo = fmt.Sprintf("%s@%s (%s)", d.repo.Branch, d.repo.ShortHash, state)
+	}
+
+	running, success, failure := 0, 0, 0
+	for _, run := range d.runs {
+		switch {
+		case run.IsRunning:
+			running++
+		case run.Success:
+			success++
+		default:
+			failure++
+		}
+	}
+	return headerStyle.Render(fmt.Sprintf("moco  %s  runs: %d  running: %d  success: %d  failure: %d",
+		repo, len(d.runs), running, success, failure))
+}
+
+// runLines returns the lines of the run list, scrolled to show the selected run
+func (d *dashboard) runLines(width, height int) []string {
+	title := "Runs"
+	if d.filter != "" {
+		title = fmt.Sprintf("Runs (%d/%d matching %q)", len(d.runs), len(d.all), d.filter)
+	}
+	lines := []string{titleStyle.Render(title)}
+	if len(d.runs) == 0 {
+		return append(lines, "No runs found")
+	}
+
+	rows := max(height-1, 1)
+	start := max(0, d.selected-rows+1)
+	for i := start; i < len(d.runs) && i < start+rows; i++ {
+		run := d.runs[i]
+		name := filepath.Base(filepath.Clean(run.Directory))
+		line := fmt.Sprintf("%-16s %8s  %s  %s", utils.StatusString(run), run.Duration(), name, run.Command)
+		line = ansi.Truncate(line, width, "…")
+		if i == d.selected {
+			line = selectedStyle.Render(line + strings.Repeat(" ", max(0, width-ansi.StringWidth(line))))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// logLines returns the title and tail of the selected run's stdout or stderr
+func (d *dashboard) logLines(height int) []string {
+	run, ok := d.selectedRun()
+	if !ok {
+		return nil
+	}
+	logFile := d.cfg.Run.StdoutFile
+	if d.showStderr {
+		logFile = d.cfg.Run.StderrFile
+	}
+
+	lines := []string{titleStyle.Render(logFile)}
+	tail, err := tailFile(filepath.Join(run.Directory, logFile), max(height-1, 0))
+	if err != nil {
+		return append(lines, err.Error())
+	}
+	return append(lines, tail...)
+}
+
+// tailFile returns up to n last lines of a file
+func tailFile(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := max(info.Size()-maxTailBytes, 0)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ReplaceAll(string(data), "\r", "")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		// The first line is likely partial
+		lines = lines[1:]
+	}
+	return lines[max(0, len(lines)-n):], nil
+}
+
+// fitLines truncates and pads lines to fill exactly width x height cells
+func fitLines(lines []string, width, height int) string {
+	var b strings.Builder
+	for i := range height {
+		line := ""
+		if i < len(lines) {
+			line = ansi.Truncate(lines[i], width, "")
+		}
+		b.WriteString(line)
+		b.WriteString(strings.Repeat(" ", max(0, width-ansi.StringWidth(line))))
+		if i < height-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScreen is a screen of a fixed size recording whether it was left
+type fakeScreen struct {
+	stopped  bool
+	startErr error
+}
+
+func (s *fakeScreen) Size() (int, int) { return 120, 30 }
+
+func (s *fakeScreen) Start() error {
+	s.stopped = false
+	return s.startErr
+}
+
+func (s *fakeScreen) Stop() { s.stopped = true }
+
+// newTestRuns creates runs finished with exit codes, or still running for
+// negative ones, one minute apart in a new base directory, which is set in
+// the global configuration for actions
+func newTestRuns(t *testing.T, exitCodes ...int) config.Config {
+	baseDir := t.TempDir()
+	cfg := config.GetPointer()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+	*cfg = config.GetDefault()
+	cfg.BaseDir = baseDir
+
+	startTime := time.Date(2025, 3, 24, 0, 0, 0, 0, time.Local)
+	for i, exitCode := range exitCodes {
+		start := startTime.Add(time.Duration(i) * time.Minute)
+		runDir := filepath.Join(baseDir, start.Format("2006-01-02T15:04:05.000")+"_main_1234567")
+		require.NoError(t, os.Mkdir(runDir, 0755))
+		summaryPath := filepath.Join(runDir, cfg.SummaryFile)
+		meta := utils.RunMetadata{StartTime: start, Repo: utils.RepoStatus{Branch: "main"}, Command: []string{"train", "--seed", "1"}}
+		require.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		if exitCode < 0 {
+			continue
+		}
+		result := utils.RunResult{EndTime: start.Add(time.Second), ExitCode: exitCode, Success: exitCode == 0}
+		require.NoError(t, utils.WriteSummaryFileEnd(summaryPath, start, result))
+	}
+	return *cfg
+}
+
+// newTestDashboard returns a dashboard of new runs
+func newTestDashboard(t *testing.T, exitCodes ...int) (*dashboard, *fakeScreen) {
+	cfg := newTestRuns(t, exitCodes...)
+	screen := &fakeScreen{}
+	idx := index.Open(cfg.BaseDir, cfg.SummaryFile)
+	d := &dashboard{cfg: cfg, screen: screen, load: func() ([]utils.RunInfo, error) {
+		return loadRuns(idx, cfg.BaseDir)
+	}}
+	d.reload()
+	return d, screen
+}
+
+// press sends keys to the dashboard, failing if it quits or fails
+func press(t *testing.T, d *dashboard, keys ...string) {
+	for _, key := range keys {
+		quit, err := d.handleKey(key)
+		require.NoError(t, err)
+		require.False(t, quit)
+	}
+}
+
+func TestDashboardSelectAndFilter(t *testing.T) {
+	d, _ := newTestDashboard(t, 0, 1, 0)
+	require.Len(t, d.runs, 3)
+	assert.Equal(t, 0, d.selected)
+
+	press(t, d, "j", KeyDown, KeyDown)
+	assert.Equal(t, 2, d.selected)
+	press(t, d, "k")
+	assert.Equal(t, 1, d.selected)
+	press(t, d, "g")
+	assert.Equal(t, 0, d.selected)
+
+	// Filtering keeps the selected run if it matches
+	press(t, d, "G", "/", "f", "a", "i", "l")
+	require.Len(t, d.runs, 1)
+	assert.Equal(t, 1, d.runs[0].ExitStatus)
+	assert.Contains(t, d.render(), `Runs (1/3 matching "fail")`)
+	press(t, d, KeyEscape)
+	assert.Len(t, d.runs, 3)
+
+	quit, err := d.handleKey("q")
+	assert.NoError(t, err)
+	assert.True(t, quit)
+}
+
+func TestDashboardTag(t *testing.T) {
+	d, screen := newTestDashboard(t, 0)
+
+	press(t, d, "t", "b", "e", "s", "t", " ", "v", "2")
+	assert.Contains(t, d.render(), "Tags: best v2")
+	press(t, d, KeyEnter)
+	assert.False(t, screen.stopped)
+	assert.True(t, strings.HasPrefix(d.message, "Tagged "), d.message)
+	require.Len(t, d.runs, 1)
+	assert.Equal(t, []string{"best", "v2"}, d.runs[0].Tags)
+
+	// Escape cancels entering tags
+	press(t, d, "t", "x", KeyEscape)
+	assert.False(t, d.tagging)
+	assert.Equal(t, []string{"best", "v2"}, d.runs[0].Tags)
+}
+
+func TestDashboardDelete(t *testing.T) {
+	d, _ := newTestDashboard(t, 0, 1)
+	run := d.runs[0]
+
+	press(t, d, "d", "n")
+	assert.Equal(t, "Cancelled", d.message)
+	assert.DirExists(t, run.Directory)
+
+	press(t, d, "d", "y")
+	assert.Equal(t, "Deleted "+run.Directory, d.message)
+	assert.NoDirExists(t, run.Directory)
+	assert.Len(t, d.runs, 1)
+}
+
+func TestDashboardRunningRuns(t *testing.T) {
+	d, _ := newTestDashboard(t, 0, -1)
+	require.True(t, d.runs[0].IsRunning)
+
+	press(t, d, "d")
+	assert.Equal(t, "Cannot delete a running run", d.message)
+	assert.Empty(t, d.confirm)
+
+	// Only running runs can be killed
+	press(t, d, "j", "x")
+	assert.Equal(t, "Cannot kill a run that is not running", d.message)
+	assert.Empty(t, d.confirm)
+	press(t, d, "k", "x")
+	assert.Equal(t, "kill", d.confirm)
+	press(t, d, "n")
+	assert.Equal(t, "Cancelled", d.message)
+}
+
+func TestDashboardRestartError(t *testing.T) {
+	d, screen := newTestDashboard(t, 0)
+	screen.startErr = errors.New("terminal gone")
+
+	// Failing to go back to the full-screen mode ends the dashboard
+	press(t, d, "t", "a")
+	_, err := d.handleKey(KeyEnter)
+	assert.EqualError(t, err, "terminal gone")
+}
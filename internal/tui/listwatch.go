@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// finishedStyle highlights runs that finished while being watched
+var finishedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2"))
+
+// listWatcher is the state of the periodically refreshed table of runs
+type listWatcher struct {
+	screen  screen
+	load    func() ([]utils.RunInfo, error)
+	columns func([]utils.RunInfo) []utils.Column
+
+	runs     []utils.RunInfo
+	running  map[string]bool // runs seen running
+	finished map[string]bool // runs seen finishing
+	updated  time.Time
+	err      error
+}
+
+// WatchList shows the runs returned by load as a table with the extra
+// columns returned by columns, refreshed periodically until the user quits;
+// runs that finish while watched are highlighted
+func WatchList(load func() ([]utils.RunInfo, error), columns func([]utils.RunInfo) []utils.Column) error {
+	interval, err := refreshInterval()
+	if err != nil {
+		return err
+	}
+	terminal, err := NewTerminal()
+	if err != nil {
+		return err
+	}
+	return show(terminal, &listWatcher{
+		screen:   terminal,
+		load:     load,
+		columns:  columns,
+		running:  map[string]bool{},
+		finished: map[string]bool{},
+	}, interval)
+}
+
+// reload loads the runs again and marks runs that were running before as
+// finished
+func (w *listWatcher) reload() {
+	w.updated = time.Now()
+	runs, err := w.load()
+	w.err = err
+	if err != nil {
+		return
+	}
+	w.runs = runs
+	for _, run := range runs {
+		if run.IsRunning && !run.Stale {
+			w.running[run.Directory] = true
+		} else if w.running[run.Directory] {
+			w.finished[run.Directory] = true
+		}
+	}
+}
+
+// handleKey refreshes the view or quits
+func (w *listWatcher) handleKey(key string) (bool, error) {
+	return watchKey(w, key)
+}
+
+// render returns the table of runs
+func (w *listWatcher) render() string {
+	running := 0
+	for _, run := range w.runs {
+		if run.IsRunning && !run.Stale {
+			running++
+		}
+	}
+	header := fmt.Sprintf("moco list  runs: %d  running: %d  finished while watching: %d  updated: %s",
+		len(w.runs), running, len(w.finished), w.updated.Format("15:04:05"))
+	lines := []string{headerStyle.Render(header), ""}
+	if w.err != nil {
+		lines = append(lines, w.err.Error(), "")
+	}
+
+	if len(w.runs) == 0 {
+		lines = append(lines, "No runs match the specified criteria")
+	} else {
+		// A table has two header lines followed by a line for each run
+		table := tableLines(w.runs, w.columns(w.runs)...)
+		for i, line := range table {
+			if i >= 2 && w.finished[w.runs[i-2].Directory] {
+				line = finishedStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return renderWatch(w.screen, lines)
+}
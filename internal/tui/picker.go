@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// picker is the state of the fuzzy selector of a run
+type picker struct {
+	screen screen
+	runs   []utils.RunInfo // most recent first
+	lines  []string        // searched text of each run
+
+	query    string
+	matches  []int // indexes of runs matching the query, best first
+	selected int
+	chosen   bool
+}
+
+// PickRun lets the user select a run in the base directory by fuzzy
+// searching its short ID, directory, status, and command, and returns its
+// directory
+func PickRun() (string, error) {
+	terminal, err := NewTerminal()
+	if err != nil {
+		return "", fmt.Errorf("no run specified (the run picker needs a terminal)")
+	}
+
+	cfg := config.Get()
+	runs, err := loadRuns(index.Open(cfg.BaseDir, cfg.SummaryFile), cfg.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "", fmt.Errorf("no runs found")
+	}
+
+	p := &picker{screen: terminal, runs: runs}
+	for _, run := range runs {
+		name := filepath.Base(filepath.Clean(run.Directory))
+		p.lines = append(p.lines, fmt.Sprintf("%s  %s  %-16s  %s", run.ID, name, utils.StatusString(run), run.Command))
+	}
+	p.update()
+
+	// The runs are not refreshed while picking
+	if err := show(terminal, p, 0); err != nil {
+		return "", err
+	}
+	if !p.chosen {
+		return "", fmt.Errorf("no run selected")
+	}
+	return p.runs[p.matches[p.selected]].Directory, nil
+}
+
+// reload does nothing since the runs are loaded only once
+func (p *picker) reload() {}
+
+// handleKey updates the state for a key press and reports whether picking
+// is done, which is canceled unless a run is chosen
+func (p *picker) handleKey(key string) (bool, error) {
+	switch key {
+	case KeyCtrlC, KeyEscape:
+		return true, nil
+	case KeyEnter:
+		p.chosen = len(p.matches) > 0
+		return p.chosen, nil
+	case KeyUp, "\x10": // Ctrl-P
+		p.selected = max(0, p.selected-1)
+	case KeyDown, "\x0e": // Ctrl-N
+		p.selected = min(max(0, len(p.matches)-1), p.selected+1)
+	case KeyBackspace:
+		runes := []rune(p.query)
+		p.query = string(runes[:max(0, len(runes)-1)])
+		p.update()
+	default:
+		if !strings.ContainsFunc(key, unicode.IsControl) {
+			p.query += key
+			p.update()
+		}
+	}
+	return false, nil
+}
+
+// update finds the runs matching the query, ordered by score and then by
+// recency, and selects the best one
+func (p *picker) update() {
+	type scored struct{ index, score int }
+	var matches []scored
+	for i, line := range p.lines {
+		if score, ok := utils.FuzzyScore(p.query, line); ok {
+			matches = append(matches, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return b.score - a.score })
+
+	p.matches = p.matches[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.index)
+	}
+	p.selected = 0
+}
+
+// render returns the query and the matching runs
+func (p *picker) render() string {
+	width, height := p.screen.Size()
+	lines := []string{
+		headerStyle.Render("> ") + p.query,
+		helpStyle.Render(fmt.Sprintf("%d/%d runs", len(p.matches), len(p.runs))),
+	}
+
+	rows := max(height-len(lines)-1, 1)
+	start := max(0, p.selected-rows+1)
+	for i := start; i < len(p.matches) && i < start+rows; i++ {
+		line := ansi.Truncate(p.lines[p.matches[i]], width, "…")
+		if i == p.selected {
+			line = selectedStyle.Render(line + strings.Repeat(" ", max(0, width-ansi.StringWidth(line))))
+		}
+		lines = append(lines, line)
+	}
+
+	footer := helpStyle.Render("type to search  ↑/↓: select  enter: choose  esc: cancel")
+	return fitLines(lines, width, height-1) + "\n" + ansi.Truncate(footer, width, "")
+}
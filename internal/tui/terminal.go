@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Terminal controls a full-screen terminal session
+//
+// The views only need raw input, redrawing the whole screen, and a few
+// keys, so they are driven by this small loop over golang.org/x/term and
+// lipgloss, which moco already depends on, rather than a framework such as
+// bubbletea.
+type Terminal struct {
+	fd    int
+	state *term.State
+}
+
+// screen is the terminal a view is drawn on, which is left while running
+// commands that write to the normal screen
+type screen interface {
+	Size() (int, int)
+	Start() error
+	Stop()
+}
+
+// Keys of special keys as decoded by ReadKeys
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyPgUp      = "pgup"
+	KeyPgDown    = "pgdown"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyTab       = "tab"
+	KeyBackspace = "backspace"
+	KeyCtrlC     = "ctrl+c"
+)
+
+// escapeKeys maps escape sequences to key names
+var escapeKeys = map[string]string{
+	"\x1b[A":  KeyUp,
+	"\x1bOA":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPgUp,
+	"\x1b[6~": KeyPgDown,
+	"\x1b":    KeyEscape,
+}
+
+// NewTerminal returns a terminal on stdin and stdout, which must be a terminal
+func NewTerminal() (*Terminal, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, fmt.Errorf("not a terminal")
+	}
+	return &Terminal{fd: fd}, nil
+}
+
+// Start switches to raw mode and the alternate screen
+func (t *Terminal) Start() error {
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	t.state = state
+	// Enter the alternate screen and hide the cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+// Stop restores the terminal to its original state
+func (t *Terminal) Stop() {
+	// Show the cursor and leave the alternate screen
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	if t.state != nil {
+		term.Restore(t.fd, t.state)
+		t.state = nil
+	}
+}
+
+// Size returns the width and height of the terminal
+func (t *Terminal) Size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Draw replaces the screen with content
+func (t *Terminal) Draw(content string) {
+	// Raw mode does not translate newlines into carriage returns
+	content = strings.ReplaceAll(content, "\n", "\r\n")
+	fmt.Print("\x1b[H\x1b[2J" + content)
+}
+
+// ReadKeys sends keys read from stdin to keys until reading fails
+func (t *Terminal) ReadKeys(keys chan<- string) {
+	buf := make([]byte, 32)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, key := range splitKeys(string(buf[:n])) {
+			keys <- key
+		}
+	}
+}
+
+// splitKeys converts bytes read at once into keys; pasted or quickly typed
+// text is split into a key for each character
+func splitKeys(s string) []string {
+	if strings.HasPrefix(s, "\x1b") || len([]rune(s)) <= 1 {
+		return []string{decodeKey(s)}
+	}
+	var keys []string
+	for _, r := range s {
+		keys = append(keys, decodeKey(string(r)))
+	}
+	return keys
+}
+
+// decodeKey converts bytes read from a terminal in raw mode to a key name
+func decodeKey(s string) string {
+	if key, ok := escapeKeys[s]; ok {
+		return key
+	}
+	switch s {
+	case "\r", "\n":
+		return KeyEnter
+	case "\t":
+		return KeyTab
+	case "\x7f", "\b":
+		return KeyBackspace
+	case "\x03":
+		return KeyCtrlC
+	}
+	return s
+}
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeKey(t *testing.T) {
+	for input, key := range map[string]string{
+		"\x1b[A":  KeyUp,
+		"\x1bOB":  KeyDown,
+		"\x1b[6~": KeyPgDown,
+		"\x1b":    KeyEscape,
+		"\r":      KeyEnter,
+		"\x7f":    KeyBackspace,
+		"\x03":    KeyCtrlC,
+		"q":       "q",
+		"é":       "é",
+	} {
+		assert.Equal(t, key, decodeKey(input), "%q", input)
+	}
+}
+
+func TestFitLines(t *testing.T) {
+	assert.Equal(t, "abc \nd   \n    ", fitLines([]string{"abc", "d"}, 4, 3))
+	assert.Equal(t, "ab", fitLines([]string{"abcdef", "g"}, 2, 1))
+	assert.Equal(t, "\x1b[1mab\x1b[m", fitLines([]string{"\x1b[1mabcd\x1b[m"}, 2, 1))
+}
+
+func TestSplitKeys(t *testing.T) {
+	assert.Equal(t, []string{KeyUp}, splitKeys("\x1b[A"))
+	assert.Equal(t, []string{"a"}, splitKeys("a"))
+	assert.Equal(t, []string{"a", "b", KeyEnter}, splitKeys("ab\r"))
+}
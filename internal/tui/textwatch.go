@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// textWatcher is the state of the periodically refreshed text
+type textWatcher struct {
+	screen   screen
+	title    string
+	interval time.Duration
+	text     func() (string, error)
+
+	lines   []string
+	updated time.Time
+	err     error
+}
+
+// WatchText shows the text returned by render under a title, refreshed
+// periodically until the user quits
+func WatchText(title string, render func() (string, error)) error {
+	interval, err := refreshInterval()
+	if err != nil {
+		return err
+	}
+	terminal, err := NewTerminal()
+	if err != nil {
+		return err
+	}
+	return show(terminal, &textWatcher{screen: terminal, title: title, interval: interval, text: render}, interval)
+}
+
+// reload renders the text again, which also updates elapsed times
+func (w *textWatcher) reload() {
+	w.updated = time.Now()
+	var text string
+	text, w.err = w.text()
+	w.lines = strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// handleKey refreshes the view or quits
+func (w *textWatcher) handleKey(key string) (bool, error) {
+	return watchKey(w, key)
+}
+
+// render returns the text under the title
+func (w *textWatcher) render() string {
+	header := fmt.Sprintf("%s  every %s  updated: %s", w.title, w.interval, w.updated.Format("15:04:05"))
+	lines := []string{headerStyle.Render(header), ""}
+	if w.err != nil {
+		lines = append(lines, w.err.Error(), "")
+	}
+	return renderWatch(w.screen, append(lines, w.lines...))
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// view is the state of a full-screen view, which is drawn again after each
+// key press and refresh
+type view interface {
+	// reload reads the data shown again
+	reload()
+	// handleKey updates the state for a key press and reports whether to
+	// quit, with an error if the view cannot continue
+	handleKey(key string) (bool, error)
+	// render returns the content of the screen
+	render() string
+}
+
+// refreshInterval returns the configured interval of refreshing views
+func refreshInterval() (time.Duration, error) {
+	refresh := config.Get().Tui.Refresh
+	interval, err := time.ParseDuration(refresh)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid refresh interval: %s", refresh)
+	}
+	return interval, nil
+}
+
+// show loads a view and draws it on the terminal until the user quits,
+// reloading it periodically unless interval is zero
+func show(terminal *Terminal, v view, interval time.Duration) error {
+	v.reload()
+
+	if err := terminal.Start(); err != nil {
+		return err
+	}
+	defer terminal.Stop()
+
+	keys := make(chan string)
+	go terminal.ReadKeys(keys)
+
+	// A nil channel never fires, so the view is not refreshed if not set
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		terminal.Draw(v.render())
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if quit, err := v.handleKey(key); quit || err != nil {
+				return err
+			}
+		case <-ticks:
+			v.reload()
+		}
+	}
+}
+
+// watchKey handles a key press in the views that only show what is being
+// watched, which can be refreshed or quit
+func watchKey(v view, key string) (bool, error) {
+	switch key {
+	case "q", KeyCtrlC:
+		return true, nil
+	case "r":
+		v.reload()
+	}
+	return false, nil
+}
+
+// renderWatch returns lines above the help of the keys of watching
+func renderWatch(s screen, lines []string) string {
+	width, height := s.Size()
+	footer := helpStyle.Render("r: refresh  q: quit")
+	return fitLines(lines, width, height-1) + "\n" + ansi.Truncate(footer, width, "")
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	cfg := newTestRuns(t, 0, -1, 1)
+	w := &watcher{cfg: cfg, screen: &fakeScreen{}, index: index.Open(cfg.BaseDir, cfg.SummaryFile)}
+	w.reload()
+	require.NoError(t, w.err)
+	require.Len(t, w.runs, 3)
+
+	screen := w.render()
+	assert.Contains(t, screen, "runs: 3  running: 1")
+	running := strings.Index(screen, "Running")
+	recent := strings.Index(screen, "Recent Completions")
+	assert.Less(t, running, recent)
+	assert.Equal(t, 30, strings.Count(screen, "\n")+1)
+
+	quit, err := w.handleKey("q")
+	assert.NoError(t, err)
+	assert.True(t, quit)
+}
+
+func TestListWatcher(t *testing.T) {
+	cfg := newTestRuns(t, 0, -1)
+	idx := index.Open(cfg.BaseDir, cfg.SummaryFile)
+	w := &listWatcher{
+		screen:   &fakeScreen{},
+		load:     func() ([]utils.RunInfo, error) { return loadRuns(idx, cfg.BaseDir) },
+		columns:  func([]utils.RunInfo) []utils.Column { return nil },
+		running:  map[string]bool{},
+		finished: map[string]bool{},
+	}
+	w.reload()
+	assert.Contains(t, w.render(), "running: 1  finished while watching: 0")
+
+	// Runs seen running are marked when they finish
+	runs, err := w.load()
+	require.NoError(t, err)
+	for i := range runs {
+		runs[i].IsRunning = false
+	}
+	w.load = func() ([]utils.RunInfo, error) { return runs, nil }
+	_, err = w.handleKey("r")
+	require.NoError(t, err)
+	assert.Contains(t, w.render(), "running: 0  finished while watching: 1")
+}
+
+func TestTextWatcher(t *testing.T) {
+	calls := 0
+	w := &textWatcher{screen: &fakeScreen{}, title: "moco status", text: func() (string, error) {
+		calls++
+		if calls > 1 {
+			return "", errors.New("broken")
+		}
+		return "line 1\nline 2\n", nil
+	}}
+	w.reload()
+	screen := w.render()
+	assert.Contains(t, screen, "line 1")
+	assert.Contains(t, screen, "r: refresh  q: quit")
+
+	_, err := w.handleKey("r")
+	require.NoError(t, err)
+	assert.Contains(t, w.render(), "broken")
+}
+
+func TestPicker(t *testing.T) {
+	p := &picker{screen: &fakeScreen{}, runs: []utils.RunInfo{{Directory: "a"}, {Directory: "b"}}, lines: []string{"train lr", "eval"}}
+	p.update()
+	for _, key := range splitKeys("ev") {
+		done, err := p.handleKey(key)
+		require.NoError(t, err)
+		require.False(t, done)
+	}
+	assert.Equal(t, []int{1}, p.matches)
+	assert.Contains(t, p.render(), "1/2 runs")
+
+	done, err := p.handleKey(KeyEnter)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.True(t, p.chosen)
+
+	// Nothing is chosen if no run matches
+	p.chosen = false
+	p.handleKey("x")
+	done, _ = p.handleKey(KeyEnter)
+	assert.False(t, done)
+	done, _ = p.handleKey(KeyEscape)
+	assert.True(t, done)
+	assert.False(t, p.chosen)
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bicycle1885/moco/internal/config"
+	"github.com/bicycle1885/moco/internal/index"
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+// watcher is the state of the live view of running and recent runs
+type watcher struct {
+	cfg    config.Config
+	screen screen
+	index  *index.Index
+
+	runs      []utils.RunInfo // most recent first
+	diskUsage int64
+	updated   time.Time
+	err       error
+}
+
+// Watch shows running experiments, recent completions, and disk usage,
+// refreshed periodically until the user quits
+func Watch() error {
+	interval, err := refreshInterval()
+	if err != nil {
+		return err
+	}
+	terminal, err := NewTerminal()
+	if err != nil {
+		return err
+	}
+	cfg := config.Get()
+	return show(terminal, &watcher{cfg: cfg, screen: terminal, index: index.Open(cfg.BaseDir, cfg.SummaryFile)}, interval)
+}
+
+// reload reads the runs and the disk usage again
+func (w *watcher) reload() {
+	w.updated = time.Now()
+	w.runs, w.err = loadRuns(w.index, w.cfg.BaseDir)
+	if w.err != nil {
+		return
+	}
+	w.diskUsage, w.err = utils.DirSize(w.cfg.BaseDir)
+}
+
+// handleKey refreshes the view or quits
+func (w *watcher) handleKey(key string) (bool, error) {
+	return watchKey(w, key)
+}
+
+// render returns the running and recently finished runs
+func (w *watcher) render() string {
+	_, height := w.screen.Size()
+
+	var running, finished []utils.RunInfo
+	for _, run := range w.runs {
+		if run.IsRunning {
+			running = append(running, run)
+		} else {
+			finished = append(finished, run)
+		}
+	}
+	slices.SortStableFunc(finished, func(a, b utils.RunInfo) int {
+		return b.EndTime.Compare(a.EndTime)
+	})
+
+	header := fmt.Sprintf("moco watch  %s  runs: %d  running: %d  disk usage: %s  updated: %s",
+		w.cfg.BaseDir, len(w.runs), len(running), utils.FormatSize(w.diskUsage), w.updated.Format("15:04:05"))
+	lines := []string{headerStyle.Render(header), ""}
+	if w.err != nil {
+		lines = append(lines, w.err.Error(), "")
+	}
+
+	lines = append(lines, titleStyle.Render("Running"))
+	if len(running) == 0 {
+		lines = append(lines, "No running experiments")
+	} else {
+		lines = append(lines, tableLines(running)...)
+	}
+	lines = append(lines, "", titleStyle.Render("Recent Completions"))
+
+	// Show as many recent completions as fit above the footer; a table has
+	// two header lines
+	rows := height - len(lines) - 1 - 2
+	if len(finished) == 0 {
+		lines = append(lines, "No finished experiments")
+	} else if rows > 0 {
+		lines = append(lines, tableLines(finished[:min(rows, len(finished))])...)
+	}
+	return renderWatch(w.screen, lines)
+}
+
+// tableLines renders runs as a table split into lines
+func tableLines(runs []utils.RunInfo, extra ...utils.Column) []string {
+	return strings.Split(strings.TrimRight(utils.RenderRunInfos(runs, extra...), "\n"), "\n")
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ArtifactsFile is the file in a run directory listing its artifacts
+const ArtifactsFile = "artifacts.json"
+
+// artifactsSection is the title of the summary section of artifacts
+const artifactsSection = "Artifacts"
+
+// artifactPattern matches an artifact in summary sections
+var artifactPattern = regexp.MustCompile("^- `(.+)`: (\\d+) bytes, sha256 `([0-9a-f]{64})`$")
+
+// Artifact is a file produced by a run
+type Artifact struct {
+	Path   string `json:"path"` // relative to the run directory
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ValidateArtifactPatterns checks the syntax of glob patterns of artifacts,
+// which must be relative to the run directory and stay inside it
+func ValidateArtifactPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid artifact pattern: %s", pattern)
+		}
+		clean := filepath.Clean(pattern)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("artifact pattern outside the run directory: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// CollectArtifacts returns the files in a run directory matching glob
+// patterns, including files in matching directories, sorted by path
+func CollectArtifacts(runDir string, patterns []string) ([]Artifact, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(runDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact pattern: %s", pattern)
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() {
+					paths = append(paths, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	artifacts := []Artifact{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := HashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(runDir, path)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, Artifact{Path: filepath.ToSlash(rel), Size: info.Size(), SHA256: hash})
+	}
+	return artifacts, nil
+}
+
+// WriteArtifacts writes the list of artifacts into a run directory
+func WriteArtifacts(runDir string, artifacts []Artifact) error {
+	data, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(runDir, ArtifactsFile), append(data, '\n'), 0644)
+}
+
+// ReadArtifacts reads the list of artifacts of a run directory, or nil if
+// it has none
+func ReadArtifacts(runDir string) ([]Artifact, error) {
+	data, err := os.ReadFile(filepath.Join(runDir, ArtifactsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var artifacts []Artifact
+	if err := json.Unmarshal(data, &artifacts); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ArtifactsFile, err)
+	}
+	return artifacts, nil
+}
+
+// ArtifactsSize returns the total size of artifacts
+func ArtifactsSize(artifacts []Artifact) int64 {
+	var size int64
+	for _, artifact := range artifacts {
+		size += artifact.Size
+	}
+	return size
+}
+
+// formatArtifacts formats artifacts, or other files recorded in the same
+// way, as a summary section
+func formatArtifacts(section string, artifacts []Artifact) string {
+	var b strings.Builder
+	b.WriteString("\n## " + section + "\n")
+	for _, artifact := range artifacts {
+		fmt.Fprintf(&b, "- `%s`: %d bytes, sha256 `%s`\n", artifact.Path, artifact.Size, artifact.SHA256)
+	}
+	return b.String()
+}
+
+// parseArtifact parses an artifact in summary sections
+func parseArtifact(line string) (Artifact, bool) {
+	matches := artifactPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return Artifact{}, false
+	}
+	size, err := strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return Artifact{}, false
+	}
+	return Artifact{Path: matches[1], Size: size, SHA256: matches[3]}, true
+}
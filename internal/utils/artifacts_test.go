@@ -0,0 +1,53 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	runDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, "model.pt"), []byte("model"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(runDir, "results", "plots"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, "results", "a.csv"), []byte("a,b\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, "results", "plots", "loss.png"), []byte("png"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, "stdout.log"), nil, 0644))
+
+	artifacts, err := utils.CollectArtifacts(runDir, []string{"model.pt", "results/*", "results/*.csv", "missing.bin"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"model.pt", "results/a.csv", "results/plots/loss.png"}, []string{artifacts[0].Path, artifacts[1].Path, artifacts[2].Path})
+	assert.Len(t, artifacts, 3)
+	assert.Equal(t, int64(5), artifacts[0].Size)
+	hash, _ := utils.HashFile(filepath.Join(runDir, "model.pt"))
+	assert.Equal(t, hash, artifacts[0].SHA256)
+	assert.Equal(t, int64(12), utils.ArtifactsSize(artifacts))
+
+	// The list is written to the run directory and the summary
+	assert.NoError(t, utils.WriteArtifacts(runDir, artifacts))
+	read, err := utils.ReadArtifacts(runDir)
+	assert.NoError(t, err)
+	assert.Equal(t, artifacts, read)
+
+	summaryPath := filepath.Join(runDir, "summary.md")
+	assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, utils.RunMetadata{Command: []string{"train"}}))
+	assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, time.Time{}, utils.RunResult{Artifacts: artifacts}))
+	info, err := utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, artifacts, info.Artifacts)
+
+	_, err = utils.CollectArtifacts(runDir, []string{"[model"})
+	assert.Error(t, err)
+}
+
+func TestValidateArtifactPatterns(t *testing.T) {
+	assert.NoError(t, utils.ValidateArtifactPatterns([]string{"model.pt", "checkpoints/*.ckpt", "./plots", "a/../b", "..weird"}))
+	for _, pattern := range []string{"[model", "/abs/model.pt", "..", "../data/*.csv", "out/../../secret", "./../x"} {
+		assert.Error(t, utils.ValidateArtifactPatterns([]string{pattern}), pattern)
+	}
+}
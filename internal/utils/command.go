@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitCommand splits a shell-quoted command line back into its arguments
+//
+// This is the inverse of shellescape.QuoteCommand, which is used to record
+// commands in summary files. Only quoting is handled; no expansions are done.
+func SplitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			// Single quotes preserve everything up to the closing quote
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in command: %s", command)
+			}
+			arg.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			// Double quotes allow backslash escapes of a few characters
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+					i++
+				}
+				arg.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, fmt.Errorf("unterminated double quote in command: %s", command)
+			}
+			inArg = true
+		case c == '\\' && i+1 < len(command):
+			i++
+			arg.WriteByte(command[i])
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,37 @@
+package utils_test
+
+import (
+	"testing"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestSplitCommand(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		commands := [][]string{
+			{"sleep", "5"},
+			{"python", "train.py", "--name", "my experiment"},
+			{"sh", "-c", "echo 'hello' \"world\" $HOME"},
+			{"echo", ""},
+		}
+		for _, command := range commands {
+			args, err := utils.SplitCommand(shellescape.QuoteCommand(command))
+			assert.NoError(t, err)
+			assert.Equal(t, command, args)
+		}
+	})
+
+	t.Run("Double quotes and escapes", func(t *testing.T) {
+		args, err := utils.SplitCommand(`echo "a \"b\"" c\ d`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"echo", `a "b"`, "c d"}, args)
+	})
+
+	t.Run("Unterminated quote", func(t *testing.T) {
+		_, err := utils.SplitCommand("echo 'hello")
+		assert.Error(t, err)
+	})
+}
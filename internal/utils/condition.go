@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Condition is a comparison of a metric or sweep parameter of runs with a
+// value, such as "accuracy>0.9" or "optimizer=adam"
+type Condition struct {
+	Name     string
+	Operator string
+	Value    string
+}
+
+// Operators of conditions; longer ones come first so that they are matched
+// before their prefixes
+var conditionOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// ParseCondition parses a condition of the form name<op>value, where op is
+// one of >, >=, <, <=, = (or ==), and !=
+func ParseCondition(s string) (Condition, error) {
+	for i := range len(s) {
+		for _, op := range conditionOperators {
+			if !strings.HasPrefix(s[i:], op) {
+				continue
+			}
+			name := strings.TrimSpace(s[:i])
+			value := strings.TrimSpace(s[i+len(op):])
+			if name == "" || value == "" {
+				return Condition{}, fmt.Errorf("invalid condition: %s", s)
+			}
+			if op == "==" {
+				op = "="
+			}
+			return Condition{Name: name, Operator: op, Value: value}, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("invalid condition: %s", s)
+}
+
+// Match reports whether the metric or sweep parameter of a run satisfies
+// the condition; metrics take precedence over parameters of the same name,
+// and runs without either never match
+func (c Condition) Match(run RunInfo) bool {
+	var actual string
+	if metric, ok := run.Metrics[c.Name]; ok {
+		actual = strconv.FormatFloat(metric, 'g', -1, 64)
+	} else if param, ok := run.SweepParams[c.Name]; ok {
+		actual = param
+	} else {
+		return false
+	}
+
+	// Compare numerically if both are numbers, otherwise as strings
+	var order int
+	x, errX := strconv.ParseFloat(actual, 64)
+	y, errY := strconv.ParseFloat(c.Value, 64)
+	if errX == nil && errY == nil {
+		switch {
+		case x < y:
+			order = -1
+		case x > y:
+			order = 1
+		}
+	} else {
+		order = strings.Compare(actual, c.Value)
+	}
+
+	switch c.Operator {
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case "!=":
+		return order != 0
+	default:
+		return order == 0
+	}
+}
@@ -0,0 +1,58 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		input string
+		want  utils.Condition
+	}{
+		{"accuracy>0.9", utils.Condition{Name: "accuracy", Operator: ">", Value: "0.9"}},
+		{"loss <= 0.1", utils.Condition{Name: "loss", Operator: "<=", Value: "0.1"}},
+		{"eval.f1>=0.5", utils.Condition{Name: "eval.f1", Operator: ">=", Value: "0.5"}},
+		{"optimizer==adam", utils.Condition{Name: "optimizer", Operator: "=", Value: "adam"}},
+		{"optimizer!=sgd", utils.Condition{Name: "optimizer", Operator: "!=", Value: "sgd"}},
+	}
+	for _, tt := range tests {
+		got, err := utils.ParseCondition(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	for _, input := range []string{"accuracy", ">0.9", "accuracy>", ""} {
+		_, err := utils.ParseCondition(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestConditionMatch(t *testing.T) {
+	run := utils.RunInfo{
+		Metrics:     map[string]float64{"accuracy": 0.92, "loss": 0.1},
+		SweepParams: map[string]string{"lr": "0.01", "optimizer": "adam"},
+	}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"accuracy>0.9", true},
+		{"accuracy>0.95", false},
+		{"loss<=0.1", true},
+		{"loss!=0.1", false},
+		{"lr<0.1", true},
+		{"lr=1e-2", true},
+		{"optimizer=adam", true},
+		{"optimizer!=adam", false},
+		{"epochs>1", false},
+	}
+	for _, tt := range tests {
+		c, err := utils.ParseCondition(tt.condition)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, c.Match(run), tt.condition)
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EventsFile is the file in a run directory logging the events of the run,
+// one JSON object per line
+const EventsFile = "events.jsonl"
+
+// Types of events of a run
+const (
+	EventCreated  = "created"  // the run directory was created
+	EventStarted  = "started"  // the command was started
+	EventSignal   = "signal"   // a signal was received and sent to the command
+	EventTimeout  = "timeout"  // the command was terminated after the timeout
+	EventFinished = "finished" // the command exited
+	EventCleanup  = "cleanup"  // the run directory is about to be removed
+)
+
+// Event is an entry of the event log of a run
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"event"`
+	PID      int       `json:"pid,omitempty"`
+	Signal   string    `json:"signal,omitempty"`
+	ExitCode *int      `json:"exit_code,omitempty"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// AppendEvent appends an event to the event log of a run, timestamped with
+// the current time unless it has a time
+func AppendEvent(runDir string, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(runDir, EventsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadEvents reads the event log of a run; a run without the log has no
+// events
+func ReadEvents(runDir string) ([]Event, error) {
+	file, err := os.Open(filepath.Join(runDir, EventsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s at line %d: %w", EventsFile, line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestEvents(t *testing.T) {
+	runDir := t.TempDir()
+
+	// A run without the log has no events
+	events, err := utils.ReadEvents(runDir)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	created, _ := time.Parse(time.RFC3339, "2025-03-24T12:34:56Z")
+	exitCode := 0
+	assert.NoError(t, utils.AppendEvent(runDir, utils.Event{Time: created, Type: utils.EventCreated}))
+	assert.NoError(t, utils.AppendEvent(runDir, utils.Event{Type: utils.EventStarted, PID: 1234}))
+	assert.NoError(t, utils.AppendEvent(runDir, utils.Event{Type: utils.EventFinished, ExitCode: &exitCode}))
+
+	events, err = utils.ReadEvents(runDir)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.True(t, events[0].Time.Equal(created))
+	assert.Equal(t, utils.EventStarted, events[1].Type)
+	assert.Equal(t, 1234, events[1].PID)
+	assert.False(t, events[1].Time.IsZero())
+	assert.Equal(t, 0, *events[2].ExitCode)
+
+	// Exit code 0 is recorded, unlike missing fields
+	data, err := os.ReadFile(filepath.Join(runDir, utils.EventsFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"exit_code":0`)
+	assert.NotContains(t, string(data), `"signal"`)
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FingerprintPath computes a fingerprint of a file or directory
+//
+// In fast mode, only the names, sizes, and modification times of files are
+// hashed; otherwise, their contents are hashed as well. The result is
+// prefixed with the mode (e.g., "fast:9f86d08...") so that fingerprints
+// computed in different modes are never considered equal.
+//
+// Symbolic links, including the root itself, are followed, and the targets of
+// links inside the root are hashed as well so that relinking is detected.
+func FingerprintPath(root string, full bool) (string, error) {
+	hash := sha256.New()
+	if err := fingerprintTree(hash, root, "", full, map[string]bool{}); err != nil {
+		return "", err
+	}
+
+	mode := "fast"
+	if full {
+		mode = "full"
+	}
+	return mode + ":" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintTree hashes the files under root, whose paths are prefixed with
+// prefix; visited holds the resolved directories already hashed so that
+// cycles of links are not followed
+func fingerprintTree(hash io.Writer, root, prefix string, full bool, visited map[string]bool) error {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	if visited[resolved] {
+		return nil
+	}
+	visited[resolved] = true
+
+	return filepath.WalkDir(resolved, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(resolved, path)
+		if err != nil {
+			return err
+		}
+		name := prefix + filepath.ToSlash(relPath)
+
+		var info fs.FileInfo
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(hash, "%s\x00->%s\x00", name, target)
+			info, err = os.Stat(path)
+			if err != nil {
+				return nil // Dangling links are hashed by their targets only
+			}
+			if info.IsDir() {
+				return fingerprintTree(hash, path, name+"/", full, visited)
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+		} else if info, err = d.Info(); err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, info.Size())
+
+		if !full {
+			fmt.Fprintf(hash, "%d\x00", info.ModTime().UnixNano())
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(hash, file)
+		return err
+	})
+}
+
+// HashFile computes the SHA-256 hash of a file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return HashReader(file)
+}
+
+// HashReader computes the SHA-256 hash of everything read from r
+func HashReader(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,91 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestFingerprintPath(t *testing.T) {
+	tempDir := t.TempDir()
+	dataDir := filepath.Join(tempDir, "data")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dataDir, "raw"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dataDir, "raw", "a.csv"), []byte("x,y\n1,2\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dataDir, "b.txt"), []byte("hello"), 0644))
+
+	t.Run("Modes", func(t *testing.T) {
+		fast, err := utils.FingerprintPath(dataDir, false)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(fast, "fast:"))
+
+		full, err := utils.FingerprintPath(dataDir, true)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(full, "full:"))
+	})
+
+	t.Run("Content changes", func(t *testing.T) {
+		before, err := utils.FingerprintPath(dataDir, true)
+		assert.NoError(t, err)
+		again, err := utils.FingerprintPath(dataDir, true)
+		assert.NoError(t, err)
+		assert.Equal(t, before, again)
+
+		// Same size, different content
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, "b.txt"), []byte("world"), 0644))
+		after, err := utils.FingerprintPath(dataDir, true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+
+	t.Run("Single file", func(t *testing.T) {
+		_, err := utils.FingerprintPath(filepath.Join(dataDir, "b.txt"), true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Symbolic links", func(t *testing.T) {
+		// A data root linked to another location, e.g., a mounted dataset
+		link := filepath.Join(tempDir, "linked")
+		if err := os.Symlink(dataDir, link); err != nil {
+			t.Skipf("symbolic links not supported: %v", err)
+		}
+		direct, err := utils.FingerprintPath(dataDir, true)
+		assert.NoError(t, err)
+		linked, err := utils.FingerprintPath(link, true)
+		assert.NoError(t, err)
+		assert.Equal(t, direct, linked)
+
+		empty := filepath.Join(tempDir, "empty")
+		assert.NoError(t, os.Mkdir(empty, 0755))
+		emptyFingerprint, err := utils.FingerprintPath(empty, true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, emptyFingerprint, linked)
+
+		// Changes of the linked data are detected
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, "b.txt"), []byte("again"), 0644))
+		changed, err := utils.FingerprintPath(link, true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, linked, changed)
+
+		// Linked files and directories inside the root, even in cycles
+		external := filepath.Join(tempDir, "external.csv")
+		assert.NoError(t, os.WriteFile(external, []byte("1,2\n"), 0644))
+		assert.NoError(t, os.Symlink(external, filepath.Join(dataDir, "c.csv")))
+		assert.NoError(t, os.Symlink(dataDir, filepath.Join(dataDir, "raw", "cycle")))
+		before, err := utils.FingerprintPath(link, true)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(external, []byte("3,4\n"), 0644))
+		after, err := utils.FingerprintPath(link, true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+
+	t.Run("Non-existent path", func(t *testing.T) {
+		_, err := utils.FingerprintPath(filepath.Join(tempDir, "missing"), false)
+		assert.Error(t, err)
+	})
+}
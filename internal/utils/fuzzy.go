@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"unicode"
+)
+
+// FuzzyScore reports whether the characters of pattern appear in text in
+// order, ignoring case, with a score that is higher for matches of
+// consecutive characters and of characters at the start of words
+func FuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	// Match greedily from each occurrence of the first character and keep
+	// the best score
+	best, found := 0, false
+	for start := range t {
+		if !equalFold(t[start], p[0]) {
+			continue
+		}
+		if score, ok := fuzzyScoreFrom(p, t, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// fuzzyScoreFrom scores the greedy match of p in t starting at t[start]
+func fuzzyScoreFrom(p, t []rune, start int) (int, bool) {
+	score, j, last := 0, 0, -2
+	for i := start; i < len(t) && j < len(p); i++ {
+		if !equalFold(t[i], p[j]) {
+			continue
+		}
+		score++
+		if last == i-1 {
+			score += 3
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 2
+		}
+		j, last = j+1, i
+	}
+	return score, j == len(p)
+}
+
+func equalFold(a, b rune) bool {
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
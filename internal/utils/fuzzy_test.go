@@ -0,0 +1,29 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := utils.FuzzyScore("", "anything")
+	assert.True(t, ok)
+
+	_, ok = utils.FuzzyScore("trn", "python Train.py")
+	assert.True(t, ok)
+
+	_, ok = utils.FuzzyScore("yx", "python train.py")
+	assert.False(t, ok)
+
+	// Consecutive matches and matches at word starts score higher
+	consecutive, _ := utils.FuzzyScore("train", "python train.py")
+	scattered, _ := utils.FuzzyScore("train", "the rain in spain")
+	assert.Greater(t, consecutive, scattered)
+
+	wordStart, _ := utils.FuzzyScore("fail", "Failed (exit: 1)")
+	inside, _ := utils.FuzzyScore("fail", "xfaily")
+	assert.Greater(t, wordStart, inside)
+}
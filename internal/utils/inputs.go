@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InputsDir is the directory in a run directory where input files are copied
+const InputsDir = "inputs"
+
+// inputsSection is the title of the summary section of input files
+const inputsSection = "Inputs"
+
+// ValidateInputPatterns checks glob patterns of input files, which must be
+// relative paths inside the working directory
+func ValidateInputPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		_, err := filepath.Match(pattern, "")
+		cleaned := filepath.Clean(pattern)
+		if err != nil || filepath.IsAbs(pattern) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid input pattern: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// CopyInputs copies the files in srcDir matching glob patterns, including
+// files in matching directories, into the inputs directory of a run and
+// returns their sizes and checksums; it is an error if a pattern matches
+// nothing, since the run would silently miss an input
+func CopyInputs(srcDir, runDir string, patterns []string) ([]Artifact, error) {
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(srcDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern: %s", pattern)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no input files match %s", pattern)
+		}
+	}
+
+	files, err := CollectArtifacts(srcDir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		// Checksums are of the copies in case the files have just changed
+		src := filepath.Join(srcDir, filepath.FromSlash(file.Path))
+		dst := filepath.Join(runDir, InputsDir, filepath.FromSlash(file.Path))
+		size, hash, err := copyInput(src, dst)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", file.Path, err)
+		}
+		files[i].Size = size
+		files[i].SHA256 = hash
+	}
+	return files, nil
+}
+
+// copyInput copies a file, returning the size and SHA-256 hash of the copy
+func copyInput(src, dst string) (int64, string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, "", err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, "", err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), in)
+	if err != nil {
+		out.Close()
+		return 0, "", err
+	}
+	if err := out.Close(); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
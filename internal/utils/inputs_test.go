@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestCopyInputs(t *testing.T) {
+	srcDir := t.TempDir()
+	runDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "configs"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "configs", "model.yaml"), []byte("layers: 4\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "params.toml"), []byte("lr = 0.1\n"), 0644))
+
+	inputs, err := utils.CopyInputs(srcDir, runDir, []string{"configs/*.yaml", "params.toml"})
+	assert.NoError(t, err)
+	assert.Len(t, inputs, 2)
+	assert.Equal(t, "configs/model.yaml", inputs[0].Path)
+	assert.Equal(t, int64(10), inputs[0].Size)
+
+	// The copies are recorded, not the files that may change later
+	data, err := os.ReadFile(filepath.Join(runDir, utils.InputsDir, "configs", "model.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "layers: 4\n", string(data))
+	hash, _ := utils.HashFile(filepath.Join(runDir, utils.InputsDir, "params.toml"))
+	assert.Equal(t, hash, inputs[1].SHA256)
+
+	summaryPath := filepath.Join(runDir, "summary.md")
+	meta := utils.RunMetadata{Command: []string{"train"}, Inputs: inputs}
+	assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+	info, err := utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, inputs, info.Inputs)
+
+	_, err = utils.CopyInputs(srcDir, runDir, []string{"missing.yaml"})
+	assert.Error(t, err)
+	assert.Error(t, utils.ValidateInputPatterns([]string{"../secrets.yaml"}))
+	assert.Error(t, utils.ValidateInputPatterns([]string{"/etc/hosts"}))
+	assert.NoError(t, utils.ValidateInputPatterns([]string{"configs/*.yaml"}))
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "strings"
+
+// IssueLink is an issue linked to a run with the URL of the issue, if known
+type IssueLink struct {
+	ID  string `json:"id"`
+	URL string `json:"url,omitempty"`
+}
+
+// IssueLinks returns links to issues, with URLs made by replacing {id} in
+// urlTemplate with each ID without a leading "#" (e.g., for GitHub issues);
+// the URLs are empty if urlTemplate is
+func IssueLinks(ids []string, urlTemplate string) []IssueLink {
+	var links []IssueLink
+	for _, id := range ids {
+		link := IssueLink{ID: id}
+		if urlTemplate != "" {
+			link.URL = strings.ReplaceAll(urlTemplate, "{id}", strings.TrimPrefix(id, "#"))
+		}
+		links = append(links, link)
+	}
+	return links
+}
@@ -0,0 +1,18 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestIssueLinks(t *testing.T) {
+	assert.Equal(t, []utils.IssueLink{
+		{ID: "PROJ-42", URL: "https://tracker.example.com/browse/PROJ-42"},
+		{ID: "#7", URL: "https://tracker.example.com/browse/7"},
+	}, utils.IssueLinks([]string{"PROJ-42", "#7"}, "https://tracker.example.com/browse/{id}"))
+	assert.Equal(t, []utils.IssueLink{{ID: "PROJ-42"}}, utils.IssueLinks([]string{"PROJ-42"}, ""))
+	assert.Nil(t, utils.IssueLinks(nil, "https://tracker.example.com/browse/{id}"))
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Files in which commands write metrics into their run directories: a JSON
+// object of final values, or JSON objects logged line by line
+const (
+	MetricsFile    = "metrics.json"
+	MetricsLogFile = "metrics.jsonl"
+)
+
+// metricsSection is the title of the summary section of final metric values
+const metricsSection = "Metrics"
+
+// ReadMetrics returns the final metric values written into a run directory,
+// or nil if there is no metrics file; numbers in nested objects are named
+// by joining keys with dots, and the last logged values are final
+func ReadMetrics(runDir string) (map[string]float64, error) {
+	metrics := map[string]float64{}
+
+	data, err := os.ReadFile(filepath.Join(runDir, MetricsFile))
+	if err == nil {
+		var values map[string]any
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", MetricsFile, err)
+		}
+		flattenMetrics(metrics, "", values)
+		return metrics, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(runDir, MetricsLogFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var values map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &values); err != nil {
+			return nil, fmt.Errorf("invalid %s at line %d: %w", MetricsLogFile, line, err)
+		}
+		flattenMetrics(metrics, "", values)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// flattenMetrics adds numbers in values to metrics, ignoring other types
+func flattenMetrics(metrics map[string]float64, prefix string, values map[string]any) {
+	for key, value := range values {
+		switch value := value.(type) {
+		case float64:
+			metrics[prefix+key] = value
+		case map[string]any:
+			flattenMetrics(metrics, prefix+key+".", value)
+		}
+	}
+}
+
+// FormatMetric formats a metric value for display
+func FormatMetric(value float64) string {
+	return strconv.FormatFloat(value, 'g', 6, 64)
+}
+
+// formatMetrics formats metric values as a summary section
+func formatMetrics(metrics map[string]float64) string {
+	values := make(map[string]string, len(metrics))
+	for key, value := range metrics {
+		values[key] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	var b strings.Builder
+	b.WriteString("\n## " + metricsSection + "\n")
+	writeKeyValues(&b, values)
+	return b.String()
+}
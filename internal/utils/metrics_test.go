@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestReadMetrics(t *testing.T) {
+	t.Run("No metrics file", func(t *testing.T) {
+		metrics, err := utils.ReadMetrics(t.TempDir())
+		assert.NoError(t, err)
+		assert.Nil(t, metrics)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		runDir := t.TempDir()
+		data := `{"loss": 0.25, "eval": {"accuracy": 0.9}, "model": "resnet", "epochs": 10}`
+		assert.NoError(t, os.WriteFile(filepath.Join(runDir, utils.MetricsFile), []byte(data), 0644))
+
+		metrics, err := utils.ReadMetrics(runDir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]float64{"loss": 0.25, "eval.accuracy": 0.9, "epochs": 10}, metrics)
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		runDir := t.TempDir()
+		data := "{\"step\": 1, \"loss\": 0.5}\n\n{\"step\": 2, \"loss\": 0.3, \"accuracy\": 0.8}\n{\"step\": 3, \"loss\": 0.2}\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(runDir, utils.MetricsLogFile), []byte(data), 0644))
+
+		metrics, err := utils.ReadMetrics(runDir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]float64{"step": 3, "loss": 0.2, "accuracy": 0.8}, metrics)
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		runDir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(runDir, utils.MetricsFile), []byte("{"), 0644))
+
+		_, err := utils.ReadMetrics(runDir)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// SummaryBackupSuffix is appended to the name of the original summary file
+// kept when it is migrated
+const SummaryBackupSuffix = ".bak"
+
+// legacyTimestampFormats are the formats of timestamps in summary files
+// written before RFC3339 with a time zone was adopted; they are in local time
+var legacyTimestampFormats = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// timestampPrefixes are the prefixes of summary lines with timestamps
+var timestampPrefixes = []string{
+	"- **Execution datetime**: ",
+	"- **Execution finished**: ",
+}
+
+// MigrateSummary upgrades a summary file written by an older version to the
+// current format, keeping the original with SummaryBackupSuffix, and writes
+// its JSON copy if missing or outdated; the problems found are returned,
+// and nothing is written if dryRun is set
+func MigrateSummary(summaryPath string, dryRun bool) ([]string, error) {
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary file: %w", err)
+	}
+
+	var changes []string
+	content, n, err := upgradeTimestamps(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		changes = append(changes, fmt.Sprintf("%d legacy timestamp(s)", n))
+		if !dryRun {
+			if err := backupFile(summaryPath, summaryPath+SummaryBackupSuffix); err != nil {
+				return nil, fmt.Errorf("failed to back up summary file: %w", err)
+			}
+			if err := rewriteFile(summaryPath, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Check that the summary can be parsed before writing its JSON copy
+	if _, err := ParseRunInfoFrom(strings.NewReader(content), summaryPath); err != nil {
+		return nil, err
+	}
+	if _, ok := readSummaryJSON(summaryPath); !ok || n > 0 {
+		changes = append(changes, "outdated "+SummaryJSONFile)
+		if !dryRun {
+			if err := WriteSummaryJSON(summaryPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
+
+// upgradeTimestamps converts timestamps in legacy formats to the current
+// format, returning the converted content and the number of timestamps
+func upgradeTimestamps(content string) (string, int, error) {
+	lines := strings.Split(content, "\n")
+	n := 0
+	for i, line := range lines {
+		for _, prefix := range timestampPrefixes {
+			value, found := strings.CutPrefix(line, prefix)
+			if !found {
+				continue
+			}
+			if _, err := time.Parse(timestampFormat, value); err == nil {
+				continue
+			}
+			t, err := parseLegacyTimestamp(value)
+			if err != nil {
+				return "", 0, err
+			}
+			lines[i] = prefix + t.Format(timestampFormat)
+			n++
+		}
+	}
+	return strings.Join(lines, "\n"), n, nil
+}
+
+// parseLegacyTimestamp parses a timestamp in any of the legacy formats
+func parseLegacyTimestamp(value string) (time.Time, error) {
+	for _, layout := range legacyTimestampFormats {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", value)
+}
+
+// backupFile copies a file unless the backup exists, so that the backup of
+// the first migration is never overwritten
+func backupFile(path, backupPath string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(backupPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
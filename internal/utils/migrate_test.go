@@ -0,0 +1,59 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestMigrateSummary(t *testing.T) {
+	runDir := t.TempDir()
+	summaryPath := filepath.Join(runDir, "summary.md")
+	legacy := `# Experiment Summary
+
+## Metadata
+- **Execution datetime**: 2025-03-24 12:34:56
+- **Branch**: ` + "`main`" + `
+- **Command**: ` + "`sleep 5`" + `
+
+## Execution Results
+- **Execution finished**: 2025-03-24 12:35:01
+- **Execution time**: 5s
+- **Exit status**: 0
+`
+	assert.NoError(t, os.WriteFile(summaryPath, []byte(legacy), 0644))
+
+	// A dry run reports the changes without writing anything
+	changes, err := utils.MigrateSummary(summaryPath, true)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	data, _ := os.ReadFile(summaryPath)
+	assert.Equal(t, legacy, string(data))
+	assert.NoFileExists(t, filepath.Join(runDir, utils.SummaryJSONFile))
+
+	changes, err = utils.MigrateSummary(summaryPath, false)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	backup, _ := os.ReadFile(summaryPath + utils.SummaryBackupSuffix)
+	assert.Equal(t, legacy, string(backup))
+	assert.FileExists(t, filepath.Join(runDir, utils.SummaryJSONFile))
+
+	info, err := utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	startTime := time.Date(2025, 3, 24, 12, 34, 56, 0, time.Local)
+	assert.True(t, info.StartTime.Equal(startTime))
+	assert.Equal(t, 5*time.Second, info.EndTime.Sub(info.StartTime))
+	data, _ = os.ReadFile(summaryPath)
+	assert.True(t, strings.Contains(string(data), startTime.Format(time.RFC3339)))
+
+	// Migrated runs are left untouched
+	changes, err = utils.MigrateSummary(summaryPath, false)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
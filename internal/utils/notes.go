@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// notesSection is the title of the section of notes in a summary file
+const notesSection = "Notes"
+
+// Note is a free-form note written about a run after the fact
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// AppendSummaryNote appends a note to the notes section of a summary file,
+// creating the section if it does not exist
+func AppendSummaryNote(summaryPath string, note Note) error {
+	text := strings.TrimSpace(note.Text)
+	if text == "" {
+		return fmt.Errorf("empty note")
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read summary file: %w", err)
+	}
+
+	// Continuation lines are indented to belong to the list item
+	entry := fmt.Sprintf("- **%s**: %s", note.Time.Format(timestampFormat),
+		strings.ReplaceAll(text, "\n", "\n  "))
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	start := slices.Index(lines, "## "+notesSection)
+	if start < 0 {
+		lines = append(lines, "", "## "+notesSection, entry)
+	} else {
+		// Insert after the last note, before the next section if any
+		end := start + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end], "## ") {
+			end++
+		}
+		for end > start+1 && lines[end-1] == "" {
+			end--
+		}
+		lines = slices.Insert(lines, end, entry)
+	}
+	return rewriteFile(summaryPath, strings.Join(lines, "\n")+"\n")
+}
+
+// LatestNote returns the first line of the latest note of a run, if any
+func LatestNote(run RunInfo) string {
+	if len(run.Notes) == 0 {
+		return ""
+	}
+	text, _, _ := strings.Cut(run.Notes[len(run.Notes)-1].Text, "\n")
+	return text
+}
+
+// parseNote parses the first line of a note in a summary file
+func parseNote(line string) (Note, bool) {
+	after, found := strings.CutPrefix(line, "- **")
+	if !found {
+		return Note{}, false
+	}
+	timestamp, text, found := strings.Cut(after, "**: ")
+	if !found {
+		return Note{}, false
+	}
+	t, err := time.Parse(timestampFormat, timestamp)
+	if err != nil {
+		return Note{}, false
+	}
+	return Note{Time: t, Text: text}, true
+}
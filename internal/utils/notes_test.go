@@ -0,0 +1,39 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestAppendSummaryNote(t *testing.T) {
+	// Copy a summary file to modify
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	data, err := os.ReadFile(filepath.Join("testdata", "summary.md"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(summaryPath, data, 0644))
+
+	first := utils.Note{Time: time.Date(2025, 3, 24, 10, 0, 0, 0, time.UTC), Text: "Loss plateaus"}
+	second := utils.Note{Time: time.Date(2025, 3, 25, 10, 0, 0, 0, time.UTC), Text: "Rerun with\nmore epochs\n"}
+	assert.NoError(t, utils.AppendSummaryNote(summaryPath, first))
+	assert.NoError(t, utils.AppendSummaryNote(summaryPath, second))
+	assert.Error(t, utils.AppendSummaryNote(summaryPath, utils.Note{Time: first.Time, Text: " \n"}))
+
+	info, err := utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	if assert.Len(t, info.Notes, 2) {
+		assert.True(t, first.Time.Equal(info.Notes[0].Time))
+		assert.Equal(t, "Loss plateaus", info.Notes[0].Text)
+		assert.Equal(t, "Rerun with\nmore epochs", info.Notes[1].Text)
+	}
+	assert.Equal(t, "Rerun with", utils.LatestNote(info))
+
+	// Other information is kept
+	assert.Equal(t, "sleep 5", info.Command)
+	assert.False(t, info.IsRunning)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PIDFile is the file in a run directory holding the PID of the running command
+const PIDFile = "run.pid"
+
+// WritePIDFile records the PID of the command of a run
+func WritePIDFile(runDir string, pid int) error {
+	return os.WriteFile(filepath.Join(runDir, PIDFile), []byte(strconv.Itoa(pid)+"\n"), 0644)
+}
+
+// ReadPIDFile reads the PID of the command of a run
+func ReadPIDFile(runDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(runDir, PIDFile))
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file: %s", filepath.Join(runDir, PIDFile))
+	}
+	return pid, nil
+}
+
+// RemovePIDFile removes the PID file of a finished run
+func RemovePIDFile(runDir string) error {
+	err := os.Remove(filepath.Join(runDir, PIDFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// HeartbeatFile is the file in a run directory touched periodically by moco
+// while it supervises the command of the run
+const HeartbeatFile = "heartbeat"
+
+// TouchHeartbeat records that the command of a run is still being supervised
+func TouchHeartbeat(runDir string) error {
+	now := time.Now().Format(time.RFC3339) + "\n"
+	return os.WriteFile(filepath.Join(runDir, HeartbeatFile), []byte(now), 0644)
+}
+
+// RemoveHeartbeat removes the heartbeat file of a finished run
+func RemoveHeartbeat(runDir string) error {
+	err := os.Remove(filepath.Join(runDir, HeartbeatFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MarkStale flags running runs whose heartbeat is older than threshold;
+// runs without a heartbeat file are left as they are
+func MarkStale(runs []RunInfo, threshold time.Duration) {
+	if threshold <= 0 {
+		return
+	}
+	for i := range runs {
+		if !runs[i].IsRunning {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(runs[i].Directory, HeartbeatFile))
+		if err != nil {
+			continue
+		}
+		runs[i].Stale = time.Since(info.ModTime()) > threshold
+	}
+}
+
+// ParseSignal parses a signal name (e.g., "TERM" or "SIGTERM") or number
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	if sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unknown signal: %s", name)
+}
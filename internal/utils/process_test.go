@@ -0,0 +1,76 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestPIDFile(t *testing.T) {
+	runDir := t.TempDir()
+
+	_, err := utils.ReadPIDFile(runDir)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, utils.WritePIDFile(runDir, 12345))
+	pid, err := utils.ReadPIDFile(runDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 12345, pid)
+
+	assert.NoError(t, utils.RemovePIDFile(runDir))
+	assert.NoError(t, utils.RemovePIDFile(runDir))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, utils.PIDFile), []byte("abc\n"), 0644))
+	_, err = utils.ReadPIDFile(runDir)
+	assert.Error(t, err)
+}
+
+func TestProcessAlive(t *testing.T) {
+	assert.True(t, utils.ProcessAlive(os.Getpid()))
+}
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"TERM", "SIGTERM", "term", "15"} {
+		sig, err := utils.ParseSignal(name)
+		assert.NoError(t, err)
+		assert.Equal(t, syscall.SIGTERM, sig)
+	}
+	sig, err := utils.ParseSignal("KILL")
+	assert.NoError(t, err)
+	assert.Equal(t, syscall.SIGKILL, sig)
+
+	_, err = utils.ParseSignal("FOO")
+	assert.Error(t, err)
+	_, err = utils.ParseSignal("-1")
+	assert.Error(t, err)
+}
+
+func TestMarkStale(t *testing.T) {
+	fresh, stale, noHeartbeat := t.TempDir(), t.TempDir(), t.TempDir()
+	assert.NoError(t, utils.TouchHeartbeat(fresh))
+	assert.NoError(t, utils.TouchHeartbeat(stale))
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(stale, utils.HeartbeatFile), old, old))
+
+	runs := []utils.RunInfo{
+		{Directory: fresh, IsRunning: true},
+		{Directory: stale, IsRunning: true},
+		{Directory: noHeartbeat, IsRunning: true},
+		{Directory: stale, IsRunning: false},
+	}
+	utils.MarkStale(runs, time.Minute)
+	assert.False(t, runs[0].Stale)
+	assert.True(t, runs[1].Stale)
+	assert.False(t, runs[2].Stale)
+	assert.False(t, runs[3].Stale)
+	assert.Equal(t, "Stale", utils.StatusString(runs[1]))
+
+	assert.NoError(t, utils.RemoveHeartbeat(stale))
+	assert.NoError(t, utils.RemoveHeartbeat(stale))
+}
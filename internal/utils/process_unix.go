@@ -0,0 +1,51 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ProcessAlive reports whether a process exists on this host by sending signal 0
+func ProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	// The process exists but is owned by another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// signals maps signal names without the "SIG" prefix to signals
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+// SignalProcessGroup sends a signal to the process group led by a process,
+// or to the process alone if it does not lead a group
+func SignalProcessGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	return err
+}
+
+// ProcessStartTime returns when a process started, to the second
+func ProcessStartTime(pid int) (time.Time, error) {
+	output, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to run ps: %w", err)
+	}
+	// e.g., "Thu Mar 24 00:34:51 2025", padded differently across systems
+	return time.ParseInLocation("Mon Jan 2 15:04:05 2006", strings.Join(strings.Fields(string(output)), " "), time.Local)
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ProcessAlive reports whether a process exists on this host
+func ProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// signals maps signal names without the "SIG" prefix to signals
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// SignalProcessGroup terminates a process; process groups and signals other
+// than KILL are not supported on Windows
+func SignalProcessGroup(pid int, sig syscall.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	defer process.Release()
+	return process.Signal(sig)
+}
+
+// ProcessStartTime returns when a process started; it is not supported on
+// Windows
+func ProcessStartTime(pid int) (time.Time, error) {
+	return time.Time{}, errors.New("process start times are not supported on Windows")
+}
@@ -1,14 +1,22 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 )
 
+// NoUncommittedChanges is recorded in place of an empty diff
+const NoUncommittedChanges = "[No uncommitted changes]\n"
+
 // RepoStatus contains information about a Git repository
 type RepoStatus struct {
 	IsValid       bool
@@ -20,14 +28,20 @@ type RepoStatus struct {
 	CommitAuthor  string
 	CommitDate    time.Time
 	StatusString  string
+	ChangedFiles  []string
 }
 
 // GetRepoStatus retrieves the current status of the Git repository
 func GetRepoStatus() (RepoStatus, error) {
+	return GetRepoStatusAt(".")
+}
+
+// GetRepoStatusAt retrieves the status of the Git repository at path
+func GetRepoStatusAt(path string) (RepoStatus, error) {
 	status := RepoStatus{IsValid: false}
 
-	// Open repository in the current directory
-	repo, err := git.PlainOpen(".")
+	// Open repository (which may be a linked worktree)
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
 	if err != nil {
 		return status, fmt.Errorf("failed to open git repository: %w", err)
 	}
@@ -72,6 +86,12 @@ func GetRepoStatus() (RepoStatus, error) {
 	}
 
 	status.IsDirty = !wStatus.IsClean()
+	for file, fileStatus := range wStatus {
+		if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
+			status.ChangedFiles = append(status.ChangedFiles, file)
+		}
+	}
+	slices.Sort(status.ChangedFiles)
 	status.StatusString = wStatus.String()
 	if status.StatusString == "" {
 		status.StatusString = "[No uncommitted changes or untracked files]\n"
@@ -114,14 +134,260 @@ func GetUncommittedChanges() (string, error) {
 
 	diff := output.String()
 	if diff == "" {
-		diff = "[No uncommitted changes]\n"
+		diff = NoUncommittedChanges
 	}
 
 	return diff, nil
 }
 
+// PatchFile is the file in a run directory holding the full patch of
+// uncommitted changes when the run started
+const PatchFile = "uncommitted.patch"
+
+// GetFullPatch returns a patch of all uncommitted changes, staged or not,
+// including untracked files outside excludeDir, that can be applied to HEAD
+// with git apply; it is preceded by the output of git status as comments
+func GetFullPatch(excludeDir string) (string, error) {
+	var patch strings.Builder
+
+	status, err := exec.Command("git", "status", "--porcelain").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git status: %w", err)
+	}
+	patch.WriteString("# git status --porcelain\n")
+	for line := range strings.SplitSeq(strings.TrimRight(string(status), "\n"), "\n") {
+		patch.WriteString("# " + line + "\n")
+	}
+	patch.WriteString("\n")
+
+	diff, err := exec.Command("git", "diff", "HEAD", "--binary").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git diff: %w", err)
+	}
+	patch.Write(diff)
+
+	// Untracked files are listed and diffed relative to the top level so that
+	// the paths match those of git diff
+	topLevel, err := RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	args := []string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", ":/"}
+	if dir, ok := repoRelativePath(topLevel, excludeDir); ok {
+		args = append(args, ":(top,exclude)"+dir)
+	}
+	untracked, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	for file := range strings.SplitSeq(string(untracked), "\x00") {
+		if file == "" {
+			continue
+		}
+		// git diff --no-index exits with 1 when the files differ
+		cmd := exec.Command("git", "diff", "--no-index", "--binary", "/dev/null", file)
+		cmd.Dir = topLevel
+		diff, err := cmd.Output()
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return "", fmt.Errorf("failed to diff untracked file %s: %w", file, err)
+		}
+		patch.Write(diff)
+	}
+
+	return patch.String(), nil
+}
+
+// RepoRoot returns the top-level directory of the current repository
+func RepoRoot() (string, error) {
+	topLevel, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find top-level directory: %w", err)
+	}
+	return strings.TrimSpace(string(topLevel)), nil
+}
+
+// repoRelativePath returns a path relative to the top-level directory of a
+// repository, or false if it is empty or outside the repository
+func repoRelativePath(topLevel, path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	// The top level is reported with symbolic links resolved
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(topLevel, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// PatchChanges returns the changes of a patch saved by GetFullPatch without
+// the preceding comments
+func PatchChanges(patch string) string {
+	for strings.HasPrefix(patch, "#") {
+		_, patch, _ = strings.Cut(patch, "\n")
+	}
+	return strings.TrimPrefix(patch, "\n")
+}
+
+// GitNotesRef is the notes ref under which run records are attached to commits
+const GitNotesRef = "moco"
+
+// AddGitNote appends a note to a commit under GitNotesRef
+func AddGitNote(commit, note string) error {
+	cmd := exec.Command("git", "notes", "--ref="+GitNotesRef, "append", "-m", note, commit)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run git notes: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// DirtyFiles returns the changed files that do not match any of the ignore patterns
+func DirtyFiles(changedFiles, ignorePatterns []string) []string {
+	var dirty []string
+	for _, file := range changedFiles {
+		ignored := slices.ContainsFunc(ignorePatterns, func(pattern string) bool {
+			return MatchPathPattern(pattern, file)
+		})
+		if !ignored {
+			dirty = append(dirty, file)
+		}
+	}
+	return dirty
+}
+
+// MatchPathPattern reports whether a slash-separated file path matches a pattern
+//
+// A pattern ending with "/" or "/**" matches everything under that directory,
+// and a pattern starting with "**/" matches at any depth. Other patterns are
+// glob patterns matched against the full path or, if they contain no slash,
+// against the base name (e.g., "*.md" matches "docs/README.md").
+func MatchPathPattern(pattern, file string) bool {
+	if rest, found := strings.CutPrefix(pattern, "**/"); found {
+		for suffix := file; ; {
+			if MatchPathPattern(rest, suffix) {
+				return true
+			}
+			_, after, found := strings.Cut(suffix, "/")
+			if !found {
+				return false
+			}
+			suffix = after
+		}
+	}
+	if dir, found := strings.CutSuffix(pattern, "/**"); found {
+		pattern = dir + "/"
+	}
+	if dir, found := strings.CutSuffix(pattern, "/"); found {
+		return file == dir || strings.HasPrefix(file, dir+"/")
+	}
+	if matched, _ := path.Match(pattern, file); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(file))
+		return matched
+	}
+	return false
+}
+
 // SanitizeBranchName replaces invalid characters in a branch name
 func SanitizeBranchName(name string) string {
 	// https://git-scm.com/docs/git-check-ref-format
 	return strings.ReplaceAll(name, "/", "-")
 }
+
+// AddWorktree checks out a commit into a new detached worktree at path
+func AddWorktree(path, commit string) error {
+	cmd := exec.Command("git", "worktree", "add", "--detach", path, commit)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run git worktree add: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// AddBranchWorktree checks out a commit into a new worktree at path on a new branch
+func AddBranchWorktree(path, branch, commit string) error {
+	cmd := exec.Command("git", "worktree", "add", "-b", branch, path, commit)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run git worktree add: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// RemoveWorktree removes a worktree created by AddWorktree
+func RemoveWorktree(path string) error {
+	cmd := exec.Command("git", "worktree", "remove", "--force", path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run git worktree remove: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// ApplyPatch applies a patch to the working tree in dir
+func ApplyPatch(dir, patch string) error {
+	cmd := exec.Command("git", "apply", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run git apply: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// RecordedPatch returns the uncommitted changes recorded with a run and
+// whether they are a full patch, which is preferred to the diff in the
+// summary as it also covers staged changes and untracked files
+func RecordedPatch(summaryPath string, run RunInfo) (string, bool, error) {
+	if run.PatchFile != "" {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(summaryPath), run.PatchFile))
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read full patch: %w", err)
+		}
+		return string(data), true, nil
+	}
+	patch, err := ReadSummarySection(summaryPath, "Uncommitted Changes")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read uncommitted changes: %w", err)
+	}
+	return patch, false, nil
+}
+
+// CompareCodeState compares the current code state with a recorded commit and
+// uncommitted changes, and returns human-readable descriptions of differences;
+// if full is true, patch is a full patch saved by GetFullPatch
+func CompareCodeState(commitHash, patch string, full bool) ([]string, error) {
+	var discrepancies []string
+
+	repo, err := GetRepoStatus()
+	if err != nil {
+		return nil, err
+	}
+	if repo.FullHash != commitHash {
+		discrepancies = append(discrepancies,
+			fmt.Sprintf("HEAD is at %s but the original run used %s", repo.FullHash, commitHash))
+	}
+
+	var diff string
+	if full {
+		diff, err = GetFullPatch("")
+		diff, patch = PatchChanges(diff), PatchChanges(patch)
+	} else {
+		diff, err = GetUncommittedChanges()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if diff != patch {
+		discrepancies = append(discrepancies, "uncommitted changes differ from those of the original run")
+	}
+
+	return discrepancies, nil
+}
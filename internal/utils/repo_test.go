@@ -21,3 +21,26 @@ func TestSanitizeBranchName(t *testing.T) {
 		assert.Equal(t, "foo-bar", sanitized)
 	})
 }
+
+func TestMatchPathPattern(t *testing.T) {
+	assert.True(t, utils.MatchPathPattern("notebooks/", "notebooks/eda.ipynb"))
+	assert.True(t, utils.MatchPathPattern("notebooks/", "notebooks/sub/eda.ipynb"))
+	assert.False(t, utils.MatchPathPattern("notebooks/", "src/notebooks.py"))
+	assert.True(t, utils.MatchPathPattern("*.md", "README.md"))
+	assert.True(t, utils.MatchPathPattern("*.md", "docs/guide.md"))
+	assert.False(t, utils.MatchPathPattern("*.md", "main.go"))
+	assert.True(t, utils.MatchPathPattern("docs/*.txt", "docs/notes.txt"))
+	assert.False(t, utils.MatchPathPattern("docs/*.txt", "other/docs/notes.txt"))
+	assert.True(t, utils.MatchPathPattern("tmp/**", "tmp/cache/data.bin"))
+	assert.False(t, utils.MatchPathPattern("tmp/**", "src/tmp/data.bin"))
+	assert.True(t, utils.MatchPathPattern("**/cache/", "a/b/cache/data.bin"))
+	assert.True(t, utils.MatchPathPattern("**/*.ckpt", "checkpoints/epoch1.ckpt"))
+	assert.False(t, utils.MatchPathPattern("**/cache/", "a/b/caches/data.bin"))
+}
+
+func TestDirtyFiles(t *testing.T) {
+	changed := []string{"README.md", "notebooks/eda.ipynb", "src/train.py"}
+	assert.Equal(t, []string{"src/train.py"}, utils.DirtyFiles(changed, []string{"notebooks/", "*.md"}))
+	assert.Equal(t, changed, utils.DirtyFiles(changed, nil))
+	assert.Empty(t, utils.DirtyFiles(changed, []string{"*"}))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resourceUsageSection is the title of the summary section of resource usage
+const resourceUsageSection = "Resource Usage"
+
+// ResourceUsage is the resource usage of the command of a finished run
+type ResourceUsage struct {
+	UserSeconds   float64 `json:"user_seconds"`
+	SystemSeconds float64 `json:"system_seconds"`
+	MaxMemory     int64   `json:"max_memory"` // peak resident set size in bytes
+	ReadBytes     int64   `json:"read_bytes"`
+	WriteBytes    int64   `json:"write_bytes"`
+}
+
+// CPUSeconds returns the total CPU time in seconds
+func (u ResourceUsage) CPUSeconds() float64 {
+	return u.UserSeconds + u.SystemSeconds
+}
+
+// formatResourceUsage formats resource usage as a summary section
+func formatResourceUsage(u ResourceUsage) string {
+	var b strings.Builder
+	b.WriteString("\n## " + resourceUsageSection + "\n")
+	fmt.Fprintf(&b, "- **CPU time**: %.2f s (user %.2f s, system %.2f s)\n",
+		u.CPUSeconds(), u.UserSeconds, u.SystemSeconds)
+	fmt.Fprintf(&b, "- **Max memory**: %d bytes (%s)\n", u.MaxMemory, FormatSize(u.MaxMemory))
+	fmt.Fprintf(&b, "- **Disk I/O**: %d bytes read, %d bytes written\n", u.ReadBytes, u.WriteBytes)
+	return b.String()
+}
+
+// parseResourceUsage parses a line of the resource usage section into u
+func parseResourceUsage(line string, u *ResourceUsage) error {
+	var err error
+	if after, found := strings.CutPrefix(line, "- **CPU time**: "); found {
+		var total float64
+		_, err = fmt.Sscanf(after, "%f s (user %f s, system %f s)", &total, &u.UserSeconds, &u.SystemSeconds)
+	} else if after, found := strings.CutPrefix(line, "- **Max memory**: "); found {
+		_, err = fmt.Sscanf(after, "%d bytes", &u.MaxMemory)
+	} else if after, found := strings.CutPrefix(line, "- **Disk I/O**: "); found {
+		_, err = fmt.Sscanf(after, "%d bytes read, %d bytes written", &u.ReadBytes, &u.WriteBytes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse resource usage: %s", line)
+	}
+	return nil
+}
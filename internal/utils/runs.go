@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RunDirPattern matches names of run directories (timestamp_branch_hash or
+// timestamp_branch_hash_name), where timestamps have an optional fraction of
+// seconds and zone; names cannot look like hashes (see SanitizeRunName)
+var RunDirPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d{3}|\.\d{6})?(?:Z|[+-]\d{4})?)_(.+)_([a-f0-9]{7})(?:_([A-Za-z0-9.-]+))?$`)
+
+// invalidRunNameChars matches characters not allowed in run names
+var invalidRunNameChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// shortHashPattern matches abbreviated commit hashes in names of run
+// directories
+var shortHashPattern = regexp.MustCompile(`^[a-f0-9]{7}$`)
+
+// SanitizeRunName replaces characters in a run name that are not allowed in
+// names of run directories with hyphens
+//
+// Names looking like abbreviated commit hashes are rejected, since run
+// directories with them could not be told from ones without a name.
+func SanitizeRunName(name string) (string, error) {
+	sanitized := strings.Trim(invalidRunNameChars.ReplaceAllString(name, "-"), "-.")
+	if sanitized == "" {
+		return "", fmt.Errorf("invalid run name: %q", name)
+	}
+	if shortHashPattern.MatchString(sanitized) {
+		return "", fmt.Errorf("invalid run name: %q looks like a commit hash", name)
+	}
+	return sanitized, nil
+}
+
+// runDirTimeLayout is the layout of timestamps in names of run directories
+// without the fraction of seconds, which is accepted when parsing anyway
+const runDirTimeLayout = "2006-01-02T15:04:05"
+
+// FormatRunDirTime formats the timestamp in the name of a run directory in
+// a time zone ("local", "UTC", or a name such as "Asia/Tokyo") with a
+// precision ("s", "ms", or "us"); the zone is included unless it is local
+func FormatRunDirTime(t time.Time, timezone, precision string) (string, error) {
+	layout := runDirTimeLayout
+	switch precision {
+	case "s":
+	case "", "ms":
+		layout += ".000"
+	case "us":
+		layout += ".000000"
+	default:
+		return "", fmt.Errorf("invalid timestamp precision: %s", precision)
+	}
+
+	switch timezone {
+	case "", "local", "Local":
+		return t.Format(layout), nil
+	case "UTC":
+		return t.UTC().Format(layout + "Z"), nil
+	default:
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return "", fmt.Errorf("invalid time zone: %s", timezone)
+		}
+		return t.In(loc).Format(layout + "-0700"), nil
+	}
+}
+
+// ParseRunDirTime parses the timestamp in the name of a run directory, which
+// is in local time unless it has a zone
+func ParseRunDirTime(runDir string) (time.Time, error) {
+	matches := RunDirPattern.FindStringSubmatch(filepath.Base(filepath.Clean(runDir)))
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("not a run directory: %s", runDir)
+	}
+	timestamp := matches[1]
+	if strings.HasSuffix(timestamp, "Z") || strings.ContainsAny(timestamp[len(runDirTimeLayout):], "+-") {
+		return time.Parse(runDirTimeLayout+"Z0700", timestamp)
+	}
+	return time.ParseInLocation(runDirTimeLayout, timestamp, time.Local)
+}
+
+// FindRunDirs returns the paths of run directories in baseDir in name (i.e., chronological) order
+func FindRunDirs(baseDir string) ([]string, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read base directory: %w", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && RunDirPattern.MatchString(entry.Name()) {
+			dirs = append(dirs, filepath.Join(baseDir, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// Names of symbolic links in the base directory to the newest run and the
+// newest successful run
+const (
+	LatestLink        = "latest"
+	LatestSuccessLink = "latest-success"
+)
+
+// UpdateRunLink points a symbolic link in the base directory to a run
+// directory, replacing the link atomically so that readers never miss it
+func UpdateRunLink(baseDir, name, runDir string) error {
+	link := filepath.Join(baseDir, name)
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not a symbolic link", link)
+	}
+
+	tmpLink := fmt.Sprintf("%s.tmp%d", link, os.Getpid())
+	if err := os.Symlink(filepath.Base(filepath.Clean(runDir)), tmpLink); err != nil {
+		return fmt.Errorf("failed to create symbolic link: %w", err)
+	}
+	if err := os.Rename(tmpLink, link); err != nil {
+		os.Remove(tmpLink)
+		return fmt.Errorf("failed to update symbolic link: %w", err)
+	}
+	return nil
+}
+
+// MoveDir moves a directory, copying it if it cannot be renamed (e.g., across
+// file systems); the destination must not exist
+func MoveDir(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+		}
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("failed to move %s: %w", src, err)
+	}
+	return os.RemoveAll(src)
+}
+
+// copyFile copies the contents of a file to a new file with the given mode
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ShortID returns the short ID of a run, which is derived from the name of
+// its directory
+func ShortID(runDir string) string {
+	sum := sha256.Sum256([]byte(filepath.Base(filepath.Clean(runDir))))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// Symbolic references to runs in the base directory
+const (
+	LastRunRef        = "@last"         // the latest run
+	LastSuccessRunRef = "@last-success" // the latest successful run
+	LastFailureRunRef = "@last-failure" // the latest failed run
+	RunningRunRef     = "@running"      // the latest run recorded as running
+)
+
+// RunRefs lists the symbolic references to runs
+var RunRefs = []string{LastRunRef, LastSuccessRunRef, LastFailureRunRef, RunningRunRef}
+
+// IsRunRef reports whether s is a symbolic reference to a run
+func IsRunRef(s string) bool {
+	return slices.Contains(RunRefs, s)
+}
+
+// ResolveRunRef returns the directory of a run given as a symbolic
+// reference, a path, the name of a directory in the base directory, a unique
+// prefix of the name, or a short ID
+func ResolveRunRef(baseDir, summaryFile, ref string) (string, error) {
+	var match func(RunInfo) bool
+	switch ref {
+	case LastRunRef:
+		match = func(RunInfo) bool { return true }
+	case LastSuccessRunRef:
+		match = func(run RunInfo) bool { return run.Succeeded() }
+	case LastFailureRunRef:
+		match = func(run RunInfo) bool { return run.Failed() }
+	case RunningRunRef:
+		match = func(run RunInfo) bool { return run.IsRunning }
+	default:
+		if _, err := os.Stat(ref); err == nil {
+			return ref, nil
+		} else if !os.IsNotExist(err) || filepath.IsAbs(ref) {
+			return "", err
+		}
+		path := filepath.Join(baseDir, ref)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		return findRunByPrefix(baseDir, ref)
+	}
+
+	dirs, err := FindRunDirs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range slices.Backward(dirs) {
+		if ref == LastRunRef {
+			return dir, nil
+		}
+		run, err := ParseRunInfo(filepath.Join(dir, summaryFile))
+		if err == nil && match(run) {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no run matching %s found in %s", ref, baseDir)
+}
+
+// findRunByPrefix returns the run in the base directory whose short ID or
+// directory name starts with prefix, which must be unique
+func findRunByPrefix(baseDir, prefix string) (string, error) {
+	dirs, err := FindRunDirs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, dir := range dirs {
+		if strings.HasPrefix(ShortID(dir), prefix) || strings.HasPrefix(filepath.Base(dir), prefix) {
+			matches = append(matches, dir)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("run not found: %s", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous run %s matches %d runs", prefix, len(matches))
+	}
+}
+
+// DirSize computes the total size of the files in a directory
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return err
+	})
+	return size, err
+}
@@ -0,0 +1,165 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestResolveRunRef(t *testing.T) {
+	baseDir := t.TempDir()
+	startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+	writeRun := func(name string, exitCode int, finished bool) string {
+		runDir := filepath.Join(baseDir, name)
+		assert.NoError(t, os.Mkdir(runDir, 0755))
+		summaryPath := filepath.Join(runDir, "summary.md")
+		meta := utils.RunMetadata{StartTime: startTime, Command: []string{"train"}}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		if finished {
+			result := utils.RunResult{EndTime: startTime, ExitCode: exitCode, Success: exitCode == 0}
+			assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+		}
+		return runDir
+	}
+	success := writeRun("2025-03-24T00:00:00.000_main_1234567", 0, true)
+	running := writeRun("2025-03-24T00:00:01.000_main_1234567", 0, false)
+	failure := writeRun("2025-03-24T00:00:02.000_main_1234567", 1, true)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{utils.LastRunRef, failure},
+		{utils.LastSuccessRunRef, success},
+		{utils.LastFailureRunRef, failure},
+		{utils.RunningRunRef, running},
+		{success, success},
+		{filepath.Base(running), running},
+		{utils.ShortID(failure), failure},
+		{utils.ShortID(failure)[:6], failure},
+		{"2025-03-24T00:00:01", running},
+	}
+	for _, tt := range tests {
+		got, err := utils.ResolveRunRef(baseDir, "summary.md", tt.ref)
+		assert.NoError(t, err, tt.ref)
+		assert.Equal(t, tt.want, got, tt.ref)
+	}
+
+	_, err := utils.ResolveRunRef(baseDir, "summary.md", "2025-01-01T00:00:00.000_main_1234567")
+	assert.Error(t, err)
+
+	_, err = utils.ResolveRunRef(baseDir, "summary.md", "2025-03-24T00:00")
+	assert.ErrorContains(t, err, "ambiguous")
+
+	_, err = utils.ResolveRunRef(t.TempDir(), "summary.md", utils.LastRunRef)
+	assert.Error(t, err)
+}
+
+func TestRunDirTime(t *testing.T) {
+	ts, _ := time.Parse(time.RFC3339Nano, "2025-03-24T12:34:56.789123Z")
+
+	cases := []struct {
+		timezone  string
+		precision string
+		expected  string
+	}{
+		{"UTC", "s", "2025-03-24T12:34:56Z"},
+		{"UTC", "ms", "2025-03-24T12:34:56.789Z"},
+		{"UTC", "us", "2025-03-24T12:34:56.789123Z"},
+		{"Asia/Tokyo", "ms", "2025-03-24T21:34:56.789+0900"},
+	}
+	for _, c := range cases {
+		name, err := utils.FormatRunDirTime(ts, c.timezone, c.precision)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, name)
+
+		runDir := name + "_main_1234567"
+		assert.True(t, utils.RunDirPattern.MatchString(runDir))
+		parsed, err := utils.ParseRunDirTime(runDir)
+		assert.NoError(t, err)
+		assert.True(t, parsed.Equal(ts.Truncate(map[string]time.Duration{
+			"s": time.Second, "ms": time.Millisecond, "us": time.Microsecond,
+		}[c.precision])))
+	}
+
+	// Directory names without a zone are in local time
+	name, err := utils.FormatRunDirTime(ts, "local", "ms")
+	assert.NoError(t, err)
+	parsed, err := utils.ParseRunDirTime(name + "_main_1234567")
+	assert.NoError(t, err)
+	assert.True(t, parsed.Equal(ts.Truncate(time.Millisecond)))
+
+	_, err = utils.FormatRunDirTime(ts, "Nowhere/City", "ms")
+	assert.Error(t, err)
+	_, err = utils.FormatRunDirTime(ts, "UTC", "ns")
+	assert.Error(t, err)
+}
+
+func TestSanitizeRunName(t *testing.T) {
+	name, err := utils.SanitizeRunName("lr 0.1/warmup_v2!")
+	assert.NoError(t, err)
+	assert.Equal(t, "lr-0.1-warmup-v2", name)
+
+	runDir := "2025-03-24T00:00:00.000_feature_x_1234567_" + name
+	matches := utils.RunDirPattern.FindStringSubmatch(runDir)
+	assert.Equal(t, []string{runDir, "2025-03-24T00:00:00.000", "feature_x", "1234567", name}, matches)
+
+	_, err = utils.SanitizeRunName("//")
+	assert.Error(t, err)
+
+	// Names that look like hashes would be taken for the hash of a run
+	// directory without a name
+	_, err = utils.SanitizeRunName("deadbee")
+	assert.Error(t, err)
+	name, err = utils.SanitizeRunName("deadbee2")
+	assert.NoError(t, err)
+	runDir = "2025-03-24T00:00:00.000_main_1234567_" + name
+	matches = utils.RunDirPattern.FindStringSubmatch(runDir)
+	assert.Equal(t, []string{runDir, "2025-03-24T00:00:00.000", "main", "1234567", name}, matches)
+}
+
+func TestUpdateRunLink(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, name := range []string{"2025-03-24T00:00:00.000_main_1234567", "2025-03-24T00:00:01.000_main_1234567"} {
+		runDir := filepath.Join(baseDir, name)
+		assert.NoError(t, os.Mkdir(runDir, 0755))
+		assert.NoError(t, utils.UpdateRunLink(baseDir, utils.LatestLink, runDir))
+
+		target, err := os.Readlink(filepath.Join(baseDir, utils.LatestLink))
+		assert.NoError(t, err)
+		assert.Equal(t, name, target)
+	}
+
+	// Links are not run directories
+	runDirs, err := utils.FindRunDirs(baseDir)
+	assert.NoError(t, err)
+	assert.Len(t, runDirs, 2)
+
+	// Regular files are not replaced
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, utils.LatestSuccessLink), nil, 0644))
+	assert.Error(t, utils.UpdateRunLink(baseDir, utils.LatestSuccessLink, runDirs[0]))
+}
+
+func TestMoveDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("data"), 0644))
+
+	dst := filepath.Join(dir, "dst")
+	assert.NoError(t, utils.MoveDir(src, dst))
+	data, err := os.ReadFile(filepath.Join(dst, "sub", "file.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	assert.NoDirExists(t, src)
+
+	// Existing directories are not overwritten
+	assert.NoError(t, os.Mkdir(src, 0755))
+	assert.Error(t, utils.MoveDir(src, dst))
+	assert.DirExists(t, src)
+}
@@ -2,15 +2,20 @@ package utils
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"al.essio.dev/pkg/shellescape"
+	"github.com/charmbracelet/log"
 )
 
 // Timezone is indispensable for correct parsing of timestamps
@@ -19,16 +24,137 @@ const timestampFormat = time.RFC3339
 
 // RunInfo contains information about a specific run
 type RunInfo struct {
+	ID          string    `json:"id"`
 	Directory   string    `json:"directory"`
 	File        string    `json:"file_name"`
+	Name        string    `json:"name,omitempty"`
 	Command     string    `json:"command"`
 	StartTime   time.Time `json:"start_time"`
 	EndTime     time.Time `json:"end_time,omitempty"`
 	ExitStatus  int       `json:"exit_status"`
+	ExitReason  string    `json:"exit_reason,omitempty"`
+	Success     bool      `json:"success"`
 	IsRunning   bool      `json:"is_running"`
+	Stale       bool      `json:"stale,omitempty"`
 	Branch      string    `json:"branch"`
 	CommitHash  string    `json:"commit_hash"`
 	Interrupted bool      `json:"interrupted"`
+	TimedOut    bool      `json:"timed_out,omitempty"`
+	Issues      []string  `json:"issues,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Hostname    string    `json:"hostname,omitempty"`
+	Project     string    `json:"project,omitempty"`
+	GPUHours    float64   `json:"gpu_hours,omitempty"`
+	Cost        float64   `json:"cost,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+
+	Resources *ResourceUsage     `json:"resources,omitempty"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Artifacts []Artifact         `json:"artifacts,omitempty"`
+	Inputs    []Artifact         `json:"inputs,omitempty"`
+
+	TruncatedLogs []string `json:"truncated_logs,omitempty"`
+
+	ExtraRepos       []ExtraRepo       `json:"extra_repos,omitempty"`
+	ToolVersions     map[string]string `json:"tool_versions,omitempty"`
+	DataFingerprints map[string]string `json:"data_fingerprints,omitempty"`
+	EnvVars          map[string]string `json:"env_vars,omitempty"`
+	EnvSnapshots     map[string]string `json:"env_snapshots,omitempty"`
+	PatchFile        string            `json:"patch_file,omitempty"`
+	TensorBoardDir   string            `json:"tensorboard_dir,omitempty"`
+	DVCHashes        map[string]string `json:"dvc_hashes,omitempty"`
+	DataChanged      bool              `json:"data_changed,omitempty"`
+	ReproducedFrom   string            `json:"reproduced_from,omitempty"`
+	RerunOf          string            `json:"rerun_of,omitempty"`
+	After            string            `json:"after,omitempty"`
+	Sweep            string            `json:"sweep,omitempty"`
+	SweepParams      map[string]string `json:"sweep_params,omitempty"`
+
+	CodeDiscrepancies []string `json:"code_discrepancies,omitempty"`
+	Notes             []Note   `json:"notes,omitempty"`
+}
+
+// ExtraRepo contains the state of an additional repository recorded with a run
+type ExtraRepo struct {
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	CommitHash string `json:"commit_hash"`
+	IsDirty    bool   `json:"is_dirty"`
+}
+
+// DVCState contains data versions of a repository using DVC
+type DVCState struct {
+	Hashes  map[string]string // path -> hash in dvc.lock and .dvc files
+	Status  string            // outputs of dvc status and dvc diff
+	Changed bool              // whether dvc status reported changes
+}
+
+// RunMetadata contains information recorded at the start of a run
+type RunMetadata struct {
+	StartTime        time.Time
+	Repo             RepoStatus
+	Command          []string
+	Name             string
+	Message          string
+	Issues           []string
+	Tags             []string
+	ExtraRepos       []ExtraRepo
+	ToolVersions     map[string]string
+	DataFingerprints map[string]string
+	EnvVars          map[string]string
+	EnvSnapshots     map[string]string // command -> file in the run directory
+	PatchFile        string            // file in the run directory with the full patch
+	TensorBoardDir   string            // TensorBoard log directory in the run directory
+	Inputs           []Artifact        // input files copied into the run directory
+	DVC              DVCState
+	GPUInfo          string
+
+	// Directory of the original run if this run reproduces it
+	ReproducedFrom string
+	// Directory of the original run if this run reruns its command
+	RerunOf string
+	// Directory of the run this run waited for, if any
+	After string
+	// ID of the sweep this run belongs to and its parameter values, if any
+	Sweep       string
+	SweepParams map[string]string
+	// Differences between the code state of this run and the original run
+	CodeDiscrepancies []string
+
+	// Recorded environment, which is retrieved from the current environment
+	// if empty (these are set only when regenerating a summary)
+	Hostname           string
+	GitStatus          string
+	CommitDetails      string
+	UncommittedChanges string
+	SystemInfo         string
+}
+
+// Succeeded reports whether the run has finished successfully
+func (r *RunInfo) Succeeded() bool {
+	return !r.IsRunning && r.Success
+}
+
+// Failed reports whether the run has finished unsuccessfully
+func (r *RunInfo) Failed() bool {
+	return !r.IsRunning && !r.Success
+}
+
+// CPUSeconds returns the CPU time of the command in seconds, zero if unknown
+func (r *RunInfo) CPUSeconds() float64 {
+	if r.Resources == nil {
+		return 0
+	}
+	return r.Resources.CPUSeconds()
+}
+
+// MaxMemory returns the peak memory of the command in bytes, zero if unknown
+func (r *RunInfo) MaxMemory() int64 {
+	if r.Resources == nil {
+		return 0
+	}
+	return r.Resources.MaxMemory
 }
 
 // Duration returns a formatted duration of the run
@@ -48,36 +174,57 @@ func (r *RunInfo) Duration() string {
 	return formatDuration(d)
 }
 
-func WriteSummaryFileInit(summaryPath string, startTime time.Time, repo RepoStatus, command []string, message string) error {
+func WriteSummaryFileInit(summaryPath string, meta RunMetadata) error {
+	var err error
+
 	// Get hostname
-	hostname, err := os.Hostname()
-	if err != nil {
-		hostname = "unknown"
+	hostname := meta.Hostname
+	if hostname == "" {
+		hostname, err = os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
 	}
 
 	// Get working directory
 	directry, _ := filepath.Split(summaryPath)
 
 	// Get git commit details
-	commitDetails, err := GetCommitDetails()
-	if err != nil {
-		commitDetails = "Error retrieving commit details"
+	commitDetails := meta.CommitDetails
+	if commitDetails == "" {
+		commitDetails, err = GetCommitDetails()
+		if err != nil {
+			commitDetails = "Error retrieving commit details"
+		}
 	}
 
 	// Get git status
-	gitStatus, err := GetRepoStatus()
-	if err != nil {
-		gitStatus = RepoStatus{IsValid: false}
+	gitStatus := meta.GitStatus
+	if gitStatus == "" {
+		status, err := GetRepoStatus()
+		if err != nil {
+			status = RepoStatus{IsValid: false}
+		}
+		gitStatus = status.StatusString
 	}
 
 	// Get git diff
-	gitDiff, err := GetUncommittedChanges()
-	if err != nil {
-		gitDiff = "Error retrieving uncommitted changes"
+	gitDiff := meta.UncommittedChanges
+	if gitDiff == "" {
+		gitDiff, err = GetUncommittedChanges()
+		if err != nil {
+			gitDiff = "Error retrieving uncommitted changes"
+		}
 	}
 
 	// Get system info
-	sysInfo := getSystemInfo()
+	sysInfo := meta.SystemInfo
+	if sysInfo == "" {
+		sysInfo = getSystemInfo()
+		if meta.GPUInfo != "" {
+			sysInfo += "\n" + meta.GPUInfo
+		}
+	}
 
 	// Construct metadata section
 	var b strings.Builder
@@ -86,6 +233,7 @@ func WriteSummaryFileInit(summaryPath string, startTime time.Time, repo RepoStat
 	b.WriteString("# Experiment Summary\n\n")
 
 	// Message
+	message := meta.Message
 	if message != "" {
 		// Make sure the message ends with a newline
 		if !strings.HasSuffix(message, "\n") {
@@ -98,19 +246,74 @@ func WriteSummaryFileInit(summaryPath string, startTime time.Time, repo RepoStat
 
 	// Metadata
 	b.WriteString("## Metadata\n")
-	fmt.Fprintf(&b, "- **Execution datetime**: %s\n", startTime.Format(timestampFormat))
-	fmt.Fprintf(&b, "- **Branch**: `%s`\n", repo.Branch)
-	fmt.Fprintf(&b, "- **Commit hash**: `%s`\n", repo.FullHash)
-	fmt.Fprintf(&b, "- **Command**: `%s`\n", shellescape.QuoteCommand(command))
+	fmt.Fprintf(&b, "- **Execution datetime**: %s\n", meta.StartTime.Format(timestampFormat))
+	if meta.Name != "" {
+		fmt.Fprintf(&b, "- **Name**: `%s`\n", meta.Name)
+	}
+	fmt.Fprintf(&b, "- **Branch**: `%s`\n", meta.Repo.Branch)
+	fmt.Fprintf(&b, "- **Commit hash**: `%s`\n", meta.Repo.FullHash)
+	fmt.Fprintf(&b, "- **Command**: `%s`\n", shellescape.QuoteCommand(meta.Command))
 	fmt.Fprintf(&b, "- **Hostname**: `%s`\n", hostname)
 	fmt.Fprintf(&b, "- **Working directory**: `%s`\n", directry)
+	if len(meta.Issues) > 0 {
+		fmt.Fprintf(&b, "- **Issues**: `%s`\n", strings.Join(meta.Issues, " "))
+	}
+	if meta.ReproducedFrom != "" {
+		fmt.Fprintf(&b, "- **Reproduction of**: `%s`\n", meta.ReproducedFrom)
+	}
+	if meta.RerunOf != "" {
+		fmt.Fprintf(&b, "- **Rerun of**: `%s`\n", meta.RerunOf)
+	}
+	if meta.After != "" {
+		fmt.Fprintf(&b, "- **After**: `%s`\n", meta.After)
+	}
+	if meta.Sweep != "" {
+		fmt.Fprintf(&b, "- **Sweep**: `%s`\n", meta.Sweep)
+	}
+	if meta.TensorBoardDir != "" {
+		fmt.Fprintf(&b, "- **TensorBoard**: `%s`\n", meta.TensorBoardDir)
+	}
+	if len(meta.Tags) > 0 {
+		fmt.Fprintf(&b, "%s`%s`\n", tagsPrefix, strings.Join(meta.Tags, " "))
+	}
+
+	// Parameter values of a sweep
+	if len(meta.SweepParams) > 0 {
+		b.WriteString("\n## Sweep Parameters\n")
+		writeKeyValues(&b, meta.SweepParams)
+	}
+
+	// Input files copied into the run directory
+	if len(meta.Inputs) > 0 {
+		b.WriteString(formatArtifacts(inputsSection, meta.Inputs))
+	}
+
+	// Code discrepancies from the original run
+	if len(meta.CodeDiscrepancies) > 0 {
+		b.WriteString("\n## Code Discrepancies\n")
+		for _, discrepancy := range meta.CodeDiscrepancies {
+			fmt.Fprintf(&b, "- %s\n", discrepancy)
+		}
+	}
 
 	// Git status
 	b.WriteString("\n## Git Status\n")
 	b.WriteString("```\n")
-	b.WriteString(gitStatus.StatusString)
+	b.WriteString(gitStatus)
 	b.WriteString("```\n")
 
+	// Additional repositories
+	if len(meta.ExtraRepos) > 0 {
+		b.WriteString("\n## Additional Repositories\n")
+		for _, repo := range meta.ExtraRepos {
+			state := "clean"
+			if repo.IsDirty {
+				state = "dirty"
+			}
+			fmt.Fprintf(&b, "- **%s**: `%s %s %s`\n", repo.Path, repo.Branch, repo.CommitHash, state)
+		}
+	}
+
 	// Latest commit details
 	b.WriteString("\n## Latest Commit Details\n")
 	b.WriteString("```diff\n")
@@ -122,12 +325,50 @@ func WriteSummaryFileInit(summaryPath string, startTime time.Time, repo RepoStat
 	b.WriteString("```diff\n")
 	b.WriteString(gitDiff)
 	b.WriteString("```\n")
+	if meta.PatchFile != "" {
+		fmt.Fprintf(&b, "- **Full patch**: `%s`\n", meta.PatchFile)
+	}
 
 	// System info
 	b.WriteString("\n## Environment Info\n")
 	b.WriteString("```\n")
 	b.WriteString(sysInfo)
 	b.WriteString("```\n")
+	writeKeyValues(&b, meta.ToolVersions)
+
+	// Data fingerprints
+	if len(meta.DataFingerprints) > 0 {
+		b.WriteString("\n## Data Fingerprints\n")
+		writeKeyValues(&b, meta.DataFingerprints)
+	}
+
+	// Data versions tracked by DVC
+	if meta.DVC.Status != "" {
+		b.WriteString("\n## DVC Status\n")
+		b.WriteString("```\n")
+		b.WriteString(meta.DVC.Status)
+		if !strings.HasSuffix(meta.DVC.Status, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n")
+		fmt.Fprintf(&b, "- **Data changed**: `%t`\n", meta.DVC.Changed)
+	}
+	if len(meta.DVC.Hashes) > 0 {
+		b.WriteString("\n## DVC Hashes\n")
+		writeKeyValues(&b, meta.DVC.Hashes)
+	}
+
+	// Environment variables
+	if len(meta.EnvVars) > 0 {
+		b.WriteString("\n## Environment Variables\n")
+		writeKeyValues(&b, meta.EnvVars)
+	}
+
+	// Snapshots of the environment saved as files
+	if len(meta.EnvSnapshots) > 0 {
+		b.WriteString("\n## Environment Snapshots\n")
+		writeKeyValues(&b, meta.EnvSnapshots)
+	}
 
 	// Create summary file
 	file, err := os.Create(summaryPath)
@@ -144,6 +385,18 @@ func WriteSummaryFileInit(summaryPath string, startTime time.Time, repo RepoStat
 	return nil
 }
 
+// writeKeyValues writes key-value pairs as a sorted list of "- **key**: `value`"
+func writeKeyValues(b *strings.Builder, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(b, "- **%s**: `%s`\n", key, values[key])
+	}
+}
+
 // getSystemInfo retrieves system information
 func getSystemInfo() string {
 	var sysInfo strings.Builder
@@ -155,7 +408,36 @@ func getSystemInfo() string {
 	return sysInfo.String()
 }
 
-func WriteSummaryFileEnd(summaryPath string, startTime, endTime time.Time, exitCode int, interrupted bool) error {
+// RunResult contains information recorded at the end of a run
+type RunResult struct {
+	EndTime     time.Time
+	ExitCode    int
+	Interrupted bool
+	TimedOut    bool
+
+	// Whether the exit code is considered successful and its description, if any
+	Success    bool
+	ExitReason string
+
+	// GPU hours and estimated cost of the run, recorded if positive
+	GPUHours float64
+	Cost     float64
+	Currency string
+
+	// Resource usage of the command, recorded if available
+	Resources *ResourceUsage
+
+	// Final metric values written by the command, if any
+	Metrics map[string]float64
+
+	// Files produced by the command matching the artifact patterns, if any
+	Artifacts []Artifact
+
+	// Output files whose contents were partly discarded due to the size limit
+	TruncatedLogs []string
+}
+
+func WriteSummaryFileEnd(summaryPath string, startTime time.Time, result RunResult) error {
 	// Open the summary file
 	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
@@ -169,11 +451,40 @@ func WriteSummaryFileEnd(summaryPath string, startTime, endTime time.Time, exitC
 - **Execution finished**: %s
 - **Execution time**: %s
 - **Exit status**: %d
-`, endTime.Format(timestampFormat), formatDuration(endTime.Sub(startTime)), exitCode)
+`, result.EndTime.Format(timestampFormat), formatDuration(result.EndTime.Sub(startTime)), result.ExitCode)
 
-	if interrupted {
+	if result.ExitReason != "" {
+		results += fmt.Sprintf("- **Exit reason**: `%s`\n", result.ExitReason)
+	}
+	if result.Success {
+		results += "- **Result**: success\n"
+	} else {
+		results += "- **Result**: failure\n"
+	}
+	if result.Interrupted {
 		results += "- **Terminated by user**\n"
 	}
+	if result.TimedOut {
+		results += "- **Timed out**\n"
+	}
+	if len(result.TruncatedLogs) > 0 {
+		results += fmt.Sprintf("- **Truncated logs**: `%s`\n", strings.Join(result.TruncatedLogs, " "))
+	}
+	if result.GPUHours > 0 {
+		results += fmt.Sprintf("- **GPU hours**: %.2f\n", result.GPUHours)
+	}
+	if result.Cost > 0 {
+		results += fmt.Sprintf("- **Estimated cost**: %.2f %s\n", result.Cost, result.Currency)
+	}
+	if result.Resources != nil {
+		results += formatResourceUsage(*result.Resources)
+	}
+	if len(result.Metrics) > 0 {
+		results += formatMetrics(result.Metrics)
+	}
+	if len(result.Artifacts) > 0 {
+		results += formatArtifacts(artifactsSection, result.Artifacts)
+	}
 
 	// Write results to file
 	if _, err := file.WriteString(results); err != nil {
@@ -183,25 +494,108 @@ func WriteSummaryFileEnd(summaryPath string, startTime, endTime time.Time, exitC
 	return nil
 }
 
-// ParseRunInfo extracts info from a summary file
-func ParseRunInfo(summaryPath string) (RunInfo, error) {
+// SummaryJSONFile is the machine-readable copy of the summary file in a run
+// directory
+const SummaryJSONFile = "summary.json"
+
+// WriteSummaryJSON writes the information in a summary file to the JSON file
+// next to it, which ParseRunInfo reads instead while it is up to date
+func WriteSummaryJSON(summaryPath string) error {
+	runInfo, err := parseRunInfoMarkdown(summaryPath)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(runInfo, "", "  ")
+	if err != nil {
+		return err
+	}
+	jsonPath := filepath.Join(filepath.Dir(summaryPath), SummaryJSONFile)
+	tmpFile, err := os.CreateTemp(filepath.Dir(summaryPath), ".summary-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(append(data, '\n')); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write %s: %w", SummaryJSONFile, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SummaryJSONFile, err)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), jsonPath)
+}
+
+// readSummaryJSON reads the JSON copy of a summary file unless it is missing,
+// broken, or older than the summary file, e.g., after the file was edited
+func readSummaryJSON(summaryPath string) (RunInfo, bool) {
+	jsonPath := filepath.Join(filepath.Dir(summaryPath), SummaryJSONFile)
+	summaryStat, err := os.Stat(summaryPath)
+	if err != nil {
+		return RunInfo{}, false
+	}
+	jsonStat, err := os.Stat(jsonPath)
+	if err != nil || jsonStat.ModTime().Before(summaryStat.ModTime()) {
+		return RunInfo{}, false
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return RunInfo{}, false
+	}
+	var runInfo RunInfo
+	if err := json.Unmarshal(data, &runInfo); err != nil {
+		return RunInfo{}, false
+	}
+
+	// The location of the run may have changed since the file was written
 	dirName, fileName := filepath.Split(summaryPath)
-	runInfo := RunInfo{
-		Directory: dirName,
-		File:      fileName,
-		IsRunning: true,
+	runInfo.ID = ShortID(dirName)
+	runInfo.Directory = dirName
+	runInfo.File = fileName
+	runInfo.Stale = false
+	return runInfo, true
+}
+
+// ParseRunInfo extracts info from a summary file, or from its JSON copy if
+// it is up to date
+func ParseRunInfo(summaryPath string) (RunInfo, error) {
+	if runInfo, ok := readSummaryJSON(summaryPath); ok {
+		return runInfo, nil
 	}
+	return parseRunInfoMarkdown(summaryPath)
+}
 
+// parseRunInfoMarkdown extracts info from a summary file
+func parseRunInfoMarkdown(summaryPath string) (RunInfo, error) {
 	// Open summary file
 	file, err := os.Open(summaryPath)
 	if err != nil {
-		return runInfo, fmt.Errorf("failed to open summary file: %w", err)
+		dirName, fileName := filepath.Split(summaryPath)
+		return RunInfo{Directory: dirName, File: fileName}, fmt.Errorf("failed to open summary file: %w", err)
 	}
 	defer file.Close()
 
+	return ParseRunInfoFrom(file, summaryPath)
+}
+
+// ParseRunInfoFrom extracts info from the contents of a summary file located
+// at summaryPath (which is used only to fill in the directory and file name)
+func ParseRunInfoFrom(r io.Reader, summaryPath string) (RunInfo, error) {
+	dirName, fileName := filepath.Split(summaryPath)
+	runInfo := RunInfo{
+		ID:        ShortID(dirName),
+		Directory: dirName,
+		File:      fileName,
+		IsRunning: true,
+	}
+
 	// Scan for relevant information
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	withinCodeBlock := false
+	section := ""
+	var message []string
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -216,6 +610,168 @@ func ParseRunInfo(summaryPath string) (RunInfo, error) {
 			continue
 		}
 
+		if after, found := strings.CutPrefix(line, "## "); found {
+			section = after
+			continue
+		}
+
+		if section == "" {
+			// The message is written between the header and the first section
+			if !strings.HasPrefix(line, "# ") {
+				message = append(message, line)
+			}
+			continue
+		}
+
+		if section == notesSection {
+			if note, found := parseNote(line); found {
+				runInfo.Notes = append(runInfo.Notes, note)
+			} else if after, found := strings.CutPrefix(line, "  "); found && len(runInfo.Notes) > 0 {
+				last := &runInfo.Notes[len(runInfo.Notes)-1]
+				last.Text += "\n" + after
+			}
+			continue
+		}
+
+		if section == resourceUsageSection {
+			if runInfo.Resources == nil {
+				runInfo.Resources = &ResourceUsage{}
+			}
+			// Resource usage is informational, so malformed lines (e.g.,
+			// edited by hand) do not make the whole summary unreadable
+			if err := parseResourceUsage(line, runInfo.Resources); err != nil {
+				log.Warnf("%s: %v", summaryPath, err)
+			}
+			continue
+		}
+
+		if section == metricsSection {
+			if name, value, found := parseKeyValue(line); found {
+				metric, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return runInfo, fmt.Errorf("failed to parse metric: %s", line)
+				}
+				if runInfo.Metrics == nil {
+					runInfo.Metrics = map[string]float64{}
+				}
+				runInfo.Metrics[name] = metric
+			}
+			continue
+		}
+
+		if section == artifactsSection {
+			if artifact, found := parseArtifact(line); found {
+				runInfo.Artifacts = append(runInfo.Artifacts, artifact)
+			}
+			continue
+		}
+
+		if section == inputsSection {
+			if input, found := parseArtifact(line); found {
+				runInfo.Inputs = append(runInfo.Inputs, input)
+			}
+			continue
+		}
+
+		if section == "Sweep Parameters" {
+			if name, value, found := parseKeyValue(line); found {
+				if runInfo.SweepParams == nil {
+					runInfo.SweepParams = map[string]string{}
+				}
+				runInfo.SweepParams[name] = value
+			}
+			continue
+		}
+
+		if section == "Code Discrepancies" {
+			if after, found := strings.CutPrefix(line, "- "); found {
+				runInfo.CodeDiscrepancies = append(runInfo.CodeDiscrepancies, after)
+			}
+			continue
+		}
+
+		if section == "Environment Info" {
+			// Tool versions are listed as "- **name**: `version`"
+			if name, version, found := parseKeyValue(line); found {
+				if runInfo.ToolVersions == nil {
+					runInfo.ToolVersions = map[string]string{}
+				}
+				runInfo.ToolVersions[name] = version
+			}
+			continue
+		}
+
+		if section == "Additional Repositories" {
+			// Repositories are listed as "- **path**: `branch hash state`"
+			if path, value, found := parseKeyValue(line); found {
+				fields := strings.Fields(value)
+				if len(fields) != 3 {
+					return runInfo, fmt.Errorf("failed to parse additional repository: %s", line)
+				}
+				runInfo.ExtraRepos = append(runInfo.ExtraRepos, ExtraRepo{
+					Path:       path,
+					Branch:     fields[0],
+					CommitHash: fields[1],
+					IsDirty:    fields[2] == "dirty",
+				})
+			}
+			continue
+		}
+
+		if section == "Data Fingerprints" {
+			if path, fingerprint, found := parseKeyValue(line); found {
+				if runInfo.DataFingerprints == nil {
+					runInfo.DataFingerprints = map[string]string{}
+				}
+				runInfo.DataFingerprints[path] = fingerprint
+			}
+			continue
+		}
+
+		if section == "DVC Status" {
+			if key, changed, found := parseKeyValue(line); found && key == "Data changed" {
+				runInfo.DataChanged = changed == "true"
+			}
+			continue
+		}
+
+		if section == "DVC Hashes" {
+			if path, hash, found := parseKeyValue(line); found {
+				if runInfo.DVCHashes == nil {
+					runInfo.DVCHashes = map[string]string{}
+				}
+				runInfo.DVCHashes[path] = hash
+			}
+			continue
+		}
+
+		if section == "Uncommitted Changes" {
+			if key, file, found := parseKeyValue(line); found && key == "Full patch" {
+				runInfo.PatchFile = file
+			}
+			continue
+		}
+
+		if section == "Environment Snapshots" {
+			if command, file, found := parseKeyValue(line); found {
+				if runInfo.EnvSnapshots == nil {
+					runInfo.EnvSnapshots = map[string]string{}
+				}
+				runInfo.EnvSnapshots[command] = file
+			}
+			continue
+		}
+
+		if section == "Environment Variables" {
+			if name, value, found := parseKeyValue(line); found {
+				if runInfo.EnvVars == nil {
+					runInfo.EnvVars = map[string]string{}
+				}
+				runInfo.EnvVars[name] = value
+			}
+			continue
+		}
+
 		if after, found := strings.CutPrefix(line, "- **Execution datetime**: "); found {
 			// Extract start time
 			startTime, err := time.Parse(timestampFormat, after)
@@ -223,6 +779,12 @@ func ParseRunInfo(summaryPath string) (RunInfo, error) {
 				return runInfo, fmt.Errorf("failed to parse start time: %w", err)
 			}
 			runInfo.StartTime = startTime
+		} else if after, found := strings.CutPrefix(line, "- **Name**: "); found {
+			name, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse name: %w", err)
+			}
+			runInfo.Name = name
 		} else if after, found := strings.CutPrefix(line, "- **Branch**: "); found {
 			branch, err := trimBackticks(after)
 			if err != nil {
@@ -250,6 +812,13 @@ func ParseRunInfo(summaryPath string) (RunInfo, error) {
 				return runInfo, fmt.Errorf("failed to parse exit status: %w", err)
 			}
 			runInfo.ExitStatus = status
+			// Summaries without a result follow the usual convention
+			runInfo.Success = status == 0
+		} else if after, found := strings.CutPrefix(line, "- **Exit reason**: "); found {
+			// Older summaries wrote the reason without backticks
+			runInfo.ExitReason = strings.Trim(after, "`")
+		} else if after, found := strings.CutPrefix(line, "- **Result**: "); found {
+			runInfo.Success = after == "success"
 		} else if after, found := strings.CutPrefix(line, "- **Execution finished**: "); found {
 			// Extract end time
 			endTime, err := time.Parse(timestampFormat, after)
@@ -257,15 +826,146 @@ func ParseRunInfo(summaryPath string) (RunInfo, error) {
 				return runInfo, fmt.Errorf("failed to parse end time: %w", err)
 			}
 			runInfo.EndTime = endTime
+		} else if after, found := strings.CutPrefix(line, "- **Hostname**: "); found {
+			hostname, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse hostname: %w", err)
+			}
+			runInfo.Hostname = hostname
+		} else if after, found := strings.CutPrefix(line, "- **Issues**: "); found {
+			issues, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse issues: %w", err)
+			}
+			runInfo.Issues = strings.Fields(issues)
+		} else if after, found := strings.CutPrefix(line, "- **Reproduction of**: "); found {
+			reproducedFrom, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse reproduced run: %w", err)
+			}
+			runInfo.ReproducedFrom = reproducedFrom
+		} else if after, found := strings.CutPrefix(line, "- **Rerun of**: "); found {
+			rerunOf, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse rerun run: %w", err)
+			}
+			runInfo.RerunOf = rerunOf
+		} else if after, found := strings.CutPrefix(line, "- **After**: "); found {
+			dependency, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse dependency: %w", err)
+			}
+			runInfo.After = dependency
+		} else if after, found := strings.CutPrefix(line, "- **TensorBoard**: "); found {
+			tensorBoardDir, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse TensorBoard directory: %w", err)
+			}
+			runInfo.TensorBoardDir = tensorBoardDir
+		} else if after, found := strings.CutPrefix(line, "- **Sweep**: "); found {
+			sweep, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse sweep: %w", err)
+			}
+			runInfo.Sweep = sweep
+		} else if after, found := strings.CutPrefix(line, tagsPrefix); found {
+			tags, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse tags: %w", err)
+			}
+			runInfo.Tags = strings.Fields(tags)
+		} else if after, found := strings.CutPrefix(line, "- **GPU hours**: "); found {
+			gpuHours, err := strconv.ParseFloat(after, 64)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse GPU hours: %w", err)
+			}
+			runInfo.GPUHours = gpuHours
+		} else if after, found := strings.CutPrefix(line, "- **Estimated cost**: "); found {
+			amount, currency, _ := strings.Cut(after, " ")
+			cost, err := strconv.ParseFloat(amount, 64)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse estimated cost: %w", err)
+			}
+			runInfo.Cost = cost
+			runInfo.Currency = currency
 		} else if strings.Contains(line, "**Terminated by user**") {
 			// Check if interrupted
 			runInfo.Interrupted = true
+		} else if strings.Contains(line, "**Timed out**") {
+			runInfo.TimedOut = true
+		} else if after, found := strings.CutPrefix(line, "- **Truncated logs**: "); found {
+			logs, err := trimBackticks(after)
+			if err != nil {
+				return runInfo, fmt.Errorf("failed to parse truncated logs: %w", err)
+			}
+			runInfo.TruncatedLogs = strings.Fields(logs)
 		}
 	}
 
+	runInfo.Message = strings.Trim(strings.Join(message, "\n"), "\n")
+
 	return runInfo, nil
 }
 
+// parseKeyValue parses a list item formatted as "- **key**: `value`"
+func parseKeyValue(line string) (string, string, bool) {
+	after, found := strings.CutPrefix(line, "- **")
+	if !found {
+		return "", "", false
+	}
+	key, value, found := strings.Cut(after, "**: ")
+	if !found {
+		return "", "", false
+	}
+	value, err := trimBackticks(value)
+	if err != nil {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// ReadSummarySection returns the content of the code block in a section of a summary file
+func ReadSummarySection(summaryPath, title string) (string, error) {
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read summary file: %w", err)
+	}
+
+	_, after, found := strings.Cut(string(data), "\n## "+title+"\n")
+	if !found {
+		return "", fmt.Errorf("section not found in summary file: %s", title)
+	}
+
+	// Skip the opening fence (e.g., "```diff") and find the closing one
+	_, block, found := strings.Cut(after, "\n")
+	if !found || !strings.HasPrefix(after, "```") {
+		return "", fmt.Errorf("no code block in section: %s", title)
+	}
+	// Contents (e.g., diffs) may contain fences, so use the last one in the section
+	if next := strings.Index(block, "\n## "); next >= 0 {
+		block = block[:next+1]
+	}
+	end := strings.LastIndex(block, "```")
+	if end < 0 {
+		return "", fmt.Errorf("unterminated code block in section: %s", title)
+	}
+
+	return block[:end], nil
+}
+
+// ResolveSummaryPath returns the summary file path of a run given either
+// its directory or the summary file itself
+func ResolveSummaryPath(run, summaryFile string) (string, error) {
+	stat, err := os.Stat(run)
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return filepath.Join(run, summaryFile), nil
+	}
+	return run, nil
+}
+
 // trimBackticks removes backticks from the both ends of a string
 func trimBackticks(s string) (string, error) {
 	if len(s) < 2 || s[0] != '`' || s[len(s)-1] != '`' {
@@ -289,3 +989,43 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%ds", seconds)
 }
+
+// FormatSize formats a file size in bytes to human-readable format
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseSize parses a size in bytes with an optional binary unit (e.g., "512",
+// "10KB", "1.5 GiB"); units are powers of 1024 as in FormatSize
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+	unit := strings.ToUpper(strings.TrimSpace(s[i:]))
+	if trimmed, found := strings.CutSuffix(unit, "B"); found {
+		unit = strings.TrimSuffix(trimmed, "I")
+	}
+	exp := 0
+	if unit != "" {
+		exp = strings.Index("KMGTPE", unit) + 1
+		if exp == 0 || len(unit) != 1 {
+			return 0, fmt.Errorf("invalid size: %q", s)
+		}
+	}
+	return int64(value * math.Pow(1024, float64(exp))), nil
+}
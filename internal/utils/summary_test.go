@@ -1,7 +1,11 @@
 package utils_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,11 +30,22 @@ func TestWriteSummaryFileInit(t *testing.T) {
 		exitCode := 0
 		interrupted := false
 		{
-			err := utils.WriteSummaryFileInit(summaryPath, startTime, repo, commmand, message)
+			meta := utils.RunMetadata{
+				StartTime: startTime,
+				Repo:      repo,
+				Command:   commmand,
+				Message:   message,
+			}
+			err := utils.WriteSummaryFileInit(summaryPath, meta)
 			assert.NoError(t, err)
 		}
 		{
-			err := utils.WriteSummaryFileEnd(summaryPath, startTime, endTime, exitCode, interrupted)
+			result := utils.RunResult{
+				EndTime:     endTime,
+				ExitCode:    exitCode,
+				Interrupted: interrupted,
+			}
+			err := utils.WriteSummaryFileEnd(summaryPath, startTime, result)
 			assert.NoError(t, err)
 		}
 	})
@@ -63,12 +78,363 @@ func TestParseRunInfo(t *testing.T) {
 		assert.False(t, info.Interrupted)
 	})
 
+	t.Run("Tool versions", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_tools.md")
+		meta := utils.RunMetadata{
+			Repo:         utils.RepoStatus{Branch: "main"},
+			Command:      []string{"python", "train.py"},
+			ToolVersions: map[string]string{"python": "Python 3.12.1"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"python": "Python 3.12.1"}, info.ToolVersions)
+		assert.True(t, info.IsRunning)
+	})
+
+	t.Run("Environment snapshots", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_snapshots.md")
+		snapshots := map[string]string{
+			"pip freeze":       "pip-freeze.txt",
+			"conda env export": "environment.yml",
+		}
+		meta := utils.RunMetadata{
+			Repo:         utils.RepoStatus{Branch: "main"},
+			Command:      []string{"python", "train.py"},
+			EnvSnapshots: snapshots,
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, snapshots, info.EnvSnapshots)
+	})
+
+	t.Run("Full patch", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_patch.md")
+		meta := utils.RunMetadata{
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"python", "train.py"},
+			PatchFile: utils.PatchFile,
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, utils.PatchFile, info.PatchFile)
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_sweep.md")
+		meta := utils.RunMetadata{
+			Repo:        utils.RepoStatus{Branch: "main"},
+			Command:     []string{"python", "train.py", "--lr", "0.1"},
+			Sweep:       "20250324-100000",
+			SweepParams: map[string]string{"lr": "0.1", "bs": "32"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "20250324-100000", info.Sweep)
+		assert.Equal(t, map[string]string{"lr": "0.1", "bs": "32"}, info.SweepParams)
+	})
+
+	t.Run("Dependency", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_after.md")
+		meta := utils.RunMetadata{
+			Repo:    utils.RepoStatus{Branch: "main"},
+			Command: []string{"python", "train.py"},
+			After:   "runs/2025-03-24T10:00:00.000_main_abc1234",
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "runs/2025-03-24T10:00:00.000_main_abc1234", info.After)
+	})
+
+	t.Run("TensorBoard directory", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_tensorboard.md")
+		meta := utils.RunMetadata{
+			Repo:           utils.RepoStatus{Branch: "main"},
+			Command:        []string{"python", "train.py"},
+			TensorBoardDir: "tb",
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "tb", info.TensorBoardDir)
+	})
+
+	t.Run("DVC", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_dvc.md")
+		hashes := map[string]string{"data/raw": "3863d0e317dee0a55c4e59d2ec0eef33.dir", "model.pkl": "a304afb96060aad90176268345e10355"}
+		meta := utils.RunMetadata{
+			Repo:    utils.RepoStatus{Branch: "main"},
+			Command: []string{"python", "train.py"},
+			DVC: utils.DVCState{
+				Hashes:  hashes,
+				Status:  "$ dvc status --json\n{\"train\": [{\"changed deps\": {\"data/raw\": \"modified\"}}]}\n",
+				Changed: true,
+			},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, hashes, info.DVCHashes)
+		assert.True(t, info.DataChanged)
+
+		block, err := utils.ReadSummarySection(summaryPath, "DVC Status")
+		assert.NoError(t, err)
+		assert.Equal(t, meta.DVC.Status, block)
+	})
+
+	t.Run("Additional repositories", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_repos.md")
+		repos := []utils.ExtraRepo{
+			{Path: "../data", Branch: "main", CommitHash: "7a9162c4ad32037a036d71e03f5a9262551a7e46", IsDirty: true},
+			{Path: "../lib", Branch: "dev", CommitHash: "0585bf6e2a3c5d5e8e0b1a7c9d3f4e5a6b7c8d9e"},
+		}
+		meta := utils.RunMetadata{
+			Repo:       utils.RepoStatus{Branch: "main"},
+			Command:    []string{"sleep", "1"},
+			ExtraRepos: repos,
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, repos, info.ExtraRepos)
+	})
+
+	t.Run("Issues", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_issues.md")
+		meta := utils.RunMetadata{
+			Repo:    utils.RepoStatus{Branch: "main"},
+			Command: []string{"sleep", "1"},
+			Issues:  []string{"PROJ-42", "#7"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"PROJ-42", "#7"}, info.Issues)
+	})
+
+	t.Run("Name", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_name.md")
+		meta := utils.RunMetadata{
+			Repo:    utils.RepoStatus{Branch: "main"},
+			Command: []string{"sleep", "1"},
+			Name:    "larger lr",
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "larger lr", info.Name)
+	})
+
+	t.Run("Message and hostname", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_message.md")
+		meta := utils.RunMetadata{
+			Repo:     utils.RepoStatus{Branch: "main"},
+			Command:  []string{"sleep", "1"},
+			Message:  "Try a larger learning rate\n\nSee the previous run.",
+			Hostname: "gpu01",
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, meta.Message, info.Message)
+		assert.Equal(t, "gpu01", info.Hostname)
+	})
+
+	t.Run("Cost", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_cost.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"sleep", "1"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		result := utils.RunResult{
+			EndTime:  startTime.Add(2 * time.Hour),
+			GPUHours: 4,
+			Cost:     12.5,
+			Currency: "USD",
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 4.0, info.GPUHours)
+		assert.Equal(t, 12.5, info.Cost)
+		assert.Equal(t, "USD", info.Currency)
+	})
+
+	t.Run("Exit code interpretation", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_exit.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"lint"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		result := utils.RunResult{
+			EndTime:    startTime.Add(time.Minute),
+			ExitCode:   99,
+			ExitReason: "Warnings only",
+			Success:    true,
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+
+		content, err := os.ReadFile(summaryPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "- **Exit reason**: `Warnings only`\n")
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 99, info.ExitStatus)
+		assert.Equal(t, "Warnings only", info.ExitReason)
+
+		// Summaries written before reasons were quoted
+		old := strings.ReplaceAll(string(content), "`Warnings only`", "Warnings only")
+		assert.NoError(t, os.WriteFile(summaryPath, []byte(old), 0o644))
+		info, err = utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "Warnings only", info.ExitReason)
+		assert.True(t, info.Succeeded())
+		assert.Equal(t, "Success", utils.StatusString(info))
+	})
+
+	t.Run("Resource usage", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_resources.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"train"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		usage := utils.ResourceUsage{
+			UserSeconds:   10.5,
+			SystemSeconds: 1.25,
+			MaxMemory:     512 * 1024 * 1024,
+			ReadBytes:     4096,
+			WriteBytes:    8192,
+		}
+		result := utils.RunResult{
+			EndTime:   startTime.Add(time.Minute),
+			Success:   true,
+			Resources: &usage,
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, &usage, info.Resources)
+		assert.Equal(t, 11.75, info.CPUSeconds())
+		assert.Equal(t, int64(512*1024*1024), info.MaxMemory())
+	})
+
+	t.Run("Malformed resource usage", func(t *testing.T) {
+		// A separate directory keeps summary.json of other runs from being read
+		summaryPath := filepath.Join(t.TempDir(), "summary.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"train"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		result := utils.RunResult{
+			EndTime:   startTime.Add(time.Minute),
+			Success:   true,
+			Resources: &utils.ResourceUsage{UserSeconds: 1, MaxMemory: 1024, ReadBytes: 1, WriteBytes: 2},
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+		content, err := os.ReadFile(summaryPath)
+		assert.NoError(t, err)
+		// The recorded diff may contain the same line, so only the section
+		// at the end is edited
+		i := strings.LastIndex(string(content), "## Resource Usage")
+		section := strings.Replace(string(content[i:]), "- **Max memory**: 1024 bytes", "- **Max memory**: about 1 KiB", 1)
+		assert.NotEqual(t, string(content[i:]), section)
+		assert.NoError(t, os.WriteFile(summaryPath, append(content[:i:i], section...), 0644))
+
+		// The malformed line is skipped and the rest is parsed
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.True(t, info.Success)
+		if assert.NotNil(t, info.Resources) {
+			assert.Equal(t, int64(0), info.Resources.MaxMemory)
+			assert.Equal(t, 1.0, info.Resources.UserSeconds)
+			assert.Equal(t, int64(2), info.Resources.WriteBytes)
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_metrics.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"train"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		metrics := map[string]float64{"loss": 0.125, "accuracy": 0.9731, "eval.f1": 0.5}
+		result := utils.RunResult{
+			EndTime: startTime.Add(time.Minute),
+			Success: true,
+			Metrics: metrics,
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, metrics, info.Metrics)
+	})
+
+	t.Run("Timed out run", func(t *testing.T) {
+		summaryPath := filepath.Join(tempDir, "summary_timeout.md")
+		startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+		meta := utils.RunMetadata{
+			StartTime: startTime,
+			Repo:      utils.RepoStatus{Branch: "main"},
+			Command:   []string{"train"},
+		}
+		assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+		result := utils.RunResult{
+			EndTime:    startTime.Add(time.Hour),
+			ExitCode:   124,
+			ExitReason: "Timeout",
+			TimedOut:   true,
+		}
+		assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.True(t, info.TimedOut)
+		assert.False(t, info.Interrupted)
+		assert.True(t, info.Failed())
+		assert.Equal(t, "Timed out", utils.StatusString(info))
+	})
+
 	t.Run("Interrupted run", func(t *testing.T) {
 		summaryPath := filepath.Join("testdata", "summary_interrupted.md")
 		info, err := utils.ParseRunInfo(summaryPath)
 		assert.NoError(t, err)
 		assert.Equal(t, 130, info.ExitStatus)
 		assert.True(t, info.Interrupted)
+		assert.True(t, info.Failed())
 	})
 
 	t.Run("Non-existent file", func(t *testing.T) {
@@ -78,3 +444,66 @@ func TestParseRunInfo(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to open summary file")
 	})
 }
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"0":       0,
+		"512":     512,
+		"512B":    512,
+		"10KB":    10 << 10,
+		"10 KiB":  10 << 10,
+		"1.5GB":   3 << 29,
+		"100m":    100 << 20,
+		" 2 TiB ": 2 << 40,
+	}
+	for s, expected := range cases {
+		size, err := utils.ParseSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, size, s)
+	}
+
+	for _, s := range []string{"", "MB", "-1KB", "10XB", "10 KBB", "5i"} {
+		_, err := utils.ParseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSummaryJSON(t *testing.T) {
+	runDir := t.TempDir()
+	summaryPath := filepath.Join(runDir, "summary.md")
+	startTime, _ := time.Parse(time.RFC3339, "2025-03-24T12:34:56+01:00")
+	meta := utils.RunMetadata{
+		StartTime: startTime,
+		Repo:      utils.RepoStatus{Branch: "main"},
+		Command:   []string{"sleep", "5"},
+		Tags:      []string{"baseline"},
+	}
+	assert.NoError(t, utils.WriteSummaryFileInit(summaryPath, meta))
+	result := utils.RunResult{EndTime: startTime.Add(time.Minute), ExitCode: 1}
+	assert.NoError(t, utils.WriteSummaryFileEnd(summaryPath, startTime, result))
+	assert.NoError(t, utils.WriteSummaryJSON(summaryPath))
+
+	// The JSON copy has the same information as the summary file
+	data, err := os.ReadFile(filepath.Join(runDir, utils.SummaryJSONFile))
+	assert.NoError(t, err)
+	var fromJSON utils.RunInfo
+	assert.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, "sleep 5", fromJSON.Command)
+	assert.Equal(t, 1, fromJSON.ExitStatus)
+	assert.False(t, fromJSON.IsRunning)
+
+	// The JSON copy is read while it is up to date
+	assert.NoError(t, os.WriteFile(filepath.Join(runDir, utils.SummaryJSONFile), bytes.Replace(data, []byte(`"sleep 5"`), []byte(`"sleep 6"`), 1), 0644))
+	info, err := utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "sleep 6", info.Command)
+	assert.Equal(t, []string{"baseline"}, info.Tags)
+	assert.Equal(t, runDir+string(filepath.Separator), info.Directory)
+
+	// The summary file is read if it has been edited since
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(summaryPath, future, future))
+	info, err = utils.ParseRunInfo(summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "sleep 5", info.Command)
+}
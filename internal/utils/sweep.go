@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SweepParam is a parameter of a sweep with the values it takes
+type SweepParam struct {
+	Name   string
+	Values []string
+}
+
+// ParseSweepParam parses a parameter given as "name=value1,value2,..."
+func ParseSweepParam(s string) (SweepParam, error) {
+	name, values, found := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || values == "" {
+		return SweepParam{}, fmt.Errorf("invalid parameter: %s (expected name=value1,value2)", s)
+	}
+	param := SweepParam{Name: name}
+	for value := range strings.SplitSeq(values, ",") {
+		param.Values = append(param.Values, strings.TrimSpace(value))
+	}
+	return param, nil
+}
+
+// ExpandGrid returns all combinations of parameter values, varying the last
+// parameter fastest
+func ExpandGrid(params []SweepParam) []map[string]string {
+	grid := []map[string]string{{}}
+	for _, param := range params {
+		var expanded []map[string]string
+		for _, values := range grid {
+			for _, value := range param.Values {
+				combination := make(map[string]string, len(values)+1)
+				for name, v := range values {
+					combination[name] = v
+				}
+				combination[param.Name] = value
+				expanded = append(expanded, combination)
+			}
+		}
+		grid = expanded
+	}
+	return grid
+}
+
+// SubstituteParams replaces "{name}" placeholders in each argument with the
+// values of parameters
+func SubstituteParams(args []string, values map[string]string) []string {
+	replacements := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	substituted := make([]string, len(args))
+	for i, arg := range args {
+		substituted[i] = replacer.Replace(arg)
+	}
+	return substituted
+}
@@ -0,0 +1,39 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestParseSweepParam(t *testing.T) {
+	param, err := utils.ParseSweepParam("lr=0.1, 0.01")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.SweepParam{Name: "lr", Values: []string{"0.1", "0.01"}}, param)
+
+	for _, s := range []string{"lr", "=0.1", "lr="} {
+		_, err := utils.ParseSweepParam(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestExpandGrid(t *testing.T) {
+	grid := utils.ExpandGrid([]utils.SweepParam{
+		{Name: "lr", Values: []string{"0.1", "0.01"}},
+		{Name: "bs", Values: []string{"32", "64"}},
+	})
+	assert.Equal(t, []map[string]string{
+		{"lr": "0.1", "bs": "32"},
+		{"lr": "0.1", "bs": "64"},
+		{"lr": "0.01", "bs": "32"},
+		{"lr": "0.01", "bs": "64"},
+	}, grid)
+}
+
+func TestSubstituteParams(t *testing.T) {
+	args := []string{"python", "train.py", "--lr={lr}", "--batch-size", "{bs}", "{other}"}
+	substituted := utils.SubstituteParams(args, map[string]string{"lr": "0.1", "bs": "32"})
+	assert.Equal(t, []string{"python", "train.py", "--lr=0.1", "--batch-size", "32", "{other}"}, substituted)
+}
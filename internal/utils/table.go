@@ -2,46 +2,196 @@ package utils
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 )
 
-func RenderRunInfos(runInfos []RunInfo) string {
-	cellStyle := lipgloss.NewStyle().Padding(0, 1)
-	headerStyle := cellStyle.Bold(true).Align(lipgloss.Left)
-	t := table.New().
-		// Enable the header border only
-		BorderHeader(true).
-		BorderTop(false).
-		BorderLeft(false).
-		BorderRight(false).
-		BorderBottom(false).
-		BorderRow(false).
-		BorderColumn(false).
+// Column is an additional column of a table of runs
+type Column struct {
+	Header string
+	Value  func(RunInfo) string
+}
+
+// RenderRunInfos renders runs as a table, with project and tags columns if
+// any run belongs to a project or has tags, followed by extra columns
+func RenderRunInfos(runInfos []RunInfo, extra ...Column) string {
+	headers, rows, durationCol := runInfoRows(runInfos, extra)
+	t := newTable().
 		StyleFunc(func(row, col int) lipgloss.Style {
 			if row == table.HeaderRow {
 				return headerStyle
-			} else if col == 2 {
+			} else if col == durationCol {
 				return cellStyle.Align(lipgloss.Right)
 			} else {
 				return cellStyle
 			}
 		}).
-		Headers("Directory", "Status", "Duration", "Command")
+		Headers(headers...).
+		Rows(rows...)
+	return t.Render()
+}
+
+// RenderRunInfosMarkdown renders runs as a GitHub-flavored Markdown table
+// with the columns of RenderRunInfos
+func RenderRunInfosMarkdown(runInfos []RunInfo, extra ...Column) string {
+	headers, rows, durationCol := runInfoRows(runInfos, extra)
+	var b strings.Builder
+	writeMarkdownRow(&b, headers)
+	delimiters := make([]string, len(headers))
+	for i := range delimiters {
+		delimiters[i] = "---"
+		if i == durationCol {
+			delimiters[i] = "---:"
+		}
+	}
+	writeMarkdownRow(&b, delimiters)
+	for _, row := range rows {
+		// Directories and commands are shown as code
+		for i, header := range headers {
+			if (header == "Directory" || header == "Command") && row[i] != "" {
+				row[i] = markdownCode(row[i])
+			}
+		}
+		writeMarkdownRow(&b, row)
+	}
+	return b.String()
+}
+
+// RenderMarkdownTable renders rows as a GitHub-flavored Markdown table
+func RenderMarkdownTable(headers []string, rows [][]string) string {
+	var b strings.Builder
+	writeMarkdownRow(&b, headers)
+	delimiters := make([]string, len(headers))
+	for i := range delimiters {
+		delimiters[i] = "---"
+	}
+	writeMarkdownRow(&b, delimiters)
+	for _, row := range rows {
+		writeMarkdownRow(&b, row)
+	}
+	return b.String()
+}
+
+// runInfoRows returns the headers and rows of a table of runs and the index
+// of the duration column
+func runInfoRows(runInfos []RunInfo, extra []Column) ([]string, [][]string, int) {
+	withProject := slices.ContainsFunc(runInfos, func(run RunInfo) bool { return run.Project != "" })
+	withName := slices.ContainsFunc(runInfos, func(run RunInfo) bool { return run.Name != "" })
+	withTags := slices.ContainsFunc(runInfos, func(run RunInfo) bool { return len(run.Tags) > 0 })
+	durationCol := 3
+	headers := []string{"ID", "Directory", "Status", "Duration", "Command"}
+	if withProject {
+		durationCol++
+		headers = append([]string{"Project"}, headers...)
+	}
+	if withName {
+		headers = append(headers, "Name")
+	}
+	if withTags {
+		headers = append(headers, "Tags")
+	}
+	for _, column := range extra {
+		headers = append(headers, column.Header)
+	}
+
+	var rows [][]string
 	for _, run := range runInfos {
-		t.Row(run.Directory, statusString(run), run.Duration(), run.Command)
+		row := []string{run.ID, run.Directory, StatusString(run), run.Duration(), run.Command}
+		if withProject {
+			row = append([]string{run.Project}, row...)
+		}
+		if withName {
+			row = append(row, run.Name)
+		}
+		if withTags {
+			row = append(row, strings.Join(run.Tags, " "))
+		}
+		for _, column := range extra {
+			row = append(row, column.Value(run))
+		}
+		rows = append(rows, row)
 	}
+	return headers, rows, durationCol
+}
+
+// writeMarkdownRow writes a row of a Markdown table, escaping pipes and line
+// breaks in cells
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	replacer := strings.NewReplacer("|", "\\|", "\n", " ")
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" " + replacer.Replace(cell) + " |")
+	}
+	b.WriteString("\n")
+}
+
+// markdownCode returns s as a code span delimited by more backticks than it
+// contains in a row
+func markdownCode(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// RenderTable renders rows as a table in the style of RenderRunInfos
+func RenderTable(headers []string, rows [][]string) string {
+	t := newTable().
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		}).
+		Headers(headers...).
+		Rows(rows...)
 	return t.Render()
 }
 
-func statusString(run RunInfo) string {
-	if run.IsRunning {
+var (
+	cellStyle   = lipgloss.NewStyle().Padding(0, 1)
+	headerStyle = cellStyle.Bold(true).Align(lipgloss.Left)
+)
+
+// newTable returns a table with only the header border enabled
+func newTable() *table.Table {
+	return table.New().
+		BorderHeader(true).
+		BorderTop(false).
+		BorderLeft(false).
+		BorderRight(false).
+		BorderBottom(false).
+		BorderRow(false).
+		BorderColumn(false)
+}
+
+// StatusString returns a human-readable status of a run
+func StatusString(run RunInfo) string {
+	if run.IsRunning && run.Stale {
+		return "Stale"
+	} else if run.IsRunning {
 		return "Running"
-	} else if run.ExitStatus == 0 {
+	} else if run.Success {
 		return "Success"
+	} else if run.TimedOut {
+		return "Timed out"
 	} else if run.Interrupted {
 		return "Interrupted"
+	} else if run.ExitReason != "" {
+		return fmt.Sprintf("Failed (%s)", run.ExitReason)
 	} else {
 		return fmt.Sprintf("Failed (exit: %d)", run.ExitStatus)
 	}
@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestRenderRunInfosMarkdown(t *testing.T) {
+	startTime, _ := time.Parse(time.RFC3339, "2025-03-24T00:00:00Z")
+	runs := []utils.RunInfo{
+		{
+			ID:        "0123abcd",
+			Directory: "runs/a",
+			Command:   "grep -E 'a|b' data.txt",
+			StartTime: startTime,
+			EndTime:   startTime.Add(90 * time.Second),
+			Success:   true,
+		},
+		{
+			ID:        "4567ef01",
+			Directory: "runs/b",
+			Command:   "echo `date`",
+			StartTime: startTime,
+			IsRunning: true,
+		},
+	}
+	note := utils.Column{Header: "Note", Value: func(run utils.RunInfo) string { return "" }}
+
+	table := utils.RenderRunInfosMarkdown(runs, note)
+	lines := []string{
+		"| ID | Directory | Status | Duration | Command | Note |",
+		"| --- | --- | --- | ---: | --- | --- |",
+		"| 0123abcd | `runs/a` | Success | " + runs[0].Duration() + " | `grep -E 'a\\|b' data.txt` |  |",
+		"| 4567ef01 | `runs/b` | Running | " + runs[1].Duration() + " | `` echo `date` `` |  |",
+	}
+	assert.Equal(t, strings.Join(lines, "\n")+"\n", table)
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	table := utils.RenderMarkdownTable([]string{"Branch", "Runs"}, [][]string{{"main", "3"}, {"feat|x", "1"}})
+	assert.Equal(t, "| Branch | Runs |\n| --- | --- |\n| main | 3 |\n| feat\\|x | 1 |\n", table)
+}
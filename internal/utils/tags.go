@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// tagsPrefix is the prefix of the line recording tags in a summary file
+const tagsPrefix = "- **Tags**: "
+
+// ValidateTag checks that a tag can be recorded in a summary file
+func ValidateTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, " \t\n`") {
+		return fmt.Errorf("invalid tag %q: tags must be non-empty and contain no spaces or backticks", tag)
+	}
+	return nil
+}
+
+// WriteSummaryTags replaces the tags recorded in the metadata of a summary file
+func WriteSummaryTags(summaryPath string, tags []string) error {
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read summary file: %w", err)
+	}
+	lines := strings.Split(string(data), "\n")
+
+	// Find the end of the metadata list and the existing tags line, if any
+	metadata := slices.Index(lines, "## Metadata")
+	if metadata < 0 {
+		return fmt.Errorf("metadata section not found in summary file")
+	}
+	end := metadata + 1
+	for end < len(lines) && strings.HasPrefix(lines[end], "- ") {
+		end++
+	}
+	existing := slices.IndexFunc(lines[metadata+1:end], func(line string) bool {
+		return strings.HasPrefix(line, tagsPrefix)
+	})
+
+	tagsLine := fmt.Sprintf("%s`%s`", tagsPrefix, strings.Join(tags, " "))
+	switch {
+	case existing >= 0 && len(tags) > 0:
+		lines[metadata+1+existing] = tagsLine
+	case existing >= 0:
+		lines = slices.Delete(lines, metadata+1+existing, metadata+2+existing)
+	case len(tags) > 0:
+		lines = slices.Insert(lines, end, tagsLine)
+	}
+
+	return rewriteFile(summaryPath, strings.Join(lines, "\n"))
+}
+
+// rewriteFile replaces the content of a file atomically so that a failure
+// never leaves it truncated
+func rewriteFile(path, content string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".summary-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if err := os.Chmod(tmpFile.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,48 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestWriteSummaryTags(t *testing.T) {
+	// Copy a summary file to modify
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	data, err := os.ReadFile(filepath.Join("testdata", "summary.md"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(summaryPath, data, 0644))
+
+	t.Run("Add tags", func(t *testing.T) {
+		assert.NoError(t, utils.WriteSummaryTags(summaryPath, []string{"baseline", "lr-sweep"}))
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"baseline", "lr-sweep"}, info.Tags)
+		assert.Equal(t, "sleep 5", info.Command)
+	})
+
+	t.Run("Replace tags", func(t *testing.T) {
+		assert.NoError(t, utils.WriteSummaryTags(summaryPath, []string{"final"}))
+		info, err := utils.ParseRunInfo(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"final"}, info.Tags)
+	})
+
+	t.Run("Remove tags", func(t *testing.T) {
+		assert.NoError(t, utils.WriteSummaryTags(summaryPath, nil))
+		content, err := os.ReadFile(summaryPath)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(content))
+	})
+}
+
+func TestValidateTag(t *testing.T) {
+	assert.NoError(t, utils.ValidateTag("baseline"))
+	assert.Error(t, utils.ValidateTag(""))
+	assert.Error(t, utils.ValidateTag("two words"))
+	assert.Error(t, utils.ValidateTag("`tag`"))
+}
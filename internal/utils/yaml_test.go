@@ -0,0 +1,38 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bicycle1885/moco/internal/utils"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	value := struct {
+		Name    string            `json:"name"`
+		Count   int               `json:"count"`
+		Tags    []string          `json:"tags"`
+		Params  map[string]string `json:"params"`
+		Missing string            `json:"missing,omitempty"`
+		Number  string            `json:"number"`
+	}{
+		Name:   "train: baseline",
+		Count:  3,
+		Tags:   []string{"a", "b"},
+		Params: map[string]string{"lr": "0.1"},
+		Number: "42",
+	}
+
+	data, err := utils.MarshalYAML(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `name: 'train: baseline'
+count: 3
+tags:
+  - a
+  - b
+params:
+  lr: "0.1"
+number: "42"
+`, string(data))
+}